@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/capture"
+	"github.com/bspippi1337/restless/internal/core/discovery"
+	"github.com/bspippi1337/restless/internal/core/profile"
+	"github.com/bspippi1337/restless/internal/core/transport"
+	"github.com/bspippi1337/restless/internal/help"
+)
+
+// cmdCapture runs a recording proxy and, on Ctrl-C, merges the endpoints it
+// saw into a profile.
+func cmdCapture(args []string) {
+	flags := flag.NewFlagSet("capture", flag.ContinueOnError)
+	flags.SetOutput(os.Stdout)
+
+	var (
+		name       = flags.String("profile", "", "Profile to create or update")
+		profileDir = flags.String("profile-dir", "", "Custom profile storage directory")
+		listen     = flags.String("listen", "127.0.0.1:8888", "Address to listen on")
+		target     = flags.String("target", "", "Upstream base URL (reverse proxy mode)")
+		host       = flags.String("host", "", "Only learn from this host (forward proxy mode)")
+		proxy      = flags.String("proxy", "", "Upstream proxy URL (http, https or socks5)")
+		dryRun     = flags.Bool("dry-run", false, "Print what was learned without saving")
+		quiet      = flags.Bool("quiet", false, "Don't log requests")
+	)
+	flags.Usage = func() { fmt.Fprintln(flags.Output(), help.CaptureHelp(help.NewDiscoverHelpContext(*profileDir))) }
+
+	rest, err := parseInterspersed(flags, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+	if len(rest) > 0 || (*name == "" && !*dryRun) {
+		flags.Usage()
+		os.Exit(2)
+	}
+	fail := func(err error) {
+		fmt.Fprintf(os.Stderr, "capture error: %v\n", err)
+		os.Exit(1)
+	}
+
+	dir := *profileDir
+	if dir == "" {
+		dir = defaultProfileDir()
+	}
+	existing := &profile.Profile{}
+	if *name != "" {
+		p, err := profile.Load(dir, *name)
+		switch {
+		case err == nil:
+			existing = p
+		case !errors.Is(err, fs.ErrNotExist):
+			fail(err)
+		}
+	}
+
+	var upstream *url.URL
+	if *target != "" {
+		u, err := url.Parse(*target)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			fail(fmt.Errorf("bad --target %q (want http(s)://host[/path])", *target))
+		}
+		upstream = u
+	}
+	rt, err := transport.New(transport.Options{
+		TLS:     existing.TLS,
+		Proxy:   firstNonEmpty(*proxy, existing.Network.Proxy),
+		Resolve: existing.Network.Resolve,
+		Retry:   transport.RetryPolicy{MaxRetries: -1},
+		BaseDir: filepath.Dir(existing.Path),
+		Warn:    os.Stderr,
+	})
+	if err != nil {
+		fail(err)
+	}
+
+	var (
+		mu   sync.Mutex
+		seen []discovery.Exchange
+	)
+	p := &capture.Proxy{
+		Target:    upstream,
+		Transport: rt,
+		OnExchange: func(x discovery.Exchange) {
+			mu.Lock()
+			seen = append(seen, x)
+			mu.Unlock()
+			if *quiet {
+				return
+			}
+			status := fmt.Sprint(x.Status)
+			if x.Status == 0 {
+				status = "failed"
+			}
+			mark := " "
+			if !discovery.APILike(x) {
+				mark = "·" // not learned from
+			}
+			fmt.Fprintf(os.Stderr, "%s %s %-6s %s → %s\n", x.When.Format("15:04:05"), mark, x.Method, transport.RedactURL(x.URL), status)
+		},
+		OnTunnel: func(h string) {
+			if !*quiet {
+				fmt.Fprintf(os.Stderr, "%s · CONNECT %s (HTTPS, not recorded; use --target)\n", time.Now().Format("15:04:05"), h)
+			}
+		},
+	}
+
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		fail(err)
+	}
+	srv := &http.Server{Handler: p, ReadHeaderTimeout: 30 * time.Second}
+	if upstream != nil {
+		fmt.Fprintf(os.Stderr, "capture: http://%s → %s (Ctrl-C to stop and save)\n", ln.Addr(), upstream)
+	} else {
+		fmt.Fprintf(os.Stderr, "capture: proxy on %s, e.g. HTTP_PROXY=http://%s (Ctrl-C to stop and save)\n", ln.Addr(), ln.Addr())
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		shut, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shut)
+	}()
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fail(err)
+	}
+	stop()
+
+	opt := discovery.TrafficOptions{Source: "capture", Host: *host}
+	for _, ep := range existing.Endpoints {
+		opt.Known = append(opt.Known, ep.Path)
+	}
+	base, _ := url.Parse(firstOf(existing.BaseURLs))
+	switch {
+	case upstream != nil:
+		opt.Host, opt.BasePath = upstream.Host, upstream.Path
+	case opt.Host == "" && base != nil:
+		opt.Host = base.Host
+	}
+	if base != nil && strings.EqualFold(base.Host, opt.Host) {
+		opt.BasePath = base.Path
+	}
+	mu.Lock()
+	learned := discovery.LearnTraffic(seen, opt)
+	mu.Unlock()
+	fmt.Fprintf(os.Stderr, "\ncapture: %d requests, %d endpoints on %s\n", len(seen), len(learned.Endpoints), firstNonEmpty(learned.Domain, "(no API traffic)"))
+	if len(learned.Endpoints) == 0 {
+		return
+	}
+
+	find := findingFromProfile(existing)
+	added := 0
+	for _, ep := range learned.Endpoints {
+		if existing.Match(ep.Method, ep.Path) == nil {
+			added++
+			fmt.Fprintf(os.Stderr, "  + %-6s %s\n", ep.Method, ep.Path)
+		}
+	}
+	find.Merge(learned)
+	if a := learned.Auth; a != nil {
+		fmt.Fprintf(os.Stderr, "  auth: %s\n", describeAuth(a))
+		if existing.Auth.Type != "" {
+			fmt.Fprintln(os.Stderr, "  (the profile's auth block is kept as it is)")
+		}
+	}
+	if *dryRun || *name == "" {
+		return
+	}
+	path, err := writeProfile(dir, *name, firstNonEmpty(find.Domain, learned.Domain), find, profileSaveOpts{})
+	if err != nil {
+		fail(err)
+	}
+	fmt.Fprintf(os.Stderr, "✅ Profile saved: %s (%d new endpoints)\n", path, added)
+}
+
+// findingFromProfile turns a saved profile back into a Finding so new
+// evidence can be merged into it and written out again.
+func findingFromProfile(p *profile.Profile) discovery.Finding {
+	find := discovery.Finding{
+		BaseURLs:   p.BaseURLs,
+		DocURLs:    p.DocURLs,
+		SpecURL:    p.SpecURL,
+		Confidence: p.Confidence,
+	}
+	if u, err := url.Parse(firstOf(p.BaseURLs)); err == nil {
+		find.Domain = u.Hostname()
+	}
+	for _, ep := range p.Endpoints {
+		out := discovery.Endpoint{Method: ep.Method, Path: ep.Path, Score: ep.Score, Schema: ep.Schema}
+		if ep.Pagination != nil {
+			pg := *ep.Pagination
+			out.Pagination = &pg
+		}
+		for _, ev := range ep.Evidence {
+			e := discovery.Evidence{Source: ev.Source, URL: ev.URL, When: ev.When, Score: ev.Score}
+			if len(ev.TimingMs) > 0 {
+				d := func(k string) time.Duration { return time.Duration(ev.TimingMs[k] * float64(time.Millisecond)) }
+				e.Timing = &transport.Timing{DNS: d("dns"), Connect: d("connect"), TLS: d("tls"), TTFB: d("ttfb"), Total: d("total")}
+			}
+			out.Evidence = append(out.Evidence, e)
+		}
+		find.Endpoints = append(find.Endpoints, out)
+	}
+	return find
+}
+
+func describeAuth(a *discovery.AuthHint) string {
+	switch a.Type {
+	case "bearer":
+		return "bearer token (Authorization: Bearer)"
+	case "basic":
+		return "HTTP basic (Authorization: Basic)"
+	case "query":
+		return fmt.Sprintf("query parameter %q (pass it with --query; not stored)", a.Name)
+	case "cookie":
+		return "session cookie"
+	case "none":
+		return "none"
+	}
+	return fmt.Sprintf("%s header", a.Name)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/bspippi1337/restless/internal/core/codegen"
+	"github.com/bspippi1337/restless/internal/core/openapi"
+	"github.com/bspippi1337/restless/internal/core/transport"
+	"github.com/bspippi1337/restless/internal/help"
+)
+
+// cmdCodegen writes a client package for a profile.
+func cmdCodegen(args []string) {
+	fs := flag.NewFlagSet("codegen", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+
+	var (
+		profileDir = fs.String("profile-dir", "", "Custom profile storage directory")
+		envName    = fs.String("env", "", "Profile environment (its base URL and headers)")
+		out        = fs.String("o", "", "Output directory")
+		pkg        = fs.String("package", "", "Package name (default: the output directory's name)")
+		specFlag   = fs.String("spec", "", "OpenAPI document (file or URL) for names and types")
+		noSpec     = fs.Bool("no-spec", false, "Generate from the profile alone")
+	)
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), help.CodegenHelp(help.NewDiscoverHelpContext(*profileDir))) }
+
+	rest, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+	if len(rest) != 2 || *out == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if rest[0] != "go" {
+		fmt.Fprintf(os.Stderr, "codegen error: unknown language %q (want go)\n", rest[0])
+		os.Exit(2)
+	}
+	fail := func(err error) {
+		fmt.Fprintf(os.Stderr, "codegen error: %v\n", err)
+		os.Exit(1)
+	}
+
+	prof, name, err := loadProfile(*profileDir, rest[1], *envName)
+	if err != nil {
+		fail(err)
+	}
+	var spec *openapi.Spec
+	if !*noSpec {
+		cfg := clientConfig{Retries: transport.DefaultRetry.MaxRetries}
+		s, _, err := loadSpec(prof, name, *specFlag, cfg)
+		switch {
+		case err == nil:
+			spec = s
+		case *specFlag != "":
+			fail(err)
+		case prof.SpecURL != "" || len(prof.DocURLs) > 0:
+			fmt.Fprintf(os.Stderr, "codegen: no spec (%v); types fall back to json.RawMessage\n", err)
+		}
+	}
+
+	dir, err := filepath.Abs(*out)
+	if err != nil {
+		fail(err)
+	}
+	if *pkg == "" {
+		*pkg = packageName(filepath.Base(dir))
+	}
+	files, err := codegen.Go(codegen.GoOptions{Package: *pkg, Profile: prof, Spec: spec})
+	if err != nil {
+		fail(err)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		fail(err)
+	}
+	names := make([]string, 0, len(files))
+	for n := range files {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	// Check every file before writing any, so a refusal leaves nothing half done.
+	for _, n := range names {
+		old, err := os.ReadFile(filepath.Join(dir, n))
+		if err == nil && !bytes.HasPrefix(old, []byte(codegen.Header)) {
+			fail(fmt.Errorf("%s exists and was not generated by restless; not overwriting", filepath.Join(*out, n)))
+		}
+	}
+	changed := 0
+	for _, n := range names {
+		path := filepath.Join(dir, n)
+		if old, err := os.ReadFile(path); err == nil && bytes.Equal(old, files[n]) {
+			continue
+		}
+		if err := os.WriteFile(path, files[n], 0o644); err != nil {
+			fail(err)
+		}
+		changed++
+	}
+	if changed == 0 {
+		fmt.Fprintf(os.Stderr, "✅ %s is up to date\n", *out)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "✅ Wrote package %s to %s\n", *pkg, *out)
+}
+
+// packageName turns a directory name into a Go package name.
+func packageName(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) && b.Len() > 0 {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return "client"
+	}
+	return b.String()
+}
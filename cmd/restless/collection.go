@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bspippi1337/restless/internal/core/discovery"
+	"github.com/bspippi1337/restless/internal/core/openapi"
+	"github.com/bspippi1337/restless/internal/core/profile"
+	"github.com/bspippi1337/restless/internal/core/transport"
+	"github.com/bspippi1337/restless/internal/help"
+)
+
+// generateRequests turns discovered endpoints into collection entries,
+// skipping names that already exist. Spec operations contribute required
+// parameters and a sample body built from the request schema.
+func generateRequests(find discovery.Finding, existing []profile.Request, redact bool) []profile.Request {
+	taken := map[string]bool{}
+	for _, r := range existing {
+		taken[r.Name] = true
+	}
+	var out []profile.Request
+	for _, ep := range find.Endpoints {
+		r := profile.Request{
+			Name:    requestName(ep),
+			Method:  ep.Method,
+			Path:    ep.Path,
+			Query:   map[string]string{},
+			Headers: map[string]string{},
+			Vars:    map[string]string{},
+		}
+		if taken[r.Name] {
+			continue
+		}
+		taken[r.Name] = true
+		if op := ep.Operation; op != nil {
+			r.Description = op.Summary
+			for _, p := range op.Params {
+				if !p.Required && p.In != "path" {
+					continue
+				}
+				ref := "${" + p.Name + "}"
+				switch p.In {
+				case "query":
+					r.Query[p.Name] = ref
+				case "header":
+					if transport.IsSecret(p.Name) {
+						continue // auth comes from the profile
+					}
+					r.Headers[p.Name] = ref
+				case "path":
+				default:
+					continue
+				}
+				if v, ok := openapi.Example(p.Schema); ok && !(redact && transport.IsSecret(p.Name)) {
+					r.Vars[p.Name] = v
+				}
+			}
+			if b := op.Body; b != nil && isJSONType(b.ContentType) {
+				sample, _ := json.Marshal(openapi.Sample(b.Schema))
+				if redact {
+					sample = transport.RedactBody("application/json", sample)
+				}
+				var pretty bytes.Buffer
+				if json.Indent(&pretty, sample, "", "  ") == nil {
+					sample = pretty.Bytes()
+				}
+				r.Body = string(sample)
+				if b.ContentType != "application/json" {
+					r.Headers["Content-Type"] = b.ContentType
+				}
+			}
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func isJSONType(ct string) bool {
+	return ct == "application/json" || strings.HasSuffix(ct, "+json")
+}
+
+// requestName uses the operationId when there is one ("listModels" ->
+// "list-models"), else the method and path ("get-v1-models-id").
+func requestName(ep discovery.Endpoint) string {
+	if ep.Operation != nil && ep.Operation.ID != "" {
+		return kebab(ep.Operation.ID)
+	}
+	return kebab(strings.ToLower(ep.Method) + " " + ep.Path)
+}
+
+func kebab(s string) string {
+	var b strings.Builder
+	prevLower := false
+	for _, r := range s {
+		switch {
+		case unicode.IsUpper(r):
+			if prevLower {
+				b.WriteByte('-')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			prevLower = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			prevLower = true
+		default:
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "-") {
+				b.WriteByte('-')
+			}
+			prevLower = false
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+func writeRequest(sb *strings.Builder, r profile.Request) {
+	sb.WriteString(fmt.Sprintf("  - name: %s\n", yamlScalar(r.Name)))
+	if r.Description != "" {
+		sb.WriteString(fmt.Sprintf("    description: %s\n", yamlScalar(r.Description)))
+	}
+	sb.WriteString(fmt.Sprintf("    method: %s\n", r.Method))
+	sb.WriteString(fmt.Sprintf("    path: %s\n", yamlScalar(r.Path)))
+	for _, blk := range []struct {
+		key string
+		m   map[string]string
+	}{{"query", r.Query}, {"headers", r.Headers}, {"vars", r.Vars}} {
+		if len(blk.m) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("    %s:\n", blk.key))
+		keys := make([]string, 0, len(blk.m))
+		for k := range blk.m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sb.WriteString(fmt.Sprintf("      %s: %s\n", yamlScalar(k), yamlScalar(blk.m[k])))
+		}
+	}
+	if len(r.Tags) > 0 {
+		sb.WriteString("    tags:\n")
+		for _, t := range r.Tags {
+			sb.WriteString(fmt.Sprintf("      - %s\n", yamlScalar(t)))
+		}
+	}
+	if r.Body != "" {
+		sb.WriteString("    body: |\n")
+		for _, line := range strings.Split(strings.TrimRight(r.Body, "\n"), "\n") {
+			sb.WriteString("      " + line + "\n")
+		}
+	}
+}
+
+// yamlScalar quotes values a YAML reader could misread.
+func yamlScalar(s string) string {
+	if s == "" || s != strings.TrimSpace(s) || strings.ContainsAny(s, "#\"'\n") || strings.Contains(s, ": ") ||
+		strings.HasSuffix(s, ":") || strings.ContainsRune("-?:,[]{}&*!|>%@`", rune(s[0])) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// cmdRun sends a collection request. Everything after the name that isn't
+// --var is passed on to `restless request`, so output, env and auth flags
+// work the same way.
+func cmdRun(args []string) {
+	vars, args := takeFlag(args, "var")
+	dirs, args := takeFlag(args, "profile-dir")
+	dir := ""
+	if len(dirs) > 0 {
+		dir = dirs[len(dirs)-1]
+	}
+	var pos []string
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") && len(pos) < 2 {
+		pos, args = append(pos, args[0]), args[1:]
+	}
+	if len(pos) == 0 || hasFlag(args, "h") || hasFlag(args, "help") {
+		fmt.Println(help.RunHelp(help.NewDiscoverHelpContext(dir)))
+		if len(pos) == 0 && !hasFlag(args, "h") && !hasFlag(args, "help") {
+			os.Exit(2)
+		}
+		return
+	}
+	if dir == "" {
+		dir = defaultProfileDir()
+	}
+	prof, err := profile.Load(dir, pos[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "profile error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(pos) == 1 {
+		listCollection(prof)
+		return
+	}
+	entry := prof.Request(pos[1])
+	if entry == nil {
+		fmt.Fprintf(os.Stderr, "run error: profile %q has no request %q (restless run %s lists them)\n", prof.Name, pos[1], prof.Name)
+		os.Exit(1)
+	}
+	given := map[string]string{}
+	for _, kv := range vars {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			fmt.Fprintf(os.Stderr, "run error: bad --var %q (want name=value)\n", kv)
+			os.Exit(2)
+		}
+		given[k] = v
+	}
+	r, err := prof.WithVars(*entry).Render(given)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "run error: %v\n", err)
+		os.Exit(2)
+	}
+
+	reqArgs := []string{"--profile", prof.Name, "--method", r.Method, "--path", r.Path}
+	if len(dirs) > 0 {
+		reqArgs = append(reqArgs, "--profile-dir", dir)
+	}
+	for _, k := range sortedKeys(r.Query) {
+		reqArgs = append(reqArgs, "--query", k+"="+r.Query[k])
+	}
+	for _, k := range sortedKeys(r.Headers) {
+		reqArgs = append(reqArgs, "-H", k+": "+r.Headers[k])
+	}
+	if r.Body != "" && !hasFlag(args, "data") && !hasFlag(args, "data-raw") {
+		reqArgs = append(reqArgs, dataArgs(r.Body)...)
+	}
+	cmdRequest(append(reqArgs, args...))
+}
+
+func listCollection(prof *profile.Profile) {
+	if len(prof.Collection) == 0 {
+		fmt.Fprintf(os.Stderr, "profile %q has no saved requests (discover --emit-examples adds them)\n", prof.Name)
+		return
+	}
+	width := 0
+	for _, r := range prof.Collection {
+		width = max(width, len(r.Name))
+	}
+	for _, r := range prof.Collection {
+		line := fmt.Sprintf("%-*s  %-6s %s", width, r.Name, r.Method, r.Path)
+		if r.Description != "" {
+			line += "  — " + r.Description
+		}
+		fmt.Println(line)
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// takeFlag removes every --name value / --name=value from args.
+func takeFlag(args []string, name string) (values, rest []string) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		trimmed := strings.TrimLeft(a, "-")
+		switch {
+		case a != trimmed && trimmed == name && i+1 < len(args):
+			values = append(values, args[i+1])
+			i++
+		case a != trimmed && strings.HasPrefix(trimmed, name+"="):
+			values = append(values, strings.TrimPrefix(trimmed, name+"="))
+		default:
+			rest = append(rest, a)
+		}
+	}
+	return values, rest
+}
+
+// dataArgs passes a literal body to `restless request`: --data, or
+// --data-raw when the body itself starts with "@" and would be read as a
+// file name.
+func dataArgs(body string) []string {
+	if strings.HasPrefix(body, "@") {
+		return []string{"--data-raw", body}
+	}
+	return []string{"--data", body}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/bspippi1337/restless/internal/core/contract"
+	"github.com/bspippi1337/restless/internal/core/history"
+	"github.com/bspippi1337/restless/internal/core/openapi"
+	"github.com/bspippi1337/restless/internal/core/profile"
+	"github.com/bspippi1337/restless/internal/core/transport"
+	"github.com/bspippi1337/restless/internal/help"
+	"github.com/bspippi1337/restless/internal/render"
+)
+
+// cmdContract calls every safe operation of a profile's OpenAPI spec and
+// reports where the live API drifts from it. Exit status is 1 on drift.
+func cmdContract(args []string) {
+	fs := flag.NewFlagSet("contract", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+
+	var (
+		profileDir = fs.String("profile-dir", "", "Custom profile storage directory")
+		envName    = fs.String("env", "", "Profile environment to use")
+		specFlag   = fs.String("spec", "", "OpenAPI document (file or URL) instead of the one discovery recorded")
+		jsonOut    = fs.Bool("json", false, "Print results as NDJSON")
+		timeout    = fs.Int("timeout", 0, "Request timeout in seconds (default from profile)")
+		proxy      = fs.String("proxy", "", "Proxy URL (http, https or socks5)")
+		noHistory  = fs.Bool("no-history", false, "Don't record the requests in the history")
+		vars       multiFlag
+		only       multiFlag
+	)
+	fs.Var(&vars, "var", "Parameter value name=value (repeatable)")
+	fs.Var(&only, "only", "Only check this operationId or path (repeatable)")
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), help.ContractHelp(help.NewDiscoverHelpContext(*profileDir))) }
+
+	rest, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+	if len(rest) != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	fail := func(err error) {
+		fmt.Fprintf(os.Stderr, "contract error: %v\n", err)
+		os.Exit(2)
+	}
+
+	given := map[string]string{}
+	for _, kv := range vars {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			fail(fmt.Errorf("bad --var %q (want name=value)", kv))
+		}
+		given[k] = v
+	}
+	prof, name, err := loadProfile(*profileDir, rest[0], *envName)
+	if err != nil {
+		fail(err)
+	}
+	cfg := clientConfig{Proxy: *proxy, Retries: transport.DefaultRetry.MaxRetries, Timeout: *timeout}
+	spec, specURL, err := loadSpec(prof, name, *specFlag, cfg)
+	if err != nil {
+		fail(err)
+	}
+
+	var ops []*openapi.Operation
+	for i := range spec.Operations {
+		op := &spec.Operations[i]
+		if contract.Safe(op) && selected(op, only) {
+			ops = append(ops, op)
+		}
+	}
+	if len(ops) == 0 {
+		fail(fmt.Errorf("%s has no GET or HEAD operations to check", specURL))
+	}
+
+	var rec *history.Recorder
+	if !*noHistory && !history.Disabled() {
+		rec = &history.Recorder{Profile: name, ProfileDir: *profileDir, Env: *envName, BaseURL: firstOf(prof.BaseURLs)}
+		cfg.History = rec
+	}
+	client, err := newClient(prof, name, cfg)
+	if err != nil {
+		fail(err)
+	}
+	r := &contract.Runner{
+		Client: client,
+		Vars:   given,
+		Build: func(ctx context.Context, c contract.Call) (*http.Request, error) {
+			var query, headers []string
+			for _, k := range sortedKeys(c.Query) {
+				query = append(query, k+"="+c.Query[k])
+			}
+			for _, k := range sortedKeys(c.Headers) {
+				headers = append(headers, k+": "+c.Headers[k])
+			}
+			target, err := resolveURL(prof, "", c.Path, query)
+			if err != nil {
+				return nil, err
+			}
+			if rec != nil {
+				rec.HeaderArgs = headerNames(headers)
+			}
+			return newRequest(ctx, prof, c.Method, target, nil, headers)
+		},
+	}
+
+	out := os.Stdout
+	if *jsonOut {
+		enc := json.NewEncoder(out)
+		enc.SetEscapeHTML(false)
+		r.OnResult = func(res contract.Result) { _ = enc.Encode(res) }
+	} else {
+		fmt.Fprintf(out, "contract: %s against %s (spec: %s)\n", name, firstOf(prof.BaseURLs), specURL)
+		r.OnResult = contractPrinter(out, ops)
+	}
+	results := r.Run(context.Background(), ops)
+
+	var ok, drift, skipped, failed int
+	for _, res := range results {
+		switch {
+		case res.Skipped != "":
+			skipped++
+		case res.Error != "":
+			failed++
+		case len(res.Problems) > 0:
+			drift++
+		default:
+			ok++
+		}
+	}
+	if !*jsonOut {
+		fmt.Fprintf(out, "%d operations: %d ok, %d drifted, %d failed, %d skipped\n", len(results), ok, drift, failed, skipped)
+	}
+	if drift+failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func contractPrinter(out *os.File, ops []*openapi.Operation) func(contract.Result) {
+	color := render.ColorEnabled(out)
+	paint := func(code, s string) string {
+		if !color {
+			return s
+		}
+		return "\x1b[" + code + "m" + s + "\x1b[0m"
+	}
+	width := 0
+	for _, op := range ops {
+		width = max(width, len(op.Path))
+	}
+	return func(res contract.Result) {
+		head := fmt.Sprintf("%-4s %-*s", res.Method, width, res.Path)
+		switch {
+		case res.Skipped != "":
+			fmt.Fprintf(out, "  %s %s  %s\n", paint("33", "[SKIP] "), head, res.Skipped)
+		case res.Error != "":
+			fmt.Fprintf(out, "  %s %s  %s\n", paint("31", "[FAIL] "), head, res.Error)
+		case len(res.Problems) > 0:
+			fmt.Fprintf(out, "  %s %s  %d  %.0fms\n", paint("31", "[DRIFT]"), head, res.Status, res.Duration)
+			for i, p := range res.Problems {
+				if i == 10 {
+					fmt.Fprintf(out, "          ... and %d more\n", len(res.Problems)-10)
+					break
+				}
+				fmt.Fprintf(out, "          %s\n", p)
+			}
+		default:
+			fmt.Fprintf(out, "  %s %s  %d  %.0fms\n", paint("32", "[ OK ] "), head, res.Status, res.Duration)
+		}
+	}
+}
+
+func selected(op *openapi.Operation, only []string) bool {
+	if len(only) == 0 {
+		return true
+	}
+	for _, o := range only {
+		if o == op.ID || o == op.Path {
+			return true
+		}
+	}
+	return false
+}
+
+// loadSpec reads --spec, else the spec discovery recorded, else the
+// first profile doc URL that parses. Profile auth is only sent to the
+// API's own host.
+func loadSpec(prof *profile.Profile, name, specFlag string, cfg clientConfig) (*openapi.Spec, string, error) {
+	if specFlag != "" && !strings.HasPrefix(specFlag, "http://") && !strings.HasPrefix(specFlag, "https://") {
+		b, err := os.ReadFile(specFlag)
+		if err != nil {
+			return nil, "", err
+		}
+		spec, err := openapi.Parse(b, firstOf(prof.BaseURLs))
+		return spec, specFlag, err
+	}
+	candidates := []string{specFlag}
+	if specFlag == "" {
+		candidates = append([]string{prof.SpecURL}, prof.DocURLs...)
+	}
+	var lastErr error
+	seen := map[string]bool{}
+	for _, u := range candidates {
+		if u == "" || seen[u] {
+			continue
+		}
+		seen[u] = true
+		spec, err := fetchSpec(prof, name, u, cfg)
+		if err == nil {
+			return spec, u, nil
+		}
+		lastErr = fmt.Errorf("%s: %w", u, err)
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("profile %q has no OpenAPI document; pass --spec", prof.Name)
+	}
+	return nil, "", lastErr
+}
+
+func fetchSpec(prof *profile.Profile, name, u string, cfg clientConfig) (*openapi.Spec, error) {
+	cfg.NoAuth = !sameHost(u, firstOf(prof.BaseURLs))
+	client, err := newClient(prof, name, cfg)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, err
+	}
+	return openapi.Parse(b, u)
+}
+
+func sameHost(a, b string) bool {
+	ua, errA := url.Parse(a)
+	ub, errB := url.Parse(b)
+	return errA == nil && errB == nil && ua.Host != "" && strings.EqualFold(ua.Host, ub.Host)
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bspippi1337/restless/internal/core/history"
+	"github.com/bspippi1337/restless/internal/core/openapi"
+	"github.com/bspippi1337/restless/internal/core/profile"
+	"github.com/bspippi1337/restless/internal/core/transport"
+	"github.com/bspippi1337/restless/internal/filter"
+	"github.com/bspippi1337/restless/internal/help"
+	"github.com/bspippi1337/restless/internal/jsondiff"
+	"github.com/bspippi1337/restless/internal/jsonschema"
+	"github.com/bspippi1337/restless/internal/render"
+)
+
+// side is one response being compared.
+type side struct {
+	Label  string `json:"label"`
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+	Type   string `json:"contentType,omitempty"`
+	Body   []byte `json:"-"`
+	// Truncated is set for history entries whose body wasn't stored in full.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// cmdDiff compares two responses: the same request against two
+// environments, a history entry against a fresh call, or two history
+// entries. Exit status follows diff(1): 0 same, 1 different, 2 trouble.
+func cmdDiff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+
+	var (
+		profileName = fs.String("profile", "", "Profile to use (defaults to the active profile)")
+		profileDir  = fs.String("profile-dir", "", "Custom profile storage directory")
+		method      = fs.String("method", "GET", "HTTP method")
+		path        = fs.String("path", "", "Request path, joined to the profile base URL")
+		data        = fs.String("data", "", "Request body (@file reads a file, @- reads stdin)")
+		timeout     = fs.Int("timeout", 0, "Request timeout in seconds (default from profile)")
+		proxy       = fs.String("proxy", "", "Proxy URL (http, https or socks5)")
+		volatile    = fs.Bool("ignore-volatile", false, "Ignore timestamps, request ids, etags and similar fields")
+		jsonOut     = fs.Bool("json", false, "Output the changes as JSON")
+		quiet       = fs.Bool("quiet", false, "Only set the exit status")
+		shape       = fs.Bool("shape", false, "Compare fields and types instead of values")
+		envs        multiFlag
+		headers     multiFlag
+		query       multiFlag
+		ignore      multiFlag
+		idKeys      multiFlag
+	)
+	fs.Var(&envs, "env", "Environment to compare (give two)")
+	fs.Var(&headers, "H", "Extra header \"Name: value\" (repeatable)")
+	fs.Var(&headers, "header", "Extra header \"Name: value\" (repeatable)")
+	fs.Var(&query, "query", "Query parameter key=value (repeatable)")
+	fs.Var(&ignore, "ignore", "Field name or path to ignore, e.g. updatedAt or .meta.requestId (repeatable)")
+	fs.Var(&idKeys, "id-key", "Key used to align arrays of objects (repeatable; default id, uuid, key, slug, name)")
+
+	fs.Usage = func() {
+		ctx := help.NewDiscoverHelpContext(*profileDir)
+		if st, ok := loadState(); ok {
+			ctx.ActiveProfile = st.ActiveProfile
+		}
+		fmt.Fprintln(fs.Output(), help.DiffHelp(ctx))
+	}
+	rest, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+	fail := func(err error) {
+		fmt.Fprintf(os.Stderr, "diff error: %v\n", err)
+		os.Exit(2)
+	}
+
+	cfg := clientConfig{Proxy: *proxy, Retries: transport.DefaultRetry.MaxRetries, Timeout: *timeout}
+	store := history.Open(configDir())
+	var left, right side
+	switch {
+	case len(rest) > 0 && isHistoryRef(rest[0]):
+		// History mode: `diff 12 15` or `diff 12 [--env prod]`.
+		var unused []string
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "profile", "profile-dir", "method", "path", "query":
+				unused = append(unused, "--"+f.Name)
+			}
+		})
+		if len(unused) > 0 {
+			fail(fmt.Errorf("%s can't be used with a history entry; it is sent again with its own profile and URL", strings.Join(unused, ", ")))
+		}
+		a, err := lookupEntry(store, rest[0])
+		if err != nil {
+			fail(err)
+		}
+		left = storedSide(a)
+		if len(rest) > 1 {
+			b, err := lookupEntry(store, rest[1])
+			if err != nil {
+				fail(err)
+			}
+			right = storedSide(b)
+			break
+		}
+		if len(envs) > 1 {
+			fail(errors.New("give at most one --env when comparing with a history entry"))
+		}
+		env := a.Env
+		if len(envs) == 1 {
+			env = envs[0]
+		}
+		body := []byte(a.Request.Body)
+		if a.Request.Size > 0 && !a.Request.Replayable() {
+			if *data == "" {
+				fail(fmt.Errorf("the request body of #%d was not stored in full; pass --data", a.ID))
+			}
+		}
+		if *data != "" {
+			if body, err = readBody(*data); err != nil {
+				fail(err)
+			}
+		}
+		prof, name, err := loadProfile(a.ProfileDir, a.Profile, env)
+		if err != nil {
+			fail(err)
+		}
+		target := replayTarget(a)
+		if a.Profile != "" && a.Path != "" {
+			if target, err = resolveURL(prof, "", target, nil); err != nil {
+				fail(err)
+			}
+		}
+		right, err = fetchSide(prof, name, a.ProfileDir, env, a.Method, target, body, append(replayHeaders(a), headers...), cfg)
+		if err != nil {
+			fail(err)
+		}
+		// Stored bodies have secret fields redacted; hide them here too so
+		// they compare equal and the live values aren't printed.
+		right.Body = transport.RedactBody(right.Type, right.Body)
+		right.Label = "now"
+		if env != a.Env {
+			right.Label = env
+		}
+
+	default:
+		if len(rest) >= 2 {
+			*method, *path = rest[0], rest[1]
+		} else if len(rest) == 1 {
+			*path = rest[0]
+		}
+		if len(envs) != 2 || *path == "" {
+			fs.Usage()
+			os.Exit(2)
+		}
+		body, err := readBody(*data)
+		if err != nil {
+			fail(err)
+		}
+		sides := make([]side, 2)
+		for i, env := range envs {
+			prof, name, err := loadProfile(*profileDir, *profileName, env)
+			if err != nil {
+				fail(err)
+			}
+			target, err := resolveURL(prof, "", *path, query)
+			if err != nil {
+				fail(err)
+			}
+			if sides[i], err = fetchSide(prof, name, *profileDir, env, *method, target, body, headers, cfg); err != nil {
+				fail(fmt.Errorf("%s: %w", env, err))
+			}
+			sides[i].Label = env
+		}
+		left, right = sides[0], sides[1]
+	}
+
+	opt := jsondiff.Options{Ignore: ignore, IgnoreVolatile: *volatile}
+	if len(idKeys) > 0 {
+		opt.IDKeys = idKeys
+	}
+	if *shape {
+		// Shapes hold one merged element per array, so align by position.
+		left.Body, right.Body = shapeBody(left.Body), shapeBody(right.Body)
+		opt.IDKeys = []string{}
+	}
+	changes, err := compareSides(left, right, opt)
+	if err != nil {
+		fail(err)
+	}
+
+	switch {
+	case *quiet:
+	case *jsonOut:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(struct {
+			Left    side              `json:"left"`
+			Right   side              `json:"right"`
+			Changes []jsondiff.Change `json:"changes"`
+		}{left, right, changes})
+	default:
+		writeDiff(os.Stdout, left, right, changes)
+	}
+	if len(changes) > 0 {
+		os.Exit(1)
+	}
+}
+
+func isHistoryRef(s string) bool {
+	if s == "last" {
+		return true
+	}
+	_, err := strconv.Atoi(strings.TrimPrefix(s, "#"))
+	return err == nil
+}
+
+func storedSide(e history.Entry) side {
+	s := side{Label: "#" + strconv.Itoa(e.ID), URL: e.URL, Status: e.Status}
+	if e.Response != nil {
+		s.Type = e.Response.Headers["Content-Type"]
+		s.Body = []byte(e.Response.Body)
+		s.Truncated = e.Response.Truncated || e.Response.Binary
+	}
+	return s
+}
+
+func fetchSide(prof *profile.Profile, name, dir, env, method, target string, body []byte, headers []string, cfg clientConfig) (side, error) {
+	if !history.Disabled() {
+		cfg.History = &history.Recorder{Profile: name, ProfileDir: dir, Env: env, BaseURL: firstOf(prof.BaseURLs), HeaderArgs: headerNames(headers)}
+	}
+	client, err := newClient(prof, name, cfg)
+	if err != nil {
+		return side{}, err
+	}
+	req, err := newRequest(context.Background(), prof, method, target, body, headers)
+	if err != nil {
+		return side{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return side{}, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return side{}, err
+	}
+	return side{URL: transport.RedactURL(req.URL), Status: resp.StatusCode, Type: resp.Header.Get("Content-Type"), Body: b}, nil
+}
+
+// compareSides diffs status and body. Non-JSON bodies are only compared
+// byte-for-byte.
+func compareSides(a, b side, opt jsondiff.Options) ([]jsondiff.Change, error) {
+	var out []jsondiff.Change
+	if a.Status != b.Status {
+		out = append(out, jsondiff.Change{Path: "(status)", Kind: jsondiff.Changed, Old: a.Status, New: b.Status})
+	}
+	da, errA := filter.Decode(a.Body)
+	db, errB := filter.Decode(b.Body)
+	switch {
+	case errA == nil && errB == nil:
+		out = append(out, jsondiff.Diff(da, db, opt)...)
+	case a.Truncated || b.Truncated:
+		return nil, errors.New("a stored body was truncated or binary and can't be compared")
+	case !bytes.Equal(a.Body, b.Body):
+		// Not JSON: report the sizes.
+		out = append(out, jsondiff.Change{Path: "(body)", Kind: jsondiff.Changed, Old: len(a.Body), New: len(b.Body)})
+	}
+	return out, nil
+}
+
+// shapeBody replaces a JSON body with its shape: every value becomes its
+// type (with a format such as date-time) and an array's items are merged
+// into one element. Other bodies are returned unchanged.
+func shapeBody(b []byte) []byte {
+	v, err := filter.Decode(b)
+	if err != nil {
+		return b
+	}
+	out, err := json.Marshal(shapeOf(jsonschema.Infer(v)))
+	if err != nil {
+		return b
+	}
+	return out
+}
+
+func shapeOf(s map[string]any) any {
+	switch openapi.Type(s) {
+	case "object":
+		props, _ := s["properties"].(map[string]any)
+		out := make(map[string]any, len(props))
+		for k, p := range props {
+			ps, _ := p.(map[string]any)
+			out[k] = shapeOf(ps)
+		}
+		return out
+	case "array":
+		if items, ok := s["items"].(map[string]any); ok {
+			return []any{shapeOf(items)}
+		}
+		return []any{}
+	}
+	var types []string
+	switch t := s["type"].(type) {
+	case string:
+		types = []string{t}
+	case []any:
+		for _, x := range t {
+			types = append(types, fmt.Sprint(x))
+		}
+	}
+	desc := strings.Join(types, "|")
+	if f, ok := s["format"].(string); ok {
+		desc += "(" + f + ")"
+	}
+	return firstNonEmpty(desc, "any")
+}
+
+func writeDiff(w *os.File, a, b side, changes []jsondiff.Change) {
+	color := render.ColorEnabled(w)
+	paint := func(code, s string) string {
+		if !color {
+			return s
+		}
+		return "\x1b[" + code + "m" + s + "\x1b[0m"
+	}
+	width := max(len(a.Label), len(b.Label))
+	fmt.Fprintln(w, paint("1;31", fmt.Sprintf("--- %-*s  %d  %s", width, a.Label, a.Status, a.URL)))
+	fmt.Fprintln(w, paint("1;32", fmt.Sprintf("+++ %-*s  %d  %s", width, b.Label, b.Status, b.URL)))
+	for _, c := range changes {
+		switch c.Kind {
+		case jsondiff.Added:
+			fmt.Fprintln(w, paint("32", "+ "+c.Path+": "+short(c.New)))
+		case jsondiff.Removed:
+			fmt.Fprintln(w, paint("31", "- "+c.Path+": "+short(c.Old)))
+		default:
+			fmt.Fprintln(w, paint("33", "~ "+c.Path+": "+short(c.Old)+" → "+short(c.New)))
+		}
+	}
+	fmt.Fprintln(w, jsondiff.Summary(changes))
+}
+
+// short renders a value on one line, cut at 80 characters.
+func short(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if r := []rune(string(b)); len(r) > 80 {
+		return string(r[:77]) + "..."
+	}
+	return string(b)
+}
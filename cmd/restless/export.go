@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/bspippi1337/restless/internal/core/openapi"
+	"github.com/bspippi1337/restless/internal/core/postman"
+	"github.com/bspippi1337/restless/internal/help"
+)
+
+// cmdExport writes a profile in another tool's format.
+func cmdExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+
+	var (
+		profileDir = fs.String("profile-dir", "", "Custom profile storage directory")
+		envName    = fs.String("env", "", "Profile environment (its base URL and headers)")
+		out        = fs.String("o", "", "Write to a file instead of stdout")
+	)
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), help.ExportHelp(help.NewDiscoverHelpContext(*profileDir))) }
+
+	rest, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+	if len(rest) != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	fail := func(err error) {
+		fmt.Fprintf(os.Stderr, "export error: %v\n", err)
+		os.Exit(1)
+	}
+
+	prof, _, err := loadProfile(*profileDir, rest[1], *envName)
+	if err != nil {
+		fail(err)
+	}
+	var doc any
+	switch rest[0] {
+	case "postman":
+		doc = postman.Export(prof)
+	case "openapi":
+		doc = openapi.FromProfile(prof)
+	default:
+		fmt.Fprintf(os.Stderr, "export error: unknown format %q (want openapi or postman)\n", rest[0])
+		os.Exit(2)
+	}
+
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		fail(err)
+	}
+	b = append(b, '\n')
+	if *out == "" {
+		_, _ = os.Stdout.Write(b)
+		return
+	}
+	if err := os.WriteFile(*out, b, 0o644); err != nil {
+		fail(err)
+	}
+	fmt.Fprintf(os.Stderr, "✅ Wrote %s\n", *out)
+}
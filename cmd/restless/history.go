@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/history"
+	"github.com/bspippi1337/restless/internal/help"
+	"github.com/bspippi1337/restless/internal/render"
+)
+
+func cmdHistory(args []string) {
+	sub := "list"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		switch args[0] {
+		case "list", "show", "clear":
+			sub, args = args[0], args[1:]
+		}
+	}
+	store := history.Open(configDir())
+
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	var (
+		profileName = fs.String("profile", "", "Only entries for this profile")
+		envName     = fs.String("env", "", "Only entries for this environment")
+		method      = fs.String("method", "", "Only entries with this method")
+		status      = fs.String("status", "", "Only entries with this status (404, 4xx or error)")
+		since       = fs.Duration("since", 0, "Only entries newer than this, e.g. 24h")
+		limit       = fs.Int("limit", 20, "Show at most this many entries (0 = all)")
+		jsonOut     = fs.Bool("json", false, "Output entries as NDJSON")
+	)
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), help.HistoryHelp(help.NewDiscoverHelpContext(""))) }
+	rest, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+
+	switch sub {
+	case "clear":
+		if err := store.Clear(); err != nil {
+			fmt.Fprintf(os.Stderr, "history error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stderr, "history cleared")
+		return
+	case "show":
+		if len(rest) != 1 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		e, err := lookupEntry(store, rest[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "history error: %v\n", err)
+			os.Exit(1)
+		}
+		if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			_ = enc.Encode(e)
+			return
+		}
+		showEntry(e)
+		return
+	}
+
+	q := history.Query{
+		Profile: *profileName,
+		Env:     *envName,
+		Method:  *method,
+		Status:  *status,
+		Terms:   rest,
+		Limit:   *limit,
+	}
+	if *since > 0 {
+		q.Since = time.Now().Add(-*since)
+	}
+	entries, err := store.List(q)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "history error: %v\n", err)
+		os.Exit(1)
+	}
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		for _, e := range entries {
+			_ = enc.Encode(e)
+		}
+		return
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(os.Stderr, "no matching history entries")
+		return
+	}
+	// Oldest at the bottom reads like a shell history.
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		st := strconv.Itoa(e.Status)
+		if e.Status == 0 {
+			st = "ERR"
+		}
+		line := fmt.Sprintf("%5d  %s  %-6s %3s %8s  %s", e.ID, e.Time.Local().Format("2006-01-02 15:04"),
+			e.Method, st, fmtMs(e.Duration), e.URL)
+		if e.Profile != "" {
+			line += "  [" + e.Profile
+			if e.Env != "" {
+				line += "/" + e.Env
+			}
+			line += "]"
+		}
+		fmt.Println(line)
+	}
+}
+
+func fmtMs(ms float64) string {
+	if ms >= 1000 {
+		return fmt.Sprintf("%.2fs", ms/1000)
+	}
+	return fmt.Sprintf("%.0fms", ms)
+}
+
+// lookupEntry accepts an id or "last".
+func lookupEntry(store *history.Store, ref string) (history.Entry, error) {
+	if ref == "last" {
+		return store.Last()
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(ref, "#"))
+	if err != nil {
+		return history.Entry{}, fmt.Errorf("bad history id %q", ref)
+	}
+	return store.Get(id)
+}
+
+func showEntry(e history.Entry) {
+	tty := render.IsTerminal(os.Stdout)
+	ro := render.Options{Color: tty && render.ColorEnabled(os.Stdout), Format: tty}
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "#%d  %s", e.ID, e.Time.Local().Format(time.RFC3339))
+	if e.Profile != "" {
+		fmt.Fprintf(&b, "  profile=%s", e.Profile)
+	}
+	if e.Env != "" {
+		fmt.Fprintf(&b, "  env=%s", e.Env)
+	}
+	b.WriteString("\n\n")
+
+	proto := "HTTP/1.1"
+	if e.Proto == "HTTP/2.0" {
+		proto = e.Proto
+	}
+	render.RequestLine(&b, e.Method, e.URL, proto, ro)
+	render.Headers(&b, toHeader(e.Request.Headers), nil, ro)
+	writeStoredBody(&b, e.Request, ro)
+
+	b.WriteString("\n")
+	if e.Response == nil {
+		fmt.Fprintf(&b, "error: %s\n", e.Error)
+	} else {
+		render.StatusLine(&b, e.Proto, fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)), e.Status, ro)
+		render.Headers(&b, toHeader(e.Response.Headers), nil, ro)
+		writeStoredBody(&b, *e.Response, ro)
+	}
+	if e.Timing != nil {
+		b.WriteString("\n")
+		for _, line := range e.Timing.Lines() {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+	if !tty {
+		_, _ = os.Stdout.Write(b.Bytes())
+		return
+	}
+	_ = render.Page(os.Stdout, b.Bytes())
+}
+
+func writeStoredBody(b *bytes.Buffer, m history.Message, ro render.Options) {
+	switch {
+	case m.Binary:
+		fmt.Fprintf(b, "\n[binary body, %d bytes not stored]\n", m.Size)
+	case m.Body != "":
+		b.WriteString("\n")
+		render.Body(b, m.Headers["Content-Type"], []byte(m.Body), ro)
+		if m.Truncated {
+			fmt.Fprintf(b, "[truncated: %d of %d bytes stored]\n", len(m.Body), m.Size)
+		}
+	}
+}
+
+func toHeader(m map[string]string) http.Header {
+	h := http.Header{}
+	for k, v := range m {
+		h[k] = []string{v}
+	}
+	return h
+}
+
+// cmdReplay resends a history entry through cmdRequest. Any request flags
+// after the id are applied on top, so `replay 12 --env prod -H 'X: 1'`
+// edits the original call.
+func cmdReplay(args []string) {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+		fmt.Println(help.HistoryHelp(help.NewDiscoverHelpContext("")))
+		if len(args) == 0 {
+			os.Exit(2)
+		}
+		return
+	}
+	e, err := lookupEntry(history.Open(configDir()), args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay error: %v\n", err)
+		os.Exit(1)
+	}
+	edits := args[1:]
+	dryRun := false
+	for i, a := range edits {
+		if a == "--dry-run" || a == "-n" {
+			dryRun = true
+			edits = append(edits[:i:i], edits[i+1:]...)
+			break
+		}
+	}
+
+	reqArgs, err := replayArgs(e, hasFlag(edits, "data") || hasFlag(edits, "data-raw"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay error: %v\n", err)
+		os.Exit(1)
+	}
+	reqArgs = append(reqArgs, edits...)
+	if dryRun {
+		fmt.Println(shellJoin(append([]string{"restless", "request"}, reqArgs...)))
+		return
+	}
+	fmt.Fprintf(os.Stderr, "replaying #%d: %s %s\n", e.ID, e.Method, e.URL)
+	cmdRequest(reqArgs)
+}
+
+// replayArgs turns an entry back into request flags. Redacted values can't
+// be replayed: redacted headers are dropped (auth comes from the profile),
+// and a redacted, truncated or binary body must be replaced with --data.
+func replayArgs(e history.Entry, haveData bool) ([]string, error) {
+	var out []string
+	if e.Profile != "" {
+		out = append(out, "--profile", e.Profile)
+		if e.ProfileDir != "" {
+			out = append(out, "--profile-dir", e.ProfileDir)
+		}
+		if e.Env != "" {
+			out = append(out, "--env", e.Env)
+		}
+	}
+	out = append(out, "--method", e.Method, "--path", replayTarget(e))
+	for _, h := range replayHeaders(e) {
+		out = append(out, "-H", h)
+	}
+
+	if e.Request.Size > 0 && !haveData {
+		if !e.Request.Replayable() {
+			return nil, fmt.Errorf("the request body of #%d was not stored in full (redacted, truncated or binary); pass --data", e.ID)
+		}
+		out = append(out, dataArgs(e.Request.Body)...)
+	}
+	return out, nil
+}
+
+// replayTarget is the path relative to the profile when there is one, so a
+// different --env points the replay elsewhere; otherwise the full URL.
+func replayTarget(e history.Entry) string {
+	target := e.URL
+	if e.Profile != "" && e.Path != "" {
+		target = e.Path
+	}
+	if strings.Contains(target, "%5Bredacted%5D") || strings.Contains(target, "xxxxx@") {
+		fmt.Fprintf(os.Stderr, "replay: #%d has redacted URL credentials; pass --path or --query to replace them\n", e.ID)
+	}
+	return target
+}
+
+// replayHeaders returns "Name: value" headers to resend. With a profile,
+// only headers given on the command line are replayed; the rest come from
+// the profile (and a possibly different --env) again.
+func replayHeaders(e history.Entry) []string {
+	names := e.HeaderArgs
+	if e.Profile == "" {
+		for k := range e.Request.Headers {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+	}
+	var out []string
+	for _, k := range names {
+		v, ok := e.Request.Headers[k]
+		if !ok || strings.Contains(v, "[redacted]") {
+			continue
+		}
+		out = append(out, k+": "+v)
+	}
+	return out
+}
+
+func hasFlag(args []string, name string) bool {
+	for _, a := range args {
+		a = strings.TrimLeft(a, "-")
+		if a == name || strings.HasPrefix(a, name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+func shellJoin(args []string) string {
+	out := make([]string, len(args))
+	for i, a := range args {
+		if a != "" && strings.IndexFunc(a, func(r rune) bool {
+			return !(r == '-' || r == '_' || r == '/' || r == '.' || r == ':' || r == '=' || r == ',' ||
+				(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'))
+		}) < 0 {
+			out[i] = a
+			continue
+		}
+		out[i] = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+	}
+	return strings.Join(out, " ")
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/discovery"
+	"github.com/bspippi1337/restless/internal/core/postman"
+	"github.com/bspippi1337/restless/internal/core/profile"
+	"github.com/bspippi1337/restless/internal/help"
+)
+
+// cmdImport creates or updates a profile from another tool's file.
+func cmdImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+
+	var (
+		name       = fs.String("profile", "", "Profile to create or update (default: from the collection name)")
+		profileDir = fs.String("profile-dir", "", "Custom profile storage directory")
+		dryRun     = fs.Bool("dry-run", false, "Print what would be imported without saving")
+	)
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), help.ImportHelp(help.NewDiscoverHelpContext(*profileDir))) }
+
+	rest, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+	if len(rest) != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	fail := func(err error) {
+		fmt.Fprintf(os.Stderr, "import error: %v\n", err)
+		os.Exit(1)
+	}
+	if rest[0] != "postman" {
+		fmt.Fprintf(os.Stderr, "import error: unknown format %q (want postman)\n", rest[0])
+		os.Exit(2)
+	}
+
+	b, err := os.ReadFile(rest[1])
+	if err != nil {
+		fail(err)
+	}
+	coll, err := postman.Parse(b)
+	if err != nil {
+		fail(err)
+	}
+	im := coll.Import()
+	if *name == "" {
+		*name = kebab(im.Name)
+	}
+	if *name == "" {
+		fail(errors.New("the collection has no name; pass --profile"))
+	}
+
+	dir := *profileDir
+	if dir == "" {
+		dir = defaultProfileDir()
+	}
+	existing := &profile.Profile{}
+	if p, err := profile.Load(dir, *name); err == nil {
+		existing = p
+	} else if !errors.Is(err, os.ErrNotExist) {
+		fail(err)
+	}
+
+	src, _ := filepath.Abs(rest[1])
+	var known []string
+	for _, ep := range existing.Endpoints {
+		known = append(known, ep.Path)
+	}
+	learned := findingFromPostman(im, src, known)
+	find := findingFromProfile(existing)
+	find.Merge(learned)
+	if find.OAuth2 == nil {
+		find.OAuth2 = learned.OAuth2
+	}
+	if find.Domain == "" {
+		fail(errors.New("no base URL in the collection (set a baseUrl variable)"))
+	}
+
+	fmt.Fprintf(os.Stderr, "import: %q — %d requests, %d endpoints, base %s\n", im.Name, len(im.Requests), len(learned.Endpoints), firstNonEmpty(im.BaseURL, firstOf(find.BaseURLs)))
+	if a := learned.Auth; a != nil {
+		fmt.Fprintf(os.Stderr, "  auth: %s\n", describeAuth(a))
+	} else if learned.OAuth2 != nil {
+		fmt.Fprintf(os.Stderr, "  auth: oauth2 (%s)\n", learned.OAuth2.TokenURL)
+	}
+	if (learned.Auth != nil || learned.OAuth2 != nil) && existing.Auth.Type != "" {
+		fmt.Fprintln(os.Stderr, "  (the profile's auth block is kept as it is)")
+	}
+	for _, k := range sortedKeys(im.Vars) {
+		fmt.Fprintf(os.Stderr, "  var %s = %s\n", k, im.Vars[k])
+	}
+	for _, w := range im.Warnings {
+		fmt.Fprintf(os.Stderr, "  ! %s\n", w)
+	}
+	if *dryRun {
+		for _, r := range im.Requests {
+			fmt.Printf("%-6s %-40s %s\n", r.Method, r.Path, r.Name)
+		}
+		return
+	}
+	path, err := writeProfile(dir, *name, find.Domain, find, profileSaveOpts{Requests: im.Requests, Vars: im.Vars})
+	if err != nil {
+		fail(err)
+	}
+	fmt.Fprintf(os.Stderr, "✅ Profile saved: %s\n", path)
+	fmt.Fprintf(os.Stderr, "Next: restless run %s <request>\n", *name)
+}
+
+// fullVar is a path segment that is entirely one ${name} reference.
+var fullVar = regexp.MustCompile(`^\$\{([A-Za-z_][A-Za-z0-9_.-]*)\}$`)
+
+// findingFromPostman turns imported requests into endpoints with postman
+// evidence, and the collection auth into the hints writeProfile uses.
+// Concrete paths (often in saved examples) map onto known templates.
+func findingFromPostman(im postman.Imported, src string, known []string) discovery.Finding {
+	var find discovery.Finding
+	if im.BaseURL != "" {
+		find.BaseURLs = []string{im.BaseURL}
+		if u, err := url.Parse(im.BaseURL); err == nil {
+			find.Domain = u.Hostname()
+		}
+	}
+	now := time.Now().Format(time.RFC3339)
+	seen := map[string]bool{}
+	for _, r := range im.Requests {
+		segs := strings.Split(r.Path, "/")
+		for i, s := range segs {
+			if m := fullVar.FindStringSubmatch(s); m != nil {
+				segs[i] = "{" + m[1] + "}"
+			}
+		}
+		path := discovery.Template(strings.Join(segs, "/"), known)
+		known = append(known, path)
+		if seen[r.Method+" "+path] {
+			continue
+		}
+		seen[r.Method+" "+path] = true
+		find.Endpoints = append(find.Endpoints, discovery.Endpoint{
+			Method:   r.Method,
+			Path:     path,
+			Score:    0.85,
+			Evidence: []discovery.Evidence{{Source: "postman", URL: "file://" + filepath.ToSlash(src), When: now, Score: 0.85}},
+		})
+	}
+	if len(find.Endpoints) > 0 {
+		find.Confidence = 0.85
+	}
+
+	switch a := im.Auth; {
+	case a == nil:
+	case a.Type == "bearer":
+		find.Auth = &discovery.AuthHint{Type: "bearer"}
+	case a.Type == "basic":
+		find.Auth = &discovery.AuthHint{Type: "basic"}
+	case a.Type == "apikey":
+		typ := "header"
+		if a.Get("in") == "query" {
+			typ = "query"
+		}
+		find.Auth = &discovery.AuthHint{Type: typ, Name: firstNonEmpty(a.Get("key"), "X-API-Key")}
+	case a.Type == "oauth2" && a.Get("accessTokenUrl") != "":
+		hint := &discovery.OAuth2Hint{TokenURL: a.Get("accessTokenUrl")}
+		if a.Get("grant_type") != "client_credentials" {
+			hint.Grants = []string{"refresh_token"}
+		}
+		if s := a.Get("scope"); s != "" {
+			hint.Scopes = strings.Fields(s)
+		}
+		find.OAuth2 = hint
+	case a.Type == "noauth":
+		find.Auth = &discovery.AuthHint{Type: "none"}
+	}
+	return find
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bspippi1337/restless/internal/core/auth"
+	"github.com/bspippi1337/restless/internal/core/postman"
+	"github.com/bspippi1337/restless/internal/core/profile"
+)
+
+// TestImportOAuth2RoundTrip imports a collection with oauth2 auth, saves
+// and loads the profile, and fetches a token with what was saved.
+func TestImportOAuth2RoundTrip(t *testing.T) {
+	tests := []struct {
+		postmanGrant string
+		wantGrant    string
+		wantForm     string
+	}{
+		{"client_credentials", "client_credentials", "grant_type=client_credentials&scope=read+write"},
+		{"authorization_code", "refresh_token", "grant_type=refresh_token&refresh_token=r3fresh"},
+		{"password_credentials", "refresh_token", "grant_type=refresh_token&refresh_token=r3fresh"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.postmanGrant, func(t *testing.T) {
+			var form string
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if id, secret, _ := r.BasicAuth(); id != "client" || secret != "s3cret" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				r.ParseForm()
+				form = r.PostForm.Encode()
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"access_token":"tok","token_type":"bearer","expires_in":60}`)
+			}))
+			defer ts.Close()
+			t.Setenv("RESTLESS_CLIENT_ID", "client")
+			t.Setenv("RESTLESS_CLIENT_SECRET", "s3cret")
+			t.Setenv("RESTLESS_REFRESH_TOKEN", "r3fresh")
+
+			coll := map[string]any{
+				"info": map[string]any{"name": "shop", "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"},
+				"auth": map[string]any{"type": "oauth2", "oauth2": []map[string]any{
+					{"key": "accessTokenUrl", "value": ts.URL + "/token"},
+					{"key": "grant_type", "value": tt.postmanGrant},
+					{"key": "scope", "value": "read write"},
+				}},
+				"item": []any{map[string]any{"name": "list", "request": map[string]any{"method": "GET", "url": "https://api.shop.test/items"}}},
+			}
+			b, _ := json.Marshal(coll)
+			c, err := postman.Parse(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			im := c.Import()
+			find := findingFromPostman(im, "shop.json", nil)
+
+			dir := t.TempDir()
+			if _, err := writeProfile(dir, "shop", find.Domain, find, profileSaveOpts{Requests: im.Requests}); err != nil {
+				t.Fatal(err)
+			}
+			p, err := profile.Load(dir, "shop")
+			if err != nil {
+				t.Fatal(err)
+			}
+			o := p.Auth.OAuth2
+			if p.Auth.Type != "oauth2" || o.Grant != tt.wantGrant || strings.Join(o.Scopes, " ") != "read write" {
+				t.Fatalf("auth = %s %s %v, want oauth2 %s [read write]", p.Auth.Type, o.Grant, o.Scopes, tt.wantGrant)
+			}
+
+			src := &auth.OAuth2Source{Config: o, Client: ts.Client()}
+			tok, err := src.Token(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tok.AccessToken != "tok" {
+				t.Errorf("access token = %q", tok.AccessToken)
+			}
+			if form != tt.wantForm {
+				t.Errorf("token request %q, want %q", form, tt.wantForm)
+			}
+		})
+	}
+}
@@ -2,15 +2,22 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/bspippi1337/restless/internal/core/discovery"
+	"github.com/bspippi1337/restless/internal/core/profile"
+	"github.com/bspippi1337/restless/internal/core/transport"
+	"github.com/bspippi1337/restless/internal/filter"
 	"github.com/bspippi1337/restless/internal/help"
+	"github.com/bspippi1337/restless/internal/jsonschema"
 )
 
 func main() {
@@ -29,6 +36,48 @@ func main() {
 	case "discover":
 		cmdDiscover(os.Args[2:])
 		return
+	case "capture":
+		cmdCapture(os.Args[2:])
+		return
+	case "request":
+		cmdRequest(os.Args[2:])
+		return
+	case "run":
+		cmdRun(os.Args[2:])
+		return
+	case "snippet":
+		cmdSnippet(os.Args[2:])
+		return
+	case "scenario":
+		cmdScenario(os.Args[2:])
+		return
+	case "contract":
+		cmdContract(os.Args[2:])
+		return
+	case "mock":
+		cmdMock(os.Args[2:])
+		return
+	case "import":
+		cmdImport(os.Args[2:])
+		return
+	case "export":
+		cmdExport(os.Args[2:])
+		return
+	case "codegen":
+		cmdCodegen(os.Args[2:])
+		return
+	case "diff":
+		cmdDiff(os.Args[2:])
+		return
+	case "tui":
+		cmdTUI(os.Args[2:])
+		return
+	case "history":
+		cmdHistory(os.Args[2:])
+		return
+	case "replay":
+		cmdReplay(os.Args[2:])
+		return
 	case "doctor":
 		cmdDoctor()
 		return
@@ -48,6 +97,20 @@ func printRootHelp(exit int) {
 	fmt.Fprintln(out, "")
 	fmt.Fprintln(out, "Commands:")
 	fmt.Fprintln(out, "  discover   Discover APIs starting from a domain")
+	fmt.Fprintln(out, "  capture    Learn a profile from proxied traffic")
+	fmt.Fprintln(out, "  request    Send a request using a saved profile")
+	fmt.Fprintln(out, "  run        Send a saved request from a profile's collection")
+	fmt.Fprintln(out, "  snippet    Print a curl, HTTPie, Go or Python version of a request")
+	fmt.Fprintln(out, "  scenario   Run multi-step request flows from YAML")
+	fmt.Fprintln(out, "  contract   Check a live API against its OpenAPI spec")
+	fmt.Fprintln(out, "  mock       Serve a fake API from a profile")
+	fmt.Fprintln(out, "  import     Create a profile from a Postman collection")
+	fmt.Fprintln(out, "  export     Write a profile as OpenAPI or a Postman collection")
+	fmt.Fprintln(out, "  codegen    Generate a Go client package from a profile")
+	fmt.Fprintln(out, "  diff       Compare responses between environments or runs")
+	fmt.Fprintln(out, "  tui        Browse a profile and send requests interactively")
+	fmt.Fprintln(out, "  history    List and inspect past requests")
+	fmt.Fprintln(out, "  replay     Resend a request from history")
 	fmt.Fprintln(out, "  doctor     Self-check and environment hints")
 	fmt.Fprintln(out, "  version    Print version")
 	fmt.Fprintln(out, "  help       Show help")
@@ -71,18 +134,25 @@ func cmdDiscover(args []string) {
 		saveProfile   = fs.String("save-profile", "", "Save discovery results to a named profile")
 		overwrite     = fs.Bool("overwrite-profile", false, "Replace existing profile instead of merging")
 		profileDir    = fs.String("profile-dir", "", "Custom profile storage directory")
-		emitExamples  = fs.Bool("emit-examples", false, "Generate example requests inside the profile")
+		emitExamples  = fs.Bool("emit-examples", false, "Add a runnable request collection to the profile")
 		redactSecrets = fs.Bool("redact-secrets", false, "Remove detected tokens from generated examples")
 		jsonOut       = fs.Bool("json", false, "Output machine-readable JSON")
+		jsonEvents    = fs.Bool("json-events", false, "Stream discovery as NDJSON events on stdout")
+		filterExpr    = fs.String("filter", "", "jq-style expression applied to the JSON output")
+		rawOutput     = fs.Bool("raw-output", false, "With --filter, print strings without quotes")
 		quiet         = fs.Bool("quiet", false, "Minimal output")
 		debug         = fs.Bool("debug", false, "Verbose diagnostic logging")
+		proxy         = fs.String("proxy", "", "Proxy URL (http, https or socks5)")
+		harFile       = fs.String("har", "", "Learn endpoints from a HAR file instead of probing")
+		resolve       multiFlag
 	)
+	fs.Var(&resolve, "resolve", "Pin host:port:addr (repeatable)")
+	fs.BoolVar(rawOutput, "r", false, "Shorthand for --raw-output")
 
 	// Dynamic help hook for stdlib flags:
 	fs.Usage = func() {
 		ctx := help.NewDiscoverHelpContext(*profileDir)
 		ctx.SupportsJSON = true
-		ctx.SupportsTUI = false
 		// Optional state file
 		if st, ok := loadState(); ok {
 			ctx.LastDomain = st.LastDomain
@@ -91,45 +161,149 @@ func cmdDiscover(args []string) {
 		fmt.Fprintln(fs.Output(), help.DiscoverHelp(ctx))
 	}
 
-	if err := fs.Parse(args); err != nil {
-		// flag already printed error; show help
-		fmt.Fprintln(os.Stdout, "")
-		fs.Usage()
+	rest, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		// flag already printed the error and the help
 		os.Exit(2)
 	}
 
-	rest := fs.Args()
-	if len(rest) < 1 {
+	if len(rest) < 1 && *harFile == "" {
 		fs.Usage()
 		os.Exit(2)
 	}
-	domain := rest[0]
+	var domain string
+	if len(rest) > 0 {
+		domain = rest[0]
+	}
+
+	// A HAR file is read before anything else so a missing domain can be
+	// taken from it.
+	var harFind discovery.Finding
+	if *harFile != "" {
+		b, err := os.ReadFile(*harFile)
+		if err == nil {
+			harFind, err = discovery.FromHAR(b, domain)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "discover error: %s: %v\n", *harFile, err)
+			os.Exit(1)
+		}
+		if domain == "" {
+			domain = harFind.Domain
+		}
+	}
+
+	var prog *filter.Program
+	if *filterExpr != "" {
+		p, err := filter.Compile(*filterExpr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		prog = p
+		*jsonOut = true
+	}
+	if *jsonEvents && *jsonOut {
+		fmt.Fprintln(os.Stderr, "discover error: --json-events can't be combined with --json or --filter")
+		os.Exit(2)
+	}
+	// Keep stdout clean for JSON consumers.
+	logOut := os.Stdout
+	if *jsonOut || *jsonEvents {
+		logOut = os.Stderr
+	}
 
 	// persist state
 	saveState(state{LastDomain: domain, ActiveProfile: strings.TrimSpace(*saveProfile)})
 
-	if !*quiet {
-		fmt.Printf("==> discover %s\n", domain)
-	}
-	find, err := discovery.DiscoverDomain(domain, discovery.Options{
-		BudgetSeconds: *budgetSeconds,
-		BudgetPages:   *budgetPages,
-		Verify:        *verify,
-		Fuzz:          *fuzz,
-		Debug:         *debug,
-	})
+	// Network settings come from the profile being refreshed, if it exists.
+	settings := &profile.Profile{}
+	if *saveProfile != "" {
+		dir := *profileDir
+		if dir == "" {
+			dir = defaultProfileDir()
+		}
+		if p, err := profile.Load(dir, *saveProfile); err == nil {
+			settings = p
+		}
+	}
+	var retryLog io.Writer
+	if *debug {
+		retryLog = os.Stderr
+	}
+	client, err := transport.NewClient(transport.Options{
+		TLS:               settings.TLS,
+		Proxy:             firstNonEmpty(*proxy, settings.Network.Proxy),
+		Resolve:           slices.Concat(settings.Network.Resolve, resolve),
+		RequestsPerSecond: settings.RateLimit.RequestsPerSecond,
+		Log:               retryLog,
+		BaseDir:           filepath.Dir(settings.Path),
+		Warn:              os.Stderr,
+	}, 0)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "discover error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "transport error: %v\n", err)
 		os.Exit(1)
 	}
 
+	if !*quiet {
+		if *harFile != "" {
+			fmt.Fprintf(logOut, "==> discover %s (from %s)\n", domain, *harFile)
+		} else {
+			fmt.Fprintf(logOut, "==> discover %s\n", domain)
+		}
+	}
+	// HAR imports are offline unless live checks are asked for too.
+	find := harFind
+	mode := pickProgressMode(logOut, *quiet, *debug, *jsonEvents)
+	progOut := logOut
+	if mode == progressJSON {
+		progOut = os.Stdout
+	}
+	pr := newProgress(progOut, mode)
+	if *harFile == "" || *verify || *fuzz {
+		live, err := discovery.DiscoverDomain(domain, discovery.Options{
+			BudgetSeconds: *budgetSeconds,
+			BudgetPages:   *budgetPages,
+			Verify:        *verify,
+			Fuzz:          *fuzz,
+			Debug:         *debug,
+			Client:        client,
+			Progress:      pr.Func(),
+		})
+		if err != nil {
+			pr.Stop()
+			pr.Fail("discover", err)
+			fmt.Fprintf(os.Stderr, "discover error: %v\n", err)
+			os.Exit(1)
+		}
+		if *harFile == "" {
+			find = live
+		} else {
+			find.Merge(live)
+		}
+	}
+	pr.Stop()
+
 	// Save profile if requested
+	var savedPath string
 	if *saveProfile != "" {
 		dir := *profileDir
 		if dir == "" {
 			dir = defaultProfileDir()
 		}
-		path, err := writeProfile(dir, *saveProfile, domain, find, profileSaveOpts{
+		// Response schemas keep refining across runs instead of starting over.
+		if !*overwrite {
+			for i := range find.Endpoints {
+				ep := &find.Endpoints[i]
+				if saved := settings.Match(ep.Method, ep.Path); saved != nil && saved.Path == ep.Path {
+					ep.Schema = jsonschema.Merge(saved.Schema, ep.Schema)
+				}
+			}
+		}
+		savedPath, err = writeProfile(dir, *saveProfile, domain, find, profileSaveOpts{
 			Overwrite:     *overwrite,
 			EmitExamples:  *emitExamples,
 			RedactSecrets: *redactSecrets,
@@ -139,16 +313,28 @@ func cmdDiscover(args []string) {
 			BudgetPages:   *budgetPages,
 		})
 		if err != nil {
+			pr.Fail("profile", err)
 			fmt.Fprintf(os.Stderr, "profile save error: %v\n", err)
 			os.Exit(1)
 		}
 		if !*quiet {
-			fmt.Printf("✅ Profile saved: %s\n", path)
-			fmt.Printf("   Endpoints: %d  Docs: %d  Confidence: %.2f\n", len(find.Endpoints), len(find.DocURLs), find.Confidence)
-			fmt.Printf("   Next: restless request --profile %s --method GET --path /v1/status\n", *saveProfile)
+			fmt.Fprintf(logOut, "✅ Profile saved: %s\n", savedPath)
+			fmt.Fprintf(logOut, "   Endpoints: %d  Docs: %d  Confidence: %.2f\n", len(find.Endpoints), len(find.DocURLs), find.Confidence)
+			fmt.Fprintf(logOut, "   Next: restless request --profile %s --method GET --path %s\n", *saveProfile, samplePath(find))
 		}
 	}
 
+	if prog != nil {
+		b, _ := json.Marshal(find)
+		out, err := prog.Apply(b, *rawOutput)
+		_, _ = os.Stdout.Write(out)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if *jsonOut {
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
@@ -156,7 +342,9 @@ func cmdDiscover(args []string) {
 		return
 	}
 
-	if *quiet {
+	// The events were the output.
+	pr.Summary(find, savedPath)
+	if *jsonEvents || *quiet {
 		return
 	}
 
@@ -176,6 +364,53 @@ func cmdDiscover(args []string) {
 	fmt.Printf("Confidence: %.2f\n", find.Confidence)
 }
 
+// samplePath picks a GET endpoint without parameters for the "Next:" hint.
+func samplePath(find discovery.Finding) string {
+	for _, ep := range find.Endpoints {
+		if ep.Method == "GET" && !strings.Contains(ep.Path, "{") {
+			return ep.Path
+		}
+	}
+	return "/v1/status"
+}
+
+// parseInterspersed parses flags that appear before or after positional
+// arguments (`discover openai.com --verify`). Everything after `--` is
+// positional.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		used := len(args) - len(fs.Args())
+		if used > 0 && args[used-1] == "--" && (used == 1 || !takesValue(fs, args[used-2])) {
+			return append(rest, fs.Args()...), nil
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return rest, nil
+		}
+		rest = append(rest, args[0])
+		args = args[1:]
+	}
+}
+
+// takesValue reports whether arg is a flag of fs that consumes the next
+// argument as its value.
+func takesValue(fs *flag.FlagSet, arg string) bool {
+	name := strings.TrimLeft(arg, "-")
+	if name == arg || name == "" || strings.Contains(name, "=") {
+		return false
+	}
+	f := fs.Lookup(name)
+	if f == nil {
+		return false
+	}
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return !ok || !b.IsBoolFlag()
+}
+
 func cmdDoctor() {
 	fmt.Println("==> doctor")
 	fmt.Println("[ OK ] Go toolchain reachable:", runtimeGoVersion())
@@ -191,6 +426,24 @@ func defaultProfileDir() string {
 	return filepath.Join(home, ".config", "restless", "profiles")
 }
 
+// configDir holds state, token caches and other per-user data.
+func configDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return ".restless"
+	}
+	return filepath.Join(home, ".config", "restless")
+}
+
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if strings.TrimSpace(s) != "" {
+			return s
+		}
+	}
+	return ""
+}
+
 func versionString() string {
 	// overridden by ldflags in CI/release if desired
 	return "v0.0.0-dev"
@@ -241,6 +494,10 @@ type profileSaveOpts struct {
 	Fuzz          bool
 	BudgetSeconds int
 	BudgetPages   int
+	// Requests are added to the collection unless an entry of the same
+	// name exists; Vars is written when the profile has no vars block.
+	Requests []profile.Request
+	Vars     map[string]string
 }
 
 func writeProfile(dir, name, domain string, find discovery.Finding, opt profileSaveOpts) (string, error) {
@@ -250,11 +507,31 @@ func writeProfile(dir, name, domain string, find discovery.Finding, opt profileS
 	// Merge-safe: if exists and not overwrite, keep auth + defaults block if present.
 	var existingAuth string
 	var existingDefaults string
+	var existingTLS string
+	var existingNetwork string
+	var existingRateLimit string
+	var existingEnvironments string
+	var existingCollection string
+	var existingExamples string
+	var existingVars string
+	var existingRequests []profile.Request
+	var existingSpecURL string
 	if !opt.Overwrite {
 		if b, err := os.ReadFile(path); err == nil {
 			s := string(b)
 			existingAuth = extractBlock(s, "auth:")
 			existingDefaults = extractBlock(s, "defaults:")
+			existingTLS = extractBlock(s, "tls:")
+			existingNetwork = extractBlock(s, "network:")
+			existingRateLimit = extractBlock(s, "rateLimit:")
+			existingEnvironments = extractBlock(s, "environments:")
+			existingCollection = extractBlock(s, "collection:")
+			existingExamples = extractBlock(s, "examples:")
+			existingVars = extractBlock(s, "vars:")
+			if p, err := profile.Load(dir, name); err == nil {
+				existingRequests = p.Collection
+				existingSpecURL = p.SpecURL
+			}
 		}
 	}
 
@@ -287,6 +564,40 @@ func writeProfile(dir, name, domain string, find discovery.Finding, opt profileS
 	if existingAuth != "" {
 		sb.WriteString(existingAuth)
 		sb.WriteString("\n")
+	} else if find.OAuth2 != nil {
+		// Discovery found an authorization server; credentials stay in env vars.
+		sb.WriteString("auth:\n")
+		sb.WriteString("  type: oauth2\n")
+		sb.WriteString(fmt.Sprintf("  grant: %s\n", find.OAuth2.PreferredGrant()))
+		sb.WriteString(fmt.Sprintf("  tokenUrl: %s\n", find.OAuth2.TokenURL))
+		if find.OAuth2.DeviceAuthURL != "" {
+			sb.WriteString(fmt.Sprintf("  deviceAuthorizationUrl: %s\n", find.OAuth2.DeviceAuthURL))
+		}
+		sb.WriteString("  clientId:\n")
+		sb.WriteString("    source: env\n")
+		sb.WriteString("    envVar: RESTLESS_CLIENT_ID\n")
+		sb.WriteString("  clientSecret:\n")
+		sb.WriteString("    source: env\n")
+		sb.WriteString("    envVar: RESTLESS_CLIENT_SECRET\n")
+		if find.OAuth2.PreferredGrant() == "refresh_token" {
+			sb.WriteString("  refreshToken:\n")
+			sb.WriteString("    source: env\n")
+			sb.WriteString("    envVar: RESTLESS_REFRESH_TOKEN\n")
+		}
+		if len(find.OAuth2.Scopes) == 0 {
+			sb.WriteString("  scopes: []\n")
+		} else {
+			sb.WriteString("  scopes:\n")
+			for _, sc := range find.OAuth2.Scopes {
+				sb.WriteString("    - " + yamlScalar(sc) + "\n")
+			}
+		}
+		sb.WriteString("\n")
+	} else if a := find.Auth; a != nil && a.Type != "bearer" {
+		// Traffic showed a non-bearer credential; it goes in a default
+		// header read from the environment (see below).
+		sb.WriteString("auth:\n")
+		sb.WriteString("  type: none\n\n")
 	} else {
 		sb.WriteString("auth:\n")
 		sb.WriteString("  type: bearer\n")
@@ -303,11 +614,59 @@ func writeProfile(dir, name, domain string, find discovery.Finding, opt profileS
 		sb.WriteString("  headers:\n")
 		sb.WriteString("    Accept: application/json\n")
 		sb.WriteString("    User-Agent: restless/alpha\n")
+		if existingAuth == "" {
+			if k, v := authHeader(find.Auth); k != "" {
+				sb.WriteString(fmt.Sprintf("    %s: %s\n", k, v))
+			}
+		}
 		sb.WriteString("  timeoutSeconds: 20\n\n")
 	}
 
+	if existingVars != "" {
+		sb.WriteString(existingVars)
+		sb.WriteString("\n")
+	} else if len(opt.Vars) > 0 {
+		sb.WriteString("vars:\n")
+		for _, k := range sortedKeys(opt.Vars) {
+			sb.WriteString(fmt.Sprintf("  %s: %s\n", k, yamlScalar(opt.Vars[k])))
+		}
+		sb.WriteString("\n")
+	}
+
+	// tls, network and environments are hand-written; discovery never generates them.
+	if existingEnvironments != "" {
+		sb.WriteString(existingEnvironments)
+		sb.WriteString("\n")
+	}
+	if existingTLS != "" {
+		sb.WriteString(existingTLS)
+		sb.WriteString("\n")
+	}
+	if existingNetwork != "" {
+		sb.WriteString(existingNetwork)
+		sb.WriteString("\n")
+	}
+
+	// A fresh observation replaces the recorded limit; otherwise keep the old one.
+	if rl := find.RateLimit; rl != nil {
+		sb.WriteString("rateLimit:\n")
+		sb.WriteString(fmt.Sprintf("  requestsPerSecond: %.3f\n", rl.RequestsPerSecond))
+		sb.WriteString(fmt.Sprintf("  limit: %d\n", rl.Limit))
+		if rl.WindowSeconds > 0 {
+			sb.WriteString(fmt.Sprintf("  windowSeconds: %d\n", rl.WindowSeconds))
+		}
+		sb.WriteString(fmt.Sprintf("  source: %s\n", rl.Source))
+		sb.WriteString(fmt.Sprintf("  observedAt: %s\n\n", rl.ObservedAt))
+	} else if existingRateLimit != "" {
+		sb.WriteString(existingRateLimit)
+		sb.WriteString("\n")
+	}
+
 	sb.WriteString("discovery:\n")
 	sb.WriteString(fmt.Sprintf("  confidence: %.2f\n", find.Confidence))
+	if u := firstNonEmpty(find.SpecURL, existingSpecURL); u != "" {
+		sb.WriteString(fmt.Sprintf("  specUrl: %s\n", u))
+	}
 	sb.WriteString("  docUrls:\n")
 	if len(find.DocURLs) == 0 {
 		sb.WriteString("    - https://" + domain + "/openapi.json\n")
@@ -329,6 +688,30 @@ func writeProfile(dir, name, domain string, find discovery.Finding, opt profileS
 			sb.WriteString(fmt.Sprintf("        url: %s\n", ev.URL))
 			sb.WriteString(fmt.Sprintf("        when: %s\n", ev.When))
 			sb.WriteString(fmt.Sprintf("        score: %.2f\n", ev.Score))
+			if t := ev.Timing; t != nil {
+				sb.WriteString("        timingMs:\n")
+				sb.WriteString(fmt.Sprintf("          dns: %.1f\n", ms(t.DNS)))
+				sb.WriteString(fmt.Sprintf("          connect: %.1f\n", ms(t.Connect)))
+				sb.WriteString(fmt.Sprintf("          tls: %.1f\n", ms(t.TLS)))
+				sb.WriteString(fmt.Sprintf("          ttfb: %.1f\n", ms(t.TTFB)))
+				sb.WriteString(fmt.Sprintf("          total: %.1f\n", ms(t.Total)))
+			}
+		}
+		if pg := ep.Pagination; pg != nil {
+			sb.WriteString("    pagination:\n")
+			sb.WriteString(fmt.Sprintf("      style: %s\n", pg.Style))
+			if pg.Field != "" {
+				sb.WriteString(fmt.Sprintf("      field: %s\n", pg.Field))
+			}
+			if pg.Param != "" {
+				sb.WriteString(fmt.Sprintf("      param: %s\n", pg.Param))
+			}
+			if pg.Items != "" {
+				sb.WriteString(fmt.Sprintf("      items: %s\n", pg.Items))
+			}
+		}
+		if ep.Schema != nil {
+			writeSchema(&sb, "    ", ep.Schema)
 		}
 	}
 	if len(find.Endpoints) == 0 {
@@ -336,40 +719,96 @@ func writeProfile(dir, name, domain string, find discovery.Finding, opt profileS
 	}
 	sb.WriteString("\n")
 
+	// Collection entries are never rewritten once they exist, so hand edits
+	// survive a refresh; --emit-examples only adds requests for new endpoints.
+	var added []profile.Request
 	if opt.EmitExamples {
-		sb.WriteString("examples:\n")
-		sb.WriteString("  - name: status\n")
-		sb.WriteString("    request:\n")
-		sb.WriteString("      method: GET\n")
-		sb.WriteString("      path: /v1/status\n")
-		sb.WriteString("      headers:\n")
-		sb.WriteString("        Authorization: \"Bearer ${ENV:RESTLESS_TOKEN}\"\n")
+		added = generateRequests(find, existingRequests, opt.RedactSecrets)
+	}
+	taken := map[string]bool{}
+	for _, r := range existingRequests {
+		taken[r.Name] = true
+	}
+	for _, r := range opt.Requests {
+		if !taken[r.Name] {
+			taken[r.Name] = true
+			added = append(added, r)
+		}
+	}
+	if existingCollection != "" || len(added) > 0 {
+		if existingCollection != "" {
+			sb.WriteString(existingCollection)
+		} else {
+			sb.WriteString("collection:")
+		}
+		sb.WriteString("\n")
+		for _, r := range added {
+			writeRequest(&sb, r)
+		}
+		sb.WriteString("\n")
+	}
+	if existingExamples != "" {
+		sb.WriteString(existingExamples)
 		sb.WriteString("\n")
 	}
 
 	return path, os.WriteFile(path, []byte(sb.String()), 0o644)
 }
 
+// writeSchema writes an endpoint's response schema as an indented JSON
+// block; the profile parser folds it back into one string.
+func writeSchema(sb *strings.Builder, indent string, schema map[string]any) {
+	b, err := json.MarshalIndent(schema, indent+"  ", "  ")
+	if err != nil {
+		return
+	}
+	sb.WriteString(indent + "schema: |\n" + indent + "  ")
+	sb.Write(b)
+	sb.WriteString("\n")
+}
+
+// authHeader is the default header for a credential seen in traffic; the
+// value always comes from an environment variable.
+func authHeader(a *discovery.AuthHint) (string, string) {
+	if a == nil || a.Type == "none" {
+		return "", ""
+	}
+	switch a.Type {
+	case "basic":
+		return "Authorization", "Basic ${ENV:RESTLESS_BASIC_AUTH}"
+	case "cookie":
+		return "Cookie", "${ENV:RESTLESS_COOKIE}"
+	case "header":
+		env := "RESTLESS_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(a.Name))
+		return a.Name, "${ENV:" + env + "}"
+	}
+	return "", ""
+}
+
+func ms(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+
 func extractBlock(s, header string) string {
-	idx := strings.Index(s, header)
-	if idx < 0 {
+	lines := strings.Split(s, "\n")
+	start := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, header) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
 		return ""
 	}
-	rest := s[idx:]
-	lines := strings.Split(rest, "\n")
-	var out []string
-	out = append(out, lines[0])
-	for i := 1; i < len(lines); i++ {
-		line := lines[i]
-		if len(line) > 0 && line[0] != ' ' && strings.HasSuffix(line, ":") {
+	out := []string{lines[start]}
+	for _, line := range lines[start+1:] {
+		// the block ends at the next top-level key
+		if len(line) > 0 && line[0] != ' ' && line[0] != '-' && line[0] != '#' {
 			break
 		}
-		if strings.TrimSpace(line) == "" && i > 1 {
-			// allow blank line but stop after block ends
-			out = append(out, line)
-			continue
-		}
 		out = append(out, line)
 	}
+	for len(out) > 1 && strings.TrimSpace(out[len(out)-1]) == "" {
+		out = out[:len(out)-1]
+	}
 	return strings.Join(out, "\n") + "\n"
 }
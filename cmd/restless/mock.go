@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/mock"
+	"github.com/bspippi1337/restless/internal/core/transport"
+	"github.com/bspippi1337/restless/internal/help"
+	"github.com/bspippi1337/restless/internal/jsonschema"
+)
+
+// cmdMock serves a profile's endpoints locally with example responses.
+func cmdMock(args []string) {
+	fs := flag.NewFlagSet("mock", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+
+	var (
+		profileDir  = fs.String("profile-dir", "", "Custom profile storage directory")
+		envName     = fs.String("env", "", "Profile environment (used to fetch the spec)")
+		specFlag    = fs.String("spec", "", "OpenAPI document (file or URL) instead of the one discovery recorded")
+		host        = fs.String("host", "127.0.0.1", "Address to listen on")
+		port        = fs.Int("port", 8080, "Port to listen on")
+		latency     = fs.String("latency", "", "Response delay: 200ms, or a range 50ms-400ms")
+		errorRate   = fs.Float64("error-rate", 0, "Fraction of requests (0-1) answered with --error-status")
+		errorStatus = fs.Int("error-status", http.StatusInternalServerError, "Status for injected errors")
+		quiet       = fs.Bool("quiet", false, "Don't log requests")
+	)
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), help.MockHelp(help.NewDiscoverHelpContext(*profileDir))) }
+
+	rest, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+	if len(rest) != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	fail := func(err error) {
+		fmt.Fprintf(os.Stderr, "mock error: %v\n", err)
+		os.Exit(2)
+	}
+
+	if *errorRate < 0 || *errorRate > 1 {
+		fail(fmt.Errorf("--error-rate must be between 0 and 1"))
+	}
+	if *errorStatus < 100 || *errorStatus > 599 {
+		fail(fmt.Errorf("bad --error-status %d", *errorStatus))
+	}
+	minDelay, maxDelay, err := parseLatency(*latency)
+	if err != nil {
+		fail(err)
+	}
+	prof, name, err := loadProfile(*profileDir, rest[0], *envName)
+	if err != nil {
+		fail(err)
+	}
+
+	srv := &mock.Server{
+		Latency:     minDelay,
+		MaxLatency:  maxDelay,
+		ErrorRate:   *errorRate,
+		ErrorStatus: *errorStatus,
+	}
+	if !*quiet {
+		srv.Log = os.Stderr
+	}
+	source := "profile endpoints"
+	cfg := clientConfig{Retries: transport.DefaultRetry.MaxRetries}
+	spec, specURL, err := loadSpec(prof, name, *specFlag, cfg)
+	switch {
+	case err == nil:
+		srv.Routes = mock.FromSpec(spec)
+		source = specURL
+	case *specFlag != "" || len(prof.Endpoints) == 0:
+		fail(err)
+	default:
+		fmt.Fprintf(os.Stderr, "mock: no spec (%v); endpoints answer with samples of their learned schemas, or empty bodies\n", err)
+	}
+	// Endpoints discovery found beyond the spec still answer.
+	for _, ep := range prof.Endpoints {
+		switch {
+		case hasRoute(srv.Routes, ep.Method, ep.Path):
+		case ep.Schema != nil:
+			srv.Routes = append(srv.Routes, mock.Inferred(ep.Method, ep.Path, jsonschema.Public(ep.Schema)))
+		default:
+			srv.Routes = append(srv.Routes, mock.Plain(ep.Method, ep.Path))
+		}
+	}
+	if len(srv.Routes) == 0 {
+		fail(fmt.Errorf("profile %q has no endpoints to serve", name))
+	}
+
+	addr := net.JoinHostPort(*host, strconv.Itoa(*port))
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		fail(err)
+	}
+	fmt.Fprintf(os.Stderr, "mock: %s on http://%s (%d routes from %s)\n", name, ln.Addr(), len(srv.Routes), source)
+	if err := http.Serve(ln, srv); err != nil {
+		fmt.Fprintf(os.Stderr, "mock error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func hasRoute(routes []mock.Route, method, path string) bool {
+	for _, rt := range routes {
+		if strings.EqualFold(rt.Method, method) && rt.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLatency reads "200ms" or "50ms-400ms"; bare numbers are milliseconds.
+func parseLatency(s string) (time.Duration, time.Duration, error) {
+	if s == "" {
+		return 0, 0, nil
+	}
+	lo, hi, ranged := strings.Cut(s, "-")
+	min, err := parseMillis(lo)
+	if err != nil {
+		return 0, 0, fmt.Errorf("bad --latency %q", s)
+	}
+	if !ranged {
+		return min, min, nil
+	}
+	max, err := parseMillis(hi)
+	if err != nil || max < min {
+		return 0, 0, fmt.Errorf("bad --latency %q", s)
+	}
+	return min, max, nil
+}
+
+func parseMillis(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+		return time.Duration(n) * time.Millisecond, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err == nil && d < 0 {
+		err = errors.New("negative")
+	}
+	return d, err
+}
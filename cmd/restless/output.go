@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/bspippi1337/restless/internal/core/transport"
+	"github.com/bspippi1337/restless/internal/filter"
+	"github.com/bspippi1337/restless/internal/render"
+)
+
+// outputOpts controls how an exchange is printed (httpie-style --print).
+type outputOpts struct {
+	Print   string // H request headers, B request body, h response headers, b response body
+	Pretty  string // auto, all, colors, format or none
+	NoPager bool
+	Output  string // write the raw response body to this file
+
+	Filter    *filter.Program // applied to JSON response bodies
+	RawOutput bool            // print filtered strings without quotes
+}
+
+func (o outputOpts) validate() error {
+	for _, c := range o.Print {
+		if !strings.ContainsRune("HBhb", c) {
+			return fmt.Errorf("--print: unknown section %q (use H, B, h, b)", c)
+		}
+	}
+	switch o.Pretty {
+	case "", "auto", "all", "colors", "format", "none":
+		return nil
+	}
+	return fmt.Errorf("--pretty: unknown mode %q (use all, colors, format or none)", o.Pretty)
+}
+
+func (o outputOpts) renderOptions(tty bool) render.Options {
+	mode := o.Pretty
+	if mode == "" || mode == "auto" {
+		mode = "none"
+		if tty {
+			mode = "all"
+		}
+	}
+	return render.Options{
+		Color:  (mode == "all" || mode == "colors") && render.ColorEnabled(os.Stdout),
+		Format: mode == "all" || mode == "format",
+	}
+}
+
+// writeExchange prints the sections selected by o.Print to stdout. Plain
+// body-only output to a pipe is streamed untouched. A --filter that fails
+// part way prints the results before the failure and returns its error.
+// midLine reports that the output didn't end with a newline.
+func writeExchange(req *http.Request, reqBody []byte, resp *http.Response, o outputOpts) (midLine bool, err error) {
+	stdout := &lineWriter{w: os.Stdout}
+	tty := render.IsTerminal(os.Stdout)
+	ro := o.renderOptions(tty)
+	show := func(c rune) bool { return strings.ContainsRune(o.Print, c) }
+	var filterErr error
+
+	if o.Output != "" {
+		f, err := os.Create(o.Output)
+		if err != nil {
+			return false, err
+		}
+		n, err := io.Copy(f, resp.Body)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return false, err
+		}
+		fmt.Fprintf(os.Stderr, "saved %d bytes to %s\n", n, o.Output)
+		o.Print = strings.ReplaceAll(o.Print, "b", "")
+		resp.Body = io.NopCloser(bytes.NewReader(nil))
+	}
+
+	if o.Print == "b" && !tty && !ro.Color && !ro.Format && o.Filter == nil {
+		_, err := io.Copy(stdout, resp.Body)
+		return stdout.midLine, err
+	}
+
+	var out bytes.Buffer
+	section := func() {
+		if out.Len() > 0 {
+			out.WriteString("\n")
+		}
+	}
+	if show('H') {
+		render.RequestLine(&out, req.Method, req.URL.RequestURI(), "HTTP/1.1", ro)
+		h := req.Header.Clone()
+		h.Set("Host", req.URL.Host)
+		render.Headers(&out, h, transport.RedactHeader, ro)
+	}
+	if show('B') && len(reqBody) > 0 {
+		section()
+		render.Body(&out, req.Header.Get("Content-Type"), reqBody, ro)
+	}
+	if show('h') {
+		section()
+		render.StatusLine(&out, resp.Proto, resp.Status, resp.StatusCode, ro)
+		render.Headers(&out, resp.Header, transport.RedactHeader, ro)
+	}
+	if show('b') {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return false, err
+		}
+		ct := resp.Header.Get("Content-Type")
+		if o.Filter != nil && len(body) > 0 {
+			// Results before a failing one are still shown, as jq does.
+			if body, filterErr = o.Filter.Apply(body, o.RawOutput); filterErr != nil && len(body) == 0 {
+				return false, filterErr
+			}
+			ct = "application/json"
+			if o.RawOutput {
+				ct = "text/plain"
+			}
+		}
+		if !tty && render.IsBinary(ct, body) {
+			// Piped binary goes out byte-for-byte.
+			if _, err := stdout.Write(out.Bytes()); err != nil {
+				return false, err
+			}
+			_, err = stdout.Write(body)
+			return stdout.midLine, err
+		}
+		if len(body) > 0 {
+			section()
+			if ro.Color || ro.Format || tty {
+				render.Body(&out, ct, body, ro)
+			} else {
+				out.Write(body)
+			}
+		}
+	}
+
+	if o.NoPager || !tty {
+		if _, err := stdout.Write(out.Bytes()); err != nil {
+			return stdout.midLine, err
+		}
+		return stdout.midLine, filterErr
+	}
+	midLine = out.Len() > 0 && out.Bytes()[out.Len()-1] != '\n'
+	if err := render.Page(os.Stdout, out.Bytes()); err != nil {
+		return midLine, err
+	}
+	return midLine, filterErr
+}
+
+// lineWriter notes whether the last byte written ended a line.
+type lineWriter struct {
+	w       io.Writer
+	midLine bool
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		l.midLine = p[len(p)-1] != '\n'
+	}
+	return l.w.Write(p)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/bspippi1337/restless/internal/core/paginate"
+	"github.com/bspippi1337/restless/internal/filter"
+	"github.com/bspippi1337/restless/internal/render"
+)
+
+type paginateOpts struct {
+	MaxPages int
+	MaxItems int
+	Merge    bool           // one JSON array instead of NDJSON
+	Spec     *paginate.Spec // from the profile; nil means detect from the first page
+}
+
+// runPaginated follows next pages from first and writes every item as an
+// NDJSON line (or one merged array). --filter applies per item, or to the
+// merged array.
+func runPaginated(client *http.Client, first *http.Request, po paginateOpts, o outputOpts, quiet bool) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	write := func(v any) error {
+		if o.Filter == nil {
+			return enc.Encode(v)
+		}
+		results, ferr := o.Filter.Run(v)
+		for _, r := range results {
+			if s, ok := r.(string); ok && o.RawOutput {
+				if _, err := fmt.Fprintln(os.Stdout, s); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return ferr
+	}
+
+	merged := []any{}
+	spec := po.Spec
+	seen := map[string]bool{first.URL.String(): true}
+	req := first
+	total := 0
+	for page := 1; ; page++ {
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode >= 400 {
+			os.Stderr.Write(body)
+			return fmt.Errorf("page %d: %s", page, resp.Status)
+		}
+		doc, err := filter.Decode(body)
+		if err != nil {
+			return fmt.Errorf("page %d is not JSON: %w", page, err)
+		}
+		if spec == nil {
+			if spec = paginate.Detect(req.URL, resp.Header, doc); spec == nil && !quiet {
+				fmt.Fprintln(os.Stderr, "no pagination detected; returning a single page")
+			}
+		}
+		items, ok := spec.Extract(doc)
+		if !ok {
+			items = []any{doc}
+		}
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "page %d: %s (%d items)\n", page, resp.Status, len(items))
+		}
+
+		for _, it := range items {
+			if po.MaxItems > 0 && total >= po.MaxItems {
+				break
+			}
+			total++
+			if po.Merge {
+				merged = append(merged, it)
+				continue
+			}
+			if err := write(it); err != nil {
+				return err
+			}
+		}
+
+		if spec == nil || (po.MaxPages > 0 && page >= po.MaxPages) || (po.MaxItems > 0 && total >= po.MaxItems) {
+			break
+		}
+		next := spec.Next(req.URL, resp.Header, doc, len(items))
+		if next == nil || seen[next.String()] {
+			break
+		}
+		// The request carries the profile's credentials; unlike a redirect,
+		// nothing would strip them on the way to another host.
+		if !strings.EqualFold(next.Host, first.URL.Host) {
+			if !quiet {
+				fmt.Fprintf(os.Stderr, "stopping: next page is on another host (%s)\n", next.Host)
+			}
+			break
+		}
+		seen[next.String()] = true
+
+		r := first.Clone(first.Context())
+		r.URL, r.Host = next, ""
+		if first.GetBody != nil {
+			if r.Body, err = first.GetBody(); err != nil {
+				return err
+			}
+		}
+		req = r
+	}
+
+	if !po.Merge {
+		return nil
+	}
+	if o.Filter != nil {
+		return write(merged)
+	}
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	tty := render.IsTerminal(os.Stdout)
+	var out bytes.Buffer
+	render.Body(&out, "application/json", raw, o.renderOptions(tty))
+	if o.NoPager || !tty {
+		_, err = os.Stdout.Write(out.Bytes())
+		return err
+	}
+	return render.Page(os.Stdout, out.Bytes())
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	"github.com/bspippi1337/restless/internal/core/discovery"
+	"github.com/bspippi1337/restless/internal/render"
+)
+
+// progressMode says how discovery progress is shown.
+type progressMode int
+
+const (
+	progressOff   progressMode = iota
+	progressLive               // one status line with a spinner, redrawn in place
+	progressLines              // one plain log line per event
+	progressJSON               // one NDJSON event per line
+)
+
+// pickProgressMode shows a live line on terminals and log lines elsewhere.
+// Debug output goes to stderr in between, so it gets log lines too.
+func pickProgressMode(out *os.File, quiet, debug, jsonEvents bool) progressMode {
+	switch {
+	case jsonEvents:
+		return progressJSON
+	case quiet:
+		return progressOff
+	case render.IsTerminal(out) && !debug && os.Getenv("TERM") != "dumb":
+		return progressLive
+	}
+	return progressLines
+}
+
+// progress renders discovery events. Stop must be called before anything
+// else is written to out.
+type progress struct {
+	mode progressMode
+	out  *os.File
+	enc  *json.Encoder
+
+	mu         sync.Mutex
+	lastFailed string // URL of the last failed fetch, logged already
+	deadline   time.Time
+	pages      *int
+	source     string
+	last       string
+	endpoints  int
+	frame      int
+	done       chan struct{}
+	stopped    chan struct{}
+}
+
+func newProgress(out *os.File, mode progressMode) *progress {
+	p := &progress{mode: mode, out: out}
+	switch mode {
+	case progressJSON:
+		p.enc = json.NewEncoder(out)
+	case progressLive:
+		p.done, p.stopped = make(chan struct{}), make(chan struct{})
+		go p.spin()
+	}
+	return p
+}
+
+// Func is the callback for discovery.Options.Progress; nil when progress
+// is off.
+func (p *progress) Func() func(discovery.Event) {
+	if p.mode == progressOff {
+		return nil
+	}
+	return p.event
+}
+
+// Stop clears the live line.
+func (p *progress) Stop() {
+	if p.mode != progressLive {
+		return
+	}
+	close(p.done)
+	<-p.stopped
+	fmt.Fprint(p.out, "\r\x1b[K")
+}
+
+// Summary ends a JSON event stream with the final finding and the path of
+// the saved profile, if any.
+func (p *progress) Summary(find discovery.Finding, profilePath string) {
+	if p.mode == progressJSON {
+		p.event(discovery.Event{
+			Type:    discovery.EventSummary,
+			Time:    time.Now().Format(time.RFC3339Nano),
+			Finding: &find,
+			Profile: profilePath,
+		})
+	}
+}
+
+// Fail reports an error that ends discovery in a JSON event stream.
+func (p *progress) Fail(source string, err error) {
+	if p.mode == progressJSON {
+		p.event(discovery.Event{
+			Type:   discovery.EventError,
+			Time:   time.Now().Format(time.RFC3339Nano),
+			Source: source,
+			Error:  err.Error(),
+		})
+	}
+}
+
+func (p *progress) event(e discovery.Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch p.mode {
+	case progressJSON:
+		_ = p.enc.Encode(e)
+	case progressLines:
+		switch {
+		case e.Type == discovery.EventFetch && e.Error != "":
+			p.lastFailed = e.URL
+		case e.Type == discovery.EventError && e.URL == p.lastFailed:
+			return
+		}
+		if line := eventLine(e); line != "" {
+			fmt.Fprintln(p.out, "   "+line)
+		}
+	case progressLive:
+		switch e.Type {
+		case discovery.EventStart:
+			p.deadline = time.Now().Add(time.Duration(e.SecondsLeft * float64(time.Second)))
+			p.pages = e.PagesLeft
+		case discovery.EventSource:
+			p.source = e.Source
+		case discovery.EventFetch:
+			p.last = eventLine(e)
+		case discovery.EventEndpoint:
+			p.endpoints++
+		case discovery.EventBudget:
+			p.pages = e.PagesLeft
+		}
+		p.drawLocked()
+	}
+}
+
+// eventLine is the log line for e, or "" for events only the live line
+// and JSON show.
+func eventLine(e discovery.Event) string {
+	switch e.Type {
+	case discovery.EventSource:
+		return "→ " + e.Source
+	case discovery.EventFetch:
+		if e.Error != "" {
+			return fmt.Sprintf("%s %s: %s", e.Method, e.URL, e.Error)
+		}
+		return fmt.Sprintf("%s %s %d (%dms)", e.Method, e.URL, e.Status, e.Millis)
+	case discovery.EventEndpoint:
+		return fmt.Sprintf("+ %s %s (%s %.2f)", e.Method, e.Path, e.Source, e.Score)
+	case discovery.EventDoc:
+		return fmt.Sprintf("+ doc %s (%s)", e.URL, e.Source)
+	case discovery.EventError:
+		return fmt.Sprintf("! %s %s: %s", e.Source, e.URL, e.Error)
+	case discovery.EventDone:
+		return fmt.Sprintf("done in %.1fs: %d endpoints", float64(e.Millis)/1000, e.Endpoints)
+	}
+	return ""
+}
+
+func (p *progress) spin() {
+	defer close(p.stopped)
+	t := time.NewTicker(100 * time.Millisecond)
+	defer t.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-t.C:
+			p.mu.Lock()
+			p.frame++
+			p.drawLocked()
+			p.mu.Unlock()
+		}
+	}
+}
+
+var spinFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// drawLocked redraws the live line: spinner, source, endpoints so far,
+// budget left and the last fetch, cut to the terminal width.
+func (p *progress) drawLocked() {
+	parts := []string{spinFrames[p.frame%len(spinFrames)] + " " + firstNonEmpty(p.source, "starting")}
+	parts = append(parts, fmt.Sprintf("%d endpoints", p.endpoints))
+	if !p.deadline.IsZero() {
+		left := max(0, time.Until(p.deadline)).Round(time.Second)
+		budget := left.String()
+		if p.pages != nil {
+			budget += fmt.Sprintf(", %d pages", *p.pages)
+		}
+		parts = append(parts, budget+" left")
+	}
+	if p.last != "" {
+		parts = append(parts, p.last)
+	}
+	line := strings.Join(parts, " · ")
+	w, _, err := term.GetSize(int(p.out.Fd()))
+	if err != nil || w <= 0 {
+		w = 80
+	}
+	fmt.Fprint(p.out, "\r\x1b[K"+truncateRunes(line, w-1))
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:max(0, n-1)]) + "…"
+}
@@ -0,0 +1,521 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/auth"
+	"github.com/bspippi1337/restless/internal/core/history"
+	"github.com/bspippi1337/restless/internal/core/profile"
+	"github.com/bspippi1337/restless/internal/core/transport"
+	"github.com/bspippi1337/restless/internal/expect"
+	"github.com/bspippi1337/restless/internal/filter"
+	"github.com/bspippi1337/restless/internal/help"
+	"github.com/bspippi1337/restless/internal/render"
+)
+
+// multiFlag collects repeated string flags such as -H.
+type multiFlag []string
+
+func (m *multiFlag) String() string     { return strings.Join(*m, ", ") }
+func (m *multiFlag) Set(v string) error { *m = append(*m, v); return nil }
+
+func cmdRequest(args []string) {
+	fs := flag.NewFlagSet("request", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+
+	var (
+		profileName = fs.String("profile", "", "Profile to use (defaults to the active profile)")
+		profileDir  = fs.String("profile-dir", "", "Custom profile storage directory")
+		envName     = fs.String("env", "", "Profile environment to use (from the environments: block)")
+		method      = fs.String("method", "GET", "HTTP method")
+		path        = fs.String("path", "", "Request path, joined to the profile base URL")
+		baseURL     = fs.String("base-url", "", "Override the profile base URL")
+		data        = fs.String("data", "", "Request body (@file reads a file, @- reads stdin)")
+		dataRaw     = fs.String("data-raw", "", "Request body sent as given, even if it starts with @")
+		timeout     = fs.Int("timeout", 0, "Request timeout in seconds (default from profile)")
+		quiet       = fs.Bool("quiet", false, "Only print the response body")
+		debug       = fs.Bool("debug", false, "Verbose diagnostic logging")
+		timing      = fs.Bool("timing", false, "Print DNS, connect, TLS, TTFB and transfer times")
+		verbose     = fs.Bool("verbose", false, "Print the request/response wire format (secrets redacted)")
+		proxy       = fs.String("proxy", "", "Proxy URL (http, https or socks5)")
+		retries     = fs.Int("retries", transport.DefaultRetry.MaxRetries, "Retries for idempotent requests on 429/5xx (0 disables)")
+		rate        = fs.Float64("rate", 0, "Max requests per second (default from profile rateLimit)")
+		printSpec   = fs.String("print", "b", "Sections to print: H req headers, B req body, h resp headers, b resp body")
+		pretty      = fs.String("pretty", "auto", "all, colors, format or none (auto: all on a terminal)")
+		raw         = fs.Bool("raw", false, "Shorthand for --pretty=none --no-pager")
+		noPager     = fs.Bool("no-pager", false, "Never page output through $PAGER")
+		output      = fs.String("output", "", "Write the response body to a file")
+		filterExpr  = fs.String("filter", "", "jq-style expression applied to the JSON response")
+		rawOutput   = fs.Bool("raw-output", false, "With --filter, print strings without quotes")
+		paginateAll = fs.Bool("paginate", false, "Follow next pages and stream items as NDJSON")
+		maxPages    = fs.Int("max-pages", 50, "With --paginate, stop after this many pages (0 = no limit)")
+		maxItems    = fs.Int("max-items", 0, "With --paginate, stop after this many items (0 = no limit)")
+		merge       = fs.Bool("merge", false, "With --paginate, print one JSON array instead of NDJSON")
+		noHistory   = fs.Bool("no-history", false, "Don't record this request in the history")
+		noLearn     = fs.Bool("no-learn", false, "Don't refine the endpoint's response schema in the profile")
+		headers     multiFlag
+		query       multiFlag
+		resolve     multiFlag
+		expects     multiFlag
+	)
+	fs.Var(&resolve, "resolve", "Pin host:port:addr (repeatable)")
+	fs.StringVar(output, "o", "", "Shorthand for --output")
+	fs.BoolVar(rawOutput, "r", false, "Shorthand for --raw-output")
+	fs.BoolVar(verbose, "v", false, "Shorthand for --verbose")
+	fs.Var(&headers, "H", "Extra header \"Name: value\" (repeatable)")
+	fs.Var(&headers, "header", "Extra header \"Name: value\" (repeatable)")
+	fs.Var(&query, "query", "Query parameter key=value (repeatable)")
+	fs.Var(&expects, "expect", "Assertion on the response, e.g. status=2xx or '$.ok==true' (repeatable)")
+
+	fs.Usage = func() {
+		ctx := help.NewDiscoverHelpContext(*profileDir)
+		if st, ok := loadState(); ok {
+			ctx.ActiveProfile = st.ActiveProfile
+		}
+		fmt.Fprintln(fs.Output(), help.RequestHelp(ctx))
+	}
+
+	rest, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		// flag already printed the error and the help
+		os.Exit(2)
+	}
+	outOpts := outputOpts{Print: *printSpec, Pretty: *pretty, NoPager: *noPager, Output: *output}
+	if *raw {
+		outOpts.Pretty, outOpts.NoPager = "none", true
+	}
+	if err := outOpts.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "request error: %v\n", err)
+		os.Exit(2)
+	}
+	if *filterExpr != "" {
+		prog, err := filter.Compile(*filterExpr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		outOpts.Filter, outOpts.RawOutput = prog, *rawOutput
+	}
+	exps, err := expect.ParseAll(expects, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "request error: %v\n", err)
+		os.Exit(2)
+	}
+	if len(exps) > 0 && *paginateAll {
+		fmt.Fprintln(os.Stderr, "request error: --expect can't be combined with --paginate")
+		os.Exit(2)
+	}
+	// Allow `restless request GET /v1/status` as a shorthand.
+	if len(rest) >= 2 {
+		*method, *path = rest[0], rest[1]
+	} else if len(rest) == 1 {
+		*path = rest[0]
+	}
+
+	prof, name, err := loadProfile(*profileDir, *profileName, *envName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "profile error: %v\n", err)
+		os.Exit(1)
+	}
+
+	target, err := resolveURL(prof, *baseURL, *path, query)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "request error: %v\n", err)
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if *data != "" && *dataRaw != "" {
+		fmt.Fprintln(os.Stderr, "request error: give --data or --data-raw, not both")
+		os.Exit(2)
+	}
+	body, err := readBody(*data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "request error: %v\n", err)
+		os.Exit(1)
+	}
+	if *dataRaw != "" {
+		body = []byte(*dataRaw)
+	}
+
+	ctx := context.Background()
+	var timer *transport.Timer
+	if *timing {
+		ctx, timer = transport.WithTimer(ctx)
+	}
+	req, err := newRequest(ctx, prof, *method, target, body, headers)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "request error: %v\n", err)
+		os.Exit(2)
+	}
+
+	cfg := clientConfig{
+		Proxy:     *proxy,
+		Resolve:   resolve,
+		Retries:   *retries,
+		RetryDial: flagGiven(fs, "retries"),
+		Rate:      *rate,
+		Timeout:   *timeout,
+	}
+	if !*quiet {
+		cfg.Log = os.Stderr
+	}
+	if *verbose {
+		cfg.Dump = os.Stderr
+	}
+	if !*noHistory && !history.Disabled() {
+		cfg.History = &history.Recorder{
+			Profile:    name,
+			ProfileDir: *profileDir,
+			Env:        *envName,
+			BaseURL:    firstNonEmpty(*baseURL, firstOf(prof.BaseURLs)),
+			HeaderArgs: headerNames(headers),
+		}
+	}
+	client, err := newClient(prof, name, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	if *debug {
+		fmt.Fprintf(os.Stderr, "> %s %s\n", req.Method, transport.RedactURL(req.URL))
+		for k, vs := range req.Header {
+			for _, v := range vs {
+				fmt.Fprintf(os.Stderr, "> %s: %s\n", k, transport.RedactHeader(k, v))
+			}
+		}
+	}
+
+	if *paginateAll {
+		po := paginateOpts{MaxPages: *maxPages, MaxItems: *maxItems, Merge: *merge}
+		if ep := prof.Match(req.Method, *path); ep != nil {
+			po.Spec = ep.Pagination
+		}
+		if err := runPaginated(client, req, po, outOpts, *quiet); err != nil {
+			fmt.Fprintf(os.Stderr, "paginate error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			ue.URL = transport.RedactURL(req.URL)
+		}
+		fmt.Fprintf(os.Stderr, "request error: %v\n", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+	var respBody []byte
+	elapsed := time.Since(start)
+	if len(exps) > 0 || timer != nil {
+		// Assertions and timing need the whole body and the time it took
+		// to arrive, before the pager or a profile write can add to it.
+		if respBody, err = io.ReadAll(resp.Body); err != nil {
+			fmt.Fprintf(os.Stderr, "request error: %v\n", err)
+			os.Exit(1)
+		}
+		elapsed = time.Since(start)
+		resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	}
+	var timed *transport.Timing
+	if timer != nil {
+		t := timer.Finish()
+		timed = &t
+	}
+
+	// A JSON answer refines the endpoint's response schema once printed.
+	var learned []byte
+	if !*noLearn && name != "" {
+		if respBody == nil {
+			learned = peekJSON(resp)
+		} else if learnable(resp) {
+			learned = respBody
+		}
+	}
+
+	if !*quiet && !strings.Contains(outOpts.Print, "h") {
+		fmt.Fprintf(os.Stderr, "%s %s  (%s)\n", resp.Proto, resp.Status, time.Since(start).Round(time.Millisecond))
+	}
+	if *debug {
+		for _, line := range transport.DescribeTLS(resp.TLS) {
+			fmt.Fprintf(os.Stderr, "* %s\n", line)
+		}
+		for k, vs := range resp.Header {
+			for _, v := range vs {
+				fmt.Fprintf(os.Stderr, "< %s: %s\n", k, transport.RedactHeader(k, v))
+			}
+		}
+	}
+	midLine, err := writeExchange(req, body, resp, outOpts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "output error: %v\n", err)
+		os.Exit(1)
+	}
+	// Reports on stderr shouldn't start on the body's last line.
+	if midLine && render.IsTerminal(os.Stdout) {
+		fmt.Fprintln(os.Stdout)
+	}
+	var expectErr error
+	if len(exps) > 0 {
+		expectErr = expect.Check(exps, expect.Response{Status: resp.StatusCode, Header: resp.Header, Body: respBody, Duration: elapsed})
+	}
+	// A response that failed its assertions mustn't widen the saved schema.
+	if expectErr == nil {
+		if err := learnSchema(*profileDir, name, req.Method, *path, learned); err != nil && !*quiet {
+			fmt.Fprintf(os.Stderr, "schema: not saved: %v\n", err)
+		}
+	}
+	if timed != nil {
+		for _, line := range timed.Lines() {
+			fmt.Fprintf(os.Stderr, "  %s\n", line)
+		}
+	}
+	if len(exps) > 0 && !reportExpect(len(exps), expectErr, *quiet) {
+		os.Exit(1)
+	}
+}
+
+// reportExpect prints failed assertions to stderr (and a one-line pass
+// note unless quiet) and reports whether they all held.
+func reportExpect(n int, err error, quiet bool) bool {
+	var fails expect.Failures
+	if !errors.As(err, &fails) {
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "expect: %d passed\n", n)
+		}
+		return true
+	}
+	for _, f := range fails {
+		fmt.Fprintf(os.Stderr, "expect failed: %s\n", f.Error())
+	}
+	fmt.Fprintf(os.Stderr, "expect: %d passed, %d failed\n", n-len(fails), len(fails))
+	return false
+}
+
+// loadProfile loads the named profile, falling back to the active one, and
+// applies env. With no name and no usable active profile it returns an
+// empty profile so absolute URLs and --base-url still work.
+func loadProfile(dir, name, env string) (*profile.Profile, string, error) {
+	if dir == "" {
+		dir = defaultProfileDir()
+	}
+	name = strings.TrimSpace(name)
+	prof := &profile.Profile{}
+	if name != "" {
+		p, err := profile.Load(dir, name)
+		if err != nil {
+			return nil, "", err
+		}
+		prof = p
+	} else if st, ok := loadState(); ok && st.ActiveProfile != "" {
+		// The active profile is a convenience; ignore it if it's gone.
+		if p, err := profile.Load(dir, st.ActiveProfile); err == nil {
+			name, prof = st.ActiveProfile, p
+		}
+	}
+	if env != "" {
+		if err := prof.UseEnv(env); err != nil {
+			return nil, "", err
+		}
+	}
+	return prof, name, nil
+}
+
+// newRequest builds a request with the profile's default headers and the
+// "Name: value" headers given on the command line.
+func newRequest(ctx context.Context, prof *profile.Profile, method, target string, body []byte, headers []string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(method), target, bodyReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range prof.Defaults.Headers {
+		req.Header.Set(k, profile.Expand(v))
+	}
+	for _, h := range headers {
+		k, v, ok := strings.Cut(h, ":")
+		if !ok {
+			return nil, fmt.Errorf("bad header %q (want \"Name: value\")", h)
+		}
+		req.Header.Set(strings.TrimSpace(k), profile.Expand(strings.TrimSpace(v)))
+	}
+	if len(body) > 0 && req.Header.Get("Content-Type") == "" && looksLikeJSON(body) {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+func headerNames(headers []string) []string {
+	var out []string
+	for _, h := range headers {
+		k, _, _ := strings.Cut(h, ":")
+		out = append(out, http.CanonicalHeaderKey(strings.TrimSpace(k)))
+	}
+	return out
+}
+
+// clientConfig holds the command-line knobs newClient layers over the profile.
+type clientConfig struct {
+	Proxy     string
+	Resolve   []string
+	Retries   int     // 0 disables retries
+	RetryDial bool    // also retry failed connects; set when --retries is given
+	Rate      float64 // requests per second; 0 uses the profile rateLimit
+	Timeout   int     // seconds; 0 uses the profile default, else 20
+	Log       io.Writer
+	Dump      io.Writer
+	NoAuth    bool // skip profile auth, e.g. for documents on another host
+	// Warn receives warnings and auth prompts; nil means stderr.
+	Warn io.Writer
+	// History, when set, records every exchange; Base and Store are filled in.
+	History *history.Recorder
+}
+
+// flagGiven reports whether the flag name was set on the command line.
+func flagGiven(fs *flag.FlagSet, name string) bool {
+	given := false
+	fs.Visit(func(f *flag.Flag) { given = given || f.Name == name })
+	return given
+}
+
+// newClient stacks history recording, auth, retries and the profile's
+// TLS/network settings into one client.
+func newClient(prof *profile.Profile, name string, cfg clientConfig) (*http.Client, error) {
+	retry := transport.DefaultRetry
+	retry.MaxRetries = cfg.Retries
+	if cfg.Retries <= 0 {
+		retry.MaxRetries = -1
+	}
+	retry.RetryDial = cfg.RetryDial
+	warn := cfg.Warn
+	if warn == nil {
+		warn = os.Stderr
+	}
+	rps := cfg.Rate
+	if rps <= 0 {
+		rps = prof.RateLimit.RequestsPerSecond
+	}
+	base, err := transport.New(transport.Options{
+		TLS:               prof.TLS,
+		Proxy:             firstNonEmpty(cfg.Proxy, prof.Network.Proxy),
+		Resolve:           append(append([]string{}, prof.Network.Resolve...), cfg.Resolve...),
+		Retry:             retry,
+		RequestsPerSecond: rps,
+		Log:               cfg.Log,
+		Dump:              cfg.Dump,
+		BaseDir:           filepath.Dir(prof.Path),
+		Warn:              warn,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("transport error: %w", err)
+	}
+	rt := base
+	if !cfg.NoAuth {
+		if rt, err = auth.Wrap(base, prof.Auth, auth.Options{
+			Profile:  name,
+			CacheDir: filepath.Join(configDir(), "tokens"),
+			Prompt:   warn,
+		}); err != nil {
+			return nil, fmt.Errorf("auth error: %w", err)
+		}
+	}
+	if rec := cfg.History; rec != nil {
+		rec.Base, rec.Store, rec.Warn = rt, history.Open(configDir()), warn
+		rt = rec
+	}
+	secs := cfg.Timeout
+	if secs <= 0 {
+		secs = prof.Defaults.TimeoutSeconds
+	}
+	if secs <= 0 {
+		secs = 20
+	}
+	// The timeout covers a whole request: every retry attempt and every
+	// backoff or Retry-After wait share it. Each page of --paginate gets its
+	// own.
+	return &http.Client{Transport: rt, Timeout: time.Duration(secs) * time.Second}, nil
+}
+
+// resolveURL joins the base URL and path and appends --query parameters.
+// An absolute --path is used as-is.
+func resolveURL(p *profile.Profile, baseOverride, path string, query []string) (string, error) {
+	var raw string
+	switch {
+	case strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://"):
+		raw = path
+	default:
+		base := firstNonEmpty(baseOverride, firstOf(p.BaseURLs))
+		if base == "" {
+			return "", errors.New("no base URL: pass --profile, --base-url or an absolute URL")
+		}
+		if path != "" && !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		raw = strings.TrimRight(base, "/") + path
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if len(query) > 0 {
+		q := u.Query()
+		for _, kv := range query {
+			k, v, _ := strings.Cut(kv, "=")
+			q.Add(k, profile.Expand(v))
+		}
+		u.RawQuery = q.Encode()
+	}
+	return u.String(), nil
+}
+
+func firstOf(s []string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	return s[0]
+}
+
+func readBody(data string) ([]byte, error) {
+	switch {
+	case data == "":
+		return nil, nil
+	case data == "@-":
+		return io.ReadAll(os.Stdin)
+	case strings.HasPrefix(data, "@"):
+		return os.ReadFile(data[1:])
+	default:
+		return []byte(data), nil
+	}
+}
+
+// bodyReader returns nil for empty bodies so GET requests stay bodiless;
+// a bytes.Reader lets net/http replay the body on retries.
+func bodyReader(b []byte) io.Reader {
+	if len(b) == 0 {
+		return nil
+	}
+	return bytes.NewReader(b)
+}
+
+func looksLikeJSON(b []byte) bool {
+	t := bytes.TrimSpace(b)
+	return len(t) > 0 && (t[0] == '{' || t[0] == '[')
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/history"
+	"github.com/bspippi1337/restless/internal/core/profile"
+	"github.com/bspippi1337/restless/internal/core/scenario"
+	"github.com/bspippi1337/restless/internal/core/transport"
+	"github.com/bspippi1337/restless/internal/expect"
+	"github.com/bspippi1337/restless/internal/help"
+	"github.com/bspippi1337/restless/internal/render"
+)
+
+// cmdScenario runs scenario files: `restless scenario run a.yaml b.yaml`.
+// Each file runs to its first failing step; the exit status is 1 if any
+// step failed.
+func cmdScenario(args []string) {
+	fs := flag.NewFlagSet("scenario", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+
+	var (
+		profileName = fs.String("profile", "", "Profile to use (overrides the scenario's profile:)")
+		profileDir  = fs.String("profile-dir", "", "Custom profile storage directory")
+		envName     = fs.String("env", "", "Profile environment to use (overrides the scenario's env:)")
+		junit       = fs.String("junit", "", "Write results as JUnit XML to this file")
+		timeout     = fs.Int("timeout", 0, "Request timeout in seconds (default from profile)")
+		proxy       = fs.String("proxy", "", "Proxy URL (http, https or socks5)")
+		retries     = fs.Int("retries", transport.DefaultRetry.MaxRetries, "Retries for idempotent requests on 429/5xx (0 disables)")
+		noHistory   = fs.Bool("no-history", false, "Don't record the requests in the history")
+		vars        multiFlag
+	)
+	fs.Var(&vars, "var", "Variable name=value, over the scenario's vars (repeatable)")
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), help.ScenarioHelp(help.NewDiscoverHelpContext(*profileDir))) }
+
+	rest, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+	if len(rest) < 2 || rest[0] != "run" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	fail := func(err error) {
+		fmt.Fprintf(os.Stderr, "scenario error: %v\n", err)
+		os.Exit(2)
+	}
+
+	given := map[string]string{}
+	for _, kv := range vars {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			fail(fmt.Errorf("bad --var %q (want name=value)", kv))
+		}
+		given[k] = v
+	}
+	// Load everything first so a typo in the last file doesn't surface
+	// after the first one has already created data.
+	var scenarios []*scenario.Scenario
+	for _, path := range rest[1:] {
+		s, err := scenario.Load(path)
+		if err != nil {
+			fail(err)
+		}
+		scenarios = append(scenarios, s)
+	}
+
+	var reports []scenario.Report
+	for _, s := range scenarios {
+		env := firstNonEmpty(*envName, s.Env)
+		prof, name, err := loadProfile(*profileDir, firstNonEmpty(*profileName, s.Profile), env)
+		if err != nil {
+			fail(fmt.Errorf("%s: %w", s.File, err))
+		}
+		cfg := clientConfig{Proxy: *proxy, Retries: *retries, RetryDial: flagGiven(fs, "retries"), Timeout: *timeout}
+		var rec *history.Recorder
+		if !*noHistory && !history.Disabled() {
+			rec = &history.Recorder{Profile: name, ProfileDir: *profileDir, Env: env, BaseURL: firstOf(prof.BaseURLs)}
+			cfg.History = rec
+		}
+		client, err := newClient(prof, name, cfg)
+		if err != nil {
+			fail(err)
+		}
+		r := &scenario.Runner{
+			Client:  client,
+			Profile: prof,
+			Vars:    given,
+			Build: func(ctx context.Context, req profile.Request) (*http.Request, error) {
+				return buildStep(ctx, prof, rec, req)
+			},
+		}
+		reports = append(reports, runScenario(r, s))
+	}
+
+	if *junit != "" {
+		f, err := os.Create(*junit)
+		if err != nil {
+			fail(err)
+		}
+		err = scenario.WriteJUnit(f, reports)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			fail(err)
+		}
+	}
+	for _, rep := range reports {
+		if rep.Failed() {
+			os.Exit(1)
+		}
+	}
+}
+
+// buildStep turns a rendered step into a request the same way `restless
+// request` would build it from flags.
+func buildStep(ctx context.Context, prof *profile.Profile, rec *history.Recorder, r profile.Request) (*http.Request, error) {
+	var query, headers []string
+	for _, k := range sortedKeys(r.Query) {
+		query = append(query, k+"="+r.Query[k])
+	}
+	for _, k := range sortedKeys(r.Headers) {
+		headers = append(headers, k+": "+r.Headers[k])
+	}
+	target, err := resolveURL(prof, "", r.Path, query)
+	if err != nil {
+		return nil, err
+	}
+	if rec != nil {
+		// Steps run one at a time, so the recorder can be retargeted.
+		rec.HeaderArgs = headerNames(headers)
+	}
+	return newRequest(ctx, prof, r.Method, target, []byte(r.Body), headers)
+}
+
+// runScenario prints each step as it finishes and a summary line.
+func runScenario(r *scenario.Runner, s *scenario.Scenario) scenario.Report {
+	out := os.Stdout
+	color := render.ColorEnabled(out)
+	paint := func(code, s string) string {
+		if !color {
+			return s
+		}
+		return "\x1b[" + code + "m" + s + "\x1b[0m"
+	}
+	width := 0
+	for _, st := range s.Steps {
+		width = max(width, len(st.Name))
+	}
+
+	fmt.Fprintf(out, "scenario: %s (%s)\n", s.Name, s.File)
+	r.OnStep = func(res scenario.Result) {
+		switch {
+		case res.Skipped:
+			fmt.Fprintf(out, "  %s %s\n", paint("33", "[SKIP]"), res.Step)
+		default:
+			mark := paint("32", "[ OK ]")
+			if res.Err != nil {
+				mark = paint("31", "[FAIL]")
+			}
+			status := "---"
+			if res.Status > 0 {
+				status = fmt.Sprint(res.Status)
+			}
+			line := fmt.Sprintf("  %s %-*s  %-6s %s  %s", mark, width, res.Step, res.Method, status, res.Duration.Round(time.Millisecond))
+			var fails expect.Failures
+			switch {
+			case errors.As(res.Err, &fails) && len(fails) > 1:
+				line += fmt.Sprintf("  %d expectations failed", len(fails))
+			case res.Err != nil:
+				line += "  " + res.Err.Error()
+			}
+			fmt.Fprintln(out, line)
+			if len(fails) > 1 {
+				for _, f := range fails {
+					fmt.Fprintf(out, "         %s\n", f.Error())
+				}
+			}
+			for _, k := range sortedKeys(res.Captured) {
+				v := short(res.Captured[k])
+				if transport.IsSecret(k) {
+					v = "[redacted]"
+				}
+				fmt.Fprintf(out, "         %s = %s\n", k, v)
+			}
+		}
+	}
+	rep := r.Run(context.Background(), s)
+
+	n := map[string]int{}
+	for _, res := range rep.Results {
+		switch {
+		case res.Skipped:
+			n["skipped"]++
+		case res.Err != nil:
+			n["failed"]++
+		default:
+			n["passed"]++
+		}
+	}
+	var parts []string
+	for _, k := range []string{"passed", "failed", "skipped"} {
+		if n[k] > 0 || k == "passed" {
+			parts = append(parts, fmt.Sprintf("%d %s", n[k], k))
+		}
+	}
+	fmt.Fprintf(out, "%s in %s\n", strings.Join(parts, ", "), rep.Duration.Round(time.Millisecond))
+	return rep
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/bspippi1337/restless/internal/core/profile"
+	"github.com/bspippi1337/restless/internal/jsonschema"
+)
+
+// maxLearnBody is the largest response body read to refine a schema.
+const maxLearnBody = 4 << 20
+
+// peekJSON reads a successful JSON response body so it can be learned from
+// after it is printed; resp.Body is replaced to read the same bytes.
+// Anything else, or a body over maxLearnBody, is left alone and nil is
+// returned.
+func peekJSON(resp *http.Response) []byte {
+	if !learnable(resp) {
+		return nil
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxLearnBody+1))
+	rest := resp.Body
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(b), rest), rest}
+	if err != nil || len(b) > maxLearnBody {
+		return nil
+	}
+	return b
+}
+
+// learnable reports whether a response is one schemas are learned from: a
+// 2xx with a JSON body.
+func learnable(resp *http.Response) bool {
+	ct := strings.ToLower(resp.Header.Get("Content-Type"))
+	return resp.StatusCode >= 200 && resp.StatusCode <= 299 && strings.Contains(ct, "json")
+}
+
+// learnSchema refines the response schema of the profile endpoint that
+// answered method and path, and saves the profile when it changed.
+func learnSchema(dir, name, method, path string, body []byte) error {
+	if name == "" || len(body) == 0 || strings.Contains(path, "://") {
+		return nil
+	}
+	if dir == "" {
+		dir = defaultProfileDir()
+	}
+	// Reload: the caller's copy may have an environment applied.
+	p, err := profile.Load(dir, name)
+	if err != nil {
+		return err
+	}
+	ep := p.Match(method, path)
+	if ep == nil {
+		return nil
+	}
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if dec.Decode(&doc) != nil {
+		return nil
+	}
+	schema := jsonschema.Refine(ep.Schema, doc)
+	before, _ := json.Marshal(ep.Schema)
+	after, _ := json.Marshal(schema)
+	if bytes.Equal(before, after) {
+		return nil
+	}
+	src, err := os.ReadFile(p.Path)
+	if err != nil {
+		return err
+	}
+	out, ok := setEndpointSchema(string(src), ep.Method, ep.Path, schema)
+	if !ok {
+		return nil
+	}
+	return os.WriteFile(p.Path, []byte(out), 0o644)
+}
+
+// setEndpointSchema replaces or adds the schema block of one entry in the
+// endpoints: list, leaving the rest of the file as it was.
+func setEndpointSchema(src, method, path string, schema map[string]any) (string, bool) {
+	lines := strings.Split(src, "\n")
+	start := -1
+	for i, line := range lines {
+		if strings.TrimRight(line, " ") == "endpoints:" {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		return src, false
+	}
+	end := start
+	for end < len(lines) {
+		line := lines[end]
+		if len(line) > 0 && line[0] != ' ' && line[0] != '-' && line[0] != '#' {
+			break
+		}
+		end++
+	}
+
+	// Split the block into list entries and find the one for method and path.
+	itemIndent := -1
+	for i := start; i < end; i++ {
+		ind := indentOf(lines[i])
+		if !strings.HasPrefix(lines[i][ind:], "- ") {
+			continue
+		}
+		if itemIndent < 0 {
+			itemIndent = ind
+		}
+		if ind != itemIndent {
+			continue
+		}
+		j := i + 1
+		for j < end && (strings.TrimSpace(lines[j]) == "" || indentOf(lines[j]) > itemIndent) {
+			j++
+		}
+		for j > i+1 && strings.TrimSpace(lines[j-1]) == "" {
+			j-- // blank lines after the entry stay where they are
+		}
+		keyIndent := itemIndent + 2
+		fields := map[string]string{}
+		schemaAt := -1
+		for k := i; k < j; k++ {
+			text := strings.TrimSpace(lines[k])
+			if k == i {
+				text = strings.TrimSpace(strings.TrimPrefix(text, "-"))
+			} else if indentOf(lines[k]) != keyIndent {
+				continue
+			}
+			key, val, _ := strings.Cut(text, ":")
+			fields[key] = strings.Trim(strings.TrimSpace(val), `"'`)
+			if key == "schema" {
+				schemaAt = k
+			}
+		}
+		if !strings.EqualFold(fields["method"], method) || fields["path"] != path {
+			i = j - 1
+			continue
+		}
+
+		var sb strings.Builder
+		writeSchema(&sb, strings.Repeat(" ", keyIndent), schema)
+		block := strings.Split(strings.TrimSuffix(sb.String(), "\n"), "\n")
+		at, stop := j, j
+		if schemaAt >= 0 {
+			at, stop = schemaAt, schemaAt+1
+			for stop < j && (strings.TrimSpace(lines[stop]) == "" || indentOf(lines[stop]) > keyIndent) {
+				stop++
+			}
+		}
+		out := append(append(append([]string{}, lines[:at]...), block...), lines[stop:]...)
+		return strings.Join(out, "\n"), true
+	}
+	return src, false
+}
+
+func indentOf(s string) int { return len(s) - len(strings.TrimLeft(s, " ")) }
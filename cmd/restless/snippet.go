@@ -0,0 +1,249 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bspippi1337/restless/internal/core/profile"
+	"github.com/bspippi1337/restless/internal/core/snippet"
+	"github.com/bspippi1337/restless/internal/core/transport"
+	"github.com/bspippi1337/restless/internal/help"
+)
+
+// cmdSnippet prints a runnable curl, HTTPie, Go or Python version of a
+// profile request. Credentials stay environment references.
+func cmdSnippet(args []string) {
+	fs := flag.NewFlagSet("snippet", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+
+	var (
+		lang       = fs.String("lang", "curl", "Snippet language: "+strings.Join(snippet.Langs, ", "))
+		profileDir = fs.String("profile-dir", "", "Custom profile storage directory")
+		envName    = fs.String("env", "", "Profile environment to use (from the environments: block)")
+		baseURL    = fs.String("base-url", "", "Override the profile base URL")
+		data       = fs.String("data", "", "Request body (@file reads a file)")
+		noAuth     = fs.Bool("no-auth", false, "Leave out the profile's auth header")
+		headers    multiFlag
+		query      multiFlag
+		vars       multiFlag
+	)
+	fs.Var(&headers, "H", "Extra header \"Name: value\" (repeatable)")
+	fs.Var(&headers, "header", "Extra header \"Name: value\" (repeatable)")
+	fs.Var(&query, "query", "Query parameter key=value (repeatable)")
+	fs.Var(&vars, "var", "Fill {name} and ${name} in the path, query or body (repeatable)")
+	fs.Usage = func() { fmt.Fprintln(fs.Output(), help.SnippetHelp(help.NewDiscoverHelpContext(*profileDir))) }
+
+	rest, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+	if len(rest) != 3 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	fail := func(err error) {
+		fmt.Fprintf(os.Stderr, "snippet error: %v\n", err)
+		os.Exit(1)
+	}
+
+	prof, name, err := loadProfile(*profileDir, rest[0], *envName)
+	if err != nil {
+		fail(err)
+	}
+	method, path := strings.ToUpper(rest[1]), rest[2]
+	given := map[string]string{}
+	for _, kv := range vars {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			fmt.Fprintf(os.Stderr, "snippet error: bad --var %q (want name=value)\n", kv)
+			os.Exit(2)
+		}
+		given[k] = v
+	}
+
+	// A collection entry for the same call supplies its query, headers and
+	// body; flags add to it.
+	entry := profile.Request{Method: method, Path: path}
+	for _, r := range prof.Collection {
+		if r.Method == method && r.Path == path {
+			entry = r
+			break
+		}
+	}
+	// Unfilled variables stay visible as placeholders in the snippet.
+	entry, _ = prof.WithVars(entry).Render(given)
+
+	body := strings.TrimRight(entry.Body, "\n")
+	if *data != "" {
+		b, err := readBody(*data)
+		if err != nil {
+			fail(err)
+		}
+		body = string(b)
+	}
+
+	base := firstNonEmpty(*baseURL, firstOf(prof.BaseURLs))
+	target := entry.Path
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		if base == "" {
+			fail(errors.New("no base URL: the profile has none; pass --base-url"))
+		}
+		if !strings.HasPrefix(target, "/") {
+			target = "/" + target
+		}
+		target = strings.TrimRight(base, "/") + target
+	}
+	params := entry.Query
+	for _, kv := range query {
+		k, v, _ := strings.Cut(kv, "=")
+		params[k] = v
+	}
+	if len(params) > 0 {
+		sep := "?"
+		if strings.Contains(target, "?") {
+			sep = "&"
+		}
+		var qs []string
+		for _, k := range sortedKeys(params) {
+			qs = append(qs, url.QueryEscape(k)+"="+escapeKeepEnv(placeholder(k, params[k])))
+		}
+		target += sep + strings.Join(qs, "&")
+	}
+
+	req := snippet.Request{Method: method, URL: target, Body: body}
+	set := func(k, v string) {
+		k = http.CanonicalHeaderKey(strings.TrimSpace(k))
+		v = placeholder(k, strings.TrimSpace(v))
+		for i := range req.Headers {
+			if req.Headers[i][0] == k {
+				req.Headers[i][1] = v
+				return
+			}
+		}
+		req.Headers = append(req.Headers, [2]string{k, v})
+	}
+	defaults := make([]string, 0, len(prof.Defaults.Headers))
+	for k := range prof.Defaults.Headers {
+		defaults = append(defaults, k)
+	}
+	sort.Strings(defaults)
+	for _, k := range defaults {
+		if !strings.EqualFold(k, "User-Agent") {
+			set(k, prof.Defaults.Headers[k])
+		}
+	}
+	if !*noAuth {
+		switch a := prof.Auth; a.Type {
+		case "bearer":
+			set("Authorization", "Bearer ${ENV:"+firstNonEmpty(a.Token.EnvVar, "RESTLESS_TOKEN")+"}")
+		case "oauth2":
+			set("Authorization", "Bearer ${ENV:RESTLESS_ACCESS_TOKEN}")
+			req.Notes = append(req.Notes, fmt.Sprintf("RESTLESS_ACCESS_TOKEN: an OAuth2 access token from %s", firstNonEmpty(a.OAuth2.TokenURL, "the profile's token URL")))
+		}
+	}
+	for _, k := range sortedKeys(entry.Headers) {
+		set(k, entry.Headers[k])
+	}
+	for _, h := range headers {
+		k, v, ok := strings.Cut(h, ":")
+		if !ok {
+			fmt.Fprintf(os.Stderr, "snippet error: bad header %q (want \"Name: value\")\n", h)
+			os.Exit(2)
+		}
+		set(k, v)
+	}
+	if body != "" && looksLikeJSON([]byte(body)) {
+		has := false
+		for _, h := range req.Headers {
+			has = has || h[0] == "Content-Type"
+		}
+		if !has {
+			set("Content-Type", "application/json")
+		}
+	}
+
+	if envs := envRefs(req); len(envs) > 0 {
+		req.Notes = append([]string{fmt.Sprintf("%s %s (profile %s); needs %s in the environment.", method, path, name, strings.Join(envs, ", "))}, req.Notes...)
+	}
+	out, err := snippet.Render(*lang, req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "snippet error: %v\n", err)
+		os.Exit(2)
+	}
+	fmt.Print(out)
+}
+
+// placeholder keeps env references and replaces a literal credential with
+// one, keeping an auth scheme such as "Bearer" in front.
+func placeholder(name, v string) string {
+	if !transport.IsSecret(name) || v == "" || strings.Contains(v, "${ENV:") {
+		return v
+	}
+	if scheme, _, ok := strings.Cut(v, " "); ok && strings.EqualFold(name, "Authorization") {
+		switch strings.ToLower(scheme) {
+		case "bearer":
+			return scheme + " ${ENV:RESTLESS_TOKEN}"
+		case "basic":
+			return scheme + " ${ENV:RESTLESS_BASIC_AUTH}"
+		}
+		return scheme + " ${ENV:RESTLESS_AUTHORIZATION}"
+	}
+	return "${ENV:RESTLESS_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name)) + "}"
+}
+
+// escapeKeepEnv query-escapes v but leaves ${ENV:NAME} references intact.
+func escapeKeepEnv(v string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(v, "${ENV:")
+		j := -1
+		if i >= 0 {
+			j = strings.IndexByte(v[i:], '}')
+		}
+		if i < 0 || j < 0 {
+			b.WriteString(url.QueryEscape(v))
+			return b.String()
+		}
+		b.WriteString(url.QueryEscape(v[:i]))
+		b.WriteString(v[i : i+j+1])
+		v = v[i+j+1:]
+	}
+}
+
+// envRefs lists the environment variables a snippet reads.
+func envRefs(r snippet.Request) []string {
+	seen := map[string]bool{}
+	var out []string
+	scan := func(s string) {
+		for {
+			i := strings.Index(s, "${ENV:")
+			if i < 0 {
+				return
+			}
+			j := strings.IndexByte(s[i:], '}')
+			if j < 0 {
+				return
+			}
+			if n := s[i+6 : i+j]; !seen[n] {
+				seen[n] = true
+				out = append(out, n)
+			}
+			s = s[i+j+1:]
+		}
+	}
+	scan(r.URL)
+	for _, h := range r.Headers {
+		scan(h[1])
+	}
+	scan(r.Body)
+	return out
+}
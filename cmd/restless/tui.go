@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/history"
+	"github.com/bspippi1337/restless/internal/core/profile"
+	"github.com/bspippi1337/restless/internal/core/transport"
+	"github.com/bspippi1337/restless/internal/help"
+	"github.com/bspippi1337/restless/internal/tui"
+)
+
+// maxViewBody is the most of a response body the TUI keeps in memory.
+const maxViewBody = 4 << 20
+
+func cmdTUI(args []string) {
+	fs := flag.NewFlagSet("tui", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	var (
+		profileName = fs.String("profile", "", "Profile to open (defaults to the active profile)")
+		profileDir  = fs.String("profile-dir", "", "Custom profile storage directory")
+		envName     = fs.String("env", "", "Profile environment to start with")
+		proxy       = fs.String("proxy", "", "Proxy URL (http, https or socks5)")
+		noHistory   = fs.Bool("no-history", false, "Don't record requests in the history")
+		noLearn     = fs.Bool("no-learn", false, "Don't refine response schemas in the profile")
+	)
+	fs.Usage = func() {
+		ctx := help.NewDiscoverHelpContext(*profileDir)
+		if st, ok := loadState(); ok {
+			ctx.ActiveProfile = st.ActiveProfile
+		}
+		fmt.Fprintln(fs.Output(), help.TUIHelp(ctx))
+	}
+	rest, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+	if len(rest) > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	name := *profileName
+	if len(rest) == 1 {
+		name = rest[0]
+	}
+	dir := *profileDir
+	if dir == "" {
+		dir = defaultProfileDir()
+	}
+	// Fall back to the active profile, if it still exists.
+	if name == "" {
+		if st, ok := loadState(); ok && st.ActiveProfile != "" {
+			if _, err := os.Stat(profile.FilePath(dir, st.ActiveProfile)); err == nil {
+				name = st.ActiveProfile
+			}
+		}
+	}
+
+	opt := tui.Options{
+		ProfileDir: dir,
+		Profile:    name,
+		Env:        *envName,
+		Send: func(ctx context.Context, c tui.Call, log io.Writer) (*tui.Result, error) {
+			return tuiSend(ctx, c, log, tuiSendOpts{
+				ProfileDir: *profileDir,
+				Proxy:      *proxy,
+				NoHistory:  *noHistory,
+				NoLearn:    *noLearn,
+			})
+		},
+	}
+	if !*noHistory && !history.Disabled() {
+		opt.History = history.Open(configDir())
+	}
+	if err := tui.Run(opt); err != nil {
+		fmt.Fprintf(os.Stderr, "tui error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+type tuiSendOpts struct {
+	ProfileDir string // as given on the command line, for history entries
+	Proxy      string
+	NoHistory  bool
+	NoLearn    bool
+}
+
+// tuiSend sends a call from the editor the way `restless request` would,
+// with the profile's auth, retries and history, and learns from the answer.
+func tuiSend(ctx context.Context, c tui.Call, log io.Writer, o tuiSendOpts) (*tui.Result, error) {
+	prof, name := &profile.Profile{}, c.Profile
+	if name != "" {
+		p, _, err := loadProfile(o.ProfileDir, name, c.Env)
+		if err != nil {
+			return nil, err
+		}
+		prof = p
+	}
+	target, err := resolveURL(prof, "", c.Path, c.Query)
+	if err != nil {
+		return nil, err
+	}
+	req, err := newRequest(ctx, prof, c.Method, target, c.Body, c.Headers)
+	if err != nil {
+		return nil, err
+	}
+	cfg := clientConfig{Proxy: o.Proxy, Retries: transport.DefaultRetry.MaxRetries, Warn: log}
+	if !o.NoHistory && !history.Disabled() {
+		cfg.History = &history.Recorder{
+			Profile:    name,
+			ProfileDir: o.ProfileDir,
+			Env:        c.Env,
+			BaseURL:    firstOf(prof.BaseURLs),
+			HeaderArgs: headerNames(c.Headers),
+		}
+	}
+	client, err := newClient(prof, name, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			ue.URL = transport.RedactURL(req.URL)
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxViewBody+1))
+	if err != nil {
+		return nil, err
+	}
+	res := &tui.Result{
+		Method:  req.Method,
+		URL:     transport.RedactURL(req.URL),
+		Proto:   resp.Proto,
+		Status:  resp.Status,
+		Code:    resp.StatusCode,
+		Header:  resp.Header,
+		Body:    body,
+		Elapsed: time.Since(start),
+	}
+	if len(body) > maxViewBody {
+		res.Body, res.Truncated = body[:maxViewBody], true
+	}
+	if !o.NoLearn && !res.Truncated && learnable(resp) {
+		if err := learnSchema(o.ProfileDir, name, req.Method, c.Path, body); err != nil {
+			res.Note = "schema: not saved: " + err.Error()
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,319 @@
+// Package auth applies a profile's auth block to outgoing HTTP requests.
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/profile"
+)
+
+// expirySkew refreshes tokens a little before the server would reject them.
+const expirySkew = 60 * time.Second
+
+const deviceCodeGrant = "urn:ietf:params:oauth:grant-type:device_code"
+
+type Token struct {
+	AccessToken  string    `json:"accessToken"`
+	TokenType    string    `json:"tokenType,omitempty"`
+	RefreshToken string    `json:"refreshToken,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// Valid reports whether the token can be used without refreshing.
+func (t *Token) Valid() bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || time.Now().Add(expirySkew).Before(t.Expiry)
+}
+
+// Header returns the Authorization header value for the token.
+func (t *Token) Header() string {
+	typ := t.TokenType
+	if typ == "" || strings.EqualFold(typ, "bearer") {
+		typ = "Bearer"
+	}
+	return typ + " " + t.AccessToken
+}
+
+// OAuth2Source fetches, caches and refreshes OAuth2 access tokens.
+type OAuth2Source struct {
+	Config    profile.OAuth2
+	CacheFile string       // optional; written with 0600 permissions
+	Client    *http.Client // used for the token and device endpoints
+	Prompt    io.Writer    // device-code instructions are printed here
+
+	mu  sync.Mutex
+	tok *Token
+}
+
+// Token returns a valid access token, refreshing or re-authorizing as needed.
+func (s *OAuth2Source) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tok == nil {
+		s.tok = s.loadCache()
+	}
+	if s.tok.Valid() {
+		return s.tok, nil
+	}
+
+	var (
+		tok *Token
+		err error
+	)
+	refresh := s.Config.RefreshToken.Resolve()
+	if s.tok != nil && s.tok.RefreshToken != "" {
+		refresh = s.tok.RefreshToken
+	}
+	if refresh != "" {
+		tok, err = s.refresh(ctx, refresh)
+		if err != nil && s.Config.Grant == "refresh_token" {
+			return nil, err
+		}
+	}
+	if tok == nil {
+		switch s.Config.Grant {
+		case "", "client_credentials":
+			tok, err = s.clientCredentials(ctx)
+		case "device_code":
+			tok, err = s.deviceCode(ctx)
+		case "refresh_token":
+			err = errors.New("oauth2: refresh_token grant requires auth.refreshToken")
+		default:
+			err = fmt.Errorf("oauth2: unsupported grant %q", s.Config.Grant)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if tok.RefreshToken == "" {
+		tok.RefreshToken = refresh
+	}
+	s.tok = tok
+	s.saveCache(tok)
+	return tok, nil
+}
+
+// Invalidate drops the current access token so the next call fetches a new one.
+// The refresh token, if any, is kept.
+func (s *OAuth2Source) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tok != nil {
+		s.tok = &Token{RefreshToken: s.tok.RefreshToken}
+	}
+}
+
+func (s *OAuth2Source) clientCredentials(ctx context.Context) (*Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	return s.exchange(ctx, form)
+}
+
+func (s *OAuth2Source) refresh(ctx context.Context, refresh string) (*Token, error) {
+	form := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refresh}}
+	return s.exchange(ctx, form)
+}
+
+// deviceCode runs the RFC 8628 device authorization flow.
+func (s *OAuth2Source) deviceCode(ctx context.Context) (*Token, error) {
+	if s.Config.DeviceAuthURL == "" {
+		return nil, errors.New("oauth2: device_code grant requires auth.deviceAuthorizationUrl")
+	}
+	form := url.Values{"client_id": {s.Config.ClientID.Resolve()}}
+	s.addScope(form)
+	var dev struct {
+		DeviceCode              string `json:"device_code"`
+		UserCode                string `json:"user_code"`
+		VerificationURI         string `json:"verification_uri"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		ExpiresIn               int    `json:"expires_in"`
+		Interval                int    `json:"interval"`
+	}
+	if err := s.post(ctx, s.Config.DeviceAuthURL, form, false, &dev); err != nil {
+		return nil, err
+	}
+	if dev.DeviceCode == "" {
+		return nil, errors.New("oauth2: device authorization response has no device_code")
+	}
+	if s.Prompt != nil {
+		fmt.Fprintf(s.Prompt, "To authorize restless, open %s and enter code %s\n", dev.VerificationURI, dev.UserCode)
+		if dev.VerificationURIComplete != "" {
+			fmt.Fprintf(s.Prompt, "  or visit %s\n", dev.VerificationURIComplete)
+		}
+	}
+
+	interval := time.Duration(max(dev.Interval, 1)) * time.Second
+	deadline := time.Now().Add(time.Duration(max(dev.ExpiresIn, 60)) * time.Second)
+	poll := url.Values{"grant_type": {deviceCodeGrant}, "device_code": {dev.DeviceCode}}
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+		tok, err := s.exchange(ctx, poll)
+		var oe *Error
+		if errors.As(err, &oe) {
+			switch oe.Code {
+			case "authorization_pending":
+				continue
+			case "slow_down":
+				interval += 5 * time.Second
+				continue
+			}
+		}
+		return tok, err
+	}
+	return nil, errors.New("oauth2: device code expired before authorization completed")
+}
+
+func (s *OAuth2Source) exchange(ctx context.Context, form url.Values) (*Token, error) {
+	if s.Config.TokenURL == "" {
+		return nil, errors.New("oauth2: auth.tokenUrl is not set")
+	}
+	if form.Get("grant_type") == "client_credentials" {
+		s.addScope(form)
+	}
+	var raw struct {
+		AccessToken  string          `json:"access_token"`
+		TokenType    string          `json:"token_type"`
+		RefreshToken string          `json:"refresh_token"`
+		ExpiresIn    json.RawMessage `json:"expires_in"`
+	}
+	if err := s.post(ctx, s.Config.TokenURL, form, true, &raw); err != nil {
+		return nil, err
+	}
+	if raw.AccessToken == "" {
+		return nil, errors.New("oauth2: token response has no access_token")
+	}
+	tok := &Token{AccessToken: raw.AccessToken, TokenType: raw.TokenType, RefreshToken: raw.RefreshToken}
+	// Some servers send expires_in as a string.
+	var secs json.Number
+	if err := json.Unmarshal([]byte(strings.Trim(string(raw.ExpiresIn), `"`)), &secs); err == nil {
+		if n, err := secs.Int64(); err == nil && n > 0 {
+			tok.Expiry = time.Now().Add(time.Duration(n) * time.Second)
+		}
+	}
+	return tok, nil
+}
+
+func (s *OAuth2Source) addScope(form url.Values) {
+	if len(s.Config.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.Config.Scopes, " "))
+	}
+	if s.Config.Audience != "" {
+		form.Set("audience", s.Config.Audience)
+	}
+}
+
+// post sends a form to an OAuth2 endpoint and decodes the JSON reply into out.
+// Client credentials go in a Basic header when a secret is configured.
+func (s *OAuth2Source) post(ctx context.Context, endpoint string, form url.Values, clientAuth bool, out any) error {
+	id, secret := s.Config.ClientID.Resolve(), s.Config.ClientSecret.Resolve()
+	if clientAuth && secret == "" && id != "" {
+		form.Set("client_id", id)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if clientAuth && secret != "" {
+		req.SetBasicAuth(url.QueryEscape(id), url.QueryEscape(secret))
+	}
+	c := s.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("oauth2: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("oauth2: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		oe := &Error{Status: resp.StatusCode}
+		_ = json.Unmarshal(body, oe)
+		return oe
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("oauth2: decode %s: %w", endpoint, err)
+	}
+	return nil
+}
+
+// Error is an OAuth2 error response (RFC 6749 section 5.2).
+type Error struct {
+	Status      int    `json:"-"`
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("oauth2: token endpoint returned %d", e.Status)
+	if e.Code != "" {
+		msg += ": " + e.Code
+	}
+	if e.Description != "" {
+		msg += " (" + e.Description + ")"
+	}
+	return msg
+}
+
+func (s *OAuth2Source) loadCache() *Token {
+	if s.CacheFile == "" {
+		return nil
+	}
+	b, err := os.ReadFile(s.CacheFile)
+	if err != nil {
+		return nil
+	}
+	var t Token
+	if json.Unmarshal(b, &t) != nil {
+		return nil
+	}
+	return &t
+}
+
+// saveCache writes the token atomically; failures only cost a refetch later.
+func (s *OAuth2Source) saveCache(t *Token) {
+	if s.CacheFile == "" {
+		return
+	}
+	dir := filepath.Dir(s.CacheFile)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return
+	}
+	b, _ := json.MarshalIndent(t, "", "  ")
+	f, err := os.CreateTemp(dir, ".token-*")
+	if err != nil {
+		return
+	}
+	_ = f.Chmod(0o600)
+	_, werr := f.Write(b)
+	cerr := f.Close()
+	if werr != nil || cerr != nil {
+		_ = os.Remove(f.Name())
+		return
+	}
+	if err := os.Rename(f.Name(), s.CacheFile); err != nil {
+		_ = os.Remove(f.Name())
+	}
+}
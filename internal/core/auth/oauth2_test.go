@@ -0,0 +1,230 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/profile"
+)
+
+// tokenServer stands in for an authorization server. It issues numbered
+// access tokens and records the grants it was asked for.
+type tokenServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	grants []string
+	scopes []string
+	n      int
+}
+
+func newTokenServer(t *testing.T) *tokenServer {
+	ts := &tokenServer{}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "client" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"invalid_client"}`)
+			return
+		}
+		ts.mu.Lock()
+		ts.n++
+		grant := r.PostForm.Get("grant_type")
+		ts.grants = append(ts.grants, grant)
+		ts.scopes = append(ts.scopes, r.PostForm.Get("scope"))
+		tok := fmt.Sprintf("%s-%d", grant, ts.n)
+		ts.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  tok,
+			"token_type":    "bearer",
+			"refresh_token": "refresh-1",
+			"expires_in":    "3600", // some servers send a string
+		})
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func (ts *tokenServer) calls() []string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return append([]string(nil), ts.grants...)
+}
+
+func oauth2Config(tokenURL string) profile.Auth {
+	return profile.Auth{
+		Type: "oauth2",
+		OAuth2: profile.OAuth2{
+			Grant:        "client_credentials",
+			TokenURL:     tokenURL,
+			ClientID:     profile.Secret{Source: "value", Value: "client"},
+			ClientSecret: profile.Secret{Source: "value", Value: "s3cret"},
+			Scopes:       []string{"read", "write"},
+		},
+	}
+}
+
+// apiServer answers 200 unless the bearer token is in reject.
+func apiServer(t *testing.T, reject map[string]bool) (*httptest.Server, *[]string) {
+	var (
+		mu   sync.Mutex
+		seen []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := r.Header.Get("Authorization")
+		mu.Lock()
+		seen = append(seen, h)
+		mu.Unlock()
+		if reject[h] {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &seen
+}
+
+func get(t *testing.T, rt http.RoundTripper, u string) int {
+	t.Helper()
+	resp, err := (&http.Client{Transport: rt}).Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestClientCredentialsCached(t *testing.T) {
+	ts := newTokenServer(t)
+	api, seen := apiServer(t, nil)
+	dir := t.TempDir()
+	a := oauth2Config(ts.URL)
+
+	rt, err := Wrap(nil, a, Options{Profile: "shop", CacheDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if code := get(t, rt, api.URL); code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i, code)
+		}
+	}
+	if got := ts.calls(); len(got) != 1 || got[0] != "client_credentials" {
+		t.Fatalf("token requests = %v, want one client_credentials", got)
+	}
+	if ts.scopes[0] != "read write" {
+		t.Errorf("scope = %q, want %q", ts.scopes[0], "read write")
+	}
+	for _, h := range *seen {
+		if h != "Bearer client_credentials-1" {
+			t.Errorf("Authorization = %q", h)
+		}
+	}
+
+	// A new process with the same settings reads the cache.
+	files, _ := filepath.Glob(filepath.Join(dir, "shop-*.json"))
+	if len(files) != 1 {
+		t.Fatalf("cache files = %v, want one", files)
+	}
+	fi, err := os.Stat(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0o600 {
+		t.Errorf("cache file mode = %v, want 0600", fi.Mode().Perm())
+	}
+	rt, _ = Wrap(nil, a, Options{Profile: "shop", CacheDir: dir})
+	get(t, rt, api.URL)
+	if got := ts.calls(); len(got) != 1 {
+		t.Fatalf("token requests after reload = %v, want the cached token", got)
+	}
+
+	// Other scopes don't reuse it.
+	a.OAuth2.Scopes = []string{"admin"}
+	rt, _ = Wrap(nil, a, Options{Profile: "shop", CacheDir: dir})
+	get(t, rt, api.URL)
+	if got := ts.calls(); len(got) != 2 {
+		t.Fatalf("token requests after scope change = %v, want a new token", got)
+	}
+}
+
+func TestRefreshExpiredToken(t *testing.T) {
+	ts := newTokenServer(t)
+	api, seen := apiServer(t, nil)
+	src := &OAuth2Source{
+		Config:    oauth2Config(ts.URL).OAuth2,
+		CacheFile: filepath.Join(t.TempDir(), "tok.json"),
+	}
+	src.saveCache(&Token{AccessToken: "old", RefreshToken: "refresh-0", Expiry: time.Now().Add(-time.Minute)})
+
+	if code := get(t, &Transport{Source: src}, api.URL); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if got := ts.calls(); len(got) != 1 || got[0] != "refresh_token" {
+		t.Fatalf("token requests = %v, want one refresh_token", got)
+	}
+	if (*seen)[0] != "Bearer refresh_token-1" {
+		t.Errorf("Authorization = %q", (*seen)[0])
+	}
+	if c := src.loadCache(); c == nil || c.AccessToken != "refresh_token-1" || !c.Valid() {
+		t.Errorf("cache = %+v, want the refreshed token", c)
+	}
+}
+
+func TestRetryOnceOn401(t *testing.T) {
+	tests := []struct {
+		name      string
+		reject    map[string]bool
+		wantCode  int
+		wantCalls int
+	}{
+		{
+			name:      "revoked token is replaced",
+			reject:    map[string]bool{"Bearer client_credentials-1": true},
+			wantCode:  http.StatusOK,
+			wantCalls: 2,
+		},
+		{
+			name: "second 401 is returned",
+			reject: map[string]bool{
+				"Bearer client_credentials-1": true,
+				"Bearer refresh_token-2":      true,
+			},
+			wantCode:  http.StatusUnauthorized,
+			wantCalls: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTokenServer(t)
+			api, seen := apiServer(t, tt.reject)
+			rt, err := Wrap(nil, oauth2Config(ts.URL), Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code := get(t, rt, api.URL); code != tt.wantCode {
+				t.Errorf("status = %d, want %d", code, tt.wantCode)
+			}
+			if len(*seen) != tt.wantCalls {
+				t.Errorf("API requests = %d (%v), want %d", len(*seen), *seen, tt.wantCalls)
+			}
+			// The retry refreshes with the kept refresh token.
+			if got := ts.calls(); len(got) != 2 || got[1] != "refresh_token" {
+				t.Errorf("token requests = %v, want client_credentials then refresh_token", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/bspippi1337/restless/internal/core/profile"
+)
+
+// TokenSource supplies access tokens for Transport.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+	Invalidate()
+}
+
+// Transport sets the Authorization header and, on a 401, fetches a fresh
+// token and retries the request once. Redirects to another host go out
+// without the token.
+type Transport struct {
+	Base   http.RoundTripper
+	Source TokenSource
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if redirectedOffHost(req) {
+		return t.base().RoundTrip(req)
+	}
+	resp, err := t.send(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil // body can't be replayed
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	_ = resp.Body.Close()
+	t.Source.Invalidate()
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	return t.send(retry)
+}
+
+func (t *Transport) send(req *http.Request) (*http.Response, error) {
+	tok, err := t.Source.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", tok.Header())
+	return t.base().RoundTrip(r)
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+type Options struct {
+	Profile  string    // profile name, used for the token cache file
+	CacheDir string    // token cache directory; empty disables caching
+	Prompt   io.Writer // device-code instructions
+}
+
+// Wrap returns a RoundTripper that authenticates requests according to a.
+// Unknown or empty auth types leave requests untouched.
+func Wrap(base http.RoundTripper, a profile.Auth, opt Options) (http.RoundTripper, error) {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	switch a.Type {
+	case "", "none":
+		return base, nil
+	case "bearer":
+		tok := a.Token.Resolve()
+		if tok == "" {
+			return base, nil
+		}
+		return &headerTransport{base: base, value: "Bearer " + tok}, nil
+	case "oauth2":
+		src := &OAuth2Source{
+			Config: a.OAuth2,
+			Client: &http.Client{Transport: base},
+			Prompt: opt.Prompt,
+		}
+		if opt.CacheDir != "" && opt.Profile != "" {
+			src.CacheFile = filepath.Join(opt.CacheDir, cacheName(opt.Profile)+"-"+configKey(a.OAuth2)+".json")
+		}
+		return &Transport{Base: base, Source: src}, nil
+	default:
+		return nil, fmt.Errorf("unsupported auth type %q", a.Type)
+	}
+}
+
+type headerTransport struct {
+	base  http.RoundTripper
+	value string
+}
+
+func (h *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" || redirectedOffHost(req) {
+		return h.base.RoundTrip(req)
+	}
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", h.value)
+	return h.base.RoundTrip(r)
+}
+
+// redirectedOffHost reports whether req follows a redirect to a host other
+// than the one first asked for. http.Client drops the caller's own
+// Authorization header in that case; the token added here must stay behind
+// too.
+func redirectedOffHost(req *http.Request) bool {
+	first := req
+	for first.Response != nil && first.Response.Request != nil {
+		first = first.Response.Request
+	}
+	return !strings.EqualFold(first.URL.Host, req.URL.Host)
+}
+
+// configKey is a short hash of the settings a token is issued for, so
+// editing the token URL, client or scopes doesn't reuse an old token.
+func configKey(c profile.OAuth2) string {
+	h := sha256.New()
+	for _, s := range []string{c.TokenURL, c.ClientID.Resolve(), strings.Join(c.Scopes, " "), c.Audience} {
+		io.WriteString(h, s)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+func cacheName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' {
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bspippi1337/restless/internal/core/profile"
+)
+
+func bearerConfig(tok string) profile.Auth {
+	return profile.Auth{Type: "bearer", Token: profile.Secret{Source: "value", Value: tok}}
+}
+
+// recorder answers 200 and keeps the Authorization header of every request.
+func recorder(t *testing.T) (*httptest.Server, func() []string) {
+	var (
+		mu   sync.Mutex
+		seen []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Get("Authorization"))
+		mu.Unlock()
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), seen...)
+	}
+}
+
+func TestBearerHeader(t *testing.T) {
+	api, seen := recorder(t)
+	rt, err := Wrap(nil, bearerConfig("SECRET"), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	get(t, rt, api.URL)
+
+	// A header the caller set wins over the profile's token.
+	req, _ := http.NewRequest("GET", api.URL, nil)
+	req.Header.Set("Authorization", "Basic dTpw")
+	resp, err := (&http.Client{Transport: rt}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got, want := seen(), []string{"Bearer SECRET", "Basic dTpw"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+	if req.Header.Get("Authorization") != "Basic dTpw" {
+		t.Error("the caller's request was modified")
+	}
+}
+
+func TestRedirectKeepsCredentialsOnHost(t *testing.T) {
+	ts := newTokenServer(t)
+	tests := []struct {
+		name string
+		auth profile.Auth
+		want string
+	}{
+		{"bearer", bearerConfig("SECRET"), "Bearer SECRET"},
+		{"oauth2", oauth2Config(ts.URL), "Bearer client_credentials-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other, otherSeen := recorder(t)
+			var (
+				mu   sync.Mutex
+				seen []string
+			)
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				seen = append(seen, r.URL.Path+" "+r.Header.Get("Authorization"))
+				mu.Unlock()
+				switch r.URL.Path {
+				case "/away":
+					http.Redirect(w, r, other.URL+"/landing", http.StatusFound)
+				case "/here":
+					http.Redirect(w, r, "/final", http.StatusFound)
+				default:
+					io.WriteString(w, "ok")
+				}
+			}))
+			t.Cleanup(api.Close)
+
+			rt, err := Wrap(nil, tt.auth, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			get(t, rt, api.URL+"/away")
+			get(t, rt, api.URL+"/here")
+
+			if got := otherSeen(); len(got) != 1 || got[0] != "" {
+				t.Errorf("the other host saw Authorization %q, want none", got)
+			}
+			want := []string{"/away " + tt.want, "/here " + tt.want, "/final " + tt.want}
+			if strings.Join(seen, ",") != strings.Join(want, ",") {
+				t.Errorf("API saw %q, want %q", seen, want)
+			}
+		})
+	}
+}
+
+func TestRetryOn401ReplaysBody(t *testing.T) {
+	ts := newTokenServer(t)
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, r.Header.Get("Authorization")+" "+string(b))
+		mu.Unlock()
+		if r.Header.Get("Authorization") == "Bearer client_credentials-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(api.Close)
+
+	rt, err := Wrap(nil, oauth2Config(ts.URL), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: rt}).Post(api.URL, "application/json", strings.NewReader(`{"name":"pen"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("status = %d, want 201", resp.StatusCode)
+	}
+	want := []string{
+		`Bearer client_credentials-1 {"name":"pen"}`,
+		`Bearer refresh_token-2 {"name":"pen"}`,
+	}
+	if strings.Join(bodies, "\n") != strings.Join(want, "\n") {
+		t.Errorf("API saw\n%s\nwant\n%s", strings.Join(bodies, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRetryOn401NeedsReplayableBody(t *testing.T) {
+	ts := newTokenServer(t)
+	api, seen := apiServer(t, map[string]bool{"Bearer client_credentials-1": true})
+	rt, err := Wrap(nil, oauth2Config(ts.URL), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A plain io.Reader gives the request no GetBody, so it can't be sent again.
+	req, _ := http.NewRequest("POST", api.URL, io.MultiReader(strings.NewReader("once")))
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized || len(*seen) != 1 {
+		t.Errorf("status %d after %d requests, want the first 401", resp.StatusCode, len(*seen))
+	}
+}
@@ -0,0 +1,192 @@
+// Package capture is a recording HTTP proxy. In reverse mode every request
+// is sent to a fixed upstream, so a frontend can simply be pointed at the
+// proxy; in forward mode clients use it as their HTTP proxy. Each exchange
+// is handed to OnExchange as it completes.
+//
+// HTTPS through a forward proxy arrives as a CONNECT tunnel, which is
+// passed through untouched: its requests can't be seen without
+// intercepting TLS. Use reverse mode for HTTPS APIs.
+package capture
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/discovery"
+)
+
+// Proxy is an http.Handler that forwards and records traffic.
+type Proxy struct {
+	// Target is the upstream for reverse mode; nil runs a forward proxy.
+	Target *url.URL
+	// Transport sends upstream requests; nil means http.DefaultTransport.
+	Transport http.RoundTripper
+	// OnExchange is called once per completed request. Calls are serialized.
+	OnExchange func(discovery.Exchange)
+	// OnTunnel, if set, is told about each CONNECT tunnel (host:port).
+	OnTunnel func(host string)
+
+	once sync.Once
+	rp   *httputil.ReverseProxy
+	mu   sync.Mutex
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.once.Do(p.init)
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+	if p.Target == nil && !r.URL.IsAbs() {
+		http.Error(w, "restless capture: not a proxy request (set this address as the HTTP proxy, or run with --target)", http.StatusBadRequest)
+		return
+	}
+	// The request as the client sent it, before rewriting.
+	orig := discovery.Exchange{Method: r.Method, URL: p.upstreamURL(r), Header: r.Header.Clone(), When: time.Now()}
+	p.rp.ServeHTTP(&recorder{ResponseWriter: w, done: func(status int, ct string) {
+		orig.Status, orig.ContentType = status, ct
+		p.record(orig)
+	}}, r)
+}
+
+func (p *Proxy) init() {
+	p.rp = &httputil.ReverseProxy{
+		Transport: p.Transport,
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			if p.Target != nil {
+				pr.SetURL(p.Target)
+				pr.Out.Host = p.Target.Host
+			} else {
+				pr.Out.URL = pr.In.URL
+				pr.Out.Host = pr.In.URL.Host
+			}
+			pr.Out.Header.Del("Proxy-Connection")
+			pr.Out.Header.Del("Proxy-Authorization")
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			// The upstream never answered; record no status.
+			if rec, ok := w.(*recorder); ok {
+				rec.failed = true
+			}
+			http.Error(w, "restless capture: "+err.Error(), http.StatusBadGateway)
+		},
+		FlushInterval: -1,
+	}
+}
+
+// upstreamURL is where the request ends up, for templating and evidence.
+func (p *Proxy) upstreamURL(r *http.Request) *url.URL {
+	if p.Target == nil {
+		u := *r.URL
+		return &u
+	}
+	u := *p.Target
+	u.Path = singleJoin(p.Target.Path, r.URL.Path)
+	u.RawPath = ""
+	u.RawQuery = r.URL.RawQuery
+	return &u
+}
+
+func singleJoin(a, b string) string {
+	switch {
+	case a == "" || a == "/":
+		return b
+	case b == "" || b == "/":
+		return a
+	}
+	return strings.TrimSuffix(a, "/") + "/" + strings.TrimPrefix(b, "/")
+}
+
+func (p *Proxy) record(x discovery.Exchange) {
+	if p.OnExchange == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.OnExchange(x)
+}
+
+// tunnel relays a CONNECT stream without looking inside it.
+func (p *Proxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	if p.Target != nil {
+		http.Error(w, "restless capture: CONNECT is not supported in reverse mode", http.StatusMethodNotAllowed)
+		return
+	}
+	up, err := net.DialTimeout("tcp", r.Host, 15*time.Second)
+	if err != nil {
+		http.Error(w, "restless capture: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		up.Close()
+		http.Error(w, "restless capture: tunnelling unsupported", http.StatusInternalServerError)
+		return
+	}
+	conn, buf, err := hj.Hijack()
+	if err != nil {
+		up.Close()
+		return
+	}
+	if p.OnTunnel != nil {
+		p.mu.Lock()
+		p.OnTunnel(r.Host)
+		p.mu.Unlock()
+	}
+	_, _ = conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
+	go func() {
+		_, _ = io.Copy(up, buf)
+		up.Close()
+	}()
+	_, _ = io.Copy(conn, up)
+	conn.Close()
+}
+
+// recorder reports the exchange when the response headers go out, so
+// streaming responses are recorded without waiting for the body.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	done   func(status int, contentType string)
+	called bool
+	failed bool
+}
+
+func (r *recorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+		r.report()
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *recorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *recorder) report() {
+	if r.called {
+		return
+	}
+	r.called = true
+	if r.failed {
+		r.done(0, "")
+		return
+	}
+	r.done(r.status, r.Header().Get("Content-Type"))
+}
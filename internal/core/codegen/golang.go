@@ -0,0 +1,867 @@
+// Package codegen writes API clients from a profile. Go emits a small,
+// dependency-free package: a Client carrying the profile's base URL and
+// credentials, one method per endpoint, and structs for the request and
+// response schemas the spec documents or restless inferred from responses. Output is deterministic, so
+// regenerating over an unchanged profile changes nothing.
+package codegen
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bspippi1337/restless/internal/core/openapi"
+	"github.com/bspippi1337/restless/internal/core/profile"
+	"github.com/bspippi1337/restless/internal/jsonschema"
+)
+
+// Header marks generated files; callers only overwrite files that have it.
+const Header = "// Code generated by restless codegen. DO NOT EDIT."
+
+// GoOptions configures Go.
+type GoOptions struct {
+	Package string
+	Profile *profile.Profile
+	// Spec, when set, supplies operation names, parameter types and schemas.
+	Spec *openapi.Spec
+}
+
+// Go returns the generated files by name, gofmt'ed.
+func Go(opt GoOptions) (map[string][]byte, error) {
+	p := opt.Profile
+	if !token.IsIdentifier(opt.Package) || token.IsKeyword(opt.Package) {
+		return nil, fmt.Errorf("bad package name %q", opt.Package)
+	}
+	// Spec operations discovery never saw still get a method.
+	if opt.Spec != nil {
+		cp := *p
+		cp.Endpoints = append([]profile.Endpoint(nil), p.Endpoints...)
+		for _, op := range opt.Spec.Operations {
+			if p.Match(op.Method, op.Path) == nil {
+				cp.Endpoints = append(cp.Endpoints, profile.Endpoint{Method: op.Method, Path: op.Path})
+			}
+		}
+		p, opt.Profile = &cp, &cp
+	}
+	if len(p.Endpoints) == 0 {
+		return nil, fmt.Errorf("profile %q has no endpoints", p.Name)
+	}
+	g := &goGen{types: map[string]string{}, shapes: map[string]string{}}
+	client := g.client(opt)
+	api := g.api(opt)
+	out := map[string][]byte{}
+	for name, src := range map[string]string{"client.go": client, "api.go": api} {
+		b, err := format.Source([]byte(src))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		out[name] = b
+	}
+	return out, nil
+}
+
+type goGen struct {
+	// types holds generated type declarations by name; shapes maps a
+	// schema's canonical form to the type already generated for it.
+	types  map[string]string
+	shapes map[string]string
+}
+
+// credential is a default header read from the environment; it becomes a
+// Client field filled from the same variable.
+type credential struct {
+	Field, Env, Header, Prefix, Suffix string
+}
+
+var envRef = regexp.MustCompile(`\$\{ENV:([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+func credentials(p *profile.Profile) (creds []credential, fixed [][2]string) {
+	keys := make([]string, 0, len(p.Defaults.Headers))
+	for k := range p.Defaults.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := p.Defaults.Headers[k]
+		if strings.EqualFold(k, "User-Agent") {
+			continue
+		}
+		m := envRef.FindStringSubmatchIndex(v)
+		if m == nil {
+			fixed = append(fixed, [2]string{k, v})
+			continue
+		}
+		env := v[m[2]:m[3]]
+		creds = append(creds, credential{
+			Field:  goName(strings.TrimPrefix(env, "RESTLESS_"), true),
+			Env:    env,
+			Header: k,
+			Prefix: v[:m[0]],
+			Suffix: v[m[1]:],
+		})
+	}
+	return creds, fixed
+}
+
+func (g *goGen) client(opt GoOptions) string {
+	p := opt.Profile
+	creds, fixed := credentials(p)
+	var b strings.Builder
+	w := func(format string, args ...any) { fmt.Fprintf(&b, format, args...) }
+
+	w("%s\n\n", Header)
+	w("// Package %s is a client for the %s API, generated from its restless profile.\n", opt.Package, p.Name)
+	w("package %s\n\n", opt.Package)
+	imports := []string{"bytes", "context", "encoding/json", "fmt", "io", "net/http", "net/url", "strings"}
+	if len(creds) > 0 || p.Auth.Type == "bearer" {
+		imports = append(imports, "os")
+	}
+	sort.Strings(imports)
+	w("import (\n")
+	for _, im := range imports {
+		w("\t%q\n", im)
+	}
+	w(")\n\n")
+
+	w("// DefaultBaseURL is the profile's first base URL.\n")
+	w("const DefaultBaseURL = %q\n\n", firstOr(p.BaseURLs, "http://localhost"))
+
+	w("// Client calls the API. The zero value is not usable; start from NewClient.\n")
+	w("type Client struct {\n")
+	w("\tBaseURL    string\n")
+	w("\tHTTPClient *http.Client\n")
+	switch p.Auth.Type {
+	case "bearer", "oauth2":
+		w("\t// Token is sent as \"Authorization: Bearer <Token>\".\n")
+		w("\tToken string\n")
+	}
+	for _, c := range creds {
+		w("\t// %s is sent in the %s header.\n", c.Field, c.Header)
+		w("\t%s string\n", c.Field)
+	}
+	w("\t// Header is added to every request.\n")
+	w("\tHeader http.Header\n")
+	w("}\n\n")
+
+	w("// NewClient returns a client for DefaultBaseURL")
+	var envs []string
+	if p.Auth.Type == "bearer" {
+		envs = append(envs, "$"+firstNonEmpty(p.Auth.Token.EnvVar, "RESTLESS_TOKEN"))
+	}
+	for _, c := range creds {
+		envs = append(envs, "$"+c.Env)
+	}
+	if len(envs) > 0 {
+		w(" with credentials\n// read from %s", strings.Join(envs, ", "))
+	}
+	w(".\n")
+	w("func NewClient() *Client {\n")
+	w("\treturn &Client{\n")
+	w("\t\tBaseURL:    DefaultBaseURL,\n")
+	w("\t\tHTTPClient: http.DefaultClient,\n")
+	if p.Auth.Type == "bearer" {
+		w("\t\tToken:      os.Getenv(%q),\n", firstNonEmpty(p.Auth.Token.EnvVar, "RESTLESS_TOKEN"))
+	}
+	for _, c := range creds {
+		w("\t\t%s: os.Getenv(%q),\n", c.Field, c.Env)
+	}
+	w("\t\tHeader:     http.Header{},\n")
+	w("\t}\n}\n\n")
+
+	if p.Auth.Type == "oauth2" {
+		g.oauth2(&b, p.Auth.OAuth2)
+	}
+
+	w("// Error is returned for responses outside 2xx.\n")
+	w("type Error struct {\n\tStatusCode int\n\tStatus     string\n\tBody       []byte\n}\n\n")
+	w("func (e *Error) Error() string {\n")
+	w("\tif len(e.Body) > 0 {\n\t\treturn fmt.Sprintf(\"%%s: %%s\", e.Status, bytes.TrimSpace(e.Body))\n\t}\n")
+	w("\treturn e.Status\n}\n\n")
+
+	w("// do sends a request and decodes a JSON response into out unless out is nil.\n")
+	w("// A []byte body is sent as is with contentType; anything else as JSON.\n")
+	w("func (c *Client) do(ctx context.Context, method, path string, query url.Values, body any, contentType string, out any) error {\n")
+	w("\tu := strings.TrimRight(c.BaseURL, \"/\") + path\n")
+	w("\tif len(query) > 0 {\n\t\tu += \"?\" + query.Encode()\n\t}\n")
+	w("\tvar rd io.Reader\n")
+	w("\tswitch v := body.(type) {\n")
+	w("\tcase nil:\n")
+	w("\tcase []byte:\n\t\trd = bytes.NewReader(v)\n")
+	w("\tdefault:\n")
+	w("\t\tdata, err := json.Marshal(v)\n\t\tif err != nil {\n\t\t\treturn err\n\t\t}\n")
+	w("\t\trd, contentType = bytes.NewReader(data), \"application/json\"\n")
+	w("\t}\n")
+	w("\treq, err := http.NewRequestWithContext(ctx, method, u, rd)\n")
+	w("\tif err != nil {\n\t\treturn err\n\t}\n")
+	for _, h := range fixed {
+		w("\treq.Header.Set(%q, %q)\n", h[0], h[1])
+	}
+	w("\tif rd != nil {\n\t\treq.Header.Set(\"Content-Type\", contentType)\n\t}\n")
+	if p.Auth.Type == "bearer" || p.Auth.Type == "oauth2" {
+		w("\tif c.Token != \"\" {\n\t\treq.Header.Set(\"Authorization\", \"Bearer \"+c.Token)\n\t}\n")
+	}
+	for _, c := range creds {
+		val := "c." + c.Field
+		if c.Prefix != "" {
+			val = strconv.Quote(c.Prefix) + " + " + val
+		}
+		if c.Suffix != "" {
+			val += " + " + strconv.Quote(c.Suffix)
+		}
+		w("\tif c.%s != \"\" {\n\t\treq.Header.Set(%q, %s)\n\t}\n", c.Field, c.Header, val)
+	}
+	w("\tfor k, vs := range c.Header {\n\t\tfor _, v := range vs {\n\t\t\treq.Header.Add(k, v)\n\t\t}\n\t}\n")
+	w("\thc := c.HTTPClient\n\tif hc == nil {\n\t\thc = http.DefaultClient\n\t}\n")
+	w("\tresp, err := hc.Do(req)\n\tif err != nil {\n\t\treturn err\n\t}\n")
+	w("\tdefer resp.Body.Close()\n")
+	w("\tdata, err := io.ReadAll(resp.Body)\n\tif err != nil {\n\t\treturn err\n\t}\n")
+	w("\tif resp.StatusCode < 200 || resp.StatusCode > 299 {\n")
+	w("\t\treturn &Error{StatusCode: resp.StatusCode, Status: resp.Status, Body: data}\n\t}\n")
+	w("\tif out == nil || len(bytes.TrimSpace(data)) == 0 {\n\t\treturn nil\n\t}\n")
+	w("\treturn json.Unmarshal(data, out)\n")
+	w("}\n")
+	return b.String()
+}
+
+// oauth2 writes FetchToken for the client credentials grant; other grants
+// need a browser or device and are left to the caller.
+func (g *goGen) oauth2(b *strings.Builder, o profile.OAuth2) {
+	w := func(format string, args ...any) { fmt.Fprintf(b, format, args...) }
+	w("// TokenURL is the OAuth2 token endpoint from the profile.\n")
+	w("const TokenURL = %q\n\n", o.TokenURL)
+	if o.Grant != "" && o.Grant != "client_credentials" {
+		w("// The profile uses the %s grant; obtain an access token for Client.Token.\n\n", o.Grant)
+		return
+	}
+	w("// FetchToken runs the OAuth2 client credentials grant and sets c.Token.\n")
+	w("func (c *Client) FetchToken(ctx context.Context, clientID, clientSecret string, scopes ...string) error {\n")
+	w("\tform := url.Values{\"grant_type\": {\"client_credentials\"}}\n")
+	w("\tif len(scopes) > 0 {\n\t\tform.Set(\"scope\", strings.Join(scopes, \" \"))\n\t}\n")
+	if o.Audience != "" {
+		w("\tform.Set(\"audience\", %q)\n", o.Audience)
+	}
+	w("\treq, err := http.NewRequestWithContext(ctx, http.MethodPost, TokenURL, strings.NewReader(form.Encode()))\n")
+	w("\tif err != nil {\n\t\treturn err\n\t}\n")
+	w("\treq.Header.Set(\"Content-Type\", \"application/x-www-form-urlencoded\")\n")
+	w("\treq.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))\n")
+	w("\thc := c.HTTPClient\n\tif hc == nil {\n\t\thc = http.DefaultClient\n\t}\n")
+	w("\tresp, err := hc.Do(req)\n\tif err != nil {\n\t\treturn err\n\t}\n")
+	w("\tdefer resp.Body.Close()\n")
+	w("\tdata, err := io.ReadAll(resp.Body)\n\tif err != nil {\n\t\treturn err\n\t}\n")
+	w("\tif resp.StatusCode != http.StatusOK {\n")
+	w("\t\treturn &Error{StatusCode: resp.StatusCode, Status: resp.Status, Body: data}\n\t}\n")
+	w("\tvar tok struct {\n\t\tAccessToken string `json:\"access_token\"`\n\t}\n")
+	w("\tif err := json.Unmarshal(data, &tok); err != nil {\n\t\treturn err\n\t}\n")
+	w("\tif tok.AccessToken == \"\" {\n\t\treturn fmt.Errorf(\"token response has no access_token\")\n\t}\n")
+	w("\tc.Token = tok.AccessToken\n\treturn nil\n}\n\n")
+}
+
+var pathParam = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// arg is a Go function parameter for an API parameter.
+type arg struct {
+	Name, Wire, Type string
+}
+
+func (g *goGen) api(opt GoOptions) string {
+	p := opt.Profile
+	var methods strings.Builder
+	imports := map[string]bool{"context": true}
+	names := map[string]bool{"NewClient": true, "FetchToken": true, "Error": true}
+
+	for i := range p.Endpoints {
+		ep := &p.Endpoints[i]
+		var op *openapi.Operation
+		if opt.Spec != nil {
+			op = opt.Spec.Find(ep.Method, ep.Path)
+		}
+		name := g.methodName(p, ep, op, names)
+		names[name] = true
+		g.method(&methods, p, ep, op, name, names, imports)
+	}
+
+	var b strings.Builder
+	b.WriteString(Header + "\n\n")
+	b.WriteString("package " + opt.Package + "\n\n")
+	list := make([]string, 0, len(imports))
+	for im := range imports {
+		list = append(list, im)
+	}
+	sort.Strings(list)
+	b.WriteString("import (\n")
+	for _, im := range list {
+		b.WriteString("\t" + strconv.Quote(im) + "\n")
+	}
+	b.WriteString(")\n\n")
+	b.WriteString(methods.String())
+
+	typeNames := make([]string, 0, len(g.types))
+	for n := range g.types {
+		typeNames = append(typeNames, n)
+	}
+	sort.Strings(typeNames)
+	for _, n := range typeNames {
+		b.WriteString(g.types[n])
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func (g *goGen) methodName(p *profile.Profile, ep *profile.Endpoint, op *openapi.Operation, taken map[string]bool) string {
+	name := ""
+	if op != nil && op.ID != "" {
+		name = goName(op.ID, true)
+	}
+	if name == "" {
+		for _, r := range p.Collection {
+			if m := p.Match(r.Method, r.Path); m == ep && r.Name != "" {
+				name = goName(r.Name, true)
+				break
+			}
+		}
+	}
+	if name == "" {
+		var parts []string
+		parts = append(parts, strings.ToLower(ep.Method))
+		for _, seg := range strings.Split(strings.Trim(ep.Path, "/"), "/") {
+			if m := pathParam.FindStringSubmatch(seg); m != nil && m[0] == seg {
+				parts = append(parts, "by", m[1])
+				continue
+			}
+			parts = append(parts, seg)
+		}
+		name = goName(strings.Join(parts, " "), true)
+	}
+	for base, n := name, 2; taken[name]; n++ {
+		name = base + strconv.Itoa(n)
+	}
+	return name
+}
+
+func (g *goGen) method(b *strings.Builder, p *profile.Profile, ep *profile.Endpoint, op *openapi.Operation, name string, names, imports map[string]bool) {
+	w := func(format string, args ...any) { fmt.Fprintf(b, format, args...) }
+	used := map[string]bool{"ctx": true, "c": true, "q": true, "path": true, "out": true, "err": true}
+	local := func(s string) string {
+		n := goName(s, false)
+		if n == "" || token.IsKeyword(n) || used[n] || isPredeclared(n) {
+			n += "Param"
+		}
+		for base, i := n, 2; used[n]; i++ {
+			n = base + strconv.Itoa(i)
+		}
+		used[n] = true
+		return n
+	}
+
+	// Path parameters are positional arguments.
+	var pathArgs []arg
+	for _, m := range pathParam.FindAllStringSubmatch(ep.Path, -1) {
+		typ := "string"
+		if op != nil {
+			for _, pr := range op.Params {
+				if pr.In == "path" && pr.Name == m[1] {
+					typ = scalarType(pr.Schema)
+				}
+			}
+		}
+		pathArgs = append(pathArgs, arg{Name: local(m[1]), Wire: m[1], Type: typ})
+	}
+
+	// Query parameters go in a <Method>Params struct.
+	var query []arg
+	addQuery := func(wire, typ string) {
+		for _, q := range query {
+			if q.Wire == wire {
+				return
+			}
+		}
+		query = append(query, arg{Wire: wire, Type: typ})
+	}
+	if op != nil {
+		for _, pr := range op.Params {
+			if pr.In == "query" {
+				typ := scalarType(pr.Schema)
+				if openapi.Type(pr.Schema) == "array" {
+					typ = "[]string"
+				}
+				addQuery(pr.Name, typ)
+			}
+		}
+	} else {
+		for _, r := range p.Collection {
+			if p.Match(r.Method, r.Path) == ep {
+				keys := make([]string, 0, len(r.Query))
+				for k := range r.Query {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+				for _, k := range keys {
+					addQuery(k, "string")
+				}
+			}
+		}
+	}
+	if pg := ep.Pagination; pg != nil && pg.Param != "" {
+		addQuery(pg.Param, "string")
+	}
+	paramsType := ""
+	if len(query) > 0 {
+		paramsType = g.uniqueType(name+"Params", names)
+		var t strings.Builder
+		fmt.Fprintf(&t, "// %s holds the query parameters of %s; zero values are left out.\n", paramsType, name)
+		fmt.Fprintf(&t, "type %s struct {\n", paramsType)
+		fields := map[string]bool{}
+		for i := range query {
+			f := goName(query[i].Wire, true)
+			if f == "" || !unicode.IsLetter(rune(f[0])) {
+				f = "P" + f
+			}
+			for base, n := f, 2; fields[f]; n++ {
+				f = base + strconv.Itoa(n)
+			}
+			fields[f] = true
+			query[i].Name = f
+			fmt.Fprintf(&t, "\t%s %s\n", f, query[i].Type)
+		}
+		t.WriteString("}\n")
+		g.types[paramsType] = t.String()
+	}
+
+	// Body.
+	bodyType, contentType := "", ""
+	switch {
+	case op != nil && op.Body != nil:
+		contentType = op.Body.ContentType
+		if isJSON(contentType) {
+			bodyType = g.typeOf(op.Body.Schema, name+"Request", names)
+		} else {
+			bodyType = "[]byte"
+		}
+	case op == nil && (ep.Method == "POST" || ep.Method == "PUT" || ep.Method == "PATCH"):
+		bodyType, contentType = "any", "application/json"
+	}
+
+	// Result.
+	result := ""
+	if op != nil {
+		for _, r := range op.Responses {
+			if strings.HasPrefix(r.Status, "2") && r.Schema != nil && isJSON(r.ContentType) {
+				result = g.typeOf(r.Schema, name+"Response", names)
+				break
+			}
+		}
+	}
+	if result == "" && ep.Schema != nil {
+		result = g.typeOf(jsonschema.Public(ep.Schema), name+"Response", names)
+	}
+	if result == "" && ep.Method != "HEAD" && ep.Method != "DELETE" {
+		result = "json.RawMessage"
+		imports["encoding/json"] = true
+	}
+
+	// Signature.
+	w("// %s calls %s %s.", name, ep.Method, ep.Path)
+	if op != nil && op.Summary != "" {
+		w("\n//\n// %s", strings.ReplaceAll(strings.TrimSpace(op.Summary), "\n", "\n// "))
+	}
+	w("\n")
+	params := []string{"ctx context.Context"}
+	for _, a := range pathArgs {
+		params = append(params, a.Name+" "+a.Type)
+	}
+	bodyArg := ""
+	if bodyType != "" {
+		bodyArg = local("body")
+		params = append(params, bodyArg+" "+bodyType)
+	}
+	if paramsType != "" {
+		params = append(params, "params *"+paramsType)
+	}
+	returns := "error"
+	if result != "" {
+		returns = "(" + resultType(result) + ", error)"
+	}
+	w("func (c *Client) %s(%s) %s {\n", name, strings.Join(params, ", "), returns)
+
+	// Path.
+	var parts []string
+	idx := 0
+	for i, m := range pathParam.FindAllStringSubmatchIndex(ep.Path, -1) {
+		if m[0] > idx {
+			parts = append(parts, strconv.Quote(ep.Path[idx:m[0]]))
+		}
+		a := pathArgs[i]
+		parts = append(parts, "url.PathEscape("+formatValue(a.Name, a.Type, imports)+")")
+		idx = m[1]
+	}
+	if idx < len(ep.Path) {
+		parts = append(parts, strconv.Quote(ep.Path[idx:]))
+	}
+	if len(parts) == 0 {
+		parts = []string{`"/"`}
+	}
+	if len(pathArgs) > 0 {
+		imports["net/url"] = true
+	}
+	w("\tpath := %s\n", strings.Join(parts, " + "))
+
+	queryExpr := "nil"
+	if paramsType != "" {
+		imports["net/url"] = true
+		queryExpr = "q"
+		w("\tq := url.Values{}\n")
+		w("\tif params != nil {\n")
+		for _, a := range query {
+			field := "params." + a.Name
+			switch a.Type {
+			case "[]string":
+				w("\t\tfor _, v := range %s {\n\t\t\tq.Add(%q, v)\n\t\t}\n", field, a.Wire)
+			case "bool":
+				w("\t\tif %s {\n\t\t\tq.Set(%q, \"true\")\n\t\t}\n", field, a.Wire)
+			case "string":
+				w("\t\tif %s != \"\" {\n\t\t\tq.Set(%q, %s)\n\t\t}\n", field, a.Wire, field)
+			default:
+				w("\t\tif %s != 0 {\n\t\t\tq.Set(%q, %s)\n\t\t}\n", field, a.Wire, formatValue(field, a.Type, imports))
+			}
+		}
+		w("\t}\n")
+	}
+	bodyExpr := "nil"
+	if bodyArg != "" {
+		bodyExpr = bodyArg
+	}
+	if result == "" {
+		w("\treturn c.do(ctx, %q, path, %s, %s, %q, nil)\n}\n\n", ep.Method, queryExpr, bodyExpr, contentType)
+		return
+	}
+	w("\tvar out %s\n", strings.TrimPrefix(result, "*"))
+	w("\tif err := c.do(ctx, %q, path, %s, %s, %q, &out); err != nil {\n", ep.Method, queryExpr, bodyExpr, contentType)
+	if strings.HasPrefix(resultType(result), "*") {
+		w("\t\treturn nil, err\n\t}\n\treturn &out, nil\n}\n\n")
+	} else {
+		w("\t\treturn nil, err\n\t}\n\treturn out, nil\n}\n\n")
+	}
+}
+
+// resultType returns structs by pointer and everything else by value.
+func resultType(t string) string {
+	if t == "json.RawMessage" || strings.HasPrefix(t, "[]") || strings.HasPrefix(t, "map[") || t == "any" || isScalar(t) {
+		return t
+	}
+	return "*" + t
+}
+
+// formatValue converts a Go value of a scalar type to a string expression.
+func formatValue(v, typ string, imports map[string]bool) string {
+	switch typ {
+	case "string":
+		return v
+	case "int64":
+		imports["strconv"] = true
+		return "strconv.FormatInt(" + v + ", 10)"
+	case "float64":
+		imports["strconv"] = true
+		return "strconv.FormatFloat(" + v + ", 'f', -1, 64)"
+	case "bool":
+		imports["strconv"] = true
+		return "strconv.FormatBool(" + v + ")"
+	}
+	imports["fmt"] = true
+	return "fmt.Sprint(" + v + ")"
+}
+
+func scalarType(s openapi.Schema) string {
+	switch openapi.Type(s) {
+	case "integer":
+		return "int64"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	}
+	return "string"
+}
+
+func isScalar(t string) bool {
+	return t == "string" || t == "int64" || t == "float64" || t == "bool"
+}
+
+func isJSON(ct string) bool {
+	ct = strings.ToLower(ct)
+	return ct == "" || strings.Contains(ct, "json")
+}
+
+func (g *goGen) uniqueType(name string, taken map[string]bool) string {
+	for base, n := name, 2; taken[name] || g.types[name] != ""; n++ {
+		name = base + strconv.Itoa(n)
+	}
+	taken[name] = true
+	return name
+}
+
+// typeOf returns the Go type for a schema, declaring structs as needed.
+// hint names an anonymous object; a schema title (the component name for
+// $refs) wins over it.
+func (g *goGen) typeOf(s map[string]any, hint string, taken map[string]bool) string {
+	if len(s) == 0 {
+		return "any"
+	}
+	if _, ok := s["$ref"]; ok {
+		return "json.RawMessage" // a cycle the reader left unresolved
+	}
+	for _, k := range []string{"oneOf", "anyOf"} {
+		if _, ok := s[k]; ok {
+			return "json.RawMessage"
+		}
+	}
+	if all, ok := s["allOf"].([]any); ok {
+		s = mergeAllOf(s, all)
+	}
+	switch openapi.Type(s) {
+	case "integer":
+		return "int64"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "string":
+		return "string"
+	case "array":
+		items, _ := s["items"].(map[string]any)
+		return "[]" + g.typeOf(items, singular(hint), taken)
+	case "object", "":
+		props, _ := s["properties"].(map[string]any)
+		if len(props) == 0 {
+			if ap, ok := s["additionalProperties"].(map[string]any); ok && len(ap) > 0 {
+				return "map[string]" + g.typeOf(ap, hint+"Value", taken)
+			}
+			if openapi.Type(s) == "" {
+				return "any"
+			}
+			return "map[string]any"
+		}
+		return g.structOf(s, props, hint, taken)
+	}
+	return "any"
+}
+
+func (g *goGen) structOf(s, props map[string]any, hint string, taken map[string]bool) string {
+	name := hint
+	if t, ok := s["title"].(string); ok && goName(t, true) != "" {
+		name = goName(t, true)
+	}
+	shape := canonical(s)
+	if t, ok := g.shapes[shape]; ok {
+		return t
+	}
+	name = g.uniqueType(name, taken)
+	g.shapes[shape] = name
+	g.types[name] = "" // reserve the name while fields are generated
+
+	required := map[string]bool{}
+	for _, r := range asList(s["required"]) {
+		if rs, ok := r.(string); ok {
+			required[rs] = true
+		}
+	}
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var t strings.Builder
+	if d, ok := s["description"].(string); ok && d != "" {
+		fmt.Fprintf(&t, "// %s %s\n", name, firstSentence(d))
+	} else {
+		fmt.Fprintf(&t, "// %s is generated from the API schema.\n", name)
+	}
+	fmt.Fprintf(&t, "type %s struct {\n", name)
+	fields := map[string]bool{}
+	for _, k := range keys {
+		ps, _ := props[k].(map[string]any)
+		f := goName(k, true)
+		if f == "" || !unicode.IsLetter(rune(f[0])) {
+			f = "F" + f
+		}
+		for base, n := f, 2; fields[f]; n++ {
+			f = base + strconv.Itoa(n)
+		}
+		fields[f] = true
+		typ := g.typeOf(ps, name+goName(k, true), taken)
+		tag := k
+		if !required[k] {
+			tag += ",omitempty"
+			if _, isStruct := g.types[typ]; isStruct {
+				typ = "*" + typ
+			}
+		}
+		fmt.Fprintf(&t, "\t%s %s `json:%q`\n", f, typ, tag)
+	}
+	t.WriteString("}\n")
+	g.types[name] = t.String()
+	return name
+}
+
+func mergeAllOf(s map[string]any, all []any) map[string]any {
+	out := map[string]any{"type": "object"}
+	props := map[string]any{}
+	var req []any
+	for _, part := range append([]any{s}, all...) {
+		m, ok := part.(map[string]any)
+		if !ok {
+			continue
+		}
+		if inner, ok := m["allOf"].([]any); ok && part != any(s) {
+			m = mergeAllOf(m, inner)
+		}
+		if pm, ok := m["properties"].(map[string]any); ok {
+			for k, v := range pm {
+				props[k] = v
+			}
+		}
+		req = append(req, asList(m["required"])...)
+		if t, ok := m["title"]; ok && out["title"] == nil {
+			out["title"] = t
+		}
+	}
+	out["properties"] = props
+	out["required"] = req
+	return out
+}
+
+func asList(v any) []any {
+	l, _ := v.([]any)
+	return l
+}
+
+// canonical is a stable string form of a schema, used to reuse types.
+func canonical(v any) string {
+	switch t := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			if k != "description" && k != "example" && k != "examples" {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		var b strings.Builder
+		b.WriteByte('{')
+		for _, k := range keys {
+			b.WriteString(strconv.Quote(k) + ":" + canonical(t[k]) + ",")
+		}
+		b.WriteByte('}')
+		return b.String()
+	case []any:
+		var b strings.Builder
+		b.WriteByte('[')
+		for _, x := range t {
+			b.WriteString(canonical(x) + ",")
+		}
+		b.WriteByte(']')
+		return b.String()
+	}
+	return fmt.Sprintf("%#v", v)
+}
+
+func singular(s string) string {
+	switch {
+	case strings.HasSuffix(s, "Response"), strings.HasSuffix(s, "Request"):
+		return s + "Item"
+	case strings.HasSuffix(s, "ies"):
+		return strings.TrimSuffix(s, "ies") + "y"
+	case strings.HasSuffix(s, "s") && !strings.HasSuffix(s, "ss"):
+		return strings.TrimSuffix(s, "s")
+	}
+	return s + "Item"
+}
+
+func firstSentence(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if i := strings.Index(s, ". "); i > 0 {
+		s = s[:i+1]
+	}
+	if len(s) > 0 {
+		s = strings.ToLower(s[:1]) + s[1:]
+	}
+	if !strings.HasPrefix(s, "is ") && !strings.HasPrefix(s, "are ") {
+		s = "is " + s
+	}
+	return s
+}
+
+// initialisms are written in capitals, as Go style asks.
+var initialisms = map[string]bool{
+	"API": true, "DNS": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+	"JSON": true, "OK": true, "SQL": true, "SSH": true, "TLS": true, "TTL": true, "UI": true, "UID": true,
+	"URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// goName joins the words of s (split on punctuation and case changes) into
+// an exported or unexported Go identifier: "user_id" -> UserID / userID.
+func goName(s string, exported bool) string {
+	var words []string
+	var cur []rune
+	flush := func() {
+		if len(cur) > 0 {
+			words = append(words, string(cur))
+			cur = nil
+		}
+	}
+	rs := []rune(s)
+	for i, r := range rs {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && len(cur) > 0 && (unicode.IsLower(cur[len(cur)-1]) || unicode.IsDigit(cur[len(cur)-1]) ||
+			(i+1 < len(rs) && unicode.IsLower(rs[i+1]))):
+			flush()
+			cur = append(cur, r)
+		default:
+			cur = append(cur, r)
+		}
+	}
+	flush()
+	var b strings.Builder
+	for i, word := range words {
+		up := strings.ToUpper(word)
+		switch {
+		case i == 0 && !exported:
+			b.WriteString(strings.ToLower(word))
+		case initialisms[up]:
+			b.WriteString(up)
+		default:
+			b.WriteString(strings.ToUpper(word[:1]) + strings.ToLower(word[1:]))
+		}
+	}
+	return b.String()
+}
+
+func isPredeclared(s string) bool {
+	switch s {
+	case "any", "bool", "byte", "error", "string", "int", "int64", "float64", "len", "cap", "new", "make", "url", "json", "fmt", "strconv", "http":
+		return true
+	}
+	return false
+}
+
+func firstOr(ss []string, def string) string {
+	if len(ss) > 0 && ss[0] != "" {
+		return ss[0]
+	}
+	return def
+}
+
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,207 @@
+// Package contract checks a live API against its OpenAPI description: each
+// safe operation is called with parameters taken from the spec, and the
+// status code, content type and body are compared with what the spec
+// documents for that response.
+package contract
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/openapi"
+	"github.com/bspippi1337/restless/internal/core/transport"
+	"github.com/bspippi1337/restless/internal/filter"
+	"github.com/bspippi1337/restless/internal/jsonschema"
+)
+
+// Call is a planned request for one operation.
+type Call struct {
+	Method  string
+	Path    string // concrete path, parameters filled in
+	Query   map[string]string
+	Headers map[string]string
+}
+
+// Result is the outcome for one operation. Problems lists the drift from
+// the spec; Skipped explains why the operation wasn't called; Error is a
+// transport error.
+type Result struct {
+	Method   string   `json:"method"`
+	Path     string   `json:"path"`
+	ID       string   `json:"operationId,omitempty"`
+	URL      string   `json:"url,omitempty"`
+	Status   int      `json:"status,omitempty"`
+	Duration float64  `json:"durationMs,omitempty"`
+	Problems []string `json:"problems,omitempty"`
+	Skipped  string   `json:"skipped,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// OK reports whether the operation was called and matched the spec.
+func (r Result) OK() bool { return r.Skipped == "" && r.Error == "" && len(r.Problems) == 0 }
+
+// Runner calls operations and checks the responses.
+type Runner struct {
+	Client *http.Client
+	// Build turns a planned call into a request against the profile.
+	Build func(ctx context.Context, c Call) (*http.Request, error)
+	// Vars supply parameter values by name, ahead of spec examples.
+	Vars map[string]string
+	// OnResult, if set, is called as each operation finishes.
+	OnResult func(Result)
+}
+
+// Safe reports whether contract testing may call the operation.
+func Safe(op *openapi.Operation) bool { return op.Method == "GET" || op.Method == "HEAD" }
+
+// Run checks every safe operation in ops, in order.
+func (r *Runner) Run(ctx context.Context, ops []*openapi.Operation) []Result {
+	var out []Result
+	for _, op := range ops {
+		if !Safe(op) {
+			continue
+		}
+		res := r.check(ctx, op)
+		out = append(out, res)
+		if r.OnResult != nil {
+			r.OnResult(res)
+		}
+	}
+	return out
+}
+
+func (r *Runner) check(ctx context.Context, op *openapi.Operation) Result {
+	res := Result{Method: op.Method, Path: op.Path, ID: op.ID}
+	call, skip := Plan(op, r.Vars)
+	if skip != "" {
+		res.Skipped = skip
+		return res
+	}
+	req, err := r.Build(ctx, call)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.URL = transport.RedactURL(req.URL)
+	start := time.Now()
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		res.Duration = ms(time.Since(start))
+		res.Error = err.Error()
+		return res
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	resp.Body.Close()
+	res.Duration = ms(time.Since(start))
+	res.Status = resp.StatusCode
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Problems = Validate(op, resp.StatusCode, resp.Header.Get("Content-Type"), body)
+	return res
+}
+
+// Plan fills the operation's path parameters and required query and header
+// parameters from vars, then from the spec's examples. Required query
+// parameters fall back to a placeholder of the right type; a path
+// parameter without a value skips the operation.
+func Plan(op *openapi.Operation, vars map[string]string) (Call, string) {
+	c := Call{Method: op.Method, Path: op.Path, Query: map[string]string{}, Headers: map[string]string{}}
+	for _, p := range op.Params {
+		v, ok := vars[p.Name]
+		if !ok {
+			v, ok = openapi.Example(p.Schema)
+		}
+		switch p.In {
+		case "path":
+			if !ok {
+				return c, fmt.Sprintf("no value for {%s} (pass --var %s=...)", p.Name, p.Name)
+			}
+			c.Path = strings.ReplaceAll(c.Path, "{"+p.Name+"}", url.PathEscape(v))
+		case "query":
+			if !ok && p.Required {
+				v, ok = openapi.Text(openapi.Sample(p.Schema)), true
+			}
+			if ok && (p.Required || vars[p.Name] != "") {
+				c.Query[p.Name] = v
+			}
+		case "header":
+			// Auth headers come from the profile.
+			if ok && p.Required && !transport.IsSecret(p.Name) && !strings.EqualFold(p.Name, "Authorization") {
+				c.Headers[p.Name] = v
+			}
+		}
+	}
+	return c, ""
+}
+
+// Validate compares a response with the spec: the status must be
+// documented, the media type must match and a JSON body must satisfy the
+// documented schema.
+func Validate(op *openapi.Operation, status int, contentType string, body []byte) []string {
+	if len(op.Responses) == 0 {
+		return nil
+	}
+	doc := op.Response(status)
+	if doc == nil {
+		return []string{fmt.Sprintf("status %d is not documented (spec has %s)", status, statuses(op))}
+	}
+	var out []string
+	got := mediaType(contentType)
+	want := mediaType(doc.ContentType)
+	if want != "" && !strings.Contains(want, "*") && len(body) > 0 && got != want {
+		out = append(out, fmt.Sprintf("content type %s, spec says %s", firstNonEmpty(got, "(none)"), want))
+	}
+	if op.Method == "HEAD" || len(doc.Schema) == 0 || !isJSON(want) || !isJSON(got) {
+		return out
+	}
+	v, err := filter.Decode(body)
+	if err != nil {
+		return append(out, "body is not valid JSON")
+	}
+	for _, e := range jsonschema.Validate(doc.Schema, v) {
+		out = append(out, e.Error())
+	}
+	return out
+}
+
+func ms(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+
+func statuses(op *openapi.Operation) string {
+	var s []string
+	for _, r := range op.Responses {
+		s = append(s, r.Status)
+	}
+	sort.Strings(s)
+	return strings.Join(s, ", ")
+}
+
+func mediaType(ct string) string {
+	if ct == "" {
+		return ""
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(ct))
+	}
+	return mt
+}
+
+func isJSON(mt string) bool { return mt == "application/json" || strings.HasSuffix(mt, "+json") }
+
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -5,8 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"os"
 	"strings"
 	"time"
+
+	"github.com/bspippi1337/restless/internal/core/openapi"
+	"github.com/bspippi1337/restless/internal/core/paginate"
+	"github.com/bspippi1337/restless/internal/core/transport"
 )
 
 type Options struct {
@@ -15,21 +20,47 @@ type Options struct {
 	Verify        bool
 	Fuzz          bool
 	Debug         bool
+	// Client is used for all network probes; nil means http.DefaultClient.
+	Client *http.Client
+	// Progress, if set, is called with each Event as discovery runs.
+	Progress func(Event)
 }
 
 type Finding struct {
-	Domain     string     `json:"domain"`
-	BaseURLs   []string   `json:"baseUrls"`
-	DocURLs    []string   `json:"docUrls"`
-	Endpoints  []Endpoint `json:"endpoints"`
-	Confidence float64    `json:"confidence"`
+	Domain     string      `json:"domain"`
+	BaseURLs   []string    `json:"baseUrls"`
+	DocURLs    []string    `json:"docUrls"`
+	Endpoints  []Endpoint  `json:"endpoints"`
+	Confidence float64     `json:"confidence"`
+	OAuth2     *OAuth2Hint `json:"oauth2,omitempty"`
+	Auth       *AuthHint   `json:"auth,omitempty"`
+	RateLimit  *RateLimit  `json:"rateLimit,omitempty"`
+	// Spec is the OpenAPI document found during verification, if any, and
+	// SpecURL where it came from.
+	Spec    *openapi.Spec `json:"-"`
+	SpecURL string        `json:"specUrl,omitempty"`
+}
+
+// RateLimit is the rate limit the API advertised in response headers.
+type RateLimit struct {
+	Limit             int     `json:"limit"`
+	Remaining         int     `json:"remaining"`
+	WindowSeconds     int     `json:"windowSeconds,omitempty"`
+	Source            string  `json:"source"`
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	ObservedAt        string  `json:"observedAt"`
 }
 
 type Endpoint struct {
-	Method   string     `json:"method"`
-	Path     string     `json:"path"`
-	Score    float64    `json:"score"`
-	Evidence []Evidence `json:"evidence"`
+	Method     string         `json:"method"`
+	Path       string         `json:"path"`
+	Score      float64        `json:"score"`
+	Evidence   []Evidence     `json:"evidence"`
+	Pagination *paginate.Spec `json:"pagination,omitempty"`
+	// Schema is the JSON Schema inferred from successful responses.
+	Schema map[string]any `json:"schema,omitempty"`
+	// Operation is the spec operation this endpoint came from, if any.
+	Operation *openapi.Operation `json:"-"`
 }
 
 type Evidence struct {
@@ -37,6 +68,8 @@ type Evidence struct {
 	URL    string  `json:"url"`
 	When   string  `json:"when"`
 	Score  float64 `json:"score"`
+	// Timing is attached to live probes when Options.Debug is set.
+	Timing *transport.Timing `json:"timing,omitempty"`
 }
 
 func DiscoverDomain(domain string, opt Options) (Finding, error) {
@@ -50,7 +83,20 @@ func DiscoverDomain(domain string, opt Options) (Finding, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(opt.BudgetSeconds)*time.Second)
 	defer cancel()
 
-	now := time.Now().Format(time.RFC3339)
+	client := opt.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	client = withProgress(client, opt)
+
+	started := time.Now()
+	deadline, _ := ctx.Deadline()
+	now := started.Format(time.RFC3339)
+	pages := opt.BudgetPages
+	if pages <= 0 {
+		pages = 6
+	}
+	opt.emit(Event{Type: EventStart, Domain: domain, SecondsLeft: secondsUntil(deadline), PagesLeft: &pages})
 
 	find := Finding{
 		Domain:     domain,
@@ -68,27 +114,70 @@ func DiscoverDomain(domain string, opt Options) (Finding, error) {
 			},
 		},
 	}
+	opt.emit(Event{Type: EventSource, Source: "heuristic"})
+	foundURLs(opt, EventBaseURL, "heuristic", find.BaseURLs)
+	foundURLs(opt, EventDoc, "heuristic", find.DocURLs)
+	foundEndpoints(opt, find.Endpoints)
 
 	// Optional verify: cheap HEAD/GET check for base URL root
 	if opt.Verify {
+		opt.emit(Event{Type: EventSource, Source: "verify"})
 		u := fmt.Sprintf("https://%s/", domain)
-		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
-		resp, err := http.DefaultClient.Do(req)
+		rctx, timer := traceContext(ctx, opt.Debug)
+		req, _ := http.NewRequestWithContext(rctx, http.MethodGet, u, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			if opt.Debug {
+				fmt.Fprintf(os.Stderr, "[debug] verify %s: %v\n", u, err)
+			}
+			opt.failed("verify", u, err)
+		}
 		if err == nil && resp != nil {
 			_ = resp.Body.Close()
+			recordRateLimit(&find, resp.Header)
+			if opt.Debug {
+				fmt.Fprintf(os.Stderr, "[debug] verify %s: %s\n", u, resp.Status)
+				for _, line := range transport.DescribeTLS(resp.TLS) {
+					fmt.Fprintf(os.Stderr, "[debug]   %s\n", line)
+				}
+			}
 			// bump confidence if any response came back
 			find.Confidence = 0.65
 			find.Endpoints[0].Score = 0.65
-			find.Endpoints[0].Evidence = append(find.Endpoints[0].Evidence, Evidence{
+			addEvidence(opt, &find.Endpoints[0], Evidence{
 				Source: "verify",
 				URL:    u,
 				When:   now,
 				Score:  0.65,
+				Timing: finishTimer(timer),
 			})
 		}
+		opt.emit(Event{Type: EventSource, Source: "oauth2"})
+		find.OAuth2 = probeOAuth2(ctx, client, domain)
+		opt.emit(Event{Type: EventSource, Source: "openapi"})
+		loadSpec(ctx, client, &find, domain, opt)
+		opt.emit(Event{Type: EventSource, Source: "endpoints"})
+		verifyEndpoints(ctx, client, &find, opt, deadline)
 	}
 
 	_ = ctx // silence linters if future changes remove verify usage
 
+	opt.emit(Event{Type: EventDone, Domain: domain, Endpoints: len(find.Endpoints), Millis: time.Since(started).Milliseconds()})
 	return find, nil
 }
+
+// traceContext attaches a timing trace to ctx when debugging.
+func traceContext(ctx context.Context, debug bool) (context.Context, *transport.Timer) {
+	if !debug {
+		return ctx, nil
+	}
+	return transport.WithTimer(ctx)
+}
+
+func finishTimer(t *transport.Timer) *transport.Timing {
+	if t == nil {
+		return nil
+	}
+	tm := t.Finish()
+	return &tm
+}
@@ -0,0 +1,100 @@
+package discovery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// har is the subset of HAR 1.2 that discovery reads.
+type har struct {
+	Log struct {
+		Version string `json:"version"`
+		Entries []struct {
+			Started  string `json:"startedDateTime"`
+			Resource string `json:"_resourceType"` // Chrome's request type
+			Request  struct {
+				Method  string `json:"method"`
+				URL     string `json:"url"`
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+			} `json:"request"`
+			Response struct {
+				Status  int `json:"status"`
+				Content struct {
+					MimeType string `json:"mimeType"`
+				} `json:"content"`
+			} `json:"response"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// browserOnly are Chrome resource types that are never API calls.
+var browserOnly = map[string]bool{
+	"document": true, "stylesheet": true, "script": true, "image": true, "font": true,
+	"media": true, "manifest": true, "texttrack": true, "websocket": true, "ping": true,
+}
+
+// ParseHAR reads the requests of a HAR 1.2 archive (as exported by browser
+// devtools). Header values are kept only so auth can be inferred; callers
+// must not persist them.
+func ParseHAR(b []byte) ([]Exchange, error) {
+	var doc har
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("not a HAR file: %w", err)
+	}
+	if doc.Log.Entries == nil {
+		return nil, errors.New("not a HAR file: no log.entries")
+	}
+	var out []Exchange
+	for _, e := range doc.Log.Entries {
+		if browserOnly[strings.ToLower(e.Resource)] {
+			continue
+		}
+		u, err := url.Parse(e.Request.URL)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		x := Exchange{
+			Method:      strings.ToUpper(e.Request.Method),
+			URL:         u,
+			Header:      http.Header{},
+			Status:      e.Response.Status,
+			ContentType: e.Response.Content.MimeType,
+		}
+		for _, h := range e.Request.Headers {
+			// HTTP/2 pseudo-headers (:authority, :path) aren't headers.
+			if !strings.HasPrefix(h.Name, ":") {
+				x.Header.Add(h.Name, h.Value)
+			}
+		}
+		if t, err := time.Parse(time.RFC3339Nano, e.Started); err == nil {
+			x.When = t
+		}
+		out = append(out, x)
+	}
+	return out, nil
+}
+
+// FromHAR builds a Finding from a HAR archive, keeping traffic to domain
+// and its subdomains (any host when domain is empty).
+func FromHAR(b []byte, domain string) (Finding, error) {
+	xs, err := ParseHAR(b)
+	if err != nil {
+		return Finding{}, err
+	}
+	find := LearnTraffic(xs, TrafficOptions{Source: "har", Domain: domain})
+	if len(find.Endpoints) == 0 {
+		if domain != "" {
+			return find, fmt.Errorf("no API requests to %s in the HAR file", domain)
+		}
+		return find, errors.New("no API requests in the HAR file")
+	}
+	return find, nil
+}
@@ -0,0 +1,97 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// OAuth2Hint records an OAuth2 authorization server advertised by the domain.
+type OAuth2Hint struct {
+	MetadataURL   string   `json:"metadataUrl"`
+	Issuer        string   `json:"issuer,omitempty"`
+	TokenURL      string   `json:"tokenUrl"`
+	DeviceAuthURL string   `json:"deviceAuthorizationUrl,omitempty"`
+	Grants        []string `json:"grants,omitempty"`
+	Scopes        []string `json:"scopes,omitempty"`
+}
+
+// wellKnownOAuth2 lists RFC 8414 and OpenID Connect metadata locations.
+var wellKnownOAuth2 = []string{
+	"/.well-known/oauth-authorization-server",
+	"/.well-known/openid-configuration",
+}
+
+// probeOAuth2 looks for authorization server metadata on the domain and its
+// api./auth. subdomains and returns the first one with a token endpoint.
+func probeOAuth2(ctx context.Context, client *http.Client, domain string) *OAuth2Hint {
+	for _, host := range []string{domain, "api." + domain, "auth." + domain} {
+		for _, p := range wellKnownOAuth2 {
+			u := fmt.Sprintf("https://%s%s", host, p)
+			if hint := fetchOAuth2Metadata(ctx, client, u); hint != nil {
+				return hint
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+func fetchOAuth2Metadata(ctx context.Context, client *http.Client, u string) *OAuth2Hint {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	var md struct {
+		Issuer        string   `json:"issuer"`
+		TokenEndpoint string   `json:"token_endpoint"`
+		DeviceAuth    string   `json:"device_authorization_endpoint"`
+		Grants        []string `json:"grant_types_supported"`
+		Scopes        []string `json:"scopes_supported"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&md); err != nil || md.TokenEndpoint == "" {
+		return nil
+	}
+	return &OAuth2Hint{
+		MetadataURL:   u,
+		Issuer:        md.Issuer,
+		TokenURL:      md.TokenEndpoint,
+		DeviceAuthURL: md.DeviceAuth,
+		Grants:        md.Grants,
+		Scopes:        md.Scopes,
+	}
+}
+
+// PreferredGrant picks the grant a CLI can run unattended, falling back to
+// the device flow when that's all the server offers.
+func (h *OAuth2Hint) PreferredGrant() string {
+	if len(h.Grants) == 0 {
+		return "client_credentials"
+	}
+	has := map[string]bool{}
+	for _, g := range h.Grants {
+		has[g] = true
+	}
+	switch {
+	case has["client_credentials"]:
+		return "client_credentials"
+	case has["urn:ietf:params:oauth:grant-type:device_code"] && h.DeviceAuthURL != "":
+		return "device_code"
+	case has["refresh_token"]:
+		return "refresh_token"
+	}
+	return "client_credentials"
+}
@@ -0,0 +1,138 @@
+package discovery
+
+import (
+	"net/http"
+	"time"
+)
+
+// Event kinds reported through Options.Progress.
+const (
+	EventStart    = "start"    // discovery began; carries the budgets
+	EventSource   = "source"   // a source (heuristic, verify, oauth2, openapi, endpoints) is being tried
+	EventFetch    = "fetch"    // a URL was fetched, or failed
+	EventEndpoint = "endpoint" // an endpoint was found, with its first evidence
+	EventEvidence = "evidence" // more evidence for an endpoint already reported
+	EventBaseURL  = "baseUrl"  // a base URL was guessed or read from a spec
+	EventDoc      = "doc"      // a doc URL was guessed or confirmed
+	EventError    = "error"    // a probe failed; discovery goes on
+	EventBudget   = "budget"   // a page of the budget was spent
+	EventDone     = "done"     // discovery finished
+	EventSummary  = "summary"  // the final Finding, sent by the caller
+)
+
+// Event is a progress report from DiscoverDomain. Only the fields that
+// matter for its Type are set.
+type Event struct {
+	Type   string  `json:"type"`
+	Time   string  `json:"time"`
+	Domain string  `json:"domain,omitempty"`
+	Source string  `json:"source,omitempty"`
+	Method string  `json:"method,omitempty"`
+	URL    string  `json:"url,omitempty"`
+	Path   string  `json:"path,omitempty"`
+	Status int     `json:"status,omitempty"`
+	Error  string  `json:"error,omitempty"`
+	Score  float64 `json:"score,omitempty"`
+	// Millis is how long a fetch took, or the whole run for "done".
+	Millis int64 `json:"ms,omitempty"`
+	// SecondsLeft and PagesLeft are what remains of the budgets.
+	SecondsLeft float64 `json:"secondsLeft,omitempty"`
+	PagesLeft   *int    `json:"pagesLeft,omitempty"`
+	Endpoints   int     `json:"endpoints,omitempty"`
+	// Finding and Profile, the saved profile's path, come with "summary".
+	Finding *Finding `json:"finding,omitempty"`
+	Profile string   `json:"profile,omitempty"`
+}
+
+// emit sends e to opt.Progress, if set.
+func (opt Options) emit(e Event) {
+	if opt.Progress == nil {
+		return
+	}
+	e.Time = time.Now().Format(time.RFC3339Nano)
+	opt.Progress(e)
+}
+
+// failed reports a probe of u by source that failed.
+func (opt Options) failed(source, u string, err error) {
+	opt.emit(Event{Type: EventError, Source: source, URL: u, Error: err.Error()})
+}
+
+// foundEndpoints reports eps as found.
+func foundEndpoints(opt Options, eps []Endpoint) {
+	for _, ep := range eps {
+		e := Event{Type: EventEndpoint, Method: ep.Method, Path: ep.Path, Score: ep.Score}
+		if len(ep.Evidence) > 0 {
+			ev := ep.Evidence[0]
+			e.Source, e.URL = ev.Source, ev.URL
+		}
+		opt.emit(e)
+	}
+}
+
+// addEvidence appends ev to ep and reports it.
+func addEvidence(opt Options, ep *Endpoint, ev Evidence) {
+	ep.Evidence = append(ep.Evidence, ev)
+	opt.emit(Event{Type: EventEvidence, Source: ev.Source, Method: ep.Method, Path: ep.Path, URL: ev.URL, Score: ev.Score})
+}
+
+// foundURLs reports base or doc URLs.
+func foundURLs(opt Options, typ, source string, urls []string) {
+	for _, u := range urls {
+		opt.emit(Event{Type: typ, Source: source, URL: u})
+	}
+}
+
+// budgetEvent reports the pages left and the time until deadline.
+func (opt Options) budgetEvent(deadline time.Time, pages int) {
+	if opt.Progress == nil {
+		return
+	}
+	opt.emit(Event{
+		Type:        EventBudget,
+		SecondsLeft: secondsUntil(deadline),
+		PagesLeft:   &pages,
+	})
+}
+
+func secondsUntil(t time.Time) float64 {
+	return max(0, time.Until(t).Round(100*time.Millisecond).Seconds())
+}
+
+// progressTransport reports every round trip as a fetch event.
+type progressTransport struct {
+	next http.RoundTripper
+	opt  Options
+}
+
+func (t *progressTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	e := Event{
+		Type:   EventFetch,
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Millis: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		e.Error = err.Error()
+	} else {
+		e.Status = resp.StatusCode
+	}
+	t.opt.emit(e)
+	return resp, err
+}
+
+// withProgress returns a copy of client whose requests are reported.
+func withProgress(client *http.Client, opt Options) *http.Client {
+	if opt.Progress == nil {
+		return client
+	}
+	c := *client
+	next := c.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	c.Transport = &progressTransport{next: next, opt: opt}
+	return &c
+}
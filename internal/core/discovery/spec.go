@@ -0,0 +1,104 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/openapi"
+)
+
+// loadSpec fetches the first OpenAPI/Swagger JSON document it can find and
+// merges its operations into find. Spec evidence outranks heuristics and
+// is refined by verifyEndpoints afterwards.
+func loadSpec(ctx context.Context, client *http.Client, find *Finding, domain string, opt Options) {
+	candidates := append([]string{}, find.DocURLs...)
+	candidates = append(candidates,
+		fmt.Sprintf("https://api.%s/openapi.json", domain),
+		fmt.Sprintf("https://%s/swagger.json", domain),
+		fmt.Sprintf("https://%s/v3/api-docs", domain),
+	)
+	seen := map[string]bool{}
+	for _, u := range candidates {
+		if seen[u] || ctx.Err() != nil {
+			continue
+		}
+		seen[u] = true
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			continue
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			if opt.Debug {
+				fmt.Fprintf(os.Stderr, "[debug] spec %s: %v\n", u, err)
+			}
+			opt.failed("openapi", u, err)
+			continue
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+		_ = resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			continue
+		}
+		spec, err := openapi.Parse(body, u)
+		if err != nil {
+			if opt.Debug {
+				fmt.Fprintf(os.Stderr, "[debug] spec %s: %v\n", u, err)
+			}
+			opt.failed("openapi", u, err)
+			continue
+		}
+		mergeSpec(find, spec, u, opt)
+		return
+	}
+}
+
+// mergeSpec records spec as evidence: its servers replace guessed base URLs
+// and every operation becomes (or confirms) an endpoint. All of it is
+// reported through opt.Progress.
+func mergeSpec(find *Finding, spec *openapi.Spec, specURL string, opt Options) {
+	find.Spec, find.SpecURL = spec, specURL
+	docs := []string{specURL}
+	for _, d := range find.DocURLs {
+		if d != specURL {
+			docs = append(docs, d)
+		}
+	}
+	find.DocURLs = docs
+	foundURLs(opt, EventDoc, "openapi", docs[:1])
+	if len(spec.Servers) > 0 {
+		find.BaseURLs = spec.Servers
+		foundURLs(opt, EventBaseURL, "openapi", spec.Servers)
+	}
+	now := time.Now().Format(time.RFC3339)
+	for i := range spec.Operations {
+		op := &spec.Operations[i]
+		ev := Evidence{Source: "openapi", URL: specURL, When: now, Score: 0.90}
+		found := false
+		for j := range find.Endpoints {
+			ep := &find.Endpoints[j]
+			if ep.Method == op.Method && ep.Path == op.Path {
+				addEvidence(opt, ep, ev)
+				ep.Score = max(ep.Score, ev.Score)
+				ep.Operation = op
+				found = true
+			}
+		}
+		if !found {
+			find.Endpoints = append(find.Endpoints, Endpoint{
+				Method:    op.Method,
+				Path:      op.Path,
+				Score:     ev.Score,
+				Evidence:  []Evidence{ev},
+				Operation: op,
+			})
+			foundEndpoints(opt, find.Endpoints[len(find.Endpoints)-1:])
+		}
+	}
+	find.Confidence = max(find.Confidence, 0.90)
+}
@@ -0,0 +1,442 @@
+package discovery
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/transport"
+	"github.com/bspippi1337/restless/internal/jsonschema"
+)
+
+// Exchange is one observed request and its response, from the capture
+// proxy or a HAR file.
+type Exchange struct {
+	Method      string
+	URL         *url.URL
+	Header      http.Header // request headers
+	Status      int
+	ContentType string // response content type
+	When        time.Time
+}
+
+// AuthHint is the credential style seen in traffic. Only the style and the
+// header, parameter or cookie name are kept, never the value.
+type AuthHint struct {
+	Type string `json:"type"` // bearer, basic, header, query or cookie
+	Name string `json:"name,omitempty"`
+}
+
+type TrafficOptions struct {
+	// Source names the evidence, e.g. "capture" or "har".
+	Source string
+	// Host keeps only traffic to this host; empty picks the host with the
+	// most API-like requests, within Domain if set.
+	Host string
+	// Domain limits the pick to this domain and its subdomains.
+	Domain string
+	// BasePath is stripped from request paths (the base URL's path).
+	BasePath string
+	// Known are endpoint templates to prefer over inferred ones.
+	Known []string
+}
+
+// LearnTraffic turns observed exchanges into a Finding: static assets and
+// pages are dropped, concrete paths become templates (/users/42 →
+// /users/{id}), and each endpoint gets one piece of evidence. Endpoints
+// only ever answered with 404 or 405 are left out.
+func LearnTraffic(xs []Exchange, opt TrafficOptions) Finding {
+	var api []Exchange
+	for _, x := range xs {
+		if x.URL != nil && APILike(x) && inDomain(x.URL.Hostname(), opt.Domain) {
+			api = append(api, x)
+		}
+	}
+	host := opt.Host
+	if host == "" {
+		host = busiestHost(api)
+	}
+	find := Finding{Domain: hostname(host)}
+	base := strings.TrimSuffix(opt.BasePath, "/")
+
+	type seen struct {
+		ep     Endpoint
+		best   int // best status class seen, see statusRank
+		sample string
+		when   time.Time
+	}
+	byKey := map[string]*seen{}
+	var order []string
+	var auth []AuthHint
+	for _, x := range api {
+		if !strings.EqualFold(x.URL.Host, host) {
+			continue
+		}
+		if len(find.BaseURLs) == 0 {
+			find.BaseURLs = []string{x.URL.Scheme + "://" + x.URL.Host + base}
+		}
+		p := x.URL.Path
+		if base != "" {
+			rest, ok := strings.CutPrefix(p, base)
+			if !ok || (rest != "" && rest[0] != '/') {
+				continue
+			}
+			p = rest
+		}
+		tmpl := Template(firstNonEmpty(p, "/"), opt.Known)
+		method := strings.ToUpper(x.Method)
+		key := method + " " + tmpl
+		s, ok := byKey[key]
+		if !ok {
+			s = &seen{ep: Endpoint{Method: method, Path: tmpl}, best: 9}
+			byKey[key] = s
+			order = append(order, key)
+		}
+		if r := statusRank(x.Status); r < s.best || s.sample == "" {
+			s.best = min(s.best, r)
+			s.sample = transport.RedactURL(x.URL)
+			s.when = x.When
+		}
+		if a := authOf(x); a != nil {
+			auth = append(auth, *a)
+		}
+	}
+
+	for _, key := range order {
+		s := byKey[key]
+		score := 0.0
+		switch s.best {
+		case 2, 3:
+			score = 0.95
+		case 4:
+			score = 0.85 // exists, but the request was refused
+		case 5:
+			score = 0.60
+		default:
+			continue
+		}
+		when := s.when
+		if when.IsZero() {
+			when = time.Now()
+		}
+		s.ep.Score = score
+		s.ep.Evidence = []Evidence{{Source: opt.Source, URL: s.sample, When: when.Format(time.RFC3339), Score: score}}
+		find.Endpoints = append(find.Endpoints, s.ep)
+		find.Confidence = max(find.Confidence, score)
+	}
+	sort.SliceStable(find.Endpoints, func(i, j int) bool {
+		a, b := find.Endpoints[i], find.Endpoints[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return methodRank(a.Method) < methodRank(b.Method)
+	})
+	find.Auth = commonAuth(auth)
+	return find
+}
+
+// Merge folds other into f: endpoints with the same method and path gain
+// other's evidence and schema, new ones are appended, and base URLs, docs, auth and
+// the spec are filled in where f has none.
+func (f *Finding) Merge(other Finding) {
+	for _, ep := range other.Endpoints {
+		found := false
+		for j := range f.Endpoints {
+			have := &f.Endpoints[j]
+			if have.Method == ep.Method && have.Path == ep.Path {
+				have.Evidence = append(have.Evidence, ep.Evidence...)
+				have.Score = max(have.Score, ep.Score)
+				if have.Pagination == nil {
+					have.Pagination = ep.Pagination
+				}
+				have.Schema = jsonschema.Merge(have.Schema, ep.Schema)
+				found = true
+				break
+			}
+		}
+		if !found {
+			f.Endpoints = append(f.Endpoints, ep)
+		}
+	}
+	if f.Domain == "" {
+		f.Domain = other.Domain
+	}
+	if len(f.BaseURLs) == 0 {
+		f.BaseURLs = other.BaseURLs
+	}
+	for _, d := range other.DocURLs {
+		if !contains(f.DocURLs, d) {
+			f.DocURLs = append(f.DocURLs, d)
+		}
+	}
+	if f.Auth == nil {
+		f.Auth = other.Auth
+	}
+	if f.SpecURL == "" {
+		f.Spec, f.SpecURL = other.Spec, other.SpecURL
+	}
+	f.Confidence = max(f.Confidence, other.Confidence)
+}
+
+var staticExt = map[string]bool{
+	".js": true, ".mjs": true, ".css": true, ".map": true, ".html": true, ".htm": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".ico": true, ".webp": true, ".avif": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".otf": true, ".eot": true,
+	".mp4": true, ".webm": true, ".mp3": true, ".wasm": true, ".pdf": true,
+}
+
+// APILike reports whether an exchange looks like an API call rather than a
+// page, script, stylesheet or image load.
+func APILike(x Exchange) bool {
+	if x.Method == http.MethodOptions || x.Method == http.MethodConnect {
+		return false
+	}
+	if x.URL != nil && staticExt[strings.ToLower(path.Ext(x.URL.Path))] {
+		return false
+	}
+	mt, _, _ := strings.Cut(strings.ToLower(x.ContentType), ";")
+	mt = strings.TrimSpace(mt)
+	switch {
+	case mt == "", mt == "text/plain", strings.Contains(mt, "json"), strings.Contains(mt, "xml") && mt != "application/xhtml+xml",
+		mt == "application/x-ndjson", mt == "text/event-stream", mt == "application/x-www-form-urlencoded",
+		mt == "application/octet-stream", strings.HasPrefix(mt, "application/grpc"), strings.HasPrefix(mt, "application/x-protobuf"):
+		return true
+	}
+	return false
+}
+
+var (
+	uuidSeg  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hexSeg   = regexp.MustCompile(`^[0-9a-fA-F]{12,}$`)
+	dateSeg  = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}`)
+	digitSeg = regexp.MustCompile(`^\d+$`)
+	tokenSeg = regexp.MustCompile(`^[A-Za-z0-9_-]{16,}$`)
+	digitAny = regexp.MustCompile(`\d`)
+	letters  = regexp.MustCompile(`[A-Za-z]`)
+)
+
+// Template maps a concrete path to an endpoint template. A known template
+// that matches wins; otherwise ID-like segments (numbers, UUIDs, long hex
+// or opaque tokens, dates, emails) become parameters named after the
+// segment before them: /orgs/7/repos/42 → /orgs/{orgId}/repos/{id}.
+func Template(p string, known []string) string {
+	for _, k := range known {
+		if templateMatch(k, p) {
+			return k
+		}
+	}
+	segs := strings.Split(strings.Trim(p, "/"), "/")
+	var params []int
+	for i, s := range segs {
+		if idLike(s) {
+			params = append(params, i)
+		}
+	}
+	used := map[string]bool{}
+	for n, i := range params {
+		name := "id"
+		if n < len(params)-1 && i > 0 && !strings.HasPrefix(segs[i-1], "{") {
+			name = camel(singular(segs[i-1])) + "Id"
+		}
+		for base, k := name, 2; used[name]; k++ {
+			name = base + strconv.Itoa(k)
+		}
+		used[name] = true
+		segs[i] = "{" + name + "}"
+	}
+	out := "/" + strings.Join(segs, "/")
+	if strings.HasSuffix(p, "/") && out != "/" {
+		out += "/"
+	}
+	return out
+}
+
+func idLike(s string) bool {
+	if s == "" {
+		return false
+	}
+	if u, err := url.PathUnescape(s); err == nil {
+		s = u
+	}
+	switch {
+	case digitSeg.MatchString(s), uuidSeg.MatchString(s), dateSeg.MatchString(s), strings.Contains(s, "@"):
+		return true
+	case hexSeg.MatchString(s) && digitAny.MatchString(s):
+		return true
+	case tokenSeg.MatchString(s) && digitAny.MatchString(s) && letters.MatchString(s):
+		return true
+	}
+	return false
+}
+
+func templateMatch(tmpl, p string) bool {
+	have := strings.Split(strings.Trim(tmpl, "/"), "/")
+	want := strings.Split(strings.Trim(p, "/"), "/")
+	if len(have) != len(want) {
+		return false
+	}
+	for i := range have {
+		if have[i] != want[i] && !(strings.HasPrefix(have[i], "{") && strings.HasSuffix(have[i], "}")) {
+			return false
+		}
+	}
+	return true
+}
+
+func singular(s string) string {
+	switch {
+	case strings.HasSuffix(s, "ies") && len(s) > 3:
+		return s[:len(s)-3] + "y"
+	case strings.HasSuffix(s, "ses") || strings.HasSuffix(s, "xes"):
+		return s[:len(s)-2]
+	case strings.HasSuffix(s, "s") && !strings.HasSuffix(s, "ss"):
+		return s[:len(s)-1]
+	}
+	return s
+}
+
+func camel(s string) string {
+	parts := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return r == '-' || r == '_' || r == '.' })
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	if len(parts) == 0 {
+		return "item"
+	}
+	return strings.Join(parts, "")
+}
+
+// authOf reports how a request authenticated, if it did.
+func authOf(x Exchange) *AuthHint {
+	if v := x.Header.Get("Authorization"); v != "" {
+		scheme, _, _ := strings.Cut(v, " ")
+		switch strings.ToLower(scheme) {
+		case "bearer":
+			return &AuthHint{Type: "bearer"}
+		case "basic":
+			return &AuthHint{Type: "basic"}
+		}
+		return &AuthHint{Type: "header", Name: "Authorization"}
+	}
+	names := make([]string, 0, len(x.Header))
+	for k := range x.Header {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if transport.IsSecret(k) && !strings.EqualFold(k, "Cookie") && !strings.EqualFold(k, "Proxy-Authorization") {
+			return &AuthHint{Type: "header", Name: http.CanonicalHeaderKey(k)}
+		}
+	}
+	var params []string
+	for k := range x.URL.Query() {
+		params = append(params, k)
+	}
+	sort.Strings(params)
+	for _, k := range params {
+		if transport.IsSecret(k) || strings.EqualFold(k, "key") {
+			return &AuthHint{Type: "query", Name: k}
+		}
+	}
+	if x.Header.Get("Cookie") != "" {
+		return &AuthHint{Type: "cookie", Name: "Cookie"}
+	}
+	return nil
+}
+
+// commonAuth picks the most frequent hint; explicit credentials beat
+// cookies on a tie.
+func commonAuth(hints []AuthHint) *AuthHint {
+	count := map[AuthHint]int{}
+	var kinds []AuthHint
+	for _, h := range hints {
+		if count[h] == 0 {
+			kinds = append(kinds, h)
+		}
+		count[h]++
+	}
+	if len(kinds) == 0 {
+		return nil
+	}
+	sort.SliceStable(kinds, func(i, j int) bool {
+		a, b := kinds[i], kinds[j]
+		if count[a] != count[b] {
+			return count[a] > count[b]
+		}
+		return a.Type != "cookie" && b.Type == "cookie"
+	})
+	return &kinds[0]
+}
+
+func busiestHost(xs []Exchange) string {
+	count := map[string]int{}
+	best := ""
+	for _, x := range xs {
+		h := strings.ToLower(x.URL.Host)
+		count[h]++
+		if best == "" || count[h] > count[best] || (count[h] == count[best] && h < best) {
+			best = h
+		}
+	}
+	return best
+}
+
+// statusRank orders what a status says about an endpoint: 2xx and 3xx
+// prove it, other 4xx mean it exists but refused the request, 5xx is
+// doubtful, and 404, 405 or no response at all prove nothing (9).
+func statusRank(code int) int {
+	switch {
+	case code == http.StatusNotFound || code == http.StatusMethodNotAllowed || code < 200 || code > 599:
+		return 9
+	default:
+		return code / 100
+	}
+}
+
+func methodRank(m string) int {
+	for i, x := range []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"} {
+		if m == x {
+			return i
+		}
+	}
+	return 9
+}
+
+func inDomain(host, domain string) bool {
+	if domain == "" {
+		return true
+	}
+	host, domain = strings.ToLower(host), strings.ToLower(strings.TrimPrefix(domain, "www."))
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,227 @@
+package discovery
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		path  string
+		known []string
+		want  string
+	}{
+		{path: "/", want: "/"},
+		{path: "/users", want: "/users"},
+		{path: "/users/", want: "/users/"},
+		{path: "/users/42", want: "/users/{id}"},
+		{path: "/users/42/", want: "/users/{id}/"},
+		{path: "/orgs/7/repos/42", want: "/orgs/{orgId}/repos/{id}"},
+		{path: "/categories/3/boxes/9/items", want: "/categories/{categoryId}/boxes/{id}/items"},
+		{path: "/user-groups/5/members/6", want: "/user-groups/{userGroupId}/members/{id}"},
+		{path: "/a/1/2", want: "/a/{aId}/{id}"},
+		{path: "/1/2", want: "/{id}/{id2}"},
+		{path: "/items/0b6c1b1e-8a6d-4c2e-9a61-3f1f0c9d2e7a", want: "/items/{id}"},
+		{path: "/commits/3fa9c0d2e1b4", want: "/commits/{id}"},
+		{path: "/commits/deadbeefcafe", want: "/commits/deadbeefcafe"}, // hex, but no digits
+		{path: "/reports/2024-05-01", want: "/reports/{id}"},
+		{path: "/people/ann%40example.com", want: "/people/{id}"},
+		{path: "/sessions/aB3dE5fG7hJ9kL1mN", want: "/sessions/{id}"},
+		{path: "/v1/status", want: "/v1/status"},
+		{path: "/api/v2/users/me", want: "/api/v2/users/me"},
+		// A known template wins over inference.
+		{path: "/users/alice", known: []string{"/users/{login}"}, want: "/users/{login}"},
+		{path: "/users/42", known: []string{"/users/{login}"}, want: "/users/{login}"},
+		{path: "/users/42/keys", known: []string{"/users/{login}"}, want: "/users/{id}/keys"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := Template(tt.path, tt.known); got != tt.want {
+				t.Errorf("Template(%q, %v) = %q, want %q", tt.path, tt.known, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPILike(t *testing.T) {
+	tests := []struct {
+		method, path, ct string
+		want             bool
+	}{
+		{"GET", "/api/users", "application/json", true},
+		{"GET", "/api/users", "application/vnd.api+json; charset=utf-8", true},
+		{"POST", "/graphql", "", true},
+		{"GET", "/feed", "application/xml", true},
+		{"GET", "/events", "text/event-stream", true},
+		{"GET", "/", "text/html", false},
+		{"GET", "/page", "application/xhtml+xml", false},
+		{"GET", "/app.js", "application/json", false},
+		{"GET", "/logo.PNG", "", false},
+		{"OPTIONS", "/api/users", "application/json", false},
+	}
+	for _, tt := range tests {
+		x := Exchange{Method: tt.method, URL: &url.URL{Path: tt.path}, ContentType: tt.ct}
+		if got := APILike(x); got != tt.want {
+			t.Errorf("APILike(%s %s, %q) = %v, want %v", tt.method, tt.path, tt.ct, got, tt.want)
+		}
+	}
+}
+
+func exchange(method, rawURL string, status int, header ...string) Exchange {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		panic(err)
+	}
+	h := http.Header{}
+	for i := 0; i+1 < len(header); i += 2 {
+		h.Set(header[i], header[i+1])
+	}
+	return Exchange{
+		Method:      method,
+		URL:         u,
+		Header:      h,
+		Status:      status,
+		ContentType: "application/json",
+		When:        time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+func endpointKeys(f Finding) []string {
+	var out []string
+	for _, ep := range f.Endpoints {
+		out = append(out, ep.Method+" "+ep.Path)
+	}
+	return out
+}
+
+func TestLearnTraffic(t *testing.T) {
+	xs := []Exchange{
+		exchange("GET", "https://api.shop.test/v1/users/1?token=abc", 200, "Authorization", "Bearer s3cret"),
+		exchange("GET", "https://api.shop.test/v1/users/2", 500),
+		exchange("get", "https://api.shop.test/v1/users", 200, "Authorization", "Bearer s3cret"),
+		exchange("POST", "https://api.shop.test/v1/users", 403),
+		exchange("DELETE", "https://api.shop.test/v1/users/3", 404), // proves nothing
+		exchange("GET", "https://api.shop.test/v1/orders/9/items", 502, "Authorization", "Bearer s3cret"),
+		exchange("GET", "https://api.shop.test/elsewhere", 200), // outside the base path
+		exchange("GET", "https://cdn.shop.test/v1/a", 200),
+		exchange("GET", "https://tracker.other.test/v1/hit", 200),
+		exchange("GET", "https://tracker.other.test/v1/hit", 200),
+		exchange("GET", "https://tracker.other.test/v1/hit", 200),
+		exchange("GET", "https://tracker.other.test/v1/hit", 200),
+		{Method: "GET", URL: mustURL("https://api.shop.test/v1/app.css"), ContentType: "text/css", Status: 200},
+	}
+	f := LearnTraffic(xs, TrafficOptions{Source: "capture", Domain: "shop.test", BasePath: "/v1/"})
+
+	if f.Domain != "api.shop.test" {
+		t.Errorf("Domain = %q, want api.shop.test", f.Domain)
+	}
+	if want := []string{"https://api.shop.test/v1"}; !reflect.DeepEqual(f.BaseURLs, want) {
+		t.Errorf("BaseURLs = %v, want %v", f.BaseURLs, want)
+	}
+	want := []string{
+		"GET /orders/{id}/items",
+		"GET /users",
+		"POST /users",
+		"GET /users/{id}",
+	}
+	if got := endpointKeys(f); !reflect.DeepEqual(got, want) {
+		t.Fatalf("endpoints = %v, want %v", got, want)
+	}
+	scores := map[string]float64{}
+	for _, ep := range f.Endpoints {
+		scores[ep.Method+" "+ep.Path] = ep.Score
+		if len(ep.Evidence) != 1 || ep.Evidence[0].Source != "capture" || ep.Evidence[0].When != "2026-01-02T03:04:05Z" {
+			t.Errorf("%s %s evidence = %+v", ep.Method, ep.Path, ep.Evidence)
+		}
+	}
+	if want := map[string]float64{
+		"GET /orders/{id}/items": 0.60,
+		"GET /users":             0.95,
+		"POST /users":            0.85,
+		"GET /users/{id}":        0.95, // the 200 beats the 500
+	}; !reflect.DeepEqual(scores, want) {
+		t.Errorf("scores = %v, want %v", scores, want)
+	}
+	// The sample is the best answer, with secrets redacted.
+	sample := f.Endpoints[3].Evidence[0].URL
+	if !strings.Contains(sample, "/v1/users/1") || strings.Contains(sample, "abc") {
+		t.Errorf("sample URL = %q, want /v1/users/1 with the token redacted", sample)
+	}
+	if f.Confidence != 0.95 {
+		t.Errorf("Confidence = %v, want 0.95", f.Confidence)
+	}
+	if f.Auth == nil || *f.Auth != (AuthHint{Type: "bearer"}) {
+		t.Errorf("Auth = %+v, want bearer", f.Auth)
+	}
+}
+
+func TestLearnTrafficPicksBusiestHost(t *testing.T) {
+	xs := []Exchange{
+		exchange("GET", "https://a.test/x", 200),
+		exchange("GET", "https://b.test/y/1", 200),
+		exchange("GET", "https://b.test/y/2", 200),
+	}
+	f := LearnTraffic(xs, TrafficOptions{Source: "har"})
+	if f.Domain != "b.test" || !reflect.DeepEqual(endpointKeys(f), []string{"GET /y/{id}"}) {
+		t.Errorf("got %s %v, want b.test [GET /y/{id}]", f.Domain, endpointKeys(f))
+	}
+	f = LearnTraffic(xs, TrafficOptions{Source: "har", Host: "a.test"})
+	if !reflect.DeepEqual(endpointKeys(f), []string{"GET /x"}) {
+		t.Errorf("with Host: %v, want [GET /x]", endpointKeys(f))
+	}
+}
+
+func TestAuthOf(t *testing.T) {
+	tests := []struct {
+		x    Exchange
+		want *AuthHint
+	}{
+		{exchange("GET", "https://a.test/", 200, "Authorization", "Bearer t"), &AuthHint{Type: "bearer"}},
+		{exchange("GET", "https://a.test/", 200, "Authorization", "Basic dTpw"), &AuthHint{Type: "basic"}},
+		{exchange("GET", "https://a.test/", 200, "Authorization", "Token t"), &AuthHint{Type: "header", Name: "Authorization"}},
+		{exchange("GET", "https://a.test/", 200, "X-Api-Key", "k"), &AuthHint{Type: "header", Name: "X-Api-Key"}},
+		{exchange("GET", "https://a.test/?api_key=k", 200), &AuthHint{Type: "query", Name: "api_key"}},
+		{exchange("GET", "https://a.test/", 200, "Cookie", "sid=1"), &AuthHint{Type: "cookie", Name: "Cookie"}},
+		{exchange("GET", "https://a.test/?page=2", 200, "Accept", "application/json"), nil},
+	}
+	for _, tt := range tests {
+		if got := authOf(tt.x); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("authOf(%s %v) = %+v, want %+v", tt.x.URL, tt.x.Header, got, tt.want)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	f := Finding{
+		Endpoints: []Endpoint{{Method: "GET", Path: "/users", Score: 0.5, Evidence: []Evidence{{Source: "heuristic"}}}},
+	}
+	f.Merge(Finding{
+		BaseURLs: []string{"https://api.a.test"},
+		Auth:     &AuthHint{Type: "bearer"},
+		Endpoints: []Endpoint{
+			{Method: "GET", Path: "/users", Score: 0.95, Evidence: []Evidence{{Source: "capture"}}},
+			{Method: "POST", Path: "/users", Score: 0.85, Evidence: []Evidence{{Source: "capture"}}},
+		},
+	})
+	if got := endpointKeys(f); !reflect.DeepEqual(got, []string{"GET /users", "POST /users"}) {
+		t.Fatalf("endpoints = %v", got)
+	}
+	if n := len(f.Endpoints[0].Evidence); n != 2 {
+		t.Errorf("GET /users has %d pieces of evidence, want 2", n)
+	}
+	if len(f.BaseURLs) != 1 || f.Auth == nil {
+		t.Errorf("base URLs %v and auth %v not filled in", f.BaseURLs, f.Auth)
+	}
+}
+
+func mustURL(s string) *url.URL {
+	u, err := url.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
@@ -0,0 +1,115 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/paginate"
+	"github.com/bspippi1337/restless/internal/core/transport"
+	"github.com/bspippi1337/restless/internal/jsonschema"
+)
+
+// verifyEndpoints issues a GET for each concrete GET endpoint against the
+// first base URL, records the outcome as evidence, notes how list
+// endpoints paginate and infers the response schema. At most
+// opt.BudgetPages endpoints are probed; each one is reported against the
+// deadline.
+func verifyEndpoints(ctx context.Context, client *http.Client, find *Finding, opt Options, deadline time.Time) {
+	if len(find.BaseURLs) == 0 {
+		return
+	}
+	base := strings.TrimRight(find.BaseURLs[0], "/")
+	budget := opt.BudgetPages
+	if budget <= 0 {
+		budget = 6
+	}
+	for i := range find.Endpoints {
+		ep := &find.Endpoints[i]
+		if budget == 0 || ctx.Err() != nil {
+			return
+		}
+		if ep.Method != http.MethodGet || strings.Contains(ep.Path, "{") {
+			continue
+		}
+		budget--
+		opt.budgetEvent(deadline, budget)
+
+		u := base + ep.Path
+		rctx, timer := traceContext(ctx, opt.Debug)
+		req, err := http.NewRequestWithContext(rctx, http.MethodGet, u, nil)
+		if err != nil {
+			continue
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := client.Do(req)
+		if err != nil {
+			if opt.Debug {
+				fmt.Fprintf(os.Stderr, "[debug] verify %s: %v\n", u, err)
+			}
+			opt.failed("verify", u, err)
+			continue
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+		_ = resp.Body.Close()
+		recordRateLimit(find, resp.Header)
+		if opt.Debug {
+			fmt.Fprintf(os.Stderr, "[debug] verify %s: %s\n", u, resp.Status)
+		}
+
+		score := 0.55
+		switch {
+		case resp.StatusCode < 300:
+			score = 0.80
+		case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+			score = 0.70 // exists, but needs auth
+		case resp.StatusCode == http.StatusNotFound:
+			score = 0.20
+		}
+		ep.Score = score
+		addEvidence(opt, ep, Evidence{
+			Source: "verify",
+			URL:    u,
+			When:   time.Now().Format(time.RFC3339),
+			Score:  score,
+			Timing: finishTimer(timer),
+		})
+
+		if resp.StatusCode < 300 {
+			var doc any
+			dec := json.NewDecoder(bytes.NewReader(body))
+			dec.UseNumber()
+			if dec.Decode(&doc) == nil {
+				ep.Pagination = paginate.Detect(req.URL, resp.Header, doc)
+				ep.Schema = jsonschema.Refine(ep.Schema, doc)
+			} else if next := paginate.NextLink(resp.Header); next != "" {
+				ep.Pagination = &paginate.Spec{Style: paginate.Link}
+			}
+		}
+	}
+}
+
+// recordRateLimit keeps the most restrictive rate limit seen so far.
+func recordRateLimit(find *Finding, h http.Header) {
+	l, ok := transport.ParseRateLimit(h, time.Now())
+	if !ok || l.Limit <= 0 {
+		return
+	}
+	rl := &RateLimit{
+		Limit:             l.Limit,
+		Remaining:         l.Remaining,
+		WindowSeconds:     int(l.Window.Seconds()),
+		Source:            l.Source,
+		RequestsPerSecond: l.RequestsPerSecond(),
+		ObservedAt:        time.Now().Format(time.RFC3339),
+	}
+	if find.RateLimit == nil || rl.RequestsPerSecond < find.RateLimit.RequestsPerSecond {
+		find.RateLimit = rl
+	}
+}
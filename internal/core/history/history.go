@@ -0,0 +1,297 @@
+// Package history keeps a local, append-only log of the requests restless
+// sends so they can be listed, searched and replayed later.
+//
+// Entries are stored one JSON object per line. Secrets are redacted before
+// anything is written and bodies are truncated, so the file is safe to keep
+// around but not always enough to replay a request byte-for-byte.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/transport"
+)
+
+const (
+	// MaxBody is how much of each request and response body is kept.
+	MaxBody = 8 << 10
+	// MaxEntries is how many entries survive pruning.
+	MaxEntries = 1000
+)
+
+// ErrNotFound is returned by Get for unknown ids.
+var ErrNotFound = errors.New("no such history entry")
+
+type Entry struct {
+	ID      int       `json:"id"`
+	Time    time.Time `json:"time"`
+	Profile string    `json:"profile,omitempty"`
+	// ProfileDir is set when the profile came from a non-default directory.
+	ProfileDir string            `json:"profileDir,omitempty"`
+	Env        string            `json:"env,omitempty"`
+	Method     string            `json:"method"`
+	URL        string            `json:"url"`
+	Path       string            `json:"path,omitempty"` // relative to the profile base URL
+	Proto      string            `json:"proto,omitempty"`
+	Status     int               `json:"status,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Duration   float64           `json:"durationMs"`
+	Timing     *transport.Timing `json:"timing,omitempty"`
+	Request    Message           `json:"request"`
+	// HeaderArgs names the request headers given on the command line, as
+	// opposed to ones filled in from the profile.
+	HeaderArgs []string `json:"headerArgs,omitempty"`
+	Response   *Message `json:"response,omitempty"`
+}
+
+// Message is one side of an exchange. Multi-value headers are joined
+// with ", ".
+type Message struct {
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      string            `json:"body,omitempty"`
+	Size      int64             `json:"size,omitempty"`
+	Truncated bool              `json:"truncated,omitempty"`
+	Binary    bool              `json:"binary,omitempty"`
+}
+
+// Replayable reports whether Body is the complete, unredacted body.
+func (m Message) Replayable() bool {
+	return !m.Truncated && !m.Binary && !strings.Contains(m.Body, "[redacted]")
+}
+
+// Store is a history file.
+type Store struct {
+	Path string
+}
+
+// Open returns the store in dir. Nothing is created until the first Append.
+func Open(dir string) *Store {
+	return &Store{Path: filepath.Join(dir, "history.jsonl")}
+}
+
+// Disabled reports whether RESTLESS_HISTORY turns recording off.
+func Disabled() bool {
+	switch strings.ToLower(os.Getenv("RESTLESS_HISTORY")) {
+	case "off", "0", "false", "no":
+		return true
+	}
+	return false
+}
+
+// Append assigns e the next id and writes it, pruning old entries once the
+// file grows well past MaxEntries. Parallel runs take turns through a lock
+// file, so ids stay unique and no entry is lost.
+func (s *Store) Append(e *Entry) error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return err
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	all, err := s.read()
+	if err != nil {
+		return err
+	}
+	e.ID = 1
+	if len(all) > 0 {
+		e.ID = all[len(all)-1].ID + 1
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if len(all)+1 > MaxEntries+MaxEntries/10 {
+		return s.rewrite(append(all[len(all)+1-MaxEntries:], *e))
+	}
+	f, err := os.OpenFile(s.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Get returns the entry with the given id.
+func (s *Store) Get(id int) (Entry, error) {
+	all, err := s.read()
+	if err != nil {
+		return Entry{}, err
+	}
+	for i := len(all) - 1; i >= 0; i-- {
+		if all[i].ID == id {
+			return all[i], nil
+		}
+	}
+	return Entry{}, fmt.Errorf("%w: %d", ErrNotFound, id)
+}
+
+// Last returns the newest entry.
+func (s *Store) Last() (Entry, error) {
+	all, err := s.read()
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(all) == 0 {
+		return Entry{}, fmt.Errorf("%w: history is empty", ErrNotFound)
+	}
+	return all[len(all)-1], nil
+}
+
+// Query selects entries for List. Zero fields match everything.
+type Query struct {
+	Profile string
+	Env     string
+	Method  string
+	Status  string   // exact code ("404") or class ("2xx")
+	Terms   []string // all must appear in the URL (case-insensitive)
+	Since   time.Time
+	Limit   int
+}
+
+// List returns matching entries, newest first.
+func (s *Store) List(q Query) ([]Entry, error) {
+	all, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	var out []Entry
+	for i := len(all) - 1; i >= 0; i-- {
+		if q.Limit > 0 && len(out) >= q.Limit {
+			break
+		}
+		if q.match(all[i]) {
+			out = append(out, all[i])
+		}
+	}
+	return out, nil
+}
+
+func (q Query) match(e Entry) bool {
+	if q.Profile != "" && e.Profile != q.Profile {
+		return false
+	}
+	if q.Env != "" && e.Env != q.Env {
+		return false
+	}
+	if q.Method != "" && !strings.EqualFold(e.Method, q.Method) {
+		return false
+	}
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if q.Status != "" && !statusMatch(q.Status, e.Status) {
+		return false
+	}
+	u := strings.ToLower(e.URL)
+	for _, t := range q.Terms {
+		if !strings.Contains(u, strings.ToLower(t)) {
+			return false
+		}
+	}
+	return true
+}
+
+func statusMatch(want string, got int) bool {
+	want = strings.ToLower(want)
+	if len(want) == 3 && strings.HasSuffix(want, "xx") {
+		return got/100 == int(want[0]-'0')
+	}
+	if want == "error" {
+		return got == 0
+	}
+	n, err := strconv.Atoi(want)
+	return err == nil && n == got
+}
+
+// Clear removes the history file.
+func (s *Store) Clear() error {
+	err := os.Remove(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// read loads every entry, skipping lines that don't parse (e.g. a write
+// interrupted halfway).
+func (s *Store) read() ([]Entry, error) {
+	b, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []Entry
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	sc.Buffer(make([]byte, 0, 64<<10), 4<<20)
+	for sc.Scan() {
+		var e Entry
+		if json.Unmarshal(sc.Bytes(), &e) == nil && e.ID > 0 {
+			out = append(out, e)
+		}
+	}
+	return out, sc.Err()
+}
+
+const (
+	// lockWait is how long Append waits for another process's write.
+	lockWait = 5 * time.Second
+	// lockStale is the age at which a lock file is taken to be left over
+	// from a process that died mid-write.
+	lockStale = 30 * time.Second
+)
+
+// lock creates the lock file next to the history file, waiting while
+// another process holds it, and returns the func that releases it.
+func (s *Store) lock() (func(), error) {
+	path := s.Path + ".lock"
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) > lockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("history is locked by another restless (remove %s if none is running)", path)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func (s *Store) rewrite(entries []Entry) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i := range entries {
+		if err := enc.Encode(&entries[i]); err != nil {
+			return err
+		}
+	}
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.Path)
+}
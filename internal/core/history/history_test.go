@@ -0,0 +1,113 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentAppend(t *testing.T) {
+	s := Open(t.TempDir())
+	const workers, each = 8, 25
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*each)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < each; i++ {
+				e := &Entry{Method: "GET", URL: fmt.Sprintf("https://a.test/%d/%d", w, i)}
+				if err := s.Append(e); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	all, err := s.read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != workers*each {
+		t.Fatalf("%d entries, want %d", len(all), workers*each)
+	}
+	urls := map[string]bool{}
+	for i, e := range all {
+		if e.ID != i+1 {
+			t.Fatalf("entry %d has id %d, want %d", i, e.ID, i+1)
+		}
+		urls[e.URL] = true
+	}
+	if len(urls) != workers*each {
+		t.Errorf("%d distinct entries, want %d", len(urls), workers*each)
+	}
+	if _, err := os.Stat(s.Path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock file left behind: %v", err)
+	}
+}
+
+func TestAppendPrunes(t *testing.T) {
+	s := Open(t.TempDir())
+	seed := make([]Entry, MaxEntries+MaxEntries/10)
+	for i := range seed {
+		seed[i] = Entry{ID: i + 1, Method: "GET", URL: "https://a.test/"}
+	}
+	if err := s.rewrite(seed); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Append(&Entry{Method: "GET", URL: "https://a.test/"}); err != nil {
+		t.Fatal(err)
+	}
+	all, _ := s.read()
+	if len(all) != MaxEntries || all[0].ID != MaxEntries/10+2 || all[len(all)-1].ID != MaxEntries+MaxEntries/10+1 {
+		t.Errorf("after pruning: %d entries, ids %d..%d", len(all), all[0].ID, all[len(all)-1].ID)
+	}
+}
+
+func TestAppendWaitsForLock(t *testing.T) {
+	s := Open(t.TempDir())
+	if err := s.Append(&Entry{Method: "GET"}); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- s.Append(&Entry{Method: "POST"}) }()
+	select {
+	case err := <-done:
+		t.Fatalf("Append didn't wait for the lock: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := s.Last(); e.ID != 2 || e.Method != "POST" {
+		t.Errorf("last = #%d %s, want #2 POST", e.ID, e.Method)
+	}
+}
+
+func TestStaleLockIsTakenOver(t *testing.T) {
+	s := Open(t.TempDir())
+	if err := s.Append(&Entry{Method: "GET"}); err != nil {
+		t.Fatal(err)
+	}
+	lock := s.Path + ".lock"
+	if err := os.WriteFile(lock, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * lockStale)
+	os.Chtimes(lock, old, old)
+	if err := s.Append(&Entry{Method: "GET"}); err != nil {
+		t.Fatalf("Append with a stale lock: %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package history
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bspippi1337/restless/internal/core/transport"
+)
+
+// Recorder is an http.RoundTripper that appends every exchange to a Store.
+// It sits outside auth and retries, so one entry is one logical request and
+// the auth headers it adds never reach the log.
+type Recorder struct {
+	Base       http.RoundTripper
+	Store      *Store
+	Profile    string
+	ProfileDir string
+	Env        string
+	HeaderArgs []string
+	BaseURL    string    // used to record the path relative to the profile
+	Warn       io.Writer // receives errors writing the history file; may be nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, timer := transport.WithTimer(req.Context())
+	start := time.Now()
+	e := &Entry{
+		Time:       start,
+		Profile:    r.Profile,
+		ProfileDir: r.ProfileDir,
+		Env:        r.Env,
+		HeaderArgs: r.HeaderArgs,
+		Method:     req.Method,
+		URL:        transport.RedactURL(req.URL),
+		Request:    Message{Headers: headers(req.Header)},
+	}
+	if base := strings.TrimRight(r.BaseURL, "/"); base != "" && strings.HasPrefix(e.URL, base+"/") {
+		e.Path = strings.TrimPrefix(e.URL, base)
+	}
+	if req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			b, _ := io.ReadAll(rc)
+			rc.Close()
+			e.Request.setBody(req.Header.Get("Content-Type"), b, int64(len(b)))
+		}
+	}
+
+	resp, err := r.Base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		e.Error = err.Error()
+		e.Duration = ms(time.Since(start))
+		r.save(e)
+		return nil, err
+	}
+	e.Proto, e.Status = resp.Proto, resp.StatusCode
+	c := &capture{ReadCloser: resp.Body}
+	c.done = func() {
+		t := timer.Finish()
+		e.Timing = &t
+		e.Duration = ms(t.Total)
+		m := Message{Headers: headers(resp.Header)}
+		m.setBody(resp.Header.Get("Content-Type"), c.buf.Bytes(), c.n)
+		e.Response = &m
+		r.save(e)
+	}
+	resp.Body = c
+	return resp, nil
+}
+
+func (r *Recorder) save(e *Entry) {
+	if err := r.Store.Append(e); err != nil && r.Warn != nil {
+		fmt.Fprintf(r.Warn, "history: %v\n", err)
+	}
+}
+
+// capture keeps the first MaxBody bytes of a body and records the entry
+// once the body is drained or closed, whichever comes first.
+type capture struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	n    int64
+	once sync.Once
+	done func()
+}
+
+func (c *capture) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	if room := MaxBody + 1 - c.buf.Len(); room > 0 {
+		c.buf.Write(p[:min(n, room)])
+	}
+	c.n += int64(n)
+	if err == io.EOF {
+		c.once.Do(c.done)
+	}
+	return n, err
+}
+
+func (c *capture) Close() error {
+	err := c.ReadCloser.Close()
+	c.once.Do(c.done)
+	return err
+}
+
+func (m *Message) setBody(contentType string, b []byte, size int64) {
+	m.Size = size
+	if len(b) > MaxBody {
+		b, m.Truncated = b[:MaxBody], true
+	}
+	if size > int64(len(b)) {
+		m.Truncated = true
+	}
+	if m.Truncated {
+		// Don't let a rune cut in half make the body look binary.
+		for i := 0; i < utf8.UTFMax-1 && len(b) > 0 && !utf8.Valid(b); i++ {
+			b = b[:len(b)-1]
+		}
+	}
+	if !utf8.Valid(b) || bytes.IndexByte(b, 0) >= 0 {
+		m.Binary = len(b) > 0
+		return
+	}
+	m.Body = string(transport.RedactBody(contentType, b))
+}
+
+func headers(h http.Header) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(h))
+	for k, vs := range h {
+		red := make([]string, len(vs))
+		for i, v := range vs {
+			red[i] = transport.RedactHeader(k, v)
+		}
+		out[k] = strings.Join(red, ", ")
+	}
+	return out
+}
+
+func ms(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
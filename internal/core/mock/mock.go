@@ -0,0 +1,372 @@
+// Package mock serves a fake API from a profile's endpoints and OpenAPI
+// spec: each route answers with the documented example, or a body
+// generated from the response schema, after an optional delay. Errors can
+// be injected at random, and a client can ask for any documented status
+// with the X-Mock-Status header.
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/openapi"
+)
+
+// StatusHeader picks one of a route's documented responses.
+const StatusHeader = "X-Mock-Status"
+
+// Reply is one canned response.
+type Reply struct {
+	Status      int
+	ContentType string
+	Body        any // decoded JSON, or a string sent as is; nil for no body
+}
+
+// Route is one endpoint. Replies[0] is the default answer.
+type Route struct {
+	Method  string
+	Path    string // template, {name} segments match anything
+	ID      string
+	Replies []Reply
+}
+
+// Server answers requests for its routes. It is an http.Handler.
+type Server struct {
+	Routes []Route
+	// Each response is delayed by a random duration in [Latency, MaxLatency].
+	Latency    time.Duration
+	MaxLatency time.Duration
+	// ErrorRate is the fraction (0-1) of requests answered with ErrorStatus
+	// instead of the route's reply.
+	ErrorRate   float64
+	ErrorStatus int
+	// Log, if set, gets one line per request.
+	Log io.Writer
+
+	mu sync.Mutex
+}
+
+// FromSpec builds a route for every operation in spec.
+func FromSpec(spec *openapi.Spec) []Route {
+	var out []Route
+	for _, op := range spec.Operations {
+		rt := Route{Method: op.Method, Path: op.Path, ID: op.ID}
+		for _, r := range op.Responses {
+			code, ok := statusCode(r.Status)
+			if !ok {
+				continue
+			}
+			rt.Replies = append(rt.Replies, reply(code, r))
+		}
+		if len(rt.Replies) == 0 {
+			// Only "default" documented, or nothing at all.
+			if r := op.Response(200); r != nil {
+				rt.Replies = append(rt.Replies, reply(200, *r))
+			}
+		}
+		sort.SliceStable(rt.Replies, func(i, j int) bool { return rank(rt.Replies[i].Status) < rank(rt.Replies[j].Status) })
+		out = append(out, rt)
+	}
+	return out
+}
+
+// Plain is the route for an endpoint the spec doesn't describe: an empty
+// JSON object, or no body for DELETE.
+func Plain(method, path string) Route {
+	r := Reply{Status: http.StatusOK, ContentType: "application/json", Body: map[string]any{}}
+	if strings.EqualFold(method, http.MethodDelete) {
+		r = Reply{Status: http.StatusNoContent}
+	}
+	return Route{Method: strings.ToUpper(method), Path: path, Replies: []Reply{r}}
+}
+
+// Inferred is the route for an endpoint with a response schema learned
+// from traffic: a sample shaped like the responses seen.
+func Inferred(method, path string, schema map[string]any) Route {
+	rt := Plain(method, path)
+	if body := openapi.Sample(schema); body != nil {
+		rt.Replies[0] = Reply{Status: http.StatusOK, ContentType: "application/json", Body: body}
+	}
+	return rt
+}
+
+func reply(code int, r openapi.Response) Reply {
+	out := Reply{Status: code, ContentType: r.ContentType}
+	switch {
+	case code == http.StatusNoContent || code == http.StatusNotModified:
+		out.ContentType = ""
+	case r.Example != nil:
+		out.Body = r.Example
+	case len(r.Schema) > 0:
+		out.Body = openapi.Sample(r.Schema)
+	}
+	if out.Body == nil {
+		out.ContentType = ""
+	}
+	return out
+}
+
+// statusCode reads "200" or "2XX"; "default" isn't a status.
+func statusCode(s string) (int, bool) {
+	if len(s) == 3 && strings.EqualFold(s[1:], "XX") && s[0] >= '1' && s[0] <= '5' {
+		return int(s[0]-'0') * 100, true
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil && n >= 100 && n <= 599
+}
+
+// rank orders 2xx first, then 3xx, then errors.
+func rank(code int) int {
+	if code >= 200 && code < 300 {
+		return code - 1000
+	}
+	return code
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	cors(w, r)
+	rt, params := s.match(r.Method, r.URL.Path)
+	if rt == nil {
+		if r.Method == http.MethodOptions && s.known(r.URL.Path) {
+			w.WriteHeader(http.StatusNoContent)
+			s.logf(r, http.StatusNoContent, "preflight", start)
+			return
+		}
+		status := http.StatusNotFound
+		if s.known(r.URL.Path) {
+			status = http.StatusMethodNotAllowed
+		}
+		writeJSON(w, status, map[string]any{"error": fmt.Sprintf("no mock for %s %s", r.Method, r.URL.Path)})
+		s.logf(r, status, "unmatched", start)
+		return
+	}
+
+	s.sleep()
+	rep, note := Reply{Status: http.StatusOK}, rt.ID
+	if len(rt.Replies) > 0 {
+		rep = rt.Replies[0]
+	}
+	if want := r.Header.Get(StatusHeader); want != "" {
+		if n, err := strconv.Atoi(want); err == nil {
+			rep = rt.reply(n)
+			note = strings.TrimSpace(note + " (requested)")
+		}
+	} else if s.inject() {
+		rep = rt.reply(s.ErrorStatus)
+		note = strings.TrimSpace(note + " (injected)")
+	}
+
+	body := fill(rep.Body, params)
+	switch {
+	case body == nil || r.Method == http.MethodHead:
+		w.WriteHeader(rep.Status)
+	case isJSON(rep.ContentType) || rep.ContentType == "":
+		w.Header().Set("Content-Type", firstNonEmpty(rep.ContentType, "application/json"))
+		writeJSON(w, rep.Status, body)
+	default:
+		w.Header().Set("Content-Type", rep.ContentType)
+		w.WriteHeader(rep.Status)
+		_, _ = io.WriteString(w, openapi.Text(body))
+	}
+	s.logf(r, rep.Status, note, start)
+}
+
+// reply returns the documented reply for code. Errors documented without
+// a body get a generic one.
+func (rt *Route) reply(code int) Reply {
+	for _, r := range rt.Replies {
+		if r.Status == code && (r.Body != nil || code < 400) {
+			return r
+		}
+	}
+	for _, r := range rt.Replies {
+		if r.Status == code/100*100 && (r.Body != nil || code < 400) {
+			r.Status = code
+			return r
+		}
+	}
+	if code < 400 {
+		return Reply{Status: code}
+	}
+	return Reply{Status: code, ContentType: "application/json", Body: map[string]any{
+		"error":  http.StatusText(code),
+		"status": code,
+	}}
+}
+
+// match picks the route with the most literal segments matching path, so
+// /items/search wins over /items/{id}.
+func (s *Server) match(method, path string) (*Route, map[string]string) {
+	var best *Route
+	var bestParams map[string]string
+	bestScore := -1
+	for i := range s.Routes {
+		rt := &s.Routes[i]
+		if !strings.EqualFold(rt.Method, method) && !(method == http.MethodHead && rt.Method == http.MethodGet) {
+			continue
+		}
+		params, score, ok := matchPath(rt.Path, path)
+		if ok && score > bestScore {
+			best, bestParams, bestScore = rt, params, score
+		}
+	}
+	return best, bestParams
+}
+
+// known reports whether any route has path, whatever the method.
+func (s *Server) known(path string) bool {
+	for _, rt := range s.Routes {
+		if _, _, ok := matchPath(rt.Path, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPath(tmpl, path string) (map[string]string, int, bool) {
+	have, want := split(tmpl), split(path)
+	if len(have) != len(want) {
+		return nil, 0, false
+	}
+	params := map[string]string{}
+	score := 0
+	for i, seg := range have {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			params[seg[1:len(seg)-1]] = want[i]
+			continue
+		}
+		if seg != want[i] {
+			return nil, 0, false
+		}
+		score++
+	}
+	return params, score, true
+}
+
+func split(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// fill copies path parameter values into top-level fields of the same name,
+// so GET /items/7 answers with "id": 7.
+func fill(body any, params map[string]string) any {
+	m, ok := body.(map[string]any)
+	if !ok || len(params) == 0 {
+		return body
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	for name, val := range params {
+		old, ok := out[name]
+		if !ok {
+			continue
+		}
+		switch old.(type) {
+		case float64, int, int64, json.Number:
+			if n, err := strconv.ParseFloat(val, 64); err == nil {
+				out[name] = n
+			}
+		case string:
+			out[name] = val
+		}
+	}
+	return out
+}
+
+func (s *Server) sleep() {
+	d := s.Latency
+	if s.MaxLatency > s.Latency {
+		s.mu.Lock()
+		d += time.Duration(rand.Int63n(int64(s.MaxLatency - s.Latency)))
+		s.mu.Unlock()
+	}
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
+
+func (s *Server) inject() bool {
+	if s.ErrorRate <= 0 {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return rand.Float64() < s.ErrorRate
+}
+
+func (s *Server) logf(r *http.Request, status int, note string, start time.Time) {
+	if s.Log == nil {
+		return
+	}
+	target := r.URL.Path
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if note != "" {
+		note = " " + note
+	}
+	fmt.Fprintf(s.Log, "%s %-6s %s → %d%s %dms\n", start.Format("15:04:05"), r.Method, target, status, note, time.Since(start).Milliseconds())
+}
+
+// cors lets browser frontends on another origin call the mock.
+func cors(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	if origin := r.Header.Get("Origin"); origin != "" {
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Set("Access-Control-Allow-Credentials", "true")
+	} else {
+		h.Set("Access-Control-Allow-Origin", "*")
+	}
+	h.Set("Access-Control-Expose-Headers", "*")
+	h.Add("Vary", "Origin")
+	if r.Method == http.MethodOptions {
+		h.Set("Access-Control-Allow-Methods", firstNonEmpty(r.Header.Get("Access-Control-Request-Method"), "GET, POST, PUT, PATCH, DELETE"))
+		if hdrs := r.Header.Get("Access-Control-Request-Headers"); hdrs != "" {
+			h.Set("Access-Control-Allow-Headers", hdrs)
+		}
+		h.Set("Access-Control-Max-Age", "600")
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func isJSON(ct string) bool {
+	mt, _, _ := strings.Cut(strings.ToLower(ct), ";")
+	mt = strings.TrimSpace(mt)
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
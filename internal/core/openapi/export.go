@@ -0,0 +1,417 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/bspippi1337/restless/internal/core/profile"
+	"github.com/bspippi1337/restless/internal/core/transport"
+	"github.com/bspippi1337/restless/internal/jsonschema"
+)
+
+// Document is an OpenAPI 3.1 document built from a profile. Fields are in
+// the order readers expect; everything below the top level is plain maps.
+type Document struct {
+	OpenAPI    string                    `json:"openapi"`
+	Info       Info                      `json:"info"`
+	Servers    []map[string]string       `json:"servers,omitempty"`
+	Security   []map[string][]string     `json:"security,omitempty"`
+	Paths      map[string]map[string]any `json:"paths"`
+	Components map[string]any            `json:"components,omitempty"`
+	// RateLimit is the limit discovery observed (x-restless-rate-limit).
+	RateLimit map[string]any `json:"x-restless-rate-limit,omitempty"`
+}
+
+type Info struct {
+	Title       string  `json:"title"`
+	Version     string  `json:"version"`
+	Description string  `json:"description,omitempty"`
+	Confidence  float64 `json:"x-restless-confidence"`
+	SpecURL     string  `json:"x-restless-spec-url,omitempty"`
+}
+
+var pathParam = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// FromProfile describes a profile's endpoints as OpenAPI 3.1. Parameters
+// come from {name} path segments, the collection entries that call each
+// endpoint (query keys, example values, bodies) and pagination; the score
+// and evidence of each endpoint are kept as x-restless-* extensions.
+// Secret values are never written.
+func FromProfile(p *profile.Profile) *Document {
+	doc := &Document{
+		OpenAPI: "3.1.0",
+		Info: Info{
+			Title:       firstNonEmpty(p.Name, "restless profile"),
+			Version:     "0.0.0",
+			Description: "Generated by restless from the profile's discovery results.",
+			Confidence:  p.Confidence,
+			SpecURL:     p.SpecURL,
+		},
+		Paths: map[string]map[string]any{},
+	}
+	for _, u := range p.BaseURLs {
+		doc.Servers = append(doc.Servers, map[string]string{"url": u})
+	}
+	envs := make([]string, 0, len(p.Environments))
+	for name := range p.Environments {
+		envs = append(envs, name)
+	}
+	sort.Strings(envs)
+	for _, name := range envs {
+		if u := p.Environments[name].BaseURL; u != "" {
+			doc.Servers = append(doc.Servers, map[string]string{"url": u, "description": name})
+		}
+	}
+
+	schemes, security := exportSecurity(p)
+	if len(schemes) > 0 {
+		doc.Components = map[string]any{"securitySchemes": schemes}
+		doc.Security = security
+	}
+	if rl := p.RateLimit; rl.Limit > 0 || rl.RequestsPerSecond > 0 {
+		doc.RateLimit = map[string]any{"limit": rl.Limit, "requestsPerSecond": rl.RequestsPerSecond}
+		if rl.WindowSeconds > 0 {
+			doc.RateLimit["windowSeconds"] = rl.WindowSeconds
+		}
+		if rl.Source != "" {
+			doc.RateLimit["source"] = rl.Source
+		}
+	}
+
+	// Collection entries are the only place parameter names and example
+	// values come from; attach each to the endpoint it calls.
+	calls := map[*profile.Endpoint][]profile.Request{}
+	for _, r := range p.Collection {
+		if ep := p.Match(r.Method, r.Path); ep != nil {
+			// Unfilled variables stay as ${name} and are skipped as examples.
+			filled, _ := p.WithVars(r).Render(nil)
+			calls[ep] = append(calls[ep], filled)
+		}
+	}
+	ids := map[string]bool{}
+	for i := range p.Endpoints {
+		ep := &p.Endpoints[i]
+		item := doc.Paths[ep.Path]
+		if item == nil {
+			item = map[string]any{}
+			doc.Paths[ep.Path] = item
+		}
+		item[strings.ToLower(ep.Method)] = exportOperation(ep, calls[ep], ids)
+	}
+	return doc
+}
+
+func exportOperation(ep *profile.Endpoint, calls []profile.Request, ids map[string]bool) map[string]any {
+	op := map[string]any{}
+	id := ""
+	var tags []string
+	for _, r := range calls {
+		if id == "" && r.Name != "" {
+			id = camel(r.Name)
+		}
+		if op["summary"] == nil && r.Description != "" {
+			op["summary"] = r.Description
+		}
+		for _, t := range r.Tags {
+			if !contains(tags, t) {
+				tags = append(tags, t)
+			}
+		}
+	}
+	if id == "" {
+		id = operationID(ep.Method, ep.Path)
+	}
+	for base, n := id, 2; ids[id]; n++ {
+		id = fmt.Sprintf("%s%d", base, n)
+	}
+	ids[id] = true
+	op["operationId"] = id
+	if len(tags) > 0 {
+		op["tags"] = tags
+	}
+
+	var params []map[string]any
+	for _, m := range pathParam.FindAllStringSubmatch(ep.Path, -1) {
+		name := m[1]
+		example := ""
+		for _, r := range calls {
+			if v := r.Vars[name]; v != "" && !strings.Contains(v, "${") {
+				example = v
+				break
+			}
+		}
+		params = append(params, param(name, "path", true, example))
+	}
+	query := map[string]string{}
+	for _, r := range calls {
+		for k, v := range r.Query {
+			if _, ok := query[k]; !ok {
+				query[k] = v
+			}
+		}
+	}
+	if pg := ep.Pagination; pg != nil && pg.Param != "" {
+		if _, ok := query[pg.Param]; !ok {
+			query[pg.Param] = ""
+		}
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := query[k]
+		if strings.Contains(v, "${") || transport.IsSecret(k) {
+			v = "" // a variable or credential, not an example
+		}
+		params = append(params, param(k, "query", false, v))
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	for _, r := range calls {
+		if body := exportBody(r); body != nil {
+			op["requestBody"] = body
+			break
+		}
+	}
+	ok := map[string]any{"description": "Success"}
+	if ep.Schema != nil {
+		// Inferred from the responses restless has seen.
+		ok["content"] = map[string]any{
+			"application/json": map[string]any{"schema": jsonschema.Public(ep.Schema)},
+		}
+	}
+	op["responses"] = map[string]any{"2XX": ok}
+
+	op["x-restless-score"] = ep.Score
+	if len(ep.Evidence) > 0 {
+		var evs []map[string]any
+		for _, ev := range ep.Evidence {
+			e := map[string]any{"source": ev.Source, "score": ev.Score}
+			if ev.URL != "" {
+				e["url"] = ev.URL
+			}
+			if ev.When != "" {
+				e["when"] = ev.When
+			}
+			if len(ev.TimingMs) > 0 {
+				e["timingMs"] = ev.TimingMs
+			}
+			evs = append(evs, e)
+		}
+		op["x-restless-evidence"] = evs
+	}
+	if pg := ep.Pagination; pg != nil {
+		op["x-restless-pagination"] = pg
+	}
+	return op
+}
+
+func param(name, in string, required bool, example string) map[string]any {
+	schema := scalarSchema(example)
+	p := map[string]any{"name": name, "in": in, "schema": schema}
+	if required {
+		p["required"] = true
+	}
+	if example != "" {
+		var typed any = example
+		if schema["type"] != "string" {
+			_ = json.Unmarshal([]byte(example), &typed)
+		}
+		p["example"] = typed
+	}
+	return p
+}
+
+var (
+	integerText = regexp.MustCompile(`^-?\d+$`)
+	numberText  = regexp.MustCompile(`^-?\d+\.\d+$`)
+	uuidText    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// scalarSchema guesses a parameter schema from one example value.
+func scalarSchema(v string) map[string]any {
+	switch {
+	case integerText.MatchString(v):
+		return map[string]any{"type": "integer"}
+	case numberText.MatchString(v):
+		return map[string]any{"type": "number"}
+	case v == "true" || v == "false":
+		return map[string]any{"type": "boolean"}
+	case uuidText.MatchString(v):
+		return map[string]any{"type": "string", "format": "uuid"}
+	}
+	return map[string]any{"type": "string"}
+}
+
+// exportBody describes an entry's body; JSON bodies keep their example
+// unless it still holds ${variables}.
+func exportBody(r profile.Request) map[string]any {
+	if strings.TrimSpace(r.Body) == "" {
+		return nil
+	}
+	ct := ""
+	for k, v := range r.Headers {
+		if strings.EqualFold(k, "Content-Type") {
+			ct, _, _ = strings.Cut(v, ";")
+			ct = strings.TrimSpace(ct)
+		}
+	}
+	var example any
+	isJSON := json.Unmarshal([]byte(r.Body), &example) == nil
+	if ct == "" {
+		ct = "text/plain"
+		if isJSON {
+			ct = "application/json"
+		}
+	}
+	media := map[string]any{}
+	switch {
+	case isJSON:
+		media["schema"] = map[string]any{"type": jsonType(example)}
+		if !strings.Contains(r.Body, "${") {
+			media["example"] = example
+		}
+	default:
+		media["schema"] = map[string]any{"type": "string"}
+	}
+	return map[string]any{"required": true, "content": map[string]any{ct: media}}
+}
+
+func jsonType(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
+
+// exportSecurity maps the auth block, and credential headers read from the
+// environment by defaults.headers, to security schemes.
+func exportSecurity(p *profile.Profile) (map[string]any, []map[string][]string) {
+	schemes := map[string]any{}
+	var security []map[string][]string
+	add := func(name string, scheme map[string]any, scopes []string) {
+		if scopes == nil {
+			scopes = []string{}
+		}
+		schemes[name] = scheme
+		security = append(security, map[string][]string{name: scopes})
+	}
+	switch a := p.Auth; a.Type {
+	case "bearer":
+		add("bearerAuth", map[string]any{"type": "http", "scheme": "bearer"}, nil)
+	case "oauth2":
+		o := a.OAuth2
+		scopes := map[string]string{}
+		for _, s := range o.Scopes {
+			scopes[s] = ""
+		}
+		flows := map[string]any{}
+		scheme := map[string]any{"type": "oauth2", "flows": flows, "x-restless-grant": firstNonEmpty(o.Grant, "client_credentials")}
+		switch o.Grant {
+		case "", "client_credentials":
+			flows["clientCredentials"] = map[string]any{"tokenUrl": o.TokenURL, "scopes": scopes}
+		default:
+			// OpenAPI has no flow for the device or refresh-token grants.
+			scheme["x-restless-token-url"] = o.TokenURL
+			if o.DeviceAuthURL != "" {
+				scheme["x-restless-device-authorization-url"] = o.DeviceAuthURL
+			}
+		}
+		add("oauth2", scheme, o.Scopes)
+	}
+	keys := make([]string, 0, len(p.Defaults.Headers))
+	for k := range p.Defaults.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := p.Defaults.Headers[k]
+		if !strings.Contains(v, "${ENV:") {
+			continue
+		}
+		switch {
+		case strings.EqualFold(k, "Authorization") && strings.HasPrefix(strings.ToLower(v), "basic "):
+			add("basicAuth", map[string]any{"type": "http", "scheme": "basic"}, nil)
+		case strings.EqualFold(k, "Authorization") && strings.HasPrefix(strings.ToLower(v), "bearer "):
+			if _, ok := schemes["bearerAuth"]; !ok {
+				add("bearerAuth", map[string]any{"type": "http", "scheme": "bearer"}, nil)
+			}
+		case strings.EqualFold(k, "Cookie"):
+			if name, _, ok := strings.Cut(v, "="); ok && !strings.Contains(name, "${") {
+				add("cookieAuth", map[string]any{"type": "apiKey", "in": "cookie", "name": strings.TrimSpace(name)}, nil)
+			}
+		case transport.IsSecret(k) || strings.HasPrefix(v, "${ENV:"):
+			add(camel(k), map[string]any{"type": "apiKey", "in": "header", "name": k}, nil)
+		}
+	}
+	return schemes, security
+}
+
+// operationID names an operation without a collection entry:
+// GET /users/{id}/repos -> getUsersByIdRepos.
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, seg := range strings.Split(strings.Trim(path, "/"), "/") {
+		if m := pathParam.FindStringSubmatch(seg); m != nil && m[0] == seg {
+			b.WriteString("By")
+			seg = m[1]
+		}
+		b.WriteString(upperFirst(camel(seg)))
+	}
+	return b.String()
+}
+
+// camel turns "get-order", "X-Api-Key" or "order_id" into getOrder,
+// xApiKey and orderId.
+func camel(s string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = b.Len() > 0
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		} else if b.Len() == 0 {
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func upperFirst(s string) string {
+	for i, r := range s {
+		return string(unicode.ToUpper(r)) + s[i+len(string(r)):]
+	}
+	return s
+}
+
+func contains(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,513 @@
+// Package openapi reads OpenAPI 3.x and Swagger 2.0 documents (JSON) into
+// the flat list of operations restless works with. Schemas are kept as
+// plain JSON Schema maps with local $refs resolved. FromProfile goes the
+// other way and describes a profile as OpenAPI 3.1.
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+type Spec struct {
+	Version    string // "3.1.0", "3.0.3", "2.0", ...
+	Title      string
+	APIVersion string
+	Servers    []string
+	Operations []Operation
+	Security   map[string]SecurityScheme
+}
+
+type Operation struct {
+	Method    string
+	Path      string
+	ID        string
+	Summary   string
+	Tags      []string
+	Params    []Param
+	Body      *Body
+	Responses []Response
+}
+
+type Param struct {
+	Name     string
+	In       string // path, query, header or cookie
+	Required bool
+	Schema   Schema
+}
+
+type Body struct {
+	ContentType string
+	Required    bool
+	Schema      Schema
+}
+
+type Response struct {
+	Status      string // "200", "4XX" or "default"
+	ContentType string
+	Schema      Schema
+	Example     any // documented example body, if any
+}
+
+// SecurityScheme is a components.securitySchemes (or securityDefinitions) entry.
+type SecurityScheme struct {
+	Type         string // http, apiKey, oauth2, openIdConnect (basic for Swagger 2)
+	Scheme       string // bearer, basic
+	In           string // header, query, cookie (apiKey)
+	Name         string // header or parameter name (apiKey)
+	TokenURL     string
+	AuthURL      string
+	Scopes       []string
+	OpenIDConfig string
+}
+
+// Schema is a JSON Schema object.
+type Schema map[string]any
+
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Parse decodes a JSON OpenAPI 3.x or Swagger 2.0 document. base is the URL
+// the document was fetched from and resolves relative server URLs; it may
+// be empty.
+func Parse(data []byte, base string) (*Spec, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	r := &resolver{doc: doc}
+	s := &Spec{Security: map[string]SecurityScheme{}}
+	info, _ := doc["info"].(map[string]any)
+	s.Title, s.APIVersion = str(info["title"]), str(info["version"])
+
+	swagger2 := false
+	switch {
+	case str(doc["openapi"]) != "":
+		s.Version = str(doc["openapi"])
+	case str(doc["swagger"]) != "":
+		s.Version, swagger2 = str(doc["swagger"]), true
+	default:
+		return nil, errors.New("openapi: not an OpenAPI or Swagger document")
+	}
+	paths, ok := doc["paths"].(map[string]any)
+	if !ok {
+		return nil, errors.New("openapi: document has no paths")
+	}
+
+	if swagger2 {
+		s.Servers = swaggerServers(doc, base)
+	} else {
+		for _, sv := range list(doc["servers"]) {
+			if u := str(obj(sv)["url"]); u != "" {
+				s.Servers = append(s.Servers, absolute(u, base))
+			}
+		}
+	}
+	s.Security = securitySchemes(doc, swagger2)
+
+	keys := make([]string, 0, len(paths))
+	for k := range paths {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, p := range keys {
+		item := obj(r.deref(paths[p]))
+		shared := list(item["parameters"])
+		for _, m := range methods {
+			raw, ok := item[m].(map[string]any)
+			if !ok {
+				continue
+			}
+			op := Operation{
+				Method:  strings.ToUpper(m),
+				Path:    p,
+				ID:      str(raw["operationId"]),
+				Summary: str(raw["summary"]),
+			}
+			for _, t := range list(raw["tags"]) {
+				op.Tags = append(op.Tags, str(t))
+			}
+			consumes := firstString(list(raw["consumes"]), list(doc["consumes"]), "application/json")
+			produces := firstString(list(raw["produces"]), list(doc["produces"]), "application/json")
+			params := mergeParams(r, shared, list(raw["parameters"]))
+			for _, pr := range params {
+				in := str(pr["in"])
+				switch {
+				case swagger2 && in == "body":
+					op.Body = &Body{ContentType: consumes, Required: boolean(pr["required"]), Schema: r.schema(pr["schema"])}
+				case swagger2 && in == "formData":
+					if op.Body == nil {
+						op.Body = &Body{ContentType: "application/x-www-form-urlencoded", Schema: Schema{"type": "object", "properties": map[string]any{}}}
+					}
+					if props, ok := op.Body.Schema["properties"].(map[string]any); ok {
+						props[str(pr["name"])] = map[string]any(swaggerParamSchema(pr))
+					}
+				default:
+					sch := r.schema(pr["schema"])
+					if swagger2 {
+						sch = swaggerParamSchema(pr)
+					}
+					op.Params = append(op.Params, Param{
+						Name:     str(pr["name"]),
+						In:       in,
+						Required: boolean(pr["required"]) || in == "path",
+						Schema:   sch,
+					})
+				}
+			}
+			if rb := obj(r.deref(raw["requestBody"])); len(rb) > 0 {
+				if ct, media := pickContent(obj(rb["content"])); ct != "" {
+					op.Body = &Body{ContentType: ct, Required: boolean(rb["required"]), Schema: r.schema(media["schema"])}
+				}
+			}
+			op.Responses = responses(r, obj(raw["responses"]), swagger2, produces)
+			s.Operations = append(s.Operations, op)
+		}
+	}
+	return s, nil
+}
+
+// Find returns the operation for method and a templated path.
+func (s *Spec) Find(method, path string) *Operation {
+	for i := range s.Operations {
+		if strings.EqualFold(s.Operations[i].Method, method) && s.Operations[i].Path == path {
+			return &s.Operations[i]
+		}
+	}
+	return nil
+}
+
+// Response returns the documented response for a status code, falling
+// back to its class (2XX) and then to "default".
+func (op *Operation) Response(status int) *Response {
+	code := fmt.Sprint(status)
+	class := code[:1] + "XX"
+	for _, want := range []string{code, class, "default"} {
+		for i := range op.Responses {
+			if strings.EqualFold(op.Responses[i].Status, want) {
+				return &op.Responses[i]
+			}
+		}
+	}
+	return nil
+}
+
+func responses(r *resolver, raw map[string]any, swagger2 bool, produces string) []Response {
+	codes := make([]string, 0, len(raw))
+	for k := range raw {
+		codes = append(codes, k)
+	}
+	sort.Strings(codes)
+	var out []Response
+	for _, code := range codes {
+		resp := obj(r.deref(raw[code]))
+		rs := Response{Status: code}
+		if swagger2 {
+			if sch, ok := resp["schema"]; ok {
+				rs.ContentType, rs.Schema = produces, r.schema(sch)
+			}
+			if ex, ok := obj(resp["examples"])[produces]; ok {
+				rs.Example = ex
+			}
+		} else if ct, media := pickContent(obj(resp["content"])); ct != "" {
+			rs.ContentType, rs.Schema = ct, r.schema(media["schema"])
+			rs.Example = mediaExample(r, media)
+		}
+		out = append(out, rs)
+	}
+	return out
+}
+
+// mediaExample returns a media type's example, or the value of the first
+// of its named examples.
+func mediaExample(r *resolver, media map[string]any) any {
+	if ex, ok := media["example"]; ok {
+		return ex
+	}
+	examples := obj(media["examples"])
+	names := make([]string, 0, len(examples))
+	for k := range examples {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if v, ok := obj(r.deref(examples[k]))["value"]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// mergeParams applies operation parameters over path-level ones (matched
+// by name and location).
+func mergeParams(r *resolver, shared, own []any) []map[string]any {
+	var out []map[string]any
+	index := map[string]int{}
+	for _, group := range [][]any{shared, own} {
+		for _, p := range group {
+			m := obj(r.deref(p))
+			if len(m) == 0 {
+				continue
+			}
+			k := str(m["in"]) + ":" + str(m["name"])
+			if i, ok := index[k]; ok {
+				out[i] = m
+				continue
+			}
+			index[k] = len(out)
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// pickContent prefers JSON media types.
+func pickContent(content map[string]any) (string, map[string]any) {
+	if len(content) == 0 {
+		return "", nil
+	}
+	types := make([]string, 0, len(content))
+	for k := range content {
+		types = append(types, k)
+	}
+	sort.Strings(types)
+	best := types[0]
+	for _, t := range types {
+		if t == "application/json" || strings.HasSuffix(t, "+json") {
+			best = t
+			break
+		}
+	}
+	return best, obj(content[best])
+}
+
+func swaggerParamSchema(p map[string]any) Schema {
+	s := Schema{}
+	for _, k := range []string{"type", "format", "enum", "default", "items", "minimum", "maximum", "pattern"} {
+		if v, ok := p[k]; ok {
+			s[k] = v
+		}
+	}
+	return s
+}
+
+func swaggerServers(doc map[string]any, base string) []string {
+	host := str(doc["host"])
+	if host == "" {
+		if u, err := url.Parse(base); err == nil {
+			host = u.Host
+		}
+	}
+	if host == "" {
+		return nil
+	}
+	schemes := list(doc["schemes"])
+	if len(schemes) == 0 {
+		schemes = []any{"https"}
+	}
+	var out []string
+	for _, sc := range schemes {
+		out = append(out, str(sc)+"://"+host+strings.TrimRight(str(doc["basePath"]), "/"))
+	}
+	return out
+}
+
+func securitySchemes(doc map[string]any, swagger2 bool) map[string]SecurityScheme {
+	var raw map[string]any
+	if swagger2 {
+		raw = obj(doc["securityDefinitions"])
+	} else {
+		raw = obj(obj(doc["components"])["securitySchemes"])
+	}
+	out := map[string]SecurityScheme{}
+	for name, v := range raw {
+		m := obj(v)
+		ss := SecurityScheme{
+			Type:         str(m["type"]),
+			Scheme:       strings.ToLower(str(m["scheme"])),
+			In:           str(m["in"]),
+			Name:         str(m["name"]),
+			TokenURL:     str(m["tokenUrl"]),
+			AuthURL:      str(m["authorizationUrl"]),
+			OpenIDConfig: str(m["openIdConnectUrl"]),
+		}
+		scopes := obj(m["scopes"])
+		for _, f := range obj(m["flows"]) {
+			fm := obj(f)
+			ss.TokenURL = firstNonEmpty(ss.TokenURL, str(fm["tokenUrl"]))
+			ss.AuthURL = firstNonEmpty(ss.AuthURL, str(fm["authorizationUrl"]))
+			for k, v := range obj(fm["scopes"]) {
+				scopes[k] = v
+			}
+		}
+		for k := range scopes {
+			ss.Scopes = append(ss.Scopes, k)
+		}
+		sort.Strings(ss.Scopes)
+		out[name] = ss
+	}
+	return out
+}
+
+// resolver inlines local "#/..." references, leaving cyclic ones in place.
+type resolver struct {
+	doc   map[string]any
+	stack []string
+}
+
+// deref follows a single $ref (for parameters, bodies and responses).
+func (r *resolver) deref(v any) any {
+	for i := 0; i < 8; i++ {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return v
+		}
+		v = r.lookup(ref)
+	}
+	return v
+}
+
+func (r *resolver) schema(v any) Schema {
+	out, _ := r.inline(v).(map[string]any)
+	return out
+}
+
+func (r *resolver) inline(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		if ref, ok := t["$ref"].(string); ok {
+			for _, s := range r.stack {
+				if s == ref {
+					return map[string]any{"$ref": ref}
+				}
+			}
+			if len(r.stack) > 32 {
+				return map[string]any{}
+			}
+			r.stack = append(r.stack, ref)
+			out := r.inline(r.lookup(ref))
+			r.stack = r.stack[:len(r.stack)-1]
+			// Keep the component name so generated code can name the type.
+			if m, ok := out.(map[string]any); ok && m["title"] == nil {
+				if i := strings.LastIndex(ref, "/"); strings.HasPrefix(ref, "#/components/schemas/") || strings.HasPrefix(ref, "#/definitions/") {
+					m["title"] = ref[i+1:]
+				}
+			}
+			if m, ok := out.(map[string]any); ok && len(t) > 1 {
+				// 3.1 allows siblings next to $ref (description, nullable, ...).
+				merged := make(map[string]any, len(m)+len(t))
+				for k, v := range m {
+					merged[k] = v
+				}
+				for k, v := range t {
+					if k != "$ref" {
+						merged[k] = r.inline(v)
+					}
+				}
+				return merged
+			}
+			return out
+		}
+		out := make(map[string]any, len(t))
+		for k, x := range t {
+			out[k] = r.inline(x)
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, x := range t {
+			out[i] = r.inline(x)
+		}
+		return out
+	}
+	return v
+}
+
+func (r *resolver) lookup(ref string) any {
+	if !strings.HasPrefix(ref, "#/") {
+		return map[string]any{} // remote refs aren't fetched
+	}
+	var cur any = r.doc
+	for _, part := range strings.Split(ref[2:], "/") {
+		part = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+		if p, err := url.PathUnescape(part); err == nil {
+			part = p
+		}
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return map[string]any{}
+		}
+		cur = m[part]
+	}
+	if cur == nil {
+		return map[string]any{}
+	}
+	return cur
+}
+
+func absolute(u, base string) string {
+	if base == "" {
+		return u
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return u
+	}
+	ref, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	return strings.TrimRight(b.ResolveReference(ref).String(), "/")
+}
+
+func obj(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+func list(v any) []any {
+	l, _ := v.([]any)
+	return l
+}
+
+func str(v any) string {
+	s, _ := v.(string)
+	return s
+}
+
+func boolean(v any) bool {
+	b, _ := v.(bool)
+	return b
+}
+
+func firstString(lists ...any) string {
+	for _, l := range lists {
+		switch t := l.(type) {
+		case []any:
+			if len(t) > 0 {
+				if s := str(t[0]); s != "" {
+					return s
+				}
+			}
+		case string:
+			return t
+		}
+	}
+	return ""
+}
+
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,149 @@
+package openapi
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Sample builds an example value for a schema: example, default, const or
+// the first enum value when given, otherwise a placeholder of the right
+// type and format.
+func Sample(s Schema) any { return sample(s, 0) }
+
+func sample(s map[string]any, depth int) any {
+	if s == nil || depth > 8 {
+		return nil
+	}
+	for _, k := range []string{"example", "default", "const"} {
+		if v, ok := s[k]; ok {
+			return v
+		}
+	}
+	if ex := list(s["examples"]); len(ex) > 0 {
+		return ex[0]
+	}
+	if enum := list(s["enum"]); len(enum) > 0 {
+		return enum[0]
+	}
+	if all := list(s["allOf"]); len(all) > 0 {
+		merged := map[string]any{}
+		for _, part := range all {
+			if m, ok := sample(obj(part), depth+1).(map[string]any); ok {
+				for k, v := range m {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+	for _, k := range []string{"oneOf", "anyOf"} {
+		if alts := list(s[k]); len(alts) > 0 {
+			return sample(obj(alts[0]), depth+1)
+		}
+	}
+
+	switch Type(s) {
+	case "object":
+		props := obj(s["properties"])
+		out := map[string]any{}
+		names := make([]string, 0, len(props))
+		for k := range props {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			p := obj(props[k])
+			if boolean(p["readOnly"]) {
+				continue
+			}
+			out[k] = sample(p, depth+1)
+		}
+		return out
+	case "array":
+		if items := obj(s["items"]); items != nil {
+			return []any{sample(items, depth+1)}
+		}
+		return []any{}
+	case "integer":
+		return 0
+	case "number":
+		return 0.0
+	case "boolean":
+		return false
+	case "null":
+		return nil
+	case "string":
+		switch str(s["format"]) {
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "date":
+			return "2024-01-01"
+		case "time":
+			return "00:00:00"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "email":
+			return "user@example.com"
+		case "uri", "url":
+			return "https://example.com"
+		case "hostname":
+			return "example.com"
+		case "ipv4":
+			return "192.0.2.1"
+		case "byte":
+			return "c3RyaW5n"
+		}
+		return "string"
+	}
+	return nil
+}
+
+// Example returns a value worth sending for a parameter as text: only an
+// explicit example, default, const or enum value, never a type placeholder.
+func Example(s Schema) (string, bool) {
+	for _, k := range []string{"example", "default", "const"} {
+		if v, ok := s[k]; ok {
+			return Text(v), true
+		}
+	}
+	if ex := list(s["examples"]); len(ex) > 0 {
+		return Text(ex[0]), true
+	}
+	if enum := list(s["enum"]); len(enum) > 0 {
+		return Text(enum[0]), true
+	}
+	return "", false
+}
+
+// Text renders a sample value for a URL or header: strings as-is,
+// anything else as JSON.
+func Text(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// Type returns the schema's type, picking the first non-null type from a
+// 3.1 type list and inferring object/array from properties/items.
+func Type(s map[string]any) string {
+	switch t := s["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, x := range t {
+			if v := str(x); v != "null" {
+				return v
+			}
+		}
+		return "null"
+	}
+	switch {
+	case s["properties"] != nil:
+		return "object"
+	case s["items"] != nil:
+		return "array"
+	}
+	return ""
+}
@@ -0,0 +1,309 @@
+// Package paginate detects and follows the common pagination styles of list
+// endpoints: RFC 8288 Link headers, next-URL and cursor fields in JSON
+// bodies, and page/offset query parameters.
+package paginate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type Style string
+
+const (
+	Link    Style = "link"     // Link: <...>; rel="next"
+	NextURL Style = "next-url" // body field holding the next page URL
+	Cursor  Style = "cursor"   // body field holding a token for Param
+	Page    Style = "page"     // Param is a 1-based page number
+	Offset  Style = "offset"   // Param is an item offset
+)
+
+// Spec describes how an endpoint paginates. It is stored per endpoint in
+// profiles so later runs don't need to guess.
+type Spec struct {
+	Style Style  `json:"style"`
+	Field string `json:"field,omitempty"` // dotted path of the next URL or cursor
+	Param string `json:"param,omitempty"` // query parameter carrying cursor/page/offset
+	Items string `json:"items,omitempty"` // dotted path of the item array ("" = auto)
+}
+
+var (
+	nextURLFields = []string{"next", "next_url", "nextUrl", "next_page_url", "nextPageUrl", "links.next", "_links.next.href", "paging.next", "pagination.next", "meta.next"}
+	cursorFields  = []string{"next_cursor", "nextCursor", "next_page_token", "nextPageToken", "next_token", "nextToken", "cursor", "continuation", "meta.next_cursor", "meta.nextCursor", "pagination.next_cursor", "pagination.cursor", "response_metadata.next_cursor", "pageInfo.endCursor", "page_info.end_cursor"}
+	itemFields    = []string{"data", "items", "results", "records", "entries", "values", "objects", "nodes", "edges", "list"}
+	pageParams    = []string{"page", "page_number", "pageNumber", "p"}
+	offsetParams  = []string{"offset", "skip", "start"}
+	cursorParams  = []string{"cursor", "page_token", "pageToken", "after", "next_token", "continuation", "starting_after"}
+)
+
+// cursorParamFor maps a cursor field to the query parameter servers usually expect.
+func cursorParamFor(field string) string {
+	switch last := field[strings.LastIndex(field, ".")+1:]; last {
+	case "nextPageToken":
+		return "pageToken"
+	case "next_page_token":
+		return "page_token"
+	case "next_token", "nextToken":
+		return last
+	case "endCursor", "end_cursor":
+		return "after"
+	case "continuation":
+		return "continuation"
+	}
+	return "cursor"
+}
+
+// Detect inspects a first response and returns how to fetch the next page,
+// or nil if the endpoint doesn't look paginated. body is a decoded JSON value
+// (nil if the response wasn't JSON).
+func Detect(reqURL *url.URL, h http.Header, body any) *Spec {
+	if NextLink(h) != "" {
+		return &Spec{Style: Link, Items: itemsPath(body)}
+	}
+	q := reqURL.Query()
+	if obj, ok := body.(map[string]any); ok {
+		for _, f := range nextURLFields {
+			if s, ok := lookup(obj, f).(string); ok && looksLikeURL(s) {
+				return &Spec{Style: NextURL, Field: f, Items: itemsPath(body)}
+			}
+		}
+		for _, f := range cursorFields {
+			if s, ok := lookup(obj, f).(string); ok && s != "" {
+				param := cursorParamFor(f)
+				for _, p := range cursorParams {
+					if q.Has(p) {
+						param = p
+						break
+					}
+				}
+				return &Spec{Style: Cursor, Field: f, Param: param, Items: itemsPath(body)}
+			}
+		}
+	}
+	for _, p := range pageParams {
+		if q.Has(p) {
+			return &Spec{Style: Page, Param: p, Items: itemsPath(body)}
+		}
+	}
+	for _, p := range offsetParams {
+		if q.Has(p) {
+			return &Spec{Style: Offset, Param: p, Items: itemsPath(body)}
+		}
+	}
+	if obj, ok := body.(map[string]any); ok {
+		for _, f := range []string{"total_pages", "totalPages", "last_page", "lastPage", "page_count", "pageCount"} {
+			if _, ok := number(lookup(obj, f)); ok {
+				return &Spec{Style: Page, Param: "page", Items: itemsPath(body)}
+			}
+		}
+	}
+	return nil
+}
+
+// Next returns the URL of the page after cur, or nil when there is none.
+// items is the number of items the current page held.
+func (s *Spec) Next(cur *url.URL, h http.Header, body any, items int) *url.URL {
+	switch s.Style {
+	case Link:
+		if next := NextLink(h); next != "" {
+			return resolve(cur, next)
+		}
+	case NextURL:
+		if v, ok := lookup(body, s.Field).(string); ok && v != "" {
+			return resolve(cur, v)
+		}
+	case Cursor:
+		v, ok := lookup(body, s.Field).(string)
+		if !ok || v == "" || hasNoMore(body, s.Field) {
+			return nil
+		}
+		return withParam(cur, s.Param, v)
+	case Page:
+		if items == 0 {
+			return nil
+		}
+		page := 1
+		if n, err := strconv.Atoi(cur.Query().Get(s.Param)); err == nil {
+			page = n
+		}
+		if obj, ok := body.(map[string]any); ok {
+			for _, f := range []string{"total_pages", "totalPages", "last_page", "lastPage", "page_count", "pageCount"} {
+				if last, ok := number(lookup(obj, f)); ok && float64(page) >= last {
+					return nil
+				}
+			}
+		}
+		return withParam(cur, s.Param, strconv.Itoa(page+1))
+	case Offset:
+		if items == 0 {
+			return nil
+		}
+		q := cur.Query()
+		off, _ := strconv.Atoi(q.Get(s.Param))
+		for _, lp := range []string{"limit", "per_page", "page_size", "pageSize", "count", "size", "take"} {
+			if lim, err := strconv.Atoi(q.Get(lp)); err == nil && lim > 0 && items < lim {
+				return nil // short page: this was the last one
+			}
+		}
+		return withParam(cur, s.Param, strconv.Itoa(off+items))
+	}
+	return nil
+}
+
+// Extract returns the list of items from a page. ok is false when the page
+// holds no recognizable array.
+func (s *Spec) Extract(body any) ([]any, bool) {
+	if arr, ok := body.([]any); ok {
+		return arr, true
+	}
+	path := ""
+	if s != nil {
+		path = s.Items
+	}
+	if path == "" {
+		path = itemsPath(body)
+	}
+	if path == "" {
+		return nil, false
+	}
+	arr, ok := lookup(body, path).([]any)
+	return arr, ok
+}
+
+// NextLink returns the rel="next" target of a Link header, if any.
+func NextLink(h http.Header) string {
+	for _, v := range h.Values("Link") {
+		for _, part := range splitLinks(v) {
+			start, end := strings.IndexByte(part, '<'), strings.IndexByte(part, '>')
+			if start < 0 || end < start {
+				continue
+			}
+			for _, param := range strings.Split(part[end+1:], ";") {
+				k, val, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(k), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(val), `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return part[start+1 : end]
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// splitLinks splits a Link header on commas that aren't inside <...>.
+func splitLinks(v string) []string {
+	var out []string
+	depth, start := 0, 0
+	for i, c := range v {
+		switch c {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case ',':
+			if depth == 0 {
+				out = append(out, v[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(out, v[start:])
+}
+
+// itemsPath guesses which field of an object holds the page's items.
+func itemsPath(body any) string {
+	obj, ok := body.(map[string]any)
+	if !ok {
+		return ""
+	}
+	for _, f := range itemFields {
+		if _, ok := obj[f].([]any); ok {
+			return f
+		}
+	}
+	// Fall back to the only array-valued field, if there is exactly one.
+	found := ""
+	for k, v := range obj {
+		if _, ok := v.([]any); ok {
+			if found != "" {
+				return ""
+			}
+			found = k
+		}
+	}
+	return found
+}
+
+// hasNoMore reports an explicit "no more pages" flag next to the cursor.
+func hasNoMore(body any, field string) bool {
+	parent := ""
+	if i := strings.LastIndex(field, "."); i >= 0 {
+		parent = field[:i]
+	}
+	for _, f := range []string{"has_more", "hasMore", "hasNextPage", "has_next_page", "more"} {
+		p := f
+		if parent != "" {
+			p = parent + "." + f
+		}
+		if b, ok := lookup(body, p).(bool); ok {
+			return !b
+		}
+		if b, ok := lookup(body, f).(bool); ok {
+			return !b
+		}
+	}
+	return false
+}
+
+func lookup(v any, path string) any {
+	if path == "" {
+		return v
+	}
+	for _, part := range strings.Split(path, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = obj[part]
+	}
+	return v
+}
+
+func number(v any) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func looksLikeURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") ||
+		strings.HasPrefix(s, "/") || strings.HasPrefix(s, "?")
+}
+
+func resolve(cur *url.URL, ref string) *url.URL {
+	u, err := cur.Parse(ref)
+	if err != nil {
+		return nil
+	}
+	return u
+}
+
+func withParam(cur *url.URL, key, val string) *url.URL {
+	u := *cur
+	q := u.Query()
+	q.Set(key, val)
+	u.RawQuery = q.Encode()
+	return &u
+}
@@ -0,0 +1,495 @@
+package postman
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/bspippi1337/restless/internal/core/profile"
+	"github.com/bspippi1337/restless/internal/core/transport"
+)
+
+// Imported is what a collection contributes to a profile.
+type Imported struct {
+	Name    string
+	BaseURL string
+	Vars    map[string]string
+	// Auth is the collection's auth, or else the one most requests use.
+	Auth     *Auth
+	Requests []profile.Request
+	// Warnings lists what couldn't be carried over.
+	Warnings []string
+}
+
+var (
+	postmanVar  = regexp.MustCompile(`\{\{([A-Za-z_][A-Za-z0-9_.-]*)\}\}`)
+	restlessVar = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+	envVar      = regexp.MustCompile(`\$\{ENV:([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// Import converts the collection. Secret values (auth attributes, secret
+// variables and headers) are never copied; headers that carried one read
+// it from a RESTLESS_* environment variable instead.
+func (c *Collection) Import() Imported {
+	im := Imported{Name: c.Info.Name, Vars: map[string]string{}}
+	for _, v := range c.Variable {
+		if v.Disabled || v.Key == "" {
+			continue
+		}
+		if transport.IsSecret(v.Key) || v.Type == "secret" {
+			if v.String() == "" {
+				continue
+			}
+			im.Warnings = append(im.Warnings, fmt.Sprintf("variable %s holds a secret; not imported (pass it with --var)", v.Key))
+			continue
+		}
+		im.Vars[v.Key] = toRestless(v.String())
+	}
+
+	var reqs []walked
+	walk(c.Item, nil, c.Auth, &reqs)
+	baseVar, base := pickBase(c.Variable, reqs)
+	if baseVar != "" {
+		delete(im.Vars, baseVar)
+	}
+	im.BaseURL = base
+
+	taken := map[string]bool{}
+	name := func(s string) string {
+		n := slug(s)
+		if n == "" {
+			n = "request"
+		}
+		for base, i := n, 2; taken[n]; i++ {
+			n = fmt.Sprintf("%s-%d", base, i)
+		}
+		taken[n] = true
+		return n
+	}
+	authUse := map[string]int{}
+	for _, w := range reqs {
+		r, err := convert(w.req, baseVar, base)
+		if err != nil {
+			im.Warnings = append(im.Warnings, fmt.Sprintf("%s: %v", w.item.Name, err))
+			continue
+		}
+		r.Name = name(w.item.Name)
+		r.Description = firstLine(string(firstNonEmpty(w.item.Description, w.req.Description)))
+		r.Tags = w.tags
+		im.Requests = append(im.Requests, r)
+		if w.auth != nil {
+			authUse[w.auth.Type]++
+			if im.Auth == nil || authUse[w.auth.Type] > authUse[im.Auth.Type] {
+				im.Auth = w.auth
+			}
+		}
+		for _, ex := range w.item.Response {
+			if ex.OriginalRequest == nil {
+				continue
+			}
+			er, err := convert(*ex.OriginalRequest, baseVar, base)
+			if err != nil {
+				continue
+			}
+			er.Name = name(w.item.Name + " " + ex.Name)
+			er.Description = "Example: " + firstNonEmpty(Text(ex.Name), "saved response").String()
+			er.Tags = w.tags
+			im.Requests = append(im.Requests, er)
+		}
+	}
+	if c.Auth != nil {
+		im.Auth = c.Auth
+	}
+	return im
+}
+
+func (t Text) String() string { return string(t) }
+
+type walked struct {
+	item Item
+	req  Request
+	tags []string
+	auth *Auth
+}
+
+// walk flattens folders, carrying folder names as tags and inherited auth.
+func walk(items []Item, tags []string, auth *Auth, out *[]walked) {
+	for _, it := range items {
+		a := auth
+		if it.Auth != nil {
+			a = it.Auth
+		}
+		if it.IsFolder() {
+			walk(it.Item, append(append([]string{}, tags...), it.Name), a, out)
+			continue
+		}
+		if it.Request == nil {
+			continue
+		}
+		if it.Request.Auth != nil {
+			a = it.Request.Auth
+		}
+		*out = append(*out, walked{item: it, req: *it.Request, tags: tags, auth: a})
+	}
+}
+
+// pickBase finds the base URL: a {{variable}} most request URLs start with,
+// else the most common scheme://host of absolute URLs.
+func pickBase(vars []Variable, reqs []walked) (string, string) {
+	values := map[string]string{}
+	for _, v := range vars {
+		values[v.Key] = v.String()
+	}
+	prefix := map[string]int{}
+	for _, w := range reqs {
+		raw := rawURL(w.req.URL)
+		if m := postmanVar.FindStringSubmatchIndex(raw); m != nil && m[0] == 0 {
+			prefix["{{"+raw[m[2]:m[3]]+"}}"]++
+		} else if u, err := url.Parse(toRestless(raw)); err == nil && u.Host != "" {
+			prefix[u.Scheme+"://"+u.Host]++
+		}
+	}
+	best := ""
+	for p, n := range prefix {
+		if best == "" || n > prefix[best] || (n == prefix[best] && p < best) {
+			best = p
+		}
+	}
+	if name, ok := strings.CutPrefix(best, "{{"); ok {
+		name = strings.TrimSuffix(name, "}}")
+		return name, strings.TrimSuffix(values[name], "/")
+	}
+	return "", best
+}
+
+func rawURL(u URL) string {
+	if u.Raw != "" {
+		return u.Raw
+	}
+	s := strings.Join(u.Host, ".")
+	if u.Protocol != "" {
+		s = u.Protocol + "://" + s
+	}
+	if len(u.Path) > 0 {
+		s += "/" + strings.Join(u.Path, "/")
+	}
+	return s
+}
+
+func convert(pr Request, baseVar, base string) (profile.Request, error) {
+	r := profile.Request{
+		Method:  strings.ToUpper(firstNonEmpty(Text(pr.Method), "GET").String()),
+		Query:   map[string]string{},
+		Headers: map[string]string{},
+		Vars:    map[string]string{},
+	}
+	raw := rawURL(pr.URL)
+	raw, _, _ = strings.Cut(raw, "#")
+	raw, rawQuery, _ := strings.Cut(raw, "?")
+	switch {
+	case baseVar != "" && strings.HasPrefix(raw, "{{"+baseVar+"}}"):
+		raw = strings.TrimPrefix(raw, "{{"+baseVar+"}}")
+	case base != "" && strings.HasPrefix(raw, base):
+		raw = strings.TrimPrefix(raw, base)
+	default:
+		if u, err := url.Parse(toRestless(raw)); err == nil && u.Host != "" {
+			return r, fmt.Errorf("URL %s is not under the base URL %s; skipped", raw, firstNonEmpty(Text(base), "(none)"))
+		}
+	}
+	segs := strings.Split(strings.Trim(raw, "/"), "/")
+	for i, s := range segs {
+		if strings.HasPrefix(s, ":") && len(s) > 1 {
+			segs[i] = "{" + s[1:] + "}"
+		}
+	}
+	r.Path = toRestless("/" + strings.Join(segs, "/"))
+	for _, v := range pr.URL.Variable {
+		if v.Key != "" && v.Value != "" {
+			r.Vars[v.Key] = toRestless(v.Value)
+		}
+	}
+
+	if pr.URL.Query != nil {
+		for _, q := range pr.URL.Query {
+			if !q.Disabled && q.Key != "" {
+				r.Query[q.Key] = secretValue(q.Key, q.Value)
+			}
+		}
+	} else if rawQuery != "" {
+		for _, kv := range strings.Split(rawQuery, "&") {
+			k, v, _ := strings.Cut(kv, "=")
+			if k != "" {
+				r.Query[k] = secretValue(k, v)
+			}
+		}
+	}
+	for _, h := range pr.Header {
+		if h.Disabled || h.Key == "" || strings.EqualFold(h.Key, "Authorization") {
+			continue // auth comes from the profile
+		}
+		r.Headers[h.Key] = secretValue(h.Key, h.Value)
+	}
+	if b := pr.Body; b != nil {
+		r.Body = body(b, r.Headers)
+	}
+	return r, nil
+}
+
+// secretValue keeps variable references and replaces literal secrets with
+// an environment reference.
+func secretValue(name, v string) string {
+	if !transport.IsSecret(name) || v == "" || postmanVar.MatchString(v) {
+		return toRestless(v)
+	}
+	return "${ENV:RESTLESS_" + envName(name) + "}"
+}
+
+func body(b *Body, headers map[string]string) string {
+	setType := func(ct string) {
+		for k := range headers {
+			if strings.EqualFold(k, "Content-Type") {
+				return
+			}
+		}
+		headers["Content-Type"] = ct
+	}
+	switch b.Mode {
+	case "raw":
+		if b.Options != nil && b.Options.Raw.Language == "json" {
+			setType("application/json")
+		}
+		return toRestless(b.Raw)
+	case "urlencoded":
+		var parts []string
+		for _, kv := range b.URLEncoded {
+			if !kv.Disabled {
+				parts = append(parts, url.QueryEscape(kv.Key)+"="+toRestless(kv.Value))
+			}
+		}
+		setType("application/x-www-form-urlencoded")
+		return strings.Join(parts, "&")
+	case "graphql":
+		if b.GraphQL == nil {
+			return ""
+		}
+		doc := map[string]any{"query": b.GraphQL.Query}
+		if v := strings.TrimSpace(b.GraphQL.Variables); v != "" {
+			doc["variables"] = json.RawMessage(v)
+		}
+		out, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return ""
+		}
+		setType("application/json")
+		return toRestless(string(out))
+	}
+	return "" // formdata and file bodies can't be expressed in a profile
+}
+
+// Export builds a collection from a profile: collection entries grouped
+// into folders by their first tag, then any endpoint no entry covers.
+func Export(p *profile.Profile) *Collection {
+	c := &Collection{Info: Info{Name: p.Name, Schema: Schema}}
+	c.Variable = append(c.Variable, Variable{Key: "baseUrl", Value: firstOr(p.BaseURLs), Type: "string"})
+	for _, k := range sortedKeys(p.Vars) {
+		c.Variable = append(c.Variable, Variable{Key: k, Value: toPostman(p.Vars[k]), Type: "string"})
+	}
+	auth, authVars := exportAuth(p.Auth)
+	c.Auth = auth
+	for _, k := range authVars {
+		c.Variable = append(c.Variable, Variable{Key: k, Value: "", Type: "secret"})
+	}
+
+	folders := map[string]int{}
+	add := func(tag string, it Item) {
+		if tag == "" {
+			c.Item = append(c.Item, it)
+			return
+		}
+		i, ok := folders[tag]
+		if !ok {
+			i = len(c.Item)
+			folders[tag] = i
+			c.Item = append(c.Item, Item{Name: tag, Item: []Item{}})
+		}
+		c.Item[i].Item = append(c.Item[i].Item, it)
+	}
+	covered := map[string]bool{}
+	for _, r := range p.Collection {
+		covered[r.Method+" "+r.Path] = true
+		tag := ""
+		if len(r.Tags) > 0 {
+			tag = r.Tags[0]
+		}
+		add(tag, Item{Name: r.Name, Description: Text(r.Description), Request: exportRequest(p, p.WithVars(r))})
+	}
+	folder := ""
+	if len(p.Collection) > 0 {
+		folder = "Endpoints"
+	}
+	for _, ep := range p.Endpoints {
+		if covered[ep.Method+" "+ep.Path] {
+			continue
+		}
+		r := profile.Request{Method: ep.Method, Path: ep.Path}
+		add(folder, Item{Name: ep.Method + " " + ep.Path, Request: exportRequest(p, r)})
+	}
+	if c.Item == nil {
+		c.Item = []Item{}
+	}
+	return c
+}
+
+func exportRequest(p *profile.Profile, r profile.Request) *Request {
+	out := &Request{Method: r.Method, Header: []KV{}}
+	var segs []string
+	for _, s := range strings.Split(strings.Trim(r.Path, "/"), "/") {
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			name := s[1 : len(s)-1]
+			out.URL.Variable = append(out.URL.Variable, KV{Key: name, Value: toPostman(r.Vars[name])})
+			s = ":" + name
+		}
+		segs = append(segs, toPostman(s))
+	}
+	out.URL.Host = []string{"{{baseUrl}}"}
+	out.URL.Path = segs
+	raw := "{{baseUrl}}/" + strings.Join(segs, "/")
+	var q []string
+	for _, k := range sortedKeys(r.Query) {
+		v := toPostman(r.Query[k])
+		out.URL.Query = append(out.URL.Query, KV{Key: k, Value: v})
+		q = append(q, k+"="+v)
+	}
+	if len(q) > 0 {
+		raw += "?" + strings.Join(q, "&")
+	}
+	out.URL.Raw = raw
+
+	headers := map[string]string{}
+	for k, v := range p.Defaults.Headers {
+		// Postman sends its own Accept and User-Agent.
+		if !strings.EqualFold(k, "User-Agent") && !strings.EqualFold(k, "Accept") {
+			headers[k] = v
+		}
+	}
+	for k, v := range r.Headers {
+		headers[k] = v
+	}
+	for _, k := range sortedKeys(headers) {
+		out.Header = append(out.Header, KV{Key: k, Value: toPostman(headers[k]), Type: "text"})
+	}
+	if r.Body != "" {
+		out.Body = &Body{Mode: "raw", Raw: toPostman(strings.TrimRight(r.Body, "\n"))}
+		if json.Valid([]byte(r.Body)) || strings.HasPrefix(strings.TrimSpace(r.Body), "{") {
+			out.Body.Options = &struct {
+				Raw struct {
+					Language string `json:"language"`
+				} `json:"raw"`
+			}{}
+			out.Body.Options.Raw.Language = "json"
+		}
+	}
+	return out
+}
+
+// exportAuth maps the profile auth block; the returned variables hold the
+// credentials and are left empty.
+func exportAuth(a profile.Auth) (*Auth, []string) {
+	str := func(k, v string) Attr { return Attr{Key: k, Value: v, Type: "string"} }
+	switch a.Type {
+	case "bearer":
+		return &Auth{Type: "bearer", Bearer: []Attr{str("token", "{{token}}")}}, []string{"token"}
+	case "oauth2":
+		o := a.OAuth2
+		grant := o.Grant
+		if grant == "" || grant == "device_code" {
+			grant = "client_credentials"
+		}
+		attrs := []Attr{
+			str("grant_type", grant),
+			str("accessTokenUrl", o.TokenURL),
+			str("clientId", "{{clientId}}"),
+			str("clientSecret", "{{clientSecret}}"),
+			str("addTokenTo", "header"),
+		}
+		if len(o.Scopes) > 0 {
+			attrs = append(attrs, str("scope", strings.Join(o.Scopes, " ")))
+		}
+		if o.Audience != "" {
+			attrs = append(attrs, Attr{Key: "tokenRequestParams", Value: []map[string]string{{"key": "audience", "value": o.Audience}}})
+		}
+		return &Auth{Type: "oauth2", OAuth2: attrs}, []string{"clientId", "clientSecret"}
+	case "none":
+		return &Auth{Type: "noauth"}, nil
+	}
+	return nil, nil
+}
+
+// toRestless rewrites {{name}} references as ${name}; {{RESTLESS_*}}, as
+// written by Export, goes back to an environment reference.
+func toRestless(s string) string {
+	return postmanVar.ReplaceAllStringFunc(s, func(m string) string {
+		name := m[2 : len(m)-2]
+		if strings.HasPrefix(name, "RESTLESS_") {
+			return "${ENV:" + name + "}"
+		}
+		return "${" + name + "}"
+	})
+}
+
+// toPostman rewrites ${name} and ${ENV:NAME} references as {{name}}.
+func toPostman(s string) string {
+	s = envVar.ReplaceAllString(s, "{{$1}}")
+	return restlessVar.ReplaceAllString(s, "{{$1}}")
+}
+
+func envName(s string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", " ", "_").Replace(s))
+}
+
+// slug makes a collection entry name: "Get user (admin)" -> "get-user-admin".
+func slug(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		} else if b.Len() > 0 && !strings.HasSuffix(b.String(), "-") {
+			b.WriteByte('-')
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+func firstLine(s string) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(s)
+}
+
+func firstNonEmpty(ts ...Text) Text {
+	for _, t := range ts {
+		if t != "" {
+			return t
+		}
+	}
+	return ""
+}
+
+func firstOr(ss []string) string {
+	if len(ss) > 0 {
+		return ss[0]
+	}
+	return ""
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,258 @@
+// Package postman reads and writes Postman Collection v2.1 documents and
+// maps them to profile collection entries: folders become tags,
+// collection variables become profile vars, and saved examples become
+// entries of their own.
+package postman
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Schema is the v2.1 schema URL; v2.0 documents read the same way.
+const Schema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+type Collection struct {
+	Info     Info       `json:"info"`
+	Item     []Item     `json:"item"`
+	Auth     *Auth      `json:"auth,omitempty"`
+	Variable []Variable `json:"variable,omitempty"`
+}
+
+type Info struct {
+	PostmanID   string `json:"_postman_id,omitempty"`
+	Name        string `json:"name"`
+	Description Text   `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// Item is a request, or a folder when Item is set.
+type Item struct {
+	Name        string     `json:"name"`
+	Description Text       `json:"description,omitempty"`
+	Item        []Item     `json:"item,omitempty"`
+	Auth        *Auth      `json:"auth,omitempty"`
+	Request     *Request   `json:"request,omitempty"`
+	Response    []Response `json:"response,omitempty"`
+}
+
+// IsFolder reports whether the item groups other items.
+func (it Item) IsFolder() bool { return it.Request == nil && it.Item != nil }
+
+type Request struct {
+	Method      string `json:"method"`
+	Header      []KV   `json:"header"`
+	URL         URL    `json:"url"`
+	Body        *Body  `json:"body,omitempty"`
+	Auth        *Auth  `json:"auth,omitempty"`
+	Description Text   `json:"description,omitempty"`
+}
+
+// UnmarshalJSON accepts the short form, where a request is just its URL.
+func (r *Request) UnmarshalJSON(b []byte) error {
+	var s string
+	if json.Unmarshal(b, &s) == nil {
+		*r = Request{Method: "GET", URL: URL{Raw: s}}
+		return nil
+	}
+	type plain Request
+	return json.Unmarshal(b, (*plain)(r))
+}
+
+// URL is Postman's URL object; a plain string decodes into Raw.
+type URL struct {
+	Raw      string   `json:"raw"`
+	Protocol string   `json:"protocol,omitempty"`
+	Host     []string `json:"host,omitempty"`
+	Path     []string `json:"path,omitempty"`
+	Query    []KV     `json:"query,omitempty"`
+	Variable []KV     `json:"variable,omitempty"`
+}
+
+func (u *URL) UnmarshalJSON(b []byte) error {
+	var s string
+	if json.Unmarshal(b, &s) == nil {
+		*u = URL{Raw: s}
+		return nil
+	}
+	var raw struct {
+		Raw      string          `json:"raw"`
+		Protocol string          `json:"protocol"`
+		Host     json.RawMessage `json:"host"`
+		Path     json.RawMessage `json:"path"`
+		Query    []KV            `json:"query"`
+		Variable []KV            `json:"variable"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*u = URL{Raw: raw.Raw, Protocol: raw.Protocol, Query: raw.Query, Variable: raw.Variable}
+	u.Host = segments(raw.Host, ".")
+	u.Path = segments(raw.Path, "/")
+	return nil
+}
+
+// segments reads a host or path given as a list or as one string. Path
+// segments may also be {type, value} objects.
+func segments(b json.RawMessage, sep string) []string {
+	if len(b) == 0 {
+		return nil
+	}
+	var s string
+	if json.Unmarshal(b, &s) == nil {
+		return strings.Split(strings.Trim(s, sep), sep)
+	}
+	var list []any
+	_ = json.Unmarshal(b, &list)
+	var out []string
+	for _, it := range list {
+		switch v := it.(type) {
+		case string:
+			out = append(out, v)
+		case map[string]any:
+			if s, ok := v["value"].(string); ok {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+// KV is a header, query parameter, path variable or form field.
+type KV struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Disabled    bool   `json:"disabled,omitempty"`
+	Type        string `json:"type,omitempty"` // form fields: text or file
+	Description Text   `json:"description,omitempty"`
+}
+
+type Body struct {
+	Mode       string   `json:"mode"` // raw, urlencoded, formdata, graphql, file
+	Raw        string   `json:"raw,omitempty"`
+	URLEncoded []KV     `json:"urlencoded,omitempty"`
+	FormData   []KV     `json:"formdata,omitempty"`
+	GraphQL    *GraphQL `json:"graphql,omitempty"`
+	Options    *struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options,omitempty"`
+}
+
+type GraphQL struct {
+	Query     string `json:"query"`
+	Variables string `json:"variables,omitempty"`
+}
+
+// Auth is a request, folder or collection auth block. Only the attributes
+// of Type are set.
+type Auth struct {
+	Type   string `json:"type"` // noauth, bearer, basic, apikey, oauth2, ...
+	Bearer []Attr `json:"bearer,omitempty"`
+	Basic  []Attr `json:"basic,omitempty"`
+	APIKey []Attr `json:"apikey,omitempty"`
+	OAuth2 []Attr `json:"oauth2,omitempty"`
+}
+
+// Attr is one auth attribute; values are usually strings.
+type Attr struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+	Type  string `json:"type,omitempty"`
+}
+
+// Get returns the string value of the named attribute of the auth type.
+func (a *Auth) Get(key string) string {
+	if a == nil {
+		return ""
+	}
+	var attrs []Attr
+	switch a.Type {
+	case "bearer":
+		attrs = a.Bearer
+	case "basic":
+		attrs = a.Basic
+	case "apikey":
+		attrs = a.APIKey
+	case "oauth2":
+		attrs = a.OAuth2
+	}
+	for _, at := range attrs {
+		if at.Key == key {
+			if s, ok := at.Value.(string); ok {
+				return s
+			}
+			if at.Value != nil {
+				return fmt.Sprint(at.Value)
+			}
+		}
+	}
+	return ""
+}
+
+// Response is a saved example.
+type Response struct {
+	Name            string   `json:"name"`
+	OriginalRequest *Request `json:"originalRequest,omitempty"`
+	Status          string   `json:"status,omitempty"`
+	Code            int      `json:"code,omitempty"`
+	Header          []KV     `json:"header,omitempty"`
+	Body            string   `json:"body,omitempty"`
+}
+
+type Variable struct {
+	Key      string `json:"key"`
+	Value    any    `json:"value"`
+	Type     string `json:"type,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// String returns the variable's value as text.
+func (v Variable) String() string {
+	switch x := v.Value.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	default:
+		b, _ := json.Marshal(x)
+		return string(b)
+	}
+}
+
+// Text is a description, given either as a string or as {content, type}.
+type Text string
+
+func (t *Text) UnmarshalJSON(b []byte) error {
+	var s string
+	if json.Unmarshal(b, &s) == nil {
+		*t = Text(s)
+		return nil
+	}
+	var obj struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return err
+	}
+	*t = Text(obj.Content)
+	return nil
+}
+
+// Parse decodes a collection, rejecting v1 exports and other JSON.
+func Parse(b []byte) (*Collection, error) {
+	var c Collection
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("not a Postman collection: %w", err)
+	}
+	if c.Info.Schema == "" && c.Item == nil {
+		return nil, errors.New("not a Postman collection (no info.schema or item)")
+	}
+	if c.Info.Schema != "" && !strings.Contains(c.Info.Schema, "/v2.") {
+		return nil, fmt.Errorf("unsupported Postman schema %s (export as Collection v2.1)", c.Info.Schema)
+	}
+	return &c, nil
+}
@@ -0,0 +1,156 @@
+package profile
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Request is a named request from the profile's `collection:` block.
+// Path, query, header and body values may reference variables as ${name};
+// {name} path segments are filled from the same variables.
+type Request struct {
+	Name        string
+	Description string
+	Method      string
+	Path        string
+	Query       map[string]string
+	Headers     map[string]string
+	Body        string
+	Vars        map[string]string // defaults, overridden by --var
+	Tags        []string          // grouping, e.g. Postman folders
+}
+
+// WithVars returns r with the profile's `vars:` under its own defaults.
+func (p *Profile) WithVars(r Request) Request {
+	if len(p.Vars) == 0 {
+		return r
+	}
+	all := map[string]string{}
+	for k, v := range p.Vars {
+		all[k] = v
+	}
+	for k, v := range r.Vars {
+		all[k] = v
+	}
+	r.Vars = all
+	return r
+}
+
+// Request returns the collection entry called name.
+func (p *Profile) Request(name string) *Request {
+	for i := range p.Collection {
+		if p.Collection[i].Name == name {
+			return &p.Collection[i]
+		}
+	}
+	return nil
+}
+
+var varRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_.-]*)\}|\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+
+// Render substitutes variables (vars over the entry's defaults) and
+// reports every variable that has no value. ${ENV:NAME} is left for Expand.
+func (r Request) Render(vars map[string]string) (Request, error) {
+	all := map[string]string{}
+	for k, v := range r.Vars {
+		all[k] = v
+	}
+	for k, v := range vars {
+		all[k] = v
+	}
+	missing := map[string]bool{}
+	sub := func(s string, braces bool) string {
+		return varRef.ReplaceAllStringFunc(s, func(m string) string {
+			g := varRef.FindStringSubmatch(m)
+			name := g[1]
+			if name == "" {
+				if !braces {
+					return m // bare {x} is only a variable in paths
+				}
+				name = g[2]
+			}
+			if v, ok := all[name]; ok {
+				return v
+			}
+			missing[name] = true
+			return m
+		})
+	}
+	out := r
+	out.Path = sub(r.Path, true)
+	out.Body = sub(r.Body, false)
+	out.Query = map[string]string{}
+	for k, v := range r.Query {
+		out.Query[k] = sub(v, false)
+	}
+	out.Headers = map[string]string{}
+	for k, v := range r.Headers {
+		out.Headers[k] = sub(v, false)
+	}
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for n := range missing {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		err := fmt.Errorf("no value for %s (pass --var name=value)", strings.Join(names, ", "))
+		if r.Name != "" {
+			err = fmt.Errorf("%s: %w", r.Name, err)
+		}
+		return out, err
+	}
+	return out, nil
+}
+
+func decodeCollection(doc map[string]any) []Request {
+	var out []Request
+	for _, it := range list(doc, "collection") {
+		m, ok := it.(map[string]any)
+		if !ok {
+			continue
+		}
+		out = append(out, Request{
+			Name:        str(m, "name"),
+			Description: str(m, "description"),
+			Method:      strings.ToUpper(str(m, "method")),
+			Path:        str(m, "path"),
+			Query:       strMap(mapOf(m, "query")),
+			Headers:     strMap(mapOf(m, "headers")),
+			Body:        str(m, "body"),
+			Vars:        strMap(mapOf(m, "vars")),
+			Tags:        strList(m, "tags"),
+		})
+	}
+	// Profiles from before collections kept one `examples:` entry.
+	for _, it := range list(doc, "examples") {
+		m, ok := it.(map[string]any)
+		if !ok {
+			continue
+		}
+		req := mapOf(m, "request")
+		out = append(out, Request{
+			Name:    str(m, "name"),
+			Method:  strings.ToUpper(str(req, "method")),
+			Path:    str(req, "path"),
+			Headers: strMap(mapOf(req, "headers")),
+		})
+	}
+	for i := range out {
+		if out[i].Method == "" {
+			out[i].Method = "GET"
+		}
+	}
+	return out
+}
+
+func strMap(m map[string]any) map[string]string {
+	out := map[string]string{}
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			out[k] = s
+		}
+	}
+	return out
+}
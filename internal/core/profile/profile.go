@@ -0,0 +1,470 @@
+// Package profile reads the profile files written by `restless discover --save-profile`.
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bspippi1337/restless/internal/core/paginate"
+)
+
+type Profile struct {
+	Name       string
+	Path       string
+	BaseURLs   []string
+	Auth       Auth
+	Defaults   Defaults
+	TLS        TLS
+	Network    Network
+	RateLimit  RateLimit
+	DocURLs    []string
+	SpecURL    string // the OpenAPI document discovery found, if any
+	Confidence float64
+	Endpoints  []Endpoint
+	Collection []Request
+	// Vars are defaults for every collection entry's ${name} variables.
+	Vars map[string]string
+	// Environments are named overrides (staging, prod, ...) selected with --env.
+	Environments map[string]Environment
+}
+
+// Environment mirrors one entry of the hand-written `environments:` block.
+type Environment struct {
+	BaseURL string
+	Headers map[string]string
+}
+
+type Auth struct {
+	Type   string // bearer, oauth2 or none
+	Token  Secret
+	OAuth2 OAuth2
+}
+
+// OAuth2 mirrors the `auth:` block for `type: oauth2`.
+type OAuth2 struct {
+	Grant         string // client_credentials, refresh_token or device_code
+	TokenURL      string
+	DeviceAuthURL string
+	ClientID      Secret
+	ClientSecret  Secret
+	RefreshToken  Secret
+	Scopes        []string
+	Audience      string
+}
+
+// Secret is a reference to a value kept outside the profile.
+type Secret struct {
+	Source string // env (default) or value
+	EnvVar string
+	Value  string
+}
+
+// TLS mirrors the optional `tls:` block. Relative paths are resolved
+// against the profile's directory.
+type TLS struct {
+	ClientCert         string
+	ClientKey          string
+	CABundle           string
+	ServerName         string
+	MinVersion         string
+	InsecureSkipVerify bool
+}
+
+// IsZero reports whether no TLS settings were given.
+func (t TLS) IsZero() bool { return t == TLS{} }
+
+// Network mirrors the optional `network:` block.
+type Network struct {
+	Proxy   string   // http, https or socks5 URL
+	Resolve []string // host:port:addr overrides
+}
+
+// RateLimit mirrors the `rateLimit:` block recorded by discovery from the
+// server's rate-limit headers.
+type RateLimit struct {
+	RequestsPerSecond float64
+	Limit             int
+	WindowSeconds     int
+	Source            string
+}
+
+type Defaults struct {
+	Headers        map[string]string
+	TimeoutSeconds int
+}
+
+type Endpoint struct {
+	Method     string
+	Path       string
+	Score      float64
+	Evidence   []Evidence
+	Pagination *paginate.Spec
+	// Schema is the JSON Schema of successful responses, inferred from
+	// the ones restless has seen. It is kept as JSON in a `schema: |` block.
+	Schema map[string]any
+}
+
+// Evidence is one entry of an endpoint's `evidence:` list.
+type Evidence struct {
+	Source string
+	URL    string
+	When   string
+	Score  float64
+	// TimingMs holds the recorded phases (dns, connect, tls, ttfb, total).
+	TimingMs map[string]float64
+}
+
+// Match finds the endpoint for method and a concrete path, treating {name}
+// segments in endpoint paths as wildcards. Query strings are ignored.
+func (p *Profile) Match(method, path string) *Endpoint {
+	path, _, _ = strings.Cut(path, "?")
+	want := splitPath(path)
+	for i := range p.Endpoints {
+		ep := &p.Endpoints[i]
+		if !strings.EqualFold(ep.Method, method) {
+			continue
+		}
+		have := splitPath(ep.Path)
+		if len(have) != len(want) {
+			continue
+		}
+		ok := true
+		for j := range have {
+			if have[j] != want[j] && !(strings.HasPrefix(have[j], "{") && strings.HasSuffix(have[j], "}")) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return ep
+		}
+	}
+	return nil
+}
+
+// UseEnv applies the named environment's base URL and headers on top of the
+// profile defaults.
+func (p *Profile) UseEnv(name string) error {
+	env, ok := p.Environments[name]
+	if !ok {
+		names := make([]string, 0, len(p.Environments))
+		for n := range p.Environments {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return fmt.Errorf("profile %q has no environments", p.Name)
+		}
+		return fmt.Errorf("profile %q has no environment %q (have: %s)", p.Name, name, strings.Join(names, ", "))
+	}
+	if env.BaseURL != "" {
+		p.BaseURLs = []string{env.BaseURL}
+	}
+	if p.Defaults.Headers == nil {
+		p.Defaults.Headers = map[string]string{}
+	}
+	for k, v := range env.Headers {
+		p.Defaults.Headers[k] = v
+	}
+	return nil
+}
+
+func splitPath(p string) []string {
+	return strings.FieldsFunc(p, func(r rune) bool { return r == '/' })
+}
+
+// Resolve returns the secret's value, reading the environment when needed.
+func (s Secret) Resolve() string {
+	switch s.Source {
+	case "value":
+		return s.Value
+	default:
+		if s.EnvVar == "" {
+			return s.Value
+		}
+		return os.Getenv(s.EnvVar)
+	}
+}
+
+// Names lists the profiles saved in dir, sorted.
+func Names(dir string) []string {
+	ents, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	var out []string
+	for _, e := range ents {
+		name := e.Name()
+		ext := filepath.Ext(name)
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		if name = strings.TrimSuffix(name, ext); !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// FilePath returns the profile path for name in dir, preferring an existing .yml file.
+func FilePath(dir, name string) string {
+	p := filepath.Join(dir, name+".yaml")
+	if _, err := os.Stat(p); err != nil {
+		if alt := filepath.Join(dir, name+".yml"); fileExists(alt) {
+			return alt
+		}
+	}
+	return p
+}
+
+// Load reads and decodes the named profile from dir.
+func Load(dir, name string) (*Profile, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("empty profile name")
+	}
+	path := FilePath(dir, name)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseYAML(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	p := decode(doc)
+	p.Path = path
+	if p.Name == "" {
+		p.Name = name
+	}
+	return p, nil
+}
+
+func decode(doc map[string]any) *Profile {
+	p := &Profile{
+		Name:     str(doc, "name"),
+		BaseURLs: strList(doc, "baseUrls"),
+	}
+
+	auth := mapOf(doc, "auth")
+	p.Auth.Type = strings.ToLower(str(auth, "type"))
+	p.Auth.Token = secret(auth["token"])
+	p.Auth.OAuth2 = OAuth2{
+		Grant:         strings.ReplaceAll(strings.ToLower(str(auth, "grant")), "-", "_"),
+		TokenURL:      str(auth, "tokenUrl"),
+		DeviceAuthURL: str(auth, "deviceAuthorizationUrl"),
+		ClientID:      secret(auth["clientId"]),
+		ClientSecret:  secret(auth["clientSecret"]),
+		RefreshToken:  secret(auth["refreshToken"]),
+		Scopes:        strList(auth, "scopes"),
+		Audience:      str(auth, "audience"),
+	}
+
+	defs := mapOf(doc, "defaults")
+	p.Defaults.Headers = map[string]string{}
+	for k, v := range mapOf(defs, "headers") {
+		if s, ok := v.(string); ok {
+			p.Defaults.Headers[k] = s
+		}
+	}
+	p.Defaults.TimeoutSeconds = atoi(str(defs, "timeoutSeconds"))
+
+	t := mapOf(doc, "tls")
+	p.TLS = TLS{
+		ClientCert:         str(t, "clientCert"),
+		ClientKey:          str(t, "clientKey"),
+		CABundle:           str(t, "caBundle"),
+		ServerName:         str(t, "serverName"),
+		MinVersion:         str(t, "minVersion"),
+		InsecureSkipVerify: boolean(str(t, "insecureSkipVerify")),
+	}
+
+	nw := mapOf(doc, "network")
+	p.Network = Network{
+		Proxy:   str(nw, "proxy"),
+		Resolve: strList(nw, "resolve"),
+	}
+
+	rl := mapOf(doc, "rateLimit")
+	p.RateLimit = RateLimit{
+		RequestsPerSecond: atof(str(rl, "requestsPerSecond")),
+		Limit:             atoi(str(rl, "limit")),
+		WindowSeconds:     atoi(str(rl, "windowSeconds")),
+		Source:            str(rl, "source"),
+	}
+
+	p.DocURLs = strList(mapOf(doc, "discovery"), "docUrls")
+	p.SpecURL = str(mapOf(doc, "discovery"), "specUrl")
+	p.Confidence = atof(str(mapOf(doc, "discovery"), "confidence"))
+
+	p.Collection = decodeCollection(doc)
+	p.Vars = strMap(mapOf(doc, "vars"))
+
+	for name, v := range mapOf(doc, "environments") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		env := Environment{BaseURL: str(m, "baseUrl"), Headers: map[string]string{}}
+		for k, h := range mapOf(m, "headers") {
+			if s, ok := h.(string); ok {
+				env.Headers[k] = s
+			}
+		}
+		if p.Environments == nil {
+			p.Environments = map[string]Environment{}
+		}
+		p.Environments[name] = env
+	}
+
+	for _, it := range list(doc, "endpoints") {
+		m, ok := it.(map[string]any)
+		if !ok {
+			continue
+		}
+		ep := Endpoint{
+			Method: strings.ToUpper(str(m, "method")),
+			Path:   str(m, "path"),
+			Score:  atof(str(m, "score")),
+		}
+		for _, it := range list(m, "evidence") {
+			if e, ok := it.(map[string]any); ok {
+				ev := Evidence{
+					Source: str(e, "source"),
+					URL:    str(e, "url"),
+					When:   str(e, "when"),
+					Score:  atof(str(e, "score")),
+				}
+				for k, v := range mapOf(e, "timingMs") {
+					if t, ok := v.(string); ok {
+						if ev.TimingMs == nil {
+							ev.TimingMs = map[string]float64{}
+						}
+						ev.TimingMs[k] = atof(t)
+					}
+				}
+				ep.Evidence = append(ep.Evidence, ev)
+			}
+		}
+		if pg := mapOf(m, "pagination"); str(pg, "style") != "" {
+			ep.Pagination = &paginate.Spec{
+				Style: paginate.Style(str(pg, "style")),
+				Field: str(pg, "field"),
+				Param: str(pg, "param"),
+				Items: str(pg, "items"),
+			}
+		}
+		if js := str(m, "schema"); js != "" {
+			// A hand-edited schema that no longer parses is relearned.
+			_ = json.Unmarshal([]byte(js), &ep.Schema)
+		}
+		p.Endpoints = append(p.Endpoints, ep)
+	}
+	return p
+}
+
+// secret accepts either a bare string or a {source, envVar, value} mapping.
+func secret(v any) Secret {
+	switch t := v.(type) {
+	case string:
+		if strings.HasPrefix(t, "${ENV:") && strings.HasSuffix(t, "}") {
+			return Secret{Source: "env", EnvVar: t[6 : len(t)-1]}
+		}
+		if t == "" {
+			return Secret{}
+		}
+		return Secret{Source: "value", Value: t}
+	case map[string]any:
+		s := Secret{Source: strings.ToLower(str(t, "source")), EnvVar: str(t, "envVar"), Value: str(t, "value")}
+		if s.Source == "" {
+			s.Source = "env"
+			if s.EnvVar == "" && s.Value != "" {
+				s.Source = "value"
+			}
+		}
+		return s
+	}
+	return Secret{}
+}
+
+// Expand replaces ${ENV:NAME} references in s with environment values.
+func Expand(s string) string {
+	for {
+		i := strings.Index(s, "${ENV:")
+		if i < 0 {
+			return s
+		}
+		j := strings.IndexByte(s[i:], '}')
+		if j < 0 {
+			return s
+		}
+		s = s[:i] + os.Getenv(s[i+6:i+j]) + s[i+j+1:]
+	}
+}
+
+func mapOf(m map[string]any, key string) map[string]any {
+	if v, ok := m[key].(map[string]any); ok {
+		return v
+	}
+	return map[string]any{}
+}
+
+func list(m map[string]any, key string) []any {
+	if v, ok := m[key].([]any); ok {
+		return v
+	}
+	return nil
+}
+
+func str(m map[string]any, key string) string {
+	if v, ok := m[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+func strList(m map[string]any, key string) []string {
+	var out []string
+	switch v := m[key].(type) {
+	case []any:
+		for _, it := range v {
+			if s, ok := it.(string); ok && s != "" {
+				out = append(out, s)
+			}
+		}
+	case string:
+		for _, s := range strings.Fields(strings.ReplaceAll(v, ",", " ")) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(s))
+	return n
+}
+
+func boolean(s string) bool {
+	b, _ := strconv.ParseBool(strings.TrimSpace(s))
+	return b
+}
+
+func atof(s string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	return f
+}
+
+func fileExists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
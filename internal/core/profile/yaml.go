@@ -0,0 +1,250 @@
+package profile
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseYAML understands the small YAML subset restless writes: nested maps,
+// "- " lists (of scalars or maps), quoted/unquoted scalars, "|" block
+// scalars and # comments.
+// Maps decode to map[string]any, lists to []any, scalars to string.
+func parseYAML(src string) (map[string]any, error) {
+	var ls []yline
+	raws := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	for i := 0; i < len(raws); i++ {
+		raw := raws[i]
+		trimmed := strings.TrimSpace(raw)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.Contains(raw[:len(raw)-len(strings.TrimLeft(raw, " \t"))], "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		indent := len(raw) - len(strings.TrimLeft(raw, " "))
+		text := strings.TrimRight(raw[indent:], " ")
+		no := i + 1
+		if head, keep, ok := blockHeader(text); ok {
+			// Fold a "key: |" block scalar into a quoted one-line value.
+			var body []string
+			j := i + 1
+			for ; j < len(raws); j++ {
+				r := strings.TrimRight(raws[j], " \r")
+				if r != "" && len(r)-len(strings.TrimLeft(r, " ")) <= indent {
+					break
+				}
+				body = append(body, r)
+			}
+			for len(body) > 0 && body[len(body)-1] == "" {
+				body = body[:len(body)-1]
+			}
+			cut := -1
+			for _, b := range body {
+				if n := len(b) - len(strings.TrimLeft(b, " ")); b != "" && (cut < 0 || n < cut) {
+					cut = n
+				}
+			}
+			for k, b := range body {
+				if len(b) >= cut && cut > 0 {
+					body[k] = b[cut:]
+				}
+			}
+			v := strings.Join(body, "\n")
+			if keep && v != "" {
+				v += "\n"
+			}
+			text = head + " " + strconv.Quote(v)
+			i = j - 1
+		}
+		ls = append(ls, yline{no: no, indent: indent, text: text})
+	}
+	if len(ls) == 0 {
+		return map[string]any{}, nil
+	}
+	p := &yparser{lines: ls}
+	v, err := p.block(ls[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].no)
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("top level must be a mapping")
+	}
+	return m, nil
+}
+
+// ParseYAML parses the same subset for other restless files, such as
+// scenarios.
+func ParseYAML(src string) (map[string]any, error) { return parseYAML(src) }
+
+type yline struct {
+	no     int
+	indent int
+	text   string
+}
+
+type yparser struct {
+	lines []yline
+	pos   int
+}
+
+func (p *yparser) block(indent int) (any, error) {
+	if isListItem(p.lines[p.pos].text) {
+		return p.list(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yparser) list(indent int) (any, error) {
+	out := []any{}
+	for p.pos < len(p.lines) {
+		ln := p.lines[p.pos]
+		if ln.indent != indent || !isListItem(ln.text) {
+			break
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(ln.text, "-"), " ")
+		if rest == "" {
+			p.pos++
+			if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+				v, err := p.block(p.lines[p.pos].indent)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, v)
+			} else {
+				out = append(out, "")
+			}
+			continue
+		}
+		if _, _, ok := splitKey(rest); ok {
+			// "- key: value" opens a mapping whose keys align with "key".
+			child := indent + (len(ln.text) - len(rest))
+			p.lines[p.pos] = yline{no: ln.no, indent: child, text: rest}
+			v, err := p.mapping(child)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+			continue
+		}
+		out = append(out, value(rest))
+		p.pos++
+	}
+	return out, nil
+}
+
+func (p *yparser) mapping(indent int) (any, error) {
+	out := map[string]any{}
+	for p.pos < len(p.lines) {
+		ln := p.lines[p.pos]
+		if ln.indent < indent {
+			break
+		}
+		if ln.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", ln.no)
+		}
+		if isListItem(ln.text) {
+			break
+		}
+		key, val, ok := splitKey(ln.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", ln.no)
+		}
+		p.pos++
+		if val != "" {
+			out[key] = value(val)
+			continue
+		}
+		if p.pos < len(p.lines) {
+			next := p.lines[p.pos]
+			if next.indent > indent || (next.indent == indent && isListItem(next.text)) {
+				v, err := p.block(next.indent)
+				if err != nil {
+					return nil, err
+				}
+				out[key] = v
+				continue
+			}
+		}
+		out[key] = ""
+	}
+	return out, nil
+}
+
+// blockHeader recognizes "key: |" and "key: |-" and returns "key:".
+func blockHeader(text string) (head string, keepNewline, ok bool) {
+	for _, ind := range []string{" |-", " |"} {
+		if strings.HasSuffix(text, ":"+ind) {
+			return strings.TrimSuffix(text, ind), ind == " |", true
+		}
+	}
+	return "", false, false
+}
+
+func isListItem(s string) bool { return s == "-" || strings.HasPrefix(s, "- ") }
+
+// splitKey splits "key: value" / "key:" while ignoring colons inside quotes
+// and URLs (a colon must be followed by a space or end the line).
+func splitKey(s string) (key, val string, ok bool) {
+	if strings.HasPrefix(s, "\"") || strings.HasPrefix(s, "'") {
+		q := s[0]
+		end := strings.IndexByte(s[1:], q)
+		if end < 0 {
+			return "", "", false
+		}
+		k := s[:end+2]
+		rest := s[end+2:]
+		if rest != ":" && !strings.HasPrefix(rest, ": ") {
+			return "", "", false
+		}
+		return scalar(k), strings.TrimSpace(strings.TrimPrefix(rest, ":")), true
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] != ':' {
+			continue
+		}
+		if i == len(s)-1 || s[i+1] == ' ' {
+			return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:]), i > 0
+		}
+	}
+	return "", "", false
+}
+
+// value decodes an inline value: a flow list like [a, "b"] or a scalar.
+func value(s string) any {
+	t := strings.TrimSpace(s)
+	if len(t) >= 2 && t[0] == '[' && t[len(t)-1] == ']' {
+		out := []any{}
+		for _, it := range strings.Split(t[1:len(t)-1], ",") {
+			if it = strings.TrimSpace(it); it != "" {
+				out = append(out, scalar(it))
+			}
+		}
+		return out
+	}
+	return scalar(s)
+}
+
+func scalar(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+		return s[1 : len(s)-1]
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	if s == "~" || s == "null" {
+		return ""
+	}
+	return s
+}
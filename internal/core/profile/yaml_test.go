@@ -0,0 +1,205 @@
+package profile
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want map[string]any
+	}{
+		{
+			name: "empty",
+			src:  "# nothing here\n\n",
+			want: map[string]any{},
+		},
+		{
+			name: "scalars",
+			src: `name: shop
+url: https://api.shop.test:8443/v1
+quoted: "a: b # c"
+single: 'it''s'
+escaped: "tab\there"
+comment: value # trailing
+nothing: ~
+null: null
+empty:
+`,
+			want: map[string]any{
+				"name":    "shop",
+				"url":     "https://api.shop.test:8443/v1",
+				"quoted":  "a: b # c",
+				"single":  "it's",
+				"escaped": "tab\there",
+				"comment": "value",
+				"nothing": "",
+				"null":    "",
+				"empty":   "",
+			},
+		},
+		{
+			name: "nested maps and lists",
+			src: `defaults:
+  headers:
+    Accept: application/json
+    "X-Odd: key": 1
+baseUrls:
+  - https://a.test
+  - https://b.test
+scopes: [read, "write all"]
+none: []
+top: last
+`,
+			want: map[string]any{
+				"defaults": map[string]any{
+					"headers": map[string]any{"Accept": "application/json", "X-Odd: key": "1"},
+				},
+				"baseUrls": []any{"https://a.test", "https://b.test"},
+				"scopes":   []any{"read", "write all"},
+				"none":     []any{},
+				"top":      "last",
+			},
+		},
+		{
+			name: "list of maps at the parent's indentation",
+			src:  "collection:\n- name: one\n  method: GET\n-\n  name: two\n- plain\n",
+			want: map[string]any{
+				"collection": []any{
+					map[string]any{"name": "one", "method": "GET"},
+					map[string]any{"name": "two"},
+					"plain",
+				},
+			},
+		},
+		{
+			name: "windows line endings",
+			src:  "a: 1\r\nb:\r\n  c: 2\r\n",
+			want: map[string]any{"a": "1", "b": map[string]any{"c": "2"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYAML(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %#v\nwant %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseYAMLBlockScalars(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string // value of the "body" key
+	}{
+		{
+			name: "keeps the final newline",
+			src:  "body: |\n  {\"a\": 1}\n",
+			want: "{\"a\": 1}\n",
+		},
+		{
+			name: "strip indicator",
+			src:  "body: |-\n  line one\n  line two\n",
+			want: "line one\nline two",
+		},
+		{
+			name: "deeper lines keep their extra indentation",
+			src:  "body: |\n    {\n      \"a\": [1,\n        2]\n    }\n",
+			want: "{\n  \"a\": [1,\n    2]\n}\n",
+		},
+		{
+			name: "blank lines inside are kept, trailing ones dropped",
+			src:  "body: |\n  one\n\n  two\n\n\nnext: x\n",
+			want: "one\n\ntwo\n",
+		},
+		{
+			name: "content that looks like YAML stays literal",
+			src:  "body: |\n  key: value\n  - item\n  # not a comment\n  \"quoted\" \\ back\n",
+			want: "key: value\n- item\n# not a comment\n\"quoted\" \\ back\n",
+		},
+		{
+			name: "trailing spaces are trimmed",
+			src:  "body: |\n  a   \n  b\n",
+			want: "a\nb\n",
+		},
+		{
+			name: "empty block",
+			src:  "body: |\nnext: x\n",
+			want: "",
+		},
+		{
+			name: "ends at the first line back at the key's indentation",
+			src:  "body: |-\n  inside\nbody2: outside\n",
+			want: "inside",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYAML(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got["body"] != tt.want {
+				t.Errorf("body = %q, want %q", got["body"], tt.want)
+			}
+		})
+	}
+}
+
+func TestParseYAMLBlockScalarInList(t *testing.T) {
+	src := `collection:
+  - name: create
+    body: |
+      {"name": "pen",
+       "tags": ["a"]}
+    expect:
+      - status == 201
+  - name: after
+schema: |
+  {"type": "object"}
+`
+	got, err := parseYAML(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"collection": []any{
+			map[string]any{
+				"name":   "create",
+				"body":   "{\"name\": \"pen\",\n \"tags\": [\"a\"]}\n",
+				"expect": []any{"status == 201"},
+			},
+			map[string]any{"name": "after"},
+		},
+		"schema": "{\"type\": \"object\"}\n",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %#v\nwant %#v", got, want)
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		src, msg string
+	}{
+		{src: "a:\n\tb: 1\n", msg: "line 2: tabs are not allowed"},
+		{src: "a: 1\n  b: 2\n", msg: "line 2: unexpected indentation"},
+		{src: "a: 1\njust text\n", msg: `line 2: expected "key: value"`},
+		{src: "- a\n- b\n", msg: "top level must be a mapping"},
+		{src: "a:\n    b: 1\n  c: 2\n", msg: "line 3: unexpected indentation"},
+	}
+	for _, tt := range tests {
+		_, err := parseYAML(tt.src)
+		if err == nil || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("parseYAML(%q) error = %v, want %q", tt.src, err, tt.msg)
+		}
+	}
+}
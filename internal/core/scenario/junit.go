@@ -0,0 +1,86 @@
+package scenario
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	File     string      `xml:"file,attr,omitempty"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the reports as JUnit XML: one testsuite per scenario,
+// one testcase per step.
+func WriteJUnit(w io.Writer, reports []Report) error {
+	var all junitSuites
+	var total time.Duration
+	for _, rep := range reports {
+		s := junitSuite{Name: rep.Scenario, File: rep.File, Time: secs(rep.Duration)}
+		for _, res := range rep.Results {
+			c := junitCase{Name: res.Step, Classname: rep.Scenario, Time: secs(res.Duration)}
+			if res.URL != "" {
+				c.SystemOut = fmt.Sprintf("%s %s -> %d", res.Method, res.URL, res.Status)
+			}
+			switch {
+			case res.Skipped:
+				c.Skipped = &struct{}{}
+				s.Skipped++
+			case res.Err != nil:
+				c.Failure = &junitFailure{Message: res.Err.Error(), Type: "failure", Text: res.Err.Error()}
+				s.Failures++
+			}
+			s.Cases = append(s.Cases, c)
+		}
+		s.Tests = len(s.Cases)
+		all.Tests += s.Tests
+		all.Failures += s.Failures
+		all.Skipped += s.Skipped
+		total += rep.Duration
+		all.Suites = append(all.Suites, s)
+	}
+	all.Time = secs(total)
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(all); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func secs(d time.Duration) string { return fmt.Sprintf("%.3f", d.Seconds()) }
@@ -0,0 +1,300 @@
+package scenario
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/profile"
+	"github.com/bspippi1337/restless/internal/core/transport"
+	"github.com/bspippi1337/restless/internal/expect"
+	"github.com/bspippi1337/restless/internal/filter"
+)
+
+// Runner sends the steps of a scenario.
+type Runner struct {
+	Client  *http.Client
+	Profile *profile.Profile
+	// Build turns a rendered step into an HTTP request: base URL, profile
+	// headers and the like.
+	Build func(ctx context.Context, r profile.Request) (*http.Request, error)
+	// Vars override the scenario's own vars (--var on the command line).
+	Vars map[string]string
+	// OnStep, if set, is called as each step finishes.
+	OnStep func(Result)
+}
+
+// Result is the outcome of one step. Err is set when it failed; steps after
+// a failure are Skipped.
+type Result struct {
+	Step     string
+	Method   string
+	URL      string
+	Status   int
+	Duration time.Duration
+	Captured map[string]string
+	Err      error
+	Skipped  bool
+}
+
+// Report collects the results of one scenario run.
+type Report struct {
+	Scenario string
+	File     string
+	Results  []Result
+	Duration time.Duration
+}
+
+// Failed reports whether any step failed.
+func (r Report) Failed() bool {
+	for _, res := range r.Results {
+		if res.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Run sends every step in order and stops at the first failure.
+func (r *Runner) Run(ctx context.Context, s *Scenario) Report {
+	rep := Report{Scenario: s.Name, File: s.File}
+	start := time.Now()
+	vars := map[string]string{}
+	for _, m := range []map[string]string{s.Vars, r.Vars} {
+		for k, v := range m {
+			vars[k] = v
+		}
+	}
+	failed := false
+	for _, st := range s.Steps {
+		var res Result
+		if failed {
+			res = Result{Step: st.Name, Method: st.Request.Method, Skipped: true}
+		} else {
+			res = r.step(ctx, st, vars, filepath.Dir(s.File))
+			failed = res.Err != nil
+			for k, v := range res.Captured {
+				vars[k] = v
+			}
+		}
+		rep.Results = append(rep.Results, res)
+		if r.OnStep != nil {
+			r.OnStep(res)
+		}
+	}
+	rep.Duration = time.Since(start)
+	return rep
+}
+
+func (r *Runner) step(ctx context.Context, st Step, vars map[string]string, dir string) Result {
+	res := Result{Step: st.Name, Method: st.Request.Method}
+	fail := func(err error) Result {
+		res.Err = err
+		return res
+	}
+
+	var entry profile.Request
+	if st.Use != "" {
+		base := r.Profile.Request(st.Use)
+		if base == nil {
+			return fail(fmt.Errorf("profile %q has no request %q", r.Profile.Name, st.Use))
+		}
+		entry = *base
+	}
+	entry = r.Profile.WithVars(overlay(entry, st.Request))
+	entry.Name = "" // the result already names the step
+	if entry.Method == "" {
+		entry.Method = "GET"
+	}
+	res.Method = entry.Method
+	stepVars := map[string]string{}
+	for _, m := range []map[string]string{vars, st.Vars} {
+		for k, v := range m {
+			stepVars[k] = v
+		}
+	}
+	rendered, err := entry.Render(stepVars)
+	if err != nil {
+		return fail(err)
+	}
+	var raws []string
+	for _, x := range st.Expect {
+		if x, err = interpolate(x, stepVars); err != nil {
+			return fail(err)
+		}
+		raws = append(raws, x)
+	}
+	exps, err := expect.ParseAll(raws, dir)
+	if err != nil {
+		return fail(err)
+	}
+
+	req, err := r.Build(ctx, rendered)
+	if err != nil {
+		return fail(err)
+	}
+	res.URL = transport.RedactURL(req.URL)
+	start := time.Now()
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		res.Duration = time.Since(start)
+		return fail(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	res.Duration = time.Since(start)
+	res.Status = resp.StatusCode
+	if err != nil {
+		return fail(err)
+	}
+
+	if (st.Status != "" || !checksStatus(exps)) && !expectStatus(st.Status, resp.StatusCode) {
+		want := st.Status
+		if want == "" {
+			want = "2xx or 3xx"
+		}
+		return fail(fmt.Errorf("expected status %s, got %d", want, resp.StatusCode))
+	}
+	if err := expect.Check(exps, expect.Response{Status: resp.StatusCode, Header: resp.Header, Body: body, Duration: res.Duration}); err != nil {
+		return fail(err)
+	}
+	if res.Captured, err = capture(st.Capture, resp.Header, body); err != nil {
+		return fail(err)
+	}
+	return res
+}
+
+var varRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+
+// interpolate fills ${name} in an assertion; ${ENV:NAME} is left alone.
+func interpolate(s string, vars map[string]string) (string, error) {
+	var missing []string
+	out := varRef.ReplaceAllStringFunc(s, func(m string) string {
+		name := m[2 : len(m)-1]
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		missing = append(missing, name)
+		return m
+	})
+	if len(missing) > 0 {
+		return out, fmt.Errorf("expect %q: no value for %s", s, strings.Join(missing, ", "))
+	}
+	return out, nil
+}
+
+// overlay lays the step's inline fields over a collection entry.
+func overlay(base, over profile.Request) profile.Request {
+	out := base
+	if over.Method != "" {
+		out.Method = over.Method
+	}
+	if over.Path != "" {
+		out.Path = over.Path
+	}
+	if over.Body != "" {
+		out.Body = over.Body
+	}
+	out.Query = merge(base.Query, over.Query)
+	out.Headers = merge(base.Headers, over.Headers)
+	return out
+}
+
+func merge(a, b map[string]string) map[string]string {
+	out := map[string]string{}
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, v := range b {
+		out[k] = v
+	}
+	return out
+}
+
+func checksStatus(exps []*expect.Expectation) bool {
+	for _, e := range exps {
+		if e.Kind == expect.Status {
+			return true
+		}
+	}
+	return false
+}
+
+func expectStatus(want string, got int) bool {
+	if want == "" {
+		return got >= 200 && got < 400
+	}
+	for _, w := range splitList(want) {
+		if ok, _ := statusMatch(w, got); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// capture evaluates the step's captures in name order. A capture that
+// matches nothing (or null) fails the step.
+func capture(exprs map[string]string, h http.Header, body []byte) (map[string]string, error) {
+	if len(exprs) == 0 {
+		return nil, nil
+	}
+	names := make([]string, 0, len(exprs))
+	for k := range exprs {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var (
+		doc     any
+		decoded bool
+		docErr  error
+	)
+	out := map[string]string{}
+	for _, name := range names {
+		expr := exprs[name]
+		if key, ok := strings.CutPrefix(expr, "header:"); ok {
+			v := h.Get(strings.TrimSpace(key))
+			if v == "" {
+				return nil, fmt.Errorf("capture %s: no %s header in the response", name, strings.TrimSpace(key))
+			}
+			out[name] = v
+			continue
+		}
+		if !decoded {
+			doc, docErr = filter.Decode(body)
+			decoded = true
+		}
+		if docErr != nil {
+			return nil, fmt.Errorf("capture %s: response is not JSON", name)
+		}
+		prog, err := filter.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("capture %s: %w", name, err)
+		}
+		vals, err := prog.Run(doc)
+		if err != nil {
+			return nil, fmt.Errorf("capture %s: %w", name, err)
+		}
+		if len(vals) == 0 || vals[0] == nil {
+			return nil, fmt.Errorf("capture %s: %s matched nothing", name, expr)
+		}
+		out[name] = text(vals[0])
+	}
+	return out, nil
+}
+
+// text renders a captured value: strings as-is, anything else as JSON.
+func text(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,211 @@
+// Package scenario runs multi-step request flows described in YAML. Each
+// step sends a request from the profile's collection or an inline one;
+// values captured from a response feed the steps after it, and the run
+// stops at the first failing step.
+package scenario
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bspippi1337/restless/internal/core/profile"
+	"github.com/bspippi1337/restless/internal/expect"
+	"github.com/bspippi1337/restless/internal/filter"
+)
+
+// Scenario is one scenario file:
+//
+//	name: item lifecycle
+//	profile: example
+//	vars:
+//	  user: demo
+//	steps:
+//	  - name: create
+//	    request: create-item      # collection entry, optional
+//	    method: POST
+//	    path: /items
+//	    body: |
+//	      {"name": "${user}"}
+//	    status: 201
+//	    expect:
+//	      - $.name==demo
+//	    capture:
+//	      id: .id
+//	  - name: fetch
+//	    path: /items/${id}
+type Scenario struct {
+	Name    string
+	File    string
+	Profile string
+	Env     string
+	Vars    map[string]string
+	Steps   []Step
+}
+
+type Step struct {
+	Name string
+	// Use names the collection entry the step starts from; the inline
+	// request fields are laid over it.
+	Use     string
+	Request profile.Request
+	Vars    map[string]string
+	// Capture maps a variable to a filter expression run on the JSON
+	// response (.data.id), or to "header:Name".
+	Capture map[string]string
+	// Status is the expected status: "201", "2xx" or a list "200, 204".
+	// Empty accepts any 2xx or 3xx, unless Expect checks the status.
+	Status string
+	// Expect holds assertions in `request --expect` syntax; ${name}
+	// variables are filled in before each run.
+	Expect []string
+}
+
+// Load reads and checks a scenario file.
+func Load(path string) (*Scenario, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := profile.ParseYAML(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	s := &Scenario{
+		Name:    str(doc, "name"),
+		File:    path,
+		Profile: str(doc, "profile"),
+		Env:     str(doc, "env"),
+		Vars:    strMap(doc["vars"]),
+	}
+	if s.Name == "" {
+		s.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	steps, _ := doc["steps"].([]any)
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("%s: no steps", path)
+	}
+	for i, it := range steps {
+		m, ok := it.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: step %d is not a mapping", path, i+1)
+		}
+		st := Step{
+			Name: str(m, "name"),
+			Use:  str(m, "request"),
+			Request: profile.Request{
+				Method:  strings.ToUpper(str(m, "method")),
+				Path:    str(m, "path"),
+				Query:   strMap(m["query"]),
+				Headers: strMap(m["headers"]),
+				Body:    str(m, "body"),
+			},
+			Vars:    strMap(m["vars"]),
+			Capture: strMap(m["capture"]),
+			Status:  str(m, "status"),
+		}
+		if st.Name == "" {
+			st.Name = firstNonEmpty(st.Use, fmt.Sprintf("step %d", i+1))
+		}
+		st.Expect = strList(m["expect"])
+		if err := st.check(filepath.Dir(path)); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, st.Name, err)
+		}
+		s.Steps = append(s.Steps, st)
+	}
+	return s, nil
+}
+
+func (st Step) check(dir string) error {
+	if st.Use == "" && st.Request.Path == "" {
+		return errors.New("needs a request: or a path:")
+	}
+	for name, expr := range st.Capture {
+		if strings.HasPrefix(expr, "header:") {
+			continue
+		}
+		if _, err := filter.Compile(expr); err != nil {
+			return fmt.Errorf("capture %s: %w", name, err)
+		}
+	}
+	if _, err := expect.ParseAll(st.Expect, dir); err != nil {
+		return err
+	}
+	for _, w := range splitList(st.Status) {
+		if _, ok := statusMatch(w, 0); !ok {
+			return fmt.Errorf("bad status %q (want 201, 2xx or a list)", st.Status)
+		}
+	}
+	return nil
+}
+
+// statusMatch reports whether got satisfies want ("404" or "4xx"); ok is
+// false when want isn't valid.
+func statusMatch(want string, got int) (match, ok bool) {
+	want = strings.ToLower(want)
+	if len(want) == 3 && strings.HasSuffix(want, "xx") && want[0] >= '1' && want[0] <= '5' {
+		return got/100 == int(want[0]-'0'), true
+	}
+	n, err := strconv.Atoi(want)
+	if err != nil || n < 100 || n > 599 {
+		return false, false
+	}
+	return n == got, true
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func str(m map[string]any, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+// strList accepts a list of strings or a single string.
+func strList(v any) []string {
+	switch v := v.(type) {
+	case string:
+		if v != "" {
+			return []string{v}
+		}
+	case []any:
+		var out []string
+		for _, it := range v {
+			if s, ok := it.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func strMap(v any) map[string]string {
+	out := map[string]string{}
+	m, _ := v.(map[string]any)
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			out[k] = s
+		}
+	}
+	return out
+}
+
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,256 @@
+// Package snippet renders a request as a ready-to-run curl, HTTPie, Go or
+// Python snippet. Values may hold ${ENV:NAME} references; they become
+// environment lookups in the target language, so credentials are never
+// written into a snippet.
+package snippet
+
+import (
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Langs are the supported languages, in the order help lists them.
+var Langs = []string{"curl", "httpie", "go", "python"}
+
+// Request is what a snippet sends. Header order is kept.
+type Request struct {
+	Method  string
+	URL     string
+	Headers [][2]string
+	Body    string
+	// Notes are emitted as comments above the snippet.
+	Notes []string
+}
+
+// Render returns the snippet for lang.
+func Render(lang string, r Request) (string, error) {
+	r.Method = strings.ToUpper(r.Method)
+	switch strings.ToLower(lang) {
+	case "curl":
+		return curl(r), nil
+	case "httpie", "http":
+		return httpie(r), nil
+	case "go":
+		return goSnippet(r), nil
+	case "python", "py":
+		return python(r), nil
+	}
+	return "", fmt.Errorf("unknown language %q (want %s)", lang, strings.Join(Langs, ", "))
+}
+
+// part is a literal run of text or an environment variable reference.
+type part struct {
+	text string
+	env  string
+}
+
+func split(s string) []part {
+	var out []part
+	for {
+		i := strings.Index(s, "${ENV:")
+		j := -1
+		if i >= 0 {
+			j = strings.IndexByte(s[i:], '}')
+		}
+		if i < 0 || j < 0 {
+			if s != "" {
+				out = append(out, part{text: s})
+			}
+			return out
+		}
+		if i > 0 {
+			out = append(out, part{text: s[:i]})
+		}
+		out = append(out, part{env: s[i+6 : i+j]})
+		s = s[i+j+1:]
+	}
+}
+
+func hasEnv(s string) bool {
+	for _, p := range split(s) {
+		if p.env != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// shell quotes s for a POSIX shell: single quotes unless it refers to the
+// environment, which needs double quotes.
+func shell(s string) string {
+	if !hasEnv(s) {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, p := range split(s) {
+		if p.env != "" {
+			b.WriteString("${" + p.env + "}")
+			continue
+		}
+		for _, r := range p.text {
+			if strings.ContainsRune("\"\\$`", r) {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func comments(b *strings.Builder, prefix string, notes []string) {
+	for _, n := range notes {
+		b.WriteString(prefix + " " + n + "\n")
+	}
+}
+
+func curl(r Request) string {
+	var b strings.Builder
+	comments(&b, "#", r.Notes)
+	b.WriteString("curl")
+	if r.Method != "GET" && !(r.Method == "POST" && r.Body != "") {
+		b.WriteString(" -X " + r.Method)
+	}
+	b.WriteString(" " + shell(r.URL))
+	for _, h := range r.Headers {
+		b.WriteString(" \\\n  -H " + shell(h[0]+": "+h[1]))
+	}
+	if r.Body != "" {
+		b.WriteString(" \\\n  --data-raw " + shell(r.Body))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func httpie(r Request) string {
+	var b strings.Builder
+	comments(&b, "#", r.Notes)
+	b.WriteString("http " + r.Method + " " + shell(r.URL))
+	for _, h := range r.Headers {
+		b.WriteString(" \\\n  " + shell(h[0]+":"+h[1]))
+	}
+	if r.Body != "" {
+		b.WriteString(" \\\n  --raw " + shell(r.Body))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// expr renders s as a string expression of a C-like language: quoted
+// literals joined with + to env lookups.
+func expr(s string, quote func(string) string, lookup func(string) string) string {
+	ps := split(s)
+	if len(ps) == 0 {
+		return quote("")
+	}
+	var out []string
+	for _, p := range ps {
+		if p.env != "" {
+			out = append(out, lookup(p.env))
+		} else {
+			out = append(out, quote(p.text))
+		}
+	}
+	return strings.Join(out, " + ")
+}
+
+func goSnippet(r Request) string {
+	goExpr := func(s string) string {
+		return expr(s, strconv.Quote, func(n string) string { return "os.Getenv(" + strconv.Quote(n) + ")" })
+	}
+	usesEnv := hasEnv(r.URL) || hasEnv(r.Body)
+	for _, h := range r.Headers {
+		usesEnv = usesEnv || hasEnv(h[1])
+	}
+	imports := []string{"fmt", "io", "net/http"}
+	if usesEnv {
+		imports = append(imports, "os")
+	}
+	if r.Body != "" {
+		imports = append(imports, "strings")
+	}
+	sort.Strings(imports)
+
+	var b strings.Builder
+	comments(&b, "//", r.Notes)
+	b.WriteString("package main\n\nimport (\n")
+	for _, im := range imports {
+		b.WriteString("\t" + strconv.Quote(im) + "\n")
+	}
+	b.WriteString(")\n\nfunc main() {\n")
+	body := "nil"
+	if r.Body != "" {
+		lit := goExpr(r.Body)
+		if !hasEnv(r.Body) && !strings.Contains(r.Body, "`") {
+			lit = "`" + r.Body + "`"
+		}
+		b.WriteString("\tbody := strings.NewReader(" + lit + ")\n")
+		body = "body"
+	}
+	method := "http.Method" + strings.ToUpper(r.Method[:1]) + strings.ToLower(r.Method[1:])
+	switch r.Method {
+	case "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS":
+	default:
+		method = strconv.Quote(r.Method)
+	}
+	b.WriteString("\treq, err := http.NewRequest(" + method + ", " + goExpr(r.URL) + ", " + body + ")\n")
+	b.WriteString("\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+	for _, h := range r.Headers {
+		b.WriteString("\treq.Header.Set(" + strconv.Quote(h[0]) + ", " + goExpr(h[1]) + ")\n")
+	}
+	b.WriteString("\n\tresp, err := http.DefaultClient.Do(req)\n")
+	b.WriteString("\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+	b.WriteString("\tdefer resp.Body.Close()\n")
+	b.WriteString("\tout, err := io.ReadAll(resp.Body)\n")
+	b.WriteString("\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+	b.WriteString("\tfmt.Println(resp.Status)\n")
+	b.WriteString("\tfmt.Println(string(out))\n")
+	b.WriteString("}\n")
+	if src, err := format.Source([]byte(b.String())); err == nil {
+		return string(src)
+	}
+	return b.String()
+}
+
+func python(r Request) string {
+	pyExpr := func(s string) string {
+		return expr(s, pyQuote, func(n string) string { return "os.environ[" + pyQuote(n) + "]" })
+	}
+	usesEnv := hasEnv(r.URL) || hasEnv(r.Body)
+	for _, h := range r.Headers {
+		usesEnv = usesEnv || hasEnv(h[1])
+	}
+
+	var b strings.Builder
+	comments(&b, "#", r.Notes)
+	if usesEnv {
+		b.WriteString("import os\n\n")
+	}
+	b.WriteString("import requests\n\n")
+	b.WriteString("response = requests.request(\n")
+	b.WriteString("    " + pyQuote(r.Method) + ",\n")
+	b.WriteString("    " + pyExpr(r.URL) + ",\n")
+	if len(r.Headers) > 0 {
+		b.WriteString("    headers={\n")
+		for _, h := range r.Headers {
+			b.WriteString("        " + pyQuote(h[0]) + ": " + pyExpr(h[1]) + ",\n")
+		}
+		b.WriteString("    },\n")
+	}
+	if r.Body != "" {
+		b.WriteString("    data=" + pyExpr(r.Body) + ",\n")
+	}
+	b.WriteString("    timeout=30,\n")
+	b.WriteString(")\n")
+	b.WriteString("print(response.status_code)\n")
+	b.WriteString("print(response.text)\n")
+	return b.String()
+}
+
+// pyQuote writes a Python string literal; every escape strconv.Quote
+// produces means the same in Python.
+func pyQuote(s string) string { return strconv.Quote(s) }
@@ -0,0 +1,113 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// dumpBodyLimit caps how much of each body the wire trace shows.
+const dumpBodyLimit = 4096
+
+// dumping writes every request and response as it goes over the wire,
+// with secrets redacted. It sits below retries and auth, so each attempt
+// and the final Authorization header are shown.
+type dumping struct {
+	base http.RoundTripper
+	w    io.Writer
+	mu   sync.Mutex
+}
+
+func (d *dumping) RoundTrip(req *http.Request) (*http.Response, error) {
+	head, err := httputil.DumpRequestOut(req, false)
+	if err != nil {
+		return nil, err
+	}
+	var reqBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(io.LimitReader(rc, dumpBodyLimit+1))
+			_ = rc.Close()
+		}
+	}
+	d.write(">", redactHead(head, req.URL.RawQuery), req.Header.Get("Content-Type"), reqBody, req.ContentLength)
+
+	resp, err := d.base.RoundTrip(req)
+	if err != nil {
+		d.mu.Lock()
+		fmt.Fprintf(d.w, "* %v\n", err)
+		d.mu.Unlock()
+		return nil, err
+	}
+	head, _ = httputil.DumpResponse(resp, false)
+	// Read the body prefix for the trace and put it back in front of the rest.
+	prefix, _ := io.ReadAll(io.LimitReader(resp.Body, dumpBodyLimit+1))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), resp.Body), resp.Body}
+	d.write("<", redactHead(head, ""), resp.Header.Get("Content-Type"), prefix, resp.ContentLength)
+	return resp, nil
+}
+
+func (d *dumping) write(prefix string, head []byte, contentType string, body []byte, length int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	sc := bufio.NewScanner(bytes.NewReader(head))
+	for sc.Scan() {
+		if line := sc.Text(); line != "" {
+			fmt.Fprintf(d.w, "%s %s\n", prefix, line)
+		}
+	}
+	fmt.Fprintf(d.w, "%s\n", prefix)
+	if len(body) == 0 {
+		return
+	}
+	more := len(body) > dumpBodyLimit
+	if more {
+		body = body[:dumpBodyLimit]
+	}
+	check := body
+	for i := 0; more && i < utf8.UTFMax-1 && !utf8.Valid(check); i++ {
+		check = check[:len(check)-1] // a rune cut at the limit
+	}
+	if bytes.IndexByte(body, 0) >= 0 || !utf8.Valid(check) {
+		fmt.Fprintf(d.w, "[binary body, %d bytes]\n", length)
+		return
+	}
+	d.w.Write(RedactBody(contentType, body))
+	if more {
+		fmt.Fprintf(d.w, "\n[... truncated after %d bytes]", dumpBodyLimit)
+	}
+	fmt.Fprintln(d.w)
+}
+
+// redactHead redacts secret header values and query parameters in a dumped
+// request or response head.
+func redactHead(head []byte, rawQuery string) []byte {
+	lines := strings.Split(string(head), "\r\n")
+	for i, line := range lines {
+		if i == 0 {
+			if rawQuery != "" {
+				method, rest, _ := strings.Cut(line, " ")
+				target, proto, _ := strings.Cut(rest, " ")
+				if u, err := http.NewRequest(method, "http://x"+target, nil); err == nil {
+					red := RedactURL(u.URL)
+					lines[i] = method + " " + strings.TrimPrefix(red, "http://x") + " " + proto
+				}
+			}
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if ok {
+			lines[i] = name + ": " + RedactHeader(name, strings.TrimSpace(value))
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
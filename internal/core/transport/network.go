@@ -0,0 +1,72 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// proxyFunc returns the Proxy hook for raw; empty raw keeps the environment
+// (HTTP_PROXY, HTTPS_PROXY, NO_PROXY) behaviour.
+func proxyFunc(raw string) (func(*http.Request) (*url.URL, error), error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	if raw == "none" || raw == "direct" {
+		return nil, nil
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("proxy: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("proxy: unsupported scheme %q (want http, https or socks5)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy: missing host in %q", raw)
+	}
+	return http.ProxyURL(u), nil
+}
+
+// parseResolve parses curl-style "host:port:addr" overrides into a map of
+// "host:port" to "addr:port". IPv6 addresses may be bracketed.
+func parseResolve(entries []string) (map[string]string, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	out := map[string]string{}
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		host, rest, ok1 := strings.Cut(e, ":")
+		port, addr, ok2 := strings.Cut(rest, ":")
+		if !ok1 || !ok2 || host == "" || port == "" || addr == "" {
+			return nil, fmt.Errorf("resolve: %q is not host:port:addr", e)
+		}
+		addr = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+		if net.ParseIP(addr) == nil {
+			return nil, fmt.Errorf("resolve: %q is not an IP address", addr)
+		}
+		out[net.JoinHostPort(strings.ToLower(host), port)] = net.JoinHostPort(addr, port)
+	}
+	return out, nil
+}
+
+// resolvingDialer sends connections for overridden host:port pairs to a fixed
+// address. TLS still verifies against the original host name.
+func resolvingDialer(dial func(ctx context.Context, network, addr string) (net.Conn, error), overrides map[string]string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if to, ok := overrides[strings.ToLower(addr)]; ok {
+			addr = to
+		}
+		return dial(ctx, network, addr)
+	}
+}
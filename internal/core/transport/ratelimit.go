@@ -0,0 +1,130 @@
+package transport
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits is a server's advertised rate limit, from RateLimit-* (IETF draft),
+// X-RateLimit-* or X-Rate-Limit-* response headers.
+type Limits struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time     // when the window resets; zero if unknown
+	Window    time.Duration // from RateLimit-Policy "100;w=60"; zero if unknown
+	Source    string        // header family the values came from
+}
+
+// ParseRateLimit extracts rate-limit headers; ok is false when none are present.
+func ParseRateLimit(h http.Header, now time.Time) (Limits, bool) {
+	// Combined draft form: RateLimit: limit=100, remaining=50, reset=5
+	if v := h.Get("RateLimit"); v != "" && strings.Contains(v, "=") {
+		l := Limits{Limit: -1, Remaining: -1, Source: "ratelimit"}
+		for _, part := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ';' }) {
+			k, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+			n, err := strconv.Atoi(strings.Trim(val, `" `))
+			if err != nil {
+				continue
+			}
+			switch strings.ToLower(k) {
+			case "limit", "l":
+				l.Limit = n
+			case "remaining", "r":
+				l.Remaining = n
+			case "reset", "t":
+				l.Reset = now.Add(time.Duration(n) * time.Second)
+			}
+		}
+		l.Window = policyWindow(h.Get("RateLimit-Policy"))
+		if l.Limit >= 0 || l.Remaining >= 0 {
+			return l, true
+		}
+	}
+	for _, prefix := range []string{"RateLimit-", "X-RateLimit-", "X-Rate-Limit-"} {
+		limit, remaining := h.Get(prefix+"Limit"), h.Get(prefix+"Remaining")
+		if limit == "" && remaining == "" {
+			continue
+		}
+		l := Limits{Limit: atoiOr(limit, -1), Remaining: atoiOr(remaining, -1), Source: strings.ToLower(strings.TrimSuffix(prefix, "-"))}
+		if reset := h.Get(prefix + "Reset"); reset != "" {
+			l.Reset = parseReset(reset, now, prefix == "RateLimit-")
+		}
+		l.Window = policyWindow(h.Get(prefix + "Policy"))
+		if l.Window == 0 {
+			// GitHub-style X-RateLimit-Used/Resource carry no window; some APIs send one.
+			if w := atoiOr(h.Get(prefix+"Window"), 0); w > 0 {
+				l.Window = time.Duration(w) * time.Second
+			}
+		}
+		return l, true
+	}
+	return Limits{}, false
+}
+
+// RequestsPerSecond suggests a steady rate that stays within the limit.
+func (l Limits) RequestsPerSecond() float64 {
+	if l.Limit <= 0 {
+		return 0
+	}
+	w := l.Window
+	if w == 0 {
+		w = time.Minute // a common default when the window isn't advertised
+	}
+	return float64(l.Limit) / w.Seconds()
+}
+
+// parseReset accepts delta-seconds, epoch seconds or an HTTP date. The IETF
+// headers are always delta-seconds.
+func parseReset(v string, now time.Time, delta bool) time.Time {
+	v = strings.TrimSpace(v)
+	if n, err := strconv.ParseFloat(v, 64); err == nil {
+		if !delta && n > 1e9 {
+			return time.Unix(int64(n), 0)
+		}
+		return now.Add(time.Duration(n * float64(time.Second)))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+// RetryAfter parses a Retry-After header (delta-seconds or HTTP date).
+func RetryAfter(h http.Header, now time.Time) (time.Duration, bool) {
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if v == "" {
+		return 0, false
+	}
+	if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+		return time.Duration(n) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func policyWindow(v string) time.Duration {
+	for _, part := range strings.Split(v, ";") {
+		k, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && strings.TrimSpace(k) == "w" {
+			if n, err := strconv.Atoi(strings.TrimSpace(val)); err == nil && n > 0 {
+				return time.Duration(n) * time.Second
+			}
+		}
+	}
+	return 0
+}
+
+func atoiOr(s string, def int) int {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return def
+	}
+	return n
+}
@@ -0,0 +1,130 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[redacted]"
+
+var jsonPair = regexp.MustCompile(`"([^"\\]+)"\s*:\s*("(?:[^"\\]|\\.)*"?)`)
+
+// secretNames are header, query and body field names whose values are never
+// printed. Matching is case-insensitive and ignores - and _.
+var secretNames = []string{
+	"authorization", "proxyauthorization", "cookie", "setcookie", "xapikey", "apikey",
+	"accesstoken", "refreshtoken", "idtoken", "token", "clientsecret", "password",
+	"secret", "xauthtoken", "privatekey", "sessiontoken",
+}
+
+// IsSecret reports whether a header, parameter or field name holds a secret.
+func IsSecret(name string) bool {
+	n := strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(name))
+	for _, s := range secretNames {
+		if n == s {
+			return true
+		}
+	}
+	return strings.HasSuffix(n, "token") || strings.HasSuffix(n, "secret") || strings.HasSuffix(n, "apikey")
+}
+
+// RedactHeader hides secret header values, keeping an auth scheme such as
+// "Bearer" visible.
+func RedactHeader(name, value string) string {
+	if !IsSecret(name) {
+		return value
+	}
+	if typ, _, ok := strings.Cut(value, " "); ok && len(typ) < 16 && !strings.Contains(typ, "=") {
+		return typ + " " + redacted
+	}
+	return redacted
+}
+
+// RedactURL hides secret query parameter values.
+func RedactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	c := *u
+	c.User = nil
+	if u.User != nil {
+		c.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	q := c.Query()
+	changed := false
+	for k := range q {
+		if IsSecret(k) {
+			q[k] = []string{redacted}
+			changed = true
+		}
+	}
+	if changed {
+		c.RawQuery = q.Encode()
+	}
+	return c.String()
+}
+
+// RedactBody hides secret fields in JSON and form-encoded bodies.
+func RedactBody(contentType string, body []byte) []byte {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mt == "application/x-www-form-urlencoded":
+		q, err := url.ParseQuery(string(body))
+		if err != nil {
+			return body
+		}
+		for k := range q {
+			if IsSecret(k) {
+				q[k] = []string{redacted}
+			}
+		}
+		return []byte(q.Encode())
+	case mt == "application/json" || strings.HasSuffix(mt, "+json") || (mt == "" && json.Valid(body)):
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		var v any
+		if dec.Decode(&v) != nil {
+			// Truncated or invalid JSON: redact "key": "value" pairs textually.
+			return jsonPair.ReplaceAllFunc(body, func(m []byte) []byte {
+				sub := jsonPair.FindSubmatch(m)
+				if !IsSecret(string(sub[1])) {
+					return m
+				}
+				return append(bytes.TrimSuffix(m, sub[2]), []byte(`"`+redacted+`"`)...)
+			})
+		}
+		if !redactValue(v) {
+			return body
+		}
+		out, err := json.Marshal(v)
+		if err != nil {
+			return body
+		}
+		return out
+	}
+	return body
+}
+
+func redactValue(v any) bool {
+	changed := false
+	switch t := v.(type) {
+	case map[string]any:
+		for k, x := range t {
+			if _, ok := x.(string); ok && IsSecret(k) {
+				t[k] = redacted
+				changed = true
+				continue
+			}
+			changed = redactValue(x) || changed
+		}
+	case []any:
+		for _, x := range t {
+			changed = redactValue(x) || changed
+		}
+	}
+	return changed
+}
@@ -0,0 +1,198 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls retries of idempotent requests on 429, 502, 503, 504
+// and network errors.
+type RetryPolicy struct {
+	MaxRetries int           // 0 disables retries
+	BaseDelay  time.Duration // first backoff step (default 500ms)
+	MaxDelay   time.Duration // cap for backoff and Retry-After (default 60s)
+	// RetryDial also retries failed connects (refused, unreachable, DNS).
+	// They are usually not transient, so by default they fail at once.
+	RetryDial bool
+}
+
+// DefaultRetry is used when Options.Retry is left zero.
+var DefaultRetry = RetryPolicy{MaxRetries: 3, BaseDelay: 500 * time.Millisecond, MaxDelay: 60 * time.Second}
+
+// lowQuota is the fraction of the limit below which requests are spread out
+// over the time left until the window resets.
+const lowQuota = 0.10
+
+// retrying retries failed requests and paces them according to the
+// server's rate-limit headers and an optional fixed rate.
+type retrying struct {
+	base   http.RoundTripper
+	policy RetryPolicy
+	log    io.Writer
+
+	mu       sync.Mutex
+	interval time.Duration        // minimum spacing from Options.RequestsPerSecond
+	last     map[string]time.Time // host -> last request start
+	limits   map[string]Limits    // host -> last advertised limits
+}
+
+func newRetrying(base http.RoundTripper, policy RetryPolicy, rps float64, log io.Writer) *retrying {
+	if policy.BaseDelay <= 0 {
+		policy.BaseDelay = DefaultRetry.BaseDelay
+	}
+	if policy.MaxDelay <= 0 {
+		policy.MaxDelay = DefaultRetry.MaxDelay
+	}
+	r := &retrying{base: base, policy: policy, log: log, last: map[string]time.Time{}, limits: map[string]Limits{}}
+	if rps > 0 {
+		r.interval = time.Duration(float64(time.Second) / rps)
+	}
+	return r
+}
+
+func (r *retrying) RoundTrip(req *http.Request) (*http.Response, error) {
+	retries := 0
+	if idempotent(req) && (req.Body == nil || req.GetBody != nil) {
+		retries = r.policy.MaxRetries
+	}
+	for attempt := 0; ; attempt++ {
+		if err := sleep(req.Context(), r.wait(req.URL.Host)); err != nil {
+			return nil, err
+		}
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		resp, err := r.base.RoundTrip(req)
+		if resp != nil {
+			r.observe(req.URL.Host, resp.Header)
+		}
+		if attempt >= retries || !r.retryable(resp, err) || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		delay := backoff(r.policy, attempt)
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			if d, ok := RetryAfter(resp.Header, time.Now()); ok {
+				delay = d
+			}
+		}
+		if delay > r.policy.MaxDelay {
+			delay = r.policy.MaxDelay
+		}
+		// The client's timeout covers every attempt and wait; a wait that
+		// outlasts it would only end in a timeout, so hand back this answer.
+		if dl, ok := req.Context().Deadline(); ok && time.Now().Add(delay).After(dl) {
+			if r.log != nil {
+				fmt.Fprintf(r.log, "not retrying %s %s: waiting %s (%s) would pass the timeout\n",
+					req.Method, RedactURL(req.URL), delay.Round(time.Millisecond), reason)
+			}
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+			_ = resp.Body.Close()
+		}
+		if r.log != nil {
+			fmt.Fprintf(r.log, "retrying %s %s in %s (%s, attempt %d/%d)\n",
+				req.Method, RedactURL(req.URL), delay.Round(time.Millisecond), reason, attempt+1, retries)
+		}
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wait returns how long to hold the next request to host, and reserves the slot.
+func (r *retrying) wait(host string) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	gap := r.interval
+	if l, ok := r.limits[host]; ok && l.Remaining >= 0 && !l.Reset.IsZero() && now.Before(l.Reset) {
+		left := l.Reset.Sub(now)
+		switch {
+		case l.Remaining == 0:
+			gap = max(gap, left)
+		case l.Limit > 0 && float64(l.Remaining) <= lowQuota*float64(l.Limit):
+			gap = max(gap, left/time.Duration(l.Remaining+1))
+		}
+	}
+	d := r.last[host].Add(gap).Sub(now)
+	d = min(max(d, 0), r.policy.MaxDelay)
+	r.last[host] = now.Add(d)
+	return d
+}
+
+func (r *retrying) observe(host string, h http.Header) {
+	if l, ok := ParseRateLimit(h, time.Now()); ok {
+		r.mu.Lock()
+		r.limits[host] = l
+		r.mu.Unlock()
+	}
+}
+
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	// An idempotency key makes a POST/PATCH safe to resend.
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+func (r *retrying) retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return r.policy.RetryDial || !dialError(err)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// dialError reports whether err means no connection was made at all.
+func dialError(err error) bool {
+	var op *net.OpError
+	return errors.As(err, &op) && op.Op == "dial"
+}
+
+// backoff is exponential with full jitter: a random delay in [d/2, d].
+func backoff(p RetryPolicy, attempt int) time.Duration {
+	d := p.BaseDelay << attempt
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
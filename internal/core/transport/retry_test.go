@@ -0,0 +1,49 @@
+package transport
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// deadURL returns a URL on a local port nothing listens on.
+func deadURL(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return "http://" + addr + "/"
+}
+
+func TestDialErrorsRetryOnlyWhenAsked(t *testing.T) {
+	tests := []struct {
+		retryDial bool
+		attempts  int
+	}{
+		{false, 1},
+		{true, 3},
+	}
+	for _, tt := range tests {
+		var attempts int
+		base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			return http.DefaultTransport.RoundTrip(req)
+		})
+		policy := RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, RetryDial: tt.retryDial}
+		rt := newRetrying(base, policy, 0, nil)
+		req, _ := http.NewRequest("GET", deadURL(t), nil)
+		if _, err := rt.RoundTrip(req); err == nil {
+			t.Fatal("no error from a closed port")
+		}
+		if attempts != tt.attempts {
+			t.Errorf("RetryDial=%v: %d attempts, want %d", tt.retryDial, attempts, tt.attempts)
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
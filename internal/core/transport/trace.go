@@ -0,0 +1,138 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timing breaks a request down into its network phases. Phases that didn't
+// happen (e.g. DNS on a reused connection) are zero.
+type Timing struct {
+	DNS      time.Duration
+	Connect  time.Duration
+	TLS      time.Duration
+	TTFB     time.Duration // request written -> first response byte
+	Transfer time.Duration // first byte -> body fully read
+	Total    time.Duration
+	Reused   bool
+}
+
+func (t Timing) MarshalJSON() ([]byte, error) {
+	ms := func(d time.Duration) float64 { return math.Round(float64(d)/1e4) / 100 }
+	return json.Marshal(struct {
+		DNS      float64 `json:"dnsMs"`
+		Connect  float64 `json:"connectMs"`
+		TLS      float64 `json:"tlsMs"`
+		TTFB     float64 `json:"ttfbMs"`
+		Transfer float64 `json:"transferMs"`
+		Total    float64 `json:"totalMs"`
+		Reused   bool    `json:"reused"`
+	}{ms(t.DNS), ms(t.Connect), ms(t.TLS), ms(t.TTFB), ms(t.Transfer), ms(t.Total), t.Reused})
+}
+
+func (t *Timing) UnmarshalJSON(b []byte) error {
+	var v struct {
+		DNS      float64 `json:"dnsMs"`
+		Connect  float64 `json:"connectMs"`
+		TLS      float64 `json:"tlsMs"`
+		TTFB     float64 `json:"ttfbMs"`
+		Transfer float64 `json:"transferMs"`
+		Total    float64 `json:"totalMs"`
+		Reused   bool    `json:"reused"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	d := func(ms float64) time.Duration { return time.Duration(ms * float64(time.Millisecond)) }
+	*t = Timing{d(v.DNS), d(v.Connect), d(v.TLS), d(v.TTFB), d(v.Transfer), d(v.Total), v.Reused}
+	return nil
+}
+
+// Lines renders the breakdown for terminal output.
+func (t Timing) Lines() []string {
+	row := func(label string, d time.Duration) string {
+		return fmt.Sprintf("%-18s %9.1f ms", label, float64(d)/float64(time.Millisecond))
+	}
+	reused := "no"
+	if t.Reused {
+		reused = "yes"
+	}
+	return []string{
+		row("DNS lookup", t.DNS),
+		row("TCP connect", t.Connect),
+		row("TLS handshake", t.TLS),
+		row("Server (TTFB)", t.TTFB),
+		row("Content transfer", t.Transfer),
+		strings.Repeat("-", 31),
+		row("Total", t.Total) + "   (connection reused: " + reused + ")",
+	}
+}
+
+// Timer collects httptrace events for one request (the last attempt wins
+// when a request is retried).
+type Timer struct {
+	mu                  sync.Mutex
+	start               time.Time
+	dnsStart, dnsDone   time.Time
+	connStart, connDone time.Time
+	tlsStart, tlsDone   time.Time
+	wrote, firstByte    time.Time
+	reused              bool
+}
+
+// WithTimer attaches a Timer to ctx; requests made with the returned context
+// report their phases to it.
+func WithTimer(ctx context.Context) (context.Context, *Timer) {
+	t := &Timer{start: time.Now()}
+	set := func(p *time.Time) func() {
+		return func() { t.mu.Lock(); *p = time.Now(); t.mu.Unlock() }
+	}
+	trace := &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { set(&t.dnsStart)() },
+		DNSDone:           func(httptrace.DNSDoneInfo) { set(&t.dnsDone)() },
+		ConnectStart:      func(string, string) { set(&t.connStart)() },
+		ConnectDone:       func(string, string, error) { set(&t.connDone)() },
+		TLSHandshakeStart: set(&t.tlsStart),
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { set(&t.tlsDone)() },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.reused = info.Reused
+			t.mu.Unlock()
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { set(&t.wrote)() },
+		GotFirstResponseByte: set(&t.firstByte),
+	}
+	return httptrace.WithClientTrace(ctx, trace), t
+}
+
+// Finish computes the breakdown; call it once the body has been read.
+func (t *Timer) Finish() Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	end := time.Now()
+	span := func(a, b time.Time) time.Duration {
+		if a.IsZero() || b.IsZero() || b.Before(a) {
+			return 0
+		}
+		return b.Sub(a)
+	}
+	out := Timing{
+		DNS:     span(t.dnsStart, t.dnsDone),
+		Connect: span(t.connStart, t.connDone),
+		TLS:     span(t.tlsStart, t.tlsDone),
+		TTFB:    span(t.wrote, t.firstByte),
+		Total:   end.Sub(t.start),
+		Reused:  t.reused,
+	}
+	if !t.firstByte.IsZero() {
+		out.Transfer = end.Sub(t.firstByte)
+	}
+	return out
+}
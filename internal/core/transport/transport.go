@@ -0,0 +1,195 @@
+// Package transport builds the HTTP clients shared by discovery and requests.
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/profile"
+)
+
+type Options struct {
+	TLS profile.TLS
+	// Proxy is an http, https or socks5 URL; empty uses the environment
+	// and "none" disables proxying.
+	Proxy string
+	// Resolve pins host:port pairs to addresses, as "host:port:addr".
+	Resolve []string
+	// Retry is the retry policy; the zero value means DefaultRetry.
+	// Set MaxRetries to -1 to disable retries.
+	Retry RetryPolicy
+	// RequestsPerSecond paces requests per host; 0 means unlimited.
+	RequestsPerSecond float64
+	// Log receives retry notices; nil keeps them quiet.
+	Log io.Writer
+	// Dump receives a redacted wire trace of every request and response.
+	Dump io.Writer
+	// BaseDir resolves relative certificate paths (usually the profile's directory).
+	BaseDir string
+	// Warn receives loud warnings, e.g. when verification is disabled.
+	Warn io.Writer
+}
+
+// New returns a transport configured from opt: proxy, pinned addresses and
+// TLS on an http.Transport, wrapped with retries and rate-limit pacing.
+func New(opt Options) (http.RoundTripper, error) {
+	t, err := newHTTPTransport(opt)
+	if err != nil {
+		return nil, err
+	}
+	policy := opt.Retry
+	switch {
+	case policy.MaxRetries < 0:
+		policy.MaxRetries = 0
+	case policy == RetryPolicy{}:
+		policy = DefaultRetry
+	}
+	var rt http.RoundTripper = t
+	if opt.Dump != nil {
+		rt = &dumping{base: t, w: opt.Dump}
+	}
+	return newRetrying(rt, policy, opt.RequestsPerSecond, opt.Log), nil
+}
+
+func newHTTPTransport(opt Options) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	proxy, err := proxyFunc(opt.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	t.Proxy = proxy
+
+	overrides, err := parseResolve(opt.Resolve)
+	if err != nil {
+		return nil, err
+	}
+	if len(overrides) > 0 {
+		d := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		t.DialContext = resolvingDialer(d.DialContext, overrides)
+	}
+
+	cfg, err := TLSConfig(opt.TLS, opt.BaseDir)
+	if err != nil {
+		return nil, err
+	}
+	if cfg != nil {
+		t.TLSClientConfig = cfg
+		if cfg.InsecureSkipVerify && opt.Warn != nil {
+			fmt.Fprintln(opt.Warn, "!!! WARNING: TLS certificate verification is DISABLED (tls.insecureSkipVerify).")
+			fmt.Fprintln(opt.Warn, "!!! Anyone on the network path can read and modify this traffic.")
+		}
+	}
+	return t, nil
+}
+
+// NewClient wraps New in an *http.Client with the given timeout.
+func NewClient(opt Options, timeout time.Duration) (*http.Client, error) {
+	t, err := New(opt)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: t, Timeout: timeout}, nil
+}
+
+// TLSConfig translates a profile tls block; it returns nil when the block is empty.
+func TLSConfig(p profile.TLS, baseDir string) (*tls.Config, error) {
+	if p.IsZero() {
+		return nil, nil
+	}
+	cfg := &tls.Config{
+		ServerName:         p.ServerName,
+		InsecureSkipVerify: p.InsecureSkipVerify,
+	}
+
+	if p.MinVersion != "" {
+		v, err := parseVersion(p.MinVersion)
+		if err != nil {
+			return nil, err
+		}
+		cfg.MinVersion = v
+	}
+
+	if p.CABundle != "" {
+		pem, err := os.ReadFile(resolvePath(baseDir, p.CABundle))
+		if err != nil {
+			return nil, fmt.Errorf("tls.caBundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls.caBundle: no PEM certificates in %s", p.CABundle)
+		}
+		cfg.RootCAs = pool
+	}
+
+	switch {
+	case p.ClientCert != "" && p.ClientKey != "":
+		cert, err := tls.LoadX509KeyPair(resolvePath(baseDir, p.ClientCert), resolvePath(baseDir, p.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("tls client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	case p.ClientCert != "" || p.ClientKey != "":
+		return nil, errors.New("tls: clientCert and clientKey must be set together")
+	}
+	return cfg, nil
+}
+
+// DescribeTLS renders the negotiated TLS parameters for --debug output.
+func DescribeTLS(cs *tls.ConnectionState) []string {
+	if cs == nil {
+		return nil
+	}
+	out := []string{
+		fmt.Sprintf("%s, cipher %s", tls.VersionName(cs.Version), tls.CipherSuiteName(cs.CipherSuite)),
+	}
+	if cs.ServerName != "" {
+		out = append(out, "server name: "+cs.ServerName)
+	}
+	if cs.NegotiatedProtocol != "" {
+		out = append(out, "ALPN: "+cs.NegotiatedProtocol)
+	}
+	for i, c := range cs.PeerCertificates {
+		out = append(out, fmt.Sprintf("cert[%d]: %s (issuer: %s, expires %s)",
+			i, c.Subject.String(), c.Issuer.String(), c.NotAfter.Format("2006-01-02")))
+	}
+	return out
+}
+
+func parseVersion(s string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), "tls") {
+	case "1.0", "10":
+		return tls.VersionTLS10, nil
+	case "1.1", "11":
+		return tls.VersionTLS11, nil
+	case "1.2", "12":
+		return tls.VersionTLS12, nil
+	case "1.3", "13":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("tls.minVersion: unknown version %q (want 1.0-1.3)", s)
+}
+
+func resolvePath(baseDir, p string) string {
+	if strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, p[2:])
+		}
+	}
+	if filepath.IsAbs(p) || baseDir == "" {
+		return p
+	}
+	return filepath.Join(baseDir, p)
+}
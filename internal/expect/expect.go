@@ -0,0 +1,527 @@
+// Package expect parses and checks response assertions such as
+//
+//	status=2xx
+//	header:Content-Type=~json
+//	$.ok==true
+//	$.items[0].id
+//	schema=@item.schema.json
+//	latency<500ms
+//
+// used by `restless request --expect` and scenario steps.
+package expect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/filter"
+	"github.com/bspippi1337/restless/internal/jsonschema"
+)
+
+// Kinds of expectation.
+const (
+	Status  = "status"
+	Header  = "header"
+	JSON    = "json"
+	Body    = "body"
+	Schema  = "schema"
+	Latency = "latency"
+)
+
+// Expectation is one parsed assertion.
+type Expectation struct {
+	Raw  string
+	Kind string
+
+	name   string // header name or JSON path
+	op     string // ==, !=, =~, !~, <, <=, >, >=, exists, absent
+	want   string
+	prog   *filter.Program
+	re     *regexp.Regexp
+	schema map[string]any
+	max    time.Duration
+}
+
+// Response is what expectations are checked against.
+type Response struct {
+	Status   int
+	Header   http.Header
+	Body     []byte
+	Duration time.Duration
+}
+
+// Failure is an expectation that did not hold.
+type Failure struct {
+	Expect string
+	Reason string
+}
+
+func (f Failure) Error() string { return f.Expect + ": " + f.Reason }
+
+// Failures is the error returned by Check when anything failed.
+type Failures []Failure
+
+func (fs Failures) Error() string {
+	if len(fs) == 1 {
+		return fs[0].Error()
+	}
+	parts := make([]string, len(fs))
+	for i, f := range fs {
+		parts[i] = f.Error()
+	}
+	return fmt.Sprintf("%d expectations failed: %s", len(fs), strings.Join(parts, "; "))
+}
+
+// comparison operators, longest first so "<=" wins over "<".
+var ops = []string{"==", "!=", "=~", "!~", "<=", ">=", "<", ">", "="}
+
+// Parse reads one assertion. Relative schema files are resolved against dir.
+func Parse(raw, dir string) (*Expectation, error) {
+	s := strings.TrimSpace(raw)
+	e := &Expectation{Raw: s}
+	bad := func(format string, args ...any) (*Expectation, error) {
+		return nil, fmt.Errorf("expect %q: %s", raw, fmt.Sprintf(format, args...))
+	}
+	neg := strings.HasPrefix(s, "!")
+	if neg {
+		s = s[1:]
+	}
+
+	switch {
+	case strings.HasPrefix(s, "$") || strings.HasPrefix(s, "."):
+		e.Kind = JSON
+		path, op, want := splitOp(s)
+		path = jqPath(path)
+		prog, err := filter.Compile(path)
+		if err != nil {
+			return bad("%v", err)
+		}
+		e.name, e.prog = path, prog
+		switch {
+		case op == "" && neg:
+			e.op = "absent"
+		case op == "":
+			e.op = "exists"
+		case neg:
+			return bad("! only goes with a bare path")
+		default:
+			e.op, e.want = normOp(op), want
+		}
+
+	case strings.HasPrefix(s, "header:"):
+		e.Kind = Header
+		name, op, want := splitOp(strings.TrimPrefix(s, "header:"))
+		e.name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if e.name == "" {
+			return bad("missing header name")
+		}
+		switch {
+		case op == "" && neg:
+			e.op = "absent"
+		case op == "":
+			e.op = "exists"
+		case neg:
+			return bad("! only goes with a bare header:Name")
+		default:
+			e.op, e.want = normOp(op), strings.TrimSpace(want)
+			if e.op != "==" && e.op != "!=" && e.op != "=~" && e.op != "!~" {
+				return bad("headers take =, !=, =~ or !~")
+			}
+		}
+
+	default:
+		key, op, want := splitOp(s)
+		e.Kind, e.op, e.want = strings.TrimSpace(key), normOp(op), strings.TrimSpace(want)
+		if neg || op == "" {
+			return bad("want status=..., header:Name..., $.path..., body=~..., schema=... or latency<...")
+		}
+		switch e.Kind {
+		case Status:
+			for _, w := range strings.Split(e.want, ",") {
+				if !validStatus(strings.TrimSpace(w), e.op) {
+					return bad("bad status %q", w)
+				}
+			}
+		case Body:
+			if e.op != "=~" && e.op != "!~" {
+				return bad("body takes =~ or !~")
+			}
+		case Schema:
+			if e.op != "==" {
+				return bad("use schema=@file.json or schema={...}")
+			}
+			sch, err := loadSchema(e.want, dir)
+			if err != nil {
+				return bad("%v", err)
+			}
+			e.schema = sch
+		case "latency", "time", "duration":
+			e.Kind = Latency
+			if e.op != "<" && e.op != "<=" {
+				return bad("latency takes < or <=")
+			}
+			d, err := parseDuration(e.want)
+			if err != nil {
+				return bad("%v", err)
+			}
+			e.max = d
+		default:
+			return bad("unknown check %q", e.Kind)
+		}
+	}
+	if e.op == "=~" || e.op == "!~" {
+		re, err := regexp.Compile(e.want)
+		if err != nil {
+			return bad("%v", err)
+		}
+		e.re = re
+	}
+	return e, nil
+}
+
+// ParseAll parses every assertion, stopping at the first bad one.
+func ParseAll(raws []string, dir string) ([]*Expectation, error) {
+	var out []*Expectation
+	for _, r := range raws {
+		e, err := Parse(r, dir)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// Check runs every expectation and returns Failures, or nil when all hold.
+func Check(exps []*Expectation, r Response) error {
+	var out Failures
+	var doc any
+	var docErr error
+	decoded := false
+	for _, e := range exps {
+		if (e.Kind == JSON || e.Kind == Schema) && !decoded {
+			doc, docErr = filter.Decode(r.Body)
+			decoded = true
+		}
+		var reason string
+		switch {
+		case (e.Kind == JSON || e.Kind == Schema) && docErr != nil:
+			reason = "response is not JSON"
+		default:
+			reason = e.check(r, doc)
+		}
+		if reason != "" {
+			out = append(out, Failure{Expect: e.Raw, Reason: reason})
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// check returns why e failed, or "".
+func (e *Expectation) check(r Response, doc any) string {
+	switch e.Kind {
+	case Status:
+		if !matchStatus(e.op, e.want, r.Status) {
+			return fmt.Sprintf("got %d", r.Status)
+		}
+	case Header:
+		vals := r.Header.Values(e.name)
+		got := strings.Join(vals, ", ")
+		switch e.op {
+		case "exists":
+			if len(vals) == 0 {
+				return "header missing"
+			}
+		case "absent":
+			if len(vals) > 0 {
+				return fmt.Sprintf("got %q", got)
+			}
+		default:
+			if len(vals) == 0 {
+				return "header missing"
+			}
+			if !compareText(e.op, got, e.want, e.re) {
+				return fmt.Sprintf("got %q", got)
+			}
+		}
+	case Body:
+		if !compareText(e.op, string(r.Body), "", e.re) {
+			if e.op == "=~" {
+				return "no match in the body"
+			}
+			return "the body matches"
+		}
+	case Schema:
+		errs := jsonschema.Validate(e.schema, doc)
+		if len(errs) > 0 {
+			var parts []string
+			for i, err := range errs {
+				if i == 3 {
+					parts = append(parts, fmt.Sprintf("and %d more", len(errs)-3))
+					break
+				}
+				parts = append(parts, err.Error())
+			}
+			return strings.Join(parts, "; ")
+		}
+	case Latency:
+		if r.Duration > e.max || (e.op == "<" && r.Duration == e.max) {
+			return fmt.Sprintf("took %s", r.Duration.Round(time.Millisecond))
+		}
+	case JSON:
+		vals, err := e.prog.Run(doc)
+		if err != nil {
+			return err.Error()
+		}
+		var got any
+		if len(vals) > 0 {
+			got = vals[0]
+		}
+		return compareJSON(e.op, got, e.want, e.re)
+	}
+	return ""
+}
+
+func compareJSON(op string, got any, want string, re *regexp.Regexp) string {
+	switch op {
+	case "exists":
+		if got == nil {
+			return "missing or null"
+		}
+		return ""
+	case "absent":
+		if got != nil {
+			return "got " + show(got)
+		}
+		return ""
+	case "=~", "!~":
+		if !compareText(op, text(got), "", re) {
+			return "got " + show(got)
+		}
+		return ""
+	case "<", "<=", ">", ">=":
+		g, okG := toFloat(got)
+		w, okW := toFloat(literal(want))
+		if !okW {
+			return fmt.Sprintf("%q is not a number", want)
+		}
+		if !okG {
+			return "got " + show(got) + ", not a number"
+		}
+		if !compareNum(op, g, w) {
+			return "got " + show(got)
+		}
+		return ""
+	}
+	eq := equal(got, literal(want))
+	if eq != (op == "==") {
+		return "got " + show(got)
+	}
+	return ""
+}
+
+func compareText(op, got, want string, re *regexp.Regexp) bool {
+	switch op {
+	case "=~":
+		return re.MatchString(got)
+	case "!~":
+		return !re.MatchString(got)
+	case "!=":
+		return got != want
+	}
+	return got == want
+}
+
+func compareNum(op string, a, b float64) bool {
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "!=":
+		return a != b
+	}
+	return a == b
+}
+
+func matchStatus(op, want string, got int) bool {
+	if op != "==" && op != "!=" {
+		n, _ := strconv.Atoi(want)
+		return compareNum(op, float64(got), float64(n))
+	}
+	hit := false
+	for _, w := range strings.Split(want, ",") {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if strings.HasSuffix(w, "xx") {
+			hit = hit || got/100 == int(w[0]-'0')
+		} else if n, err := strconv.Atoi(w); err == nil {
+			hit = hit || n == got
+		}
+	}
+	return hit == (op == "==")
+}
+
+func validStatus(w, op string) bool {
+	w = strings.ToLower(w)
+	if len(w) == 3 && strings.HasSuffix(w, "xx") {
+		return w[0] >= '1' && w[0] <= '5' && (op == "==" || op == "!=")
+	}
+	n, err := strconv.Atoi(w)
+	return err == nil && n >= 100 && n <= 599
+}
+
+// splitOp splits "lhs op rhs" at the first operator outside brackets,
+// parentheses and quotes.
+func splitOp(s string) (lhs, op, rhs string) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		case c == '"':
+			quote = c
+			continue
+		case c == '[' || c == '(' || c == '{':
+			depth++
+			continue
+		case c == ']' || c == ')' || c == '}':
+			depth--
+			continue
+		}
+		if depth > 0 {
+			continue
+		}
+		for _, o := range ops {
+			if strings.HasPrefix(s[i:], o) {
+				return strings.TrimSpace(s[:i]), o, strings.TrimSpace(s[i+len(o):])
+			}
+		}
+	}
+	return strings.TrimSpace(s), "", ""
+}
+
+func normOp(op string) string {
+	if op == "=" {
+		return "=="
+	}
+	return op
+}
+
+// jqPath turns a JSONPath-style $.a.b[0] into the filter syntax .a.b[0].
+func jqPath(p string) string {
+	switch {
+	case p == "$":
+		return "."
+	case strings.HasPrefix(p, "$."):
+		return p[1:]
+	case strings.HasPrefix(p, "$["):
+		return "." + p[1:]
+	}
+	return p
+}
+
+// literal reads the right-hand side as JSON when it is valid JSON
+// (true, 3, "x", null), otherwise as a plain string.
+func literal(s string) any {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err == nil && !dec.More() {
+		return v
+	}
+	return s
+}
+
+func loadSchema(spec, dir string) (map[string]any, error) {
+	data := []byte(spec)
+	if name, ok := strings.CutPrefix(spec, "@"); ok {
+		if !filepath.IsAbs(name) && dir != "" {
+			name = filepath.Join(dir, name)
+		}
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		data = b
+	}
+	v, err := filter.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("schema is not JSON: %w", err)
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, errors.New("schema must be a JSON object")
+	}
+	return m, nil
+}
+
+// parseDuration accepts Go durations and bare milliseconds.
+func parseDuration(s string) (time.Duration, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return time.Duration(n) * time.Millisecond, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func equal(a, b any) bool {
+	fa, okA := toFloat(a)
+	fb, okB := toFloat(b)
+	if okA && okB {
+		return fa == fb
+	}
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
+
+func text(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func show(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if r := []rune(string(b)); len(r) > 60 {
+		return string(r[:57]) + "..."
+	}
+	return string(b)
+}
@@ -0,0 +1,508 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// arity lists the supported builtins and how many arguments each takes.
+var arity = map[string]int{
+	"empty": 0, "not": 0, "length": 0, "keys": 0, "values": 0, "type": 0,
+	"first": 0, "last": 0, "reverse": 0, "sort": 0, "unique": 0, "add": 0,
+	"min": 0, "max": 0, "flatten": 0, "any": 0, "all": 0,
+	"to_entries": 0, "from_entries": 0, "tostring": 0, "tonumber": 0,
+	"ascii_downcase": 0, "ascii_upcase": 0,
+	"map": 1, "select": 1, "has": 1, "join": 1, "sort_by": 1, "unique_by": 1,
+	"group_by": 1, "min_by": 1, "max_by": 1, "test": 1, "startswith": 1,
+	"endswith": 1, "split": 1, "contains": 1, "map_values": 1, "with_entries": 1,
+}
+
+func checkCall(c call) error {
+	n, ok := arity[c.name]
+	if !ok {
+		return fmt.Errorf("unknown function %s", c.name)
+	}
+	if n != len(c.args) {
+		return fmt.Errorf("%s/%d is not defined (%s takes %d argument(s))", c.name, len(c.args), c.name, n)
+	}
+	return nil
+}
+
+func callBuiltin(c call, in any) ([]any, error) {
+	one := func(v any) ([]any, error) { return []any{v}, nil }
+	bad := func() ([]any, error) { return nil, fail(c, "%s cannot be applied to %s", c.name, typeOf(in)) }
+
+	switch c.name {
+	case "empty":
+		return nil, nil
+	case "not":
+		return one(!truthy(in))
+	case "type":
+		return one(typeOf(in))
+	case "length":
+		switch v := in.(type) {
+		case nil:
+			return one(number(0))
+		case string:
+			return one(number(float64(utf8.RuneCountInString(v))))
+		case []any:
+			return one(number(float64(len(v))))
+		case map[string]any:
+			return one(number(float64(len(v))))
+		case bool:
+			return bad()
+		}
+		f, _ := toFloat(in)
+		if f < 0 {
+			f = -f
+		}
+		return one(number(f))
+	case "keys":
+		switch v := in.(type) {
+		case map[string]any:
+			return one(toAny(sortedKeys(v)))
+		case []any:
+			out := make([]any, len(v))
+			for i := range v {
+				out[i] = number(float64(i))
+			}
+			return one(out)
+		}
+		return bad()
+	case "values":
+		if in == nil {
+			return nil, nil
+		}
+		return one(in)
+	case "first", "last":
+		arr, ok := in.([]any)
+		if !ok {
+			return bad()
+		}
+		if len(arr) == 0 {
+			return one(nil)
+		}
+		if c.name == "first" {
+			return one(arr[0])
+		}
+		return one(arr[len(arr)-1])
+	case "reverse":
+		switch v := in.(type) {
+		case []any:
+			out := make([]any, len(v))
+			for i, x := range v {
+				out[len(v)-1-i] = x
+			}
+			return one(out)
+		case string:
+			r := []rune(v)
+			for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+				r[i], r[j] = r[j], r[i]
+			}
+			return one(string(r))
+		case nil:
+			return one([]any{})
+		}
+		return bad()
+	case "sort", "unique", "min", "max":
+		arr, ok := in.([]any)
+		if !ok {
+			return bad()
+		}
+		s := append([]any{}, arr...)
+		sort.SliceStable(s, func(i, j int) bool { return compare(s[i], s[j]) < 0 })
+		switch c.name {
+		case "unique":
+			return one(dedupe(s, s))
+		case "min":
+			if len(s) == 0 {
+				return one(nil)
+			}
+			return one(s[0])
+		case "max":
+			if len(s) == 0 {
+				return one(nil)
+			}
+			return one(s[len(s)-1])
+		}
+		return one(s)
+	case "add":
+		arr, ok := in.([]any)
+		if !ok {
+			return bad()
+		}
+		var acc any
+		for _, x := range arr {
+			v, err := apply("+", acc, x)
+			if err != nil {
+				return nil, fail(c, "%v", err)
+			}
+			acc = v
+		}
+		return one(acc)
+	case "flatten":
+		arr, ok := in.([]any)
+		if !ok {
+			return bad()
+		}
+		return one(flatten(arr))
+	case "any", "all":
+		arr, ok := in.([]any)
+		if !ok {
+			return bad()
+		}
+		res := c.name == "all"
+		for _, x := range arr {
+			if truthy(x) != res {
+				return one(!res)
+			}
+		}
+		return one(res)
+	case "to_entries":
+		m, ok := in.(map[string]any)
+		if !ok {
+			return bad()
+		}
+		out := []any{}
+		for _, k := range sortedKeys(m) {
+			out = append(out, map[string]any{"key": k, "value": m[k]})
+		}
+		return one(out)
+	case "from_entries":
+		arr, ok := in.([]any)
+		if !ok {
+			return bad()
+		}
+		out := map[string]any{}
+		for _, e := range arr {
+			m, ok := e.(map[string]any)
+			if !ok {
+				return nil, fail(c, "from_entries expects objects, got %s", typeOf(e))
+			}
+			k := firstOf(m, "key", "k", "name", "Name", "Key")
+			var ks string
+			switch kv := k.(type) {
+			case string:
+				ks = kv
+			case nil:
+				return nil, fail(c, "from_entries: entry has no key")
+			default:
+				b, _ := json.Marshal(kv)
+				ks = string(b)
+			}
+			out[ks] = firstOf(m, "value", "v", "Value")
+		}
+		return one(out)
+	case "tostring":
+		if s, ok := in.(string); ok {
+			return one(s)
+		}
+		b, _ := json.Marshal(in)
+		return one(string(b))
+	case "tonumber":
+		switch v := in.(type) {
+		case string:
+			n := json.Number(strings.TrimSpace(v))
+			if _, err := n.Float64(); err != nil {
+				return nil, fail(c, "cannot parse %q as a number", v)
+			}
+			return one(n)
+		case json.Number, float64:
+			return one(v)
+		}
+		return bad()
+	case "ascii_downcase", "ascii_upcase":
+		s, ok := in.(string)
+		if !ok {
+			return bad()
+		}
+		if c.name == "ascii_downcase" {
+			return one(strings.ToLower(s))
+		}
+		return one(strings.ToUpper(s))
+	}
+
+	// Builtins with a filter argument.
+	arg := c.args[0]
+	switch c.name {
+	case "map":
+		var it node = &iterate{p: c.p, target: identity{p: c.p}}
+		return eval(array{p: c.p, body: pipe{p: c.p, l: it, r: arg}}, in)
+	case "map_values":
+		switch v := in.(type) {
+		case map[string]any:
+			out := map[string]any{}
+			for k, x := range v {
+				rs, err := eval(arg, x)
+				if err != nil {
+					return nil, err
+				}
+				if len(rs) > 0 {
+					out[k] = rs[0]
+				}
+			}
+			return one(out)
+		case []any:
+			out := []any{}
+			for _, x := range v {
+				rs, err := eval(arg, x)
+				if err != nil {
+					return nil, err
+				}
+				if len(rs) > 0 {
+					out = append(out, rs[0])
+				}
+			}
+			return one(out)
+		}
+		return bad()
+	case "with_entries":
+		m, ok := in.(map[string]any)
+		if !ok {
+			return bad()
+		}
+		entries, _ := callBuiltin(call{p: c.p, name: "to_entries"}, m)
+		mapped, err := callBuiltin(call{p: c.p, name: "map", args: []node{arg}}, entries[0])
+		if err != nil {
+			return nil, err
+		}
+		return callBuiltin(call{p: c.p, name: "from_entries"}, mapped[0])
+	case "select":
+		rs, err := eval(arg, in)
+		if err != nil {
+			return nil, err
+		}
+		var out []any
+		for _, r := range rs {
+			if truthy(r) {
+				out = append(out, in)
+			}
+		}
+		return out, nil
+	case "sort_by", "unique_by", "group_by", "min_by", "max_by":
+		arr, ok := in.([]any)
+		if !ok {
+			return bad()
+		}
+		keys := make([]any, len(arr))
+		for i, x := range arr {
+			rs, err := eval(arg, x)
+			if err != nil {
+				return nil, err
+			}
+			keys[i] = rs
+			if rs == nil {
+				keys[i] = []any{}
+			}
+		}
+		idx := make([]int, len(arr))
+		for i := range idx {
+			idx[i] = i
+		}
+		sort.SliceStable(idx, func(a, b int) bool { return compare(keys[idx[a]], keys[idx[b]]) < 0 })
+		sorted := make([]any, len(arr))
+		sortedKeys := make([]any, len(arr))
+		for i, j := range idx {
+			sorted[i], sortedKeys[i] = arr[j], keys[j]
+		}
+		switch c.name {
+		case "unique_by":
+			return one(dedupe(sorted, sortedKeys))
+		case "group_by":
+			out := []any{}
+			for i := 0; i < len(sorted); {
+				j := i
+				for j < len(sorted) && compare(sortedKeys[i], sortedKeys[j]) == 0 {
+					j++
+				}
+				out = append(out, append([]any{}, sorted[i:j]...))
+				i = j
+			}
+			return one(out)
+		case "min_by":
+			if len(sorted) == 0 {
+				return one(nil)
+			}
+			return one(sorted[0])
+		case "max_by":
+			if len(sorted) == 0 {
+				return one(nil)
+			}
+			return one(sorted[len(sorted)-1])
+		}
+		return one(sorted)
+	}
+
+	// Builtins whose argument is a value, evaluated against the input.
+	args, err := eval(arg, in)
+	if err != nil {
+		return nil, err
+	}
+	var out []any
+	for _, a := range args {
+		v, err := valueBuiltin(c, in, a)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func valueBuiltin(c call, in, a any) (any, error) {
+	str := func(v any, what string) (string, error) {
+		s, ok := v.(string)
+		if !ok {
+			return "", fail(c, "%s: %s must be a string, not %s", c.name, what, typeOf(v))
+		}
+		return s, nil
+	}
+	switch c.name {
+	case "has":
+		switch v := in.(type) {
+		case map[string]any:
+			k, err := str(a, "key")
+			if err != nil {
+				return nil, err
+			}
+			_, ok := v[k]
+			return ok, nil
+		case []any:
+			f, ok := toFloat(a)
+			if !ok {
+				return nil, fail(c, "has: array index must be a number")
+			}
+			return f >= 0 && int(f) < len(v), nil
+		}
+		return nil, fail(c, "has cannot be applied to %s", typeOf(in))
+	case "join":
+		sep, err := str(a, "separator")
+		if err != nil {
+			return nil, err
+		}
+		arr, ok := in.([]any)
+		if !ok {
+			return nil, fail(c, "join cannot be applied to %s", typeOf(in))
+		}
+		parts := make([]string, 0, len(arr))
+		for _, x := range arr {
+			switch t := x.(type) {
+			case nil:
+				parts = append(parts, "")
+			case string:
+				parts = append(parts, t)
+			case bool, json.Number, float64:
+				b, _ := json.Marshal(t)
+				parts = append(parts, string(b))
+			default:
+				return nil, fail(c, "join: cannot join %s", typeOf(x))
+			}
+		}
+		return strings.Join(parts, sep), nil
+	case "test", "startswith", "endswith", "split":
+		s, err := str(in, "input")
+		if err != nil {
+			return nil, err
+		}
+		p, err := str(a, "argument")
+		if err != nil {
+			return nil, err
+		}
+		switch c.name {
+		case "test":
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, fail(c, "test: %v", err)
+			}
+			return re.MatchString(s), nil
+		case "startswith":
+			return strings.HasPrefix(s, p), nil
+		case "endswith":
+			return strings.HasSuffix(s, p), nil
+		}
+		out := []any{}
+		for _, part := range strings.Split(s, p) {
+			out = append(out, part)
+		}
+		return out, nil
+	case "contains":
+		return contains(in, a), nil
+	}
+	return nil, fail(c, "unknown function %s", c.name)
+}
+
+func contains(a, b any) bool {
+	switch av := a.(type) {
+	case string:
+		bs, ok := b.(string)
+		return ok && strings.Contains(av, bs)
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			return false
+		}
+		for _, y := range bv {
+			found := false
+			for _, x := range av {
+				if contains(x, y) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, y := range bv {
+			x, ok := av[k]
+			if !ok || !contains(x, y) {
+				return false
+			}
+		}
+		return true
+	}
+	return compare(a, b) == 0
+}
+
+// dedupe keeps the first item of each run of equal keys in a sorted slice.
+func dedupe(items, keys []any) []any {
+	out := []any{}
+	for i := range items {
+		if i > 0 && compare(keys[i], keys[i-1]) == 0 {
+			continue
+		}
+		out = append(out, items[i])
+	}
+	return out
+}
+
+func flatten(arr []any) []any {
+	out := []any{}
+	for _, x := range arr {
+		if inner, ok := x.([]any); ok {
+			out = append(out, flatten(inner)...)
+			continue
+		}
+		out = append(out, x)
+	}
+	return out
+}
+
+func firstOf(m map[string]any, keys ...string) any {
+	for _, k := range keys {
+		if v, ok := m[k]; ok {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,549 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func fail(n node, format string, args ...any) error {
+	return &Error{Pos: n.position(), Msg: fmt.Sprintf(format, args...)}
+}
+
+func eval(n node, in any) ([]any, error) {
+	switch n := n.(type) {
+	case identity:
+		return []any{in}, nil
+	case recurse:
+		var out []any
+		walk(in, func(v any) { out = append(out, v) })
+		return out, nil
+	case literal:
+		return []any{n.v}, nil
+	// Pipes, commas and iteration return the outputs produced before an
+	// error along with it, as jq streams them.
+	case pipe:
+		ls, lerr := eval(n.l, in)
+		var out []any
+		for _, l := range ls {
+			rs, err := eval(n.r, l)
+			out = append(out, rs...)
+			if err != nil {
+				return out, err
+			}
+		}
+		return out, lerr
+	case comma:
+		ls, err := eval(n.l, in)
+		if err != nil {
+			return ls, err
+		}
+		rs, err := eval(n.r, in)
+		return append(ls, rs...), err
+	case *index:
+		return evalIndex(n, in)
+	case *iterate:
+		targets, terr := eval(n.target, in)
+		var out []any
+		for _, t := range targets {
+			switch v := t.(type) {
+			case []any:
+				out = append(out, v...)
+			case map[string]any:
+				for _, k := range sortedKeys(v) {
+					out = append(out, v[k])
+				}
+			default:
+				if !n.optional {
+					return out, fail(n, "cannot iterate over %s", typeOf(t))
+				}
+			}
+		}
+		return out, terr
+	case *slice:
+		return evalSlice(n, in)
+	case binary:
+		return evalBinary(n, in)
+	case array:
+		if n.body == nil {
+			return []any{[]any{}}, nil
+		}
+		vs, err := eval(n.body, in)
+		if err != nil {
+			return nil, err
+		}
+		if vs == nil {
+			vs = []any{}
+		}
+		return []any{vs}, nil
+	case object:
+		return evalObject(n, in)
+	case call:
+		return callBuiltin(n, in)
+	case try:
+		out, _ := eval(n.body, in)
+		return out, nil
+	}
+	return nil, fail(n, "unsupported expression")
+}
+
+func evalIndex(n *index, in any) ([]any, error) {
+	targets, terr := eval(n.target, in)
+	// The key is evaluated against the original input, as in jq.
+	keys, err := eval(n.key, in)
+	if err != nil {
+		return nil, err
+	}
+	var out []any
+	for _, t := range targets {
+		for _, k := range keys {
+			v, err := lookup(t, k)
+			if err != nil {
+				if n.optional {
+					continue
+				}
+				return out, fail(n, "%v", err)
+			}
+			out = append(out, v)
+		}
+	}
+	return out, terr
+}
+
+func lookup(t, k any) (any, error) {
+	switch tv := t.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		ks, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot index object with %s", typeOf(k))
+		}
+		return tv[ks], nil
+	case []any:
+		f, ok := toFloat(k)
+		if ks, isStr := k.(string); isStr {
+			return nil, fmt.Errorf("cannot index array with %q", ks)
+		} else if !ok {
+			return nil, fmt.Errorf("cannot index array with %s", typeOf(k))
+		}
+		i := int(math.Floor(f))
+		if i < 0 {
+			i += len(tv)
+		}
+		if i < 0 || i >= len(tv) {
+			return nil, nil
+		}
+		return tv[i], nil
+	}
+	if ks, ok := k.(string); ok {
+		return nil, fmt.Errorf("cannot index %s with %q", typeOf(t), ks)
+	}
+	return nil, fmt.Errorf("cannot index %s with %s", typeOf(t), typeOf(k))
+}
+
+func evalSlice(n *slice, in any) ([]any, error) {
+	targets, err := eval(n.target, in)
+	if err != nil {
+		return nil, err
+	}
+	bound := func(b node, def int, length int) (int, error) {
+		if b == nil {
+			return def, nil
+		}
+		vs, err := eval(b, in)
+		if err != nil {
+			return 0, err
+		}
+		if len(vs) != 1 {
+			return 0, fail(b, "slice bound must be a single value")
+		}
+		if vs[0] == nil {
+			return def, nil
+		}
+		f, ok := toFloat(vs[0])
+		if !ok {
+			return 0, fail(b, "slice bound must be a number, not %s", typeOf(vs[0]))
+		}
+		i := int(math.Floor(f))
+		if i < 0 {
+			i += length
+		}
+		return max(0, min(i, length)), nil
+	}
+	var out []any
+	for _, t := range targets {
+		var length int
+		switch v := t.(type) {
+		case []any:
+			length = len(v)
+		case string:
+			length = len([]rune(v))
+		case nil:
+			out = append(out, nil)
+			continue
+		default:
+			if n.optional {
+				continue
+			}
+			return nil, fail(n, "cannot slice %s", typeOf(t))
+		}
+		from, err := bound(n.from, 0, length)
+		if err != nil {
+			return nil, err
+		}
+		to, err := bound(n.to, length, length)
+		if err != nil {
+			return nil, err
+		}
+		if to < from {
+			to = from
+		}
+		switch v := t.(type) {
+		case []any:
+			out = append(out, append([]any{}, v[from:to]...))
+		case string:
+			out = append(out, string([]rune(v)[from:to]))
+		}
+	}
+	return out, nil
+}
+
+func evalBinary(n binary, in any) ([]any, error) {
+	switch n.op {
+	case "//":
+		// Errors on the left count as false; outputs before one still count.
+		ls, _ := eval(n.l, in)
+		var out []any
+		for _, l := range ls {
+			if truthy(l) {
+				out = append(out, l)
+			}
+		}
+		if len(out) > 0 {
+			return out, nil
+		}
+		return eval(n.r, in)
+	case "and", "or":
+		ls, err := eval(n.l, in)
+		if err != nil {
+			return nil, err
+		}
+		var out []any
+		for _, l := range ls {
+			if n.op == "and" && !truthy(l) || n.op == "or" && truthy(l) {
+				out = append(out, truthy(l))
+				continue
+			}
+			rs, err := eval(n.r, in)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range rs {
+				out = append(out, truthy(r))
+			}
+		}
+		return out, nil
+	}
+	rs, err := eval(n.r, in)
+	if err != nil {
+		return nil, err
+	}
+	ls, err := eval(n.l, in)
+	if err != nil {
+		return nil, err
+	}
+	var out []any
+	for _, r := range rs {
+		for _, l := range ls {
+			v, err := apply(n.op, l, r)
+			if err != nil {
+				return nil, fail(n, "%v", err)
+			}
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+func apply(op string, l, r any) (any, error) {
+	switch op {
+	case "==":
+		return compare(l, r) == 0, nil
+	case "!=":
+		return compare(l, r) != 0, nil
+	case "<":
+		return compare(l, r) < 0, nil
+	case "<=":
+		return compare(l, r) <= 0, nil
+	case ">":
+		return compare(l, r) > 0, nil
+	case ">=":
+		return compare(l, r) >= 0, nil
+	}
+	lf, lok := toFloat(l)
+	rf, rok := toFloat(r)
+	if lok && rok {
+		switch op {
+		case "+":
+			return number(lf + rf), nil
+		case "-":
+			return number(lf - rf), nil
+		case "*":
+			return number(lf * rf), nil
+		case "/":
+			if rf == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return number(lf / rf), nil
+		case "%":
+			if int64(rf) == 0 {
+				return nil, fmt.Errorf("modulo by zero")
+			}
+			return number(float64(int64(lf) % int64(rf))), nil
+		}
+	}
+	if op == "+" {
+		switch lv := l.(type) {
+		case nil:
+			return r, nil
+		case string:
+			if rv, ok := r.(string); ok {
+				return lv + rv, nil
+			}
+		case []any:
+			if rv, ok := r.([]any); ok {
+				return append(append([]any{}, lv...), rv...), nil
+			}
+		case map[string]any:
+			if rv, ok := r.(map[string]any); ok {
+				out := map[string]any{}
+				for k, v := range lv {
+					out[k] = v
+				}
+				for k, v := range rv {
+					out[k] = v
+				}
+				return out, nil
+			}
+		}
+		if r == nil {
+			return l, nil
+		}
+	}
+	if op == "-" {
+		if lv, ok := l.([]any); ok {
+			if rv, ok := r.([]any); ok {
+				var out []any
+				for _, x := range lv {
+					keep := true
+					for _, y := range rv {
+						if compare(x, y) == 0 {
+							keep = false
+							break
+						}
+					}
+					if keep {
+						out = append(out, x)
+					}
+				}
+				if out == nil {
+					out = []any{}
+				}
+				return out, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("cannot apply %s to %s and %s", op, typeOf(l), typeOf(r))
+}
+
+func evalObject(n object, in any) ([]any, error) {
+	results := []map[string]any{{}}
+	for _, e := range n.entries {
+		keys, err := eval(e.key, in)
+		if err != nil {
+			return nil, err
+		}
+		vals, err := eval(e.value, in)
+		if err != nil {
+			return nil, err
+		}
+		var next []map[string]any
+		for _, base := range results {
+			for _, k := range keys {
+				ks, ok := k.(string)
+				if !ok {
+					return nil, fail(e.key, "object key must be a string, not %s", typeOf(k))
+				}
+				for _, v := range vals {
+					m := make(map[string]any, len(base)+1)
+					for bk, bv := range base {
+						m[bk] = bv
+					}
+					m[ks] = v
+					next = append(next, m)
+				}
+			}
+		}
+		results = next
+	}
+	out := make([]any, len(results))
+	for i, m := range results {
+		out[i] = m
+	}
+	return out, nil
+}
+
+func walk(v any, fn func(any)) {
+	fn(v)
+	switch t := v.(type) {
+	case []any:
+		for _, x := range t {
+			walk(x, fn)
+		}
+	case map[string]any:
+		for _, k := range sortedKeys(t) {
+			walk(t[k], fn)
+		}
+	}
+}
+
+func truthy(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	}
+	return true
+}
+
+func typeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64, int:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func toFloat(v any) (float64, bool) {
+	switch t := v.(type) {
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	case float64:
+		return t, true
+	case int:
+		return float64(t), true
+	}
+	return 0, false
+}
+
+// number keeps integral results free of a trailing ".0" when encoded.
+func number(f float64) any {
+	if f == math.Trunc(f) && math.Abs(f) < 1e15 {
+		return json.Number(strconv.FormatInt(int64(f), 10))
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// compare orders values like jq: null < false < true < numbers < strings < arrays < objects.
+func compare(a, b any) int {
+	ra, rb := rank(a), rank(b)
+	if ra != rb {
+		return ra - rb
+	}
+	switch av := a.(type) {
+	case bool:
+		bv := b.(bool)
+		switch {
+		case av == bv:
+			return 0
+		case !av:
+			return -1
+		}
+		return 1
+	case string:
+		return strings.Compare(av, b.(string))
+	case []any:
+		bv := b.([]any)
+		for i := 0; i < len(av) && i < len(bv); i++ {
+			if c := compare(av[i], bv[i]); c != 0 {
+				return c
+			}
+		}
+		return len(av) - len(bv)
+	case map[string]any:
+		bv := b.(map[string]any)
+		ak, bk := sortedKeys(av), sortedKeys(bv)
+		if c := compare(toAny(ak), toAny(bk)); c != 0 {
+			return c
+		}
+		for _, k := range ak {
+			if c := compare(av[k], bv[k]); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+	if ra == 3 {
+		af, _ := toFloat(a)
+		bf, _ := toFloat(b)
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+	}
+	return 0
+}
+
+func rank(v any) int {
+	switch t := v.(type) {
+	case nil:
+		return 0
+	case bool:
+		if t {
+			return 2
+		}
+		return 1
+	case string:
+		return 4
+	case []any:
+		return 5
+	case map[string]any:
+		return 6
+	}
+	return 3
+}
+
+func sortedKeys(m map[string]any) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
+
+func toAny(ss []string) []any {
+	out := make([]any, len(ss))
+	for i, s := range ss {
+		out[i] = s
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+// Package filter implements a jq-compatible subset for extracting data from
+// JSON responses: paths (.a.b, .[0], .[], .[1:3], ..), pipes, commas,
+// comparisons, and/or, //, arithmetic, array/object construction and common
+// builtins such as map, select, keys, length and join.
+package filter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Error reports a problem at a byte offset of the filter expression.
+type Error struct {
+	Expr string
+	Pos  int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("filter error at position %d: %s\n  %s\n  %s^", e.Pos+1, e.Msg, e.Expr, strings.Repeat(" ", e.Pos))
+}
+
+type Program struct {
+	src  string
+	root node
+}
+
+// Compile parses expr.
+func Compile(expr string) (*Program, error) {
+	toks, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{src: expr, toks: toks}
+	if p.peek().kind == tEOF {
+		return &Program{src: expr, root: identity{}}, nil
+	}
+	root, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tEOF {
+		return nil, p.errorf(t, "unexpected %s", describe(t))
+	}
+	return &Program{src: expr, root: root}, nil
+}
+
+// Run evaluates the program against a decoded JSON value and returns every output.
+func (p *Program) Run(input any) ([]any, error) {
+	out, err := eval(p.root, input)
+	if e, ok := err.(*Error); ok {
+		e.Expr = p.src
+	}
+	return out, err
+}
+
+// Decode parses JSON the way Run expects (numbers kept as json.Number).
+// The data must hold exactly one value, so text such as "404 page not
+// found" is an error rather than the number 404.
+func Decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return nil, fmt.Errorf("invalid character after top-level value at offset %d", dec.InputOffset())
+	}
+	return v, nil
+}
+
+// Apply decodes data, runs the program and encodes each result on its own
+// line. With raw, string results are written without quotes. When the
+// program fails part way, the results before the failure are returned with
+// the error.
+func (p *Program) Apply(data []byte, raw bool) ([]byte, error) {
+	v, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("filter: input is not JSON: %w", err)
+	}
+	results, runErr := p.Run(v)
+	var b bytes.Buffer
+	for _, r := range results {
+		if s, ok := r.(string); ok && raw {
+			b.WriteString(s)
+			b.WriteString("\n")
+			continue
+		}
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(r); err != nil {
+			return nil, err
+		}
+	}
+	return b.Bytes(), runErr
+}
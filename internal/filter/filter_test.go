@@ -0,0 +1,291 @@
+package filter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const doc = `{
+  "name": "shop",
+  "count": 3,
+  "price": 1.5,
+  "tags": ["a", "b"],
+  "items": [
+    {"id": 1, "name": "pen", "price": 2, "tags": ["x"], "stock": null},
+    {"id": 2, "name": "ink", "price": 10, "tags": [], "stock": 4},
+    {"id": 3, "name": "Pad", "price": 5, "tags": ["x", "y"], "stock": 0}
+  ],
+  "meta": {"next": "/p/2", "a b": true}
+}`
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		expr string
+		raw  bool
+		want string // one compact JSON value per line
+	}{
+		// paths
+		{expr: ".name", want: `"shop"`},
+		{expr: ".meta.next", want: `"/p/2"`},
+		{expr: `.meta."a b"`, want: `true`},
+		{expr: `.meta["a b"]`, want: `true`},
+		{expr: ".missing", want: `null`},
+		{expr: ".missing.deeper", want: `null`},
+		{expr: ".items[0].id", want: `1`},
+		{expr: ".items[-1].id", want: `3`},
+		{expr: ".items[5]", want: `null`},
+		{expr: ".items[].id", want: "1\n2\n3"},
+		{expr: ".tags[]", raw: true, want: "a\nb"},
+		{expr: ".items[1:].[].id", want: "2\n3"},
+		{expr: ".items[:1] | length", want: `1`},
+		{expr: `"hello"[1:3]`, want: `"el"`},
+		{expr: ".name?", want: `"shop"`},
+		{expr: ".count.x?", want: ""},
+		// pipes, commas, construction
+		{expr: ".items[0] | .name, .price", want: "\"pen\"\n2"},
+		{expr: "[.items[].name]", want: `["pen","ink","Pad"]`},
+		{expr: "{name, n: .count}", want: `{"n":3,"name":"shop"}`},
+		{expr: `{(.name): .count}`, want: `{"shop":3}`},
+		{expr: "{a: (1, 2)}", want: "{\"a\":1}\n{\"a\":2}"},
+		// arithmetic and comparisons
+		{expr: ".count + 1", want: `4`},
+		{expr: ".price * 2", want: `3`},
+		{expr: "10 / 4", want: `2.5`},
+		{expr: "7 % 3", want: `1`},
+		{expr: "-.count", want: `-3`},
+		{expr: `.name + "-x"`, want: `"shop-x"`},
+		{expr: ".tags + [\"c\"]", want: `["a","b","c"]`},
+		{expr: `{a: 1} + {b: 2}`, want: `{"a":1,"b":2}`},
+		{expr: "[1,2,3] - [2]", want: `[1,3]`},
+		{expr: "null + 1", want: `1`},
+		{expr: ".count == 3, .count != 3, .count < 4, .count >= 4", want: "true\nfalse\ntrue\nfalse"},
+		{expr: `"a" < "b"`, want: `true`},
+		{expr: "null < false", want: `true`},
+		{expr: "true and false, true or false, (null | not)", want: "false\ntrue\ntrue"},
+		{expr: ".missing // \"fallback\"", want: `"fallback"`},
+		{expr: ".items[0].stock // 0", want: `0`},
+		// builtins
+		{expr: ".items | map(.price)", want: `[2,10,5]`},
+		{expr: ".items[] | select(.price > 4) | .name", raw: true, want: "ink\nPad"},
+		{expr: ".items | map(select(.tags | length > 0)) | length", want: `2`},
+		{expr: ".meta | keys", want: `["a b","next"]`},
+		{expr: ".meta | has(\"next\")", want: `true`},
+		{expr: ".tags | join(\",\")", want: `"a,b"`},
+		{expr: ".items | sort_by(.price) | map(.id)", want: `[1,3,2]`},
+		{expr: ".items | max_by(.price) | .name", want: `"ink"`},
+		{expr: ".items | group_by(.tags | length) | map(length)", want: `[1,1,1]`},
+		{expr: "[3,1,2,1] | sort, unique, min, max, add", want: "[1,1,2,3]\n[1,2,3]\n1\n3\n7"},
+		{expr: "[[1,[2]],3] | flatten", want: `[1,2,3]`},
+		{expr: ".items | map(.name | ascii_downcase) | reverse", want: `["pad","ink","pen"]`},
+		{expr: ".meta | to_entries | map(.key)", want: `["a b","next"]`},
+		{expr: `{a: 1, b: 2} | with_entries(select(.value > 1))`, want: `{"b":2}`},
+		{expr: `[{key: "k", value: 1}] | from_entries`, want: `{"k":1}`},
+		{expr: ".name | test(\"^sh\"), startswith(\"sh\"), endswith(\"x\")", want: "true\ntrue\nfalse"},
+		{expr: `"a,b" | split(",")`, want: `["a","b"]`},
+		{expr: `.tags | contains(["a"])`, want: `true`},
+		{expr: ".count | tostring", want: `"3"`},
+		{expr: `"42" | tonumber`, want: `42`},
+		{expr: "[.items[] | .id] | first, last", want: "1\n3"},
+		{expr: "[.items[].stock] | map(type)", want: `["null","number","number"]`},
+		{expr: "[true, false] | any, all", want: "true\nfalse"},
+		{expr: "[1, empty, 2]", want: `[1,2]`},
+		{expr: "(.items | length), (.name | length), (.meta | length)", want: "3\n4\n2"},
+		{expr: "[..] | length", want: `32`},
+		{expr: "1 # a comment", want: `1`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			p, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			out, err := p.Apply([]byte(doc), tt.raw)
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if got := strings.TrimSuffix(string(out), "\n"); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	for _, expr := range []string{"", ".", "  . "} {
+		p, err := Compile(expr)
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", expr, err)
+		}
+		out, err := p.Apply([]byte(`{"b":1,"a":[true,null]}`), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(out); got != "{\"a\":[true,null],\"b\":1}\n" {
+			t.Errorf("Compile(%q) = %q", expr, got)
+		}
+	}
+}
+
+func TestNumbersKeepPrecision(t *testing.T) {
+	p, _ := Compile(".id")
+	out, err := p.Apply([]byte(`{"id": 12345678901234567890}`), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(out); got != "12345678901234567890\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  int // 1-based, as printed
+		msg  string
+	}{
+		{expr: ".a |", pos: 5, msg: "end of expression"},
+		{expr: ".a ]", pos: 4, msg: "unexpected"},
+		{expr: `.a | "open`, pos: 6, msg: "unterminated string"},
+		{expr: "[.a", pos: 4, msg: `expected "]"`},
+		{expr: "nosuch", pos: 1, msg: "unknown function nosuch"},
+		{expr: "map", pos: 1, msg: "map takes 1 argument"},
+		{expr: "{a: }", pos: 5, msg: `unexpected "}"`},
+		{expr: ".a @ .b", pos: 4, msg: "unexpected character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Compile(tt.expr)
+			var fe *Error
+			if !errors.As(err, &fe) {
+				t.Fatalf("Compile error = %v, want *Error", err)
+			}
+			if fe.Pos+1 != tt.pos {
+				t.Errorf("position = %d, want %d (%v)", fe.Pos+1, tt.pos, err)
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("error %q does not mention %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		expr, input, msg string
+	}{
+		{expr: ".a", input: `[1]`, msg: "cannot index array"},
+		{expr: ".[0]", input: `{"a":1}`, msg: "cannot index object"},
+		{expr: ".[]", input: `3`, msg: "cannot iterate"},
+		{expr: ". + 1", input: `"s"`, msg: "cannot apply +"},
+		{expr: "keys", input: `1`, msg: "keys"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			p, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = p.Apply([]byte(tt.input), false)
+			if err == nil || !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("error = %v, want one mentioning %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestApplyRejectsNonJSON(t *testing.T) {
+	p, _ := Compile(".items")
+	for _, in := range []string{
+		"<html>",
+		"404 page not found",
+		`{"a":1} {"b":2}`,
+		`[1] x`,
+		"",
+	} {
+		if _, err := p.Apply([]byte(in), false); err == nil || !strings.Contains(err.Error(), "not JSON") {
+			t.Errorf("Apply(%q) error = %v, want one saying it is not JSON", in, err)
+		}
+	}
+	for _, in := range []string{`{"items":1}`, " {\"items\":1}\n\n", "1\r\n"} {
+		if _, err := Decode([]byte(in)); err != nil {
+			t.Errorf("Decode(%q) = %v", in, err)
+		}
+	}
+}
+
+func TestApplyKeepsOutputsBeforeAnError(t *testing.T) {
+	tests := []struct {
+		expr, input, want, msg string
+	}{
+		{expr: ".[] | .a", input: `[{"a":1},{"a":2},3,{"a":4}]`, want: "1\n2\n", msg: "cannot index number"},
+		{expr: ".[].a", input: `[{"a":1},"x"]`, want: "1\n", msg: "cannot index string"},
+		{expr: "1, (.a | keys), 3", input: `{"a":5}`, want: "1\n", msg: "keys"},
+		{expr: "(.[] | .a)?", input: `[{"a":1},3]`, want: "1\n"},
+		{expr: "(.[] | .a) // 9", input: `[{"a":1},3]`, want: "1\n"},
+		{expr: "[.[] | .a]", input: `[{"a":1},3]`, want: "", msg: "cannot index number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			p, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := p.Apply([]byte(tt.input), false)
+			if string(out) != tt.want {
+				t.Errorf("output = %q, want %q", out, tt.want)
+			}
+			switch {
+			case tt.msg == "" && err != nil:
+				t.Errorf("error = %v, want none", err)
+			case tt.msg != "" && (err == nil || !strings.Contains(err.Error(), tt.msg)):
+				t.Errorf("error = %v, want one mentioning %q", err, tt.msg)
+			}
+		})
+	}
+}
+
+func TestLex(t *testing.T) {
+	toks, err := lex(`.a ..  ."b c" | .[0] // 1.5e1 "x\né" foo? == != <= >= and`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type tk struct {
+		kind tokKind
+		text string
+		pos  int
+	}
+	want := []tk{
+		{tField, "a", 0},
+		{tDotDot, "..", 3},
+		{tField, "b c", 7},
+		{tPunct, "|", 14},
+		{tDot, ".", 16},
+		{tPunct, "[", 17},
+		{tNumber, "", 18},
+		{tPunct, "]", 19},
+		{tPunct, "//", 21},
+		{tNumber, "", 24},
+		{tString, "x\né", 30},
+		{tIdent, "foo", 38},
+		{tPunct, "?", 41},
+		{tPunct, "==", 43},
+		{tPunct, "!=", 46},
+		{tPunct, "<=", 49},
+		{tPunct, ">=", 52},
+		{tIdent, "and", 55},
+		{tEOF, "", 58},
+	}
+	if len(toks) != len(want) {
+		t.Fatalf("got %d tokens %+v, want %d", len(toks), toks, len(want))
+	}
+	for i, w := range want {
+		g := toks[i]
+		if g.kind != w.kind || (w.text != "" && g.text != w.text) || g.pos != w.pos {
+			t.Errorf("token %d = {%v %q %d}, want {%v %q %d}", i, g.kind, g.text, g.pos, w.kind, w.text, w.pos)
+		}
+	}
+	if toks[9].num != 15 {
+		t.Errorf("1.5e1 = %v, want 15", toks[9].num)
+	}
+}
@@ -0,0 +1,130 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokKind int
+
+const (
+	tEOF    tokKind = iota
+	tDot            // .
+	tDotDot         // ..
+	tField          // .name or ."name"
+	tIdent          // name
+	tString
+	tNumber
+	tPunct // | , ( ) [ ] { } : ? and operators
+)
+
+type token struct {
+	kind tokKind
+	text string // identifier, field name, punctuation or decoded string
+	num  float64
+	pos  int // 0-based byte offset into the expression
+}
+
+func lex(src string) ([]token, error) {
+	var out []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '.':
+			start := i
+			i++
+			switch {
+			case i < len(src) && src[i] == '.':
+				i++
+				out = append(out, token{kind: tDotDot, text: "..", pos: start})
+			case i < len(src) && isIdentStart(src[i]):
+				j := i
+				for j < len(src) && isIdentPart(src[j]) {
+					j++
+				}
+				out = append(out, token{kind: tField, text: src[i:j], pos: start})
+				i = j
+			case i < len(src) && src[i] == '"':
+				s, n, err := lexString(src, i)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, token{kind: tField, text: s, pos: start})
+				i = n
+			default:
+				out = append(out, token{kind: tDot, text: ".", pos: start})
+			}
+		case c == '"':
+			s, n, err := lexString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, token{kind: tString, text: s, pos: i})
+			i = n
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.' || src[j] == 'e' || src[j] == 'E' ||
+				((src[j] == '+' || src[j] == '-') && (src[j-1] == 'e' || src[j-1] == 'E'))) {
+				j++
+			}
+			f, err := strconv.ParseFloat(src[i:j], 64)
+			if err != nil {
+				return nil, &Error{Expr: src, Pos: i, Msg: fmt.Sprintf("invalid number %q", src[i:j])}
+			}
+			out = append(out, token{kind: tNumber, text: src[i:j], num: f, pos: i})
+			i = j
+		case isIdentStart(c) || c == '$':
+			j := i + 1
+			for j < len(src) && isIdentPart(src[j]) {
+				j++
+			}
+			out = append(out, token{kind: tIdent, text: src[i:j], pos: i})
+			i = j
+		default:
+			for _, op := range []string{"//", "==", "!=", "<=", ">=", "|", ",", "(", ")", "[", "]", "{", "}", ":", "?", "<", ">", "+", "-", "*", "/", "%", ";"} {
+				if strings.HasPrefix(src[i:], op) {
+					out = append(out, token{kind: tPunct, text: op, pos: i})
+					i += len(op)
+					goto next
+				}
+			}
+			return nil, &Error{Expr: src, Pos: i, Msg: fmt.Sprintf("unexpected character %q", c)}
+		next:
+		}
+	}
+	out = append(out, token{kind: tEOF, pos: len(src)})
+	return out, nil
+}
+
+// lexString decodes a JSON-style double-quoted string starting at src[i].
+func lexString(src string, i int) (string, int, error) {
+	j := i + 1
+	for j < len(src) && src[j] != '"' {
+		if src[j] == '\\' {
+			j++
+		}
+		j++
+	}
+	if j >= len(src) {
+		return "", 0, &Error{Expr: src, Pos: i, Msg: "unterminated string"}
+	}
+	s, err := strconv.Unquote(src[i : j+1])
+	if err != nil {
+		return "", 0, &Error{Expr: src, Pos: i, Msg: "invalid string escape"}
+	}
+	return s, j + 1, nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool { return isIdentStart(c) || (c >= '0' && c <= '9') }
@@ -0,0 +1,435 @@
+package filter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+type node interface{ position() int }
+
+type (
+	identity struct{ p int }
+	recurse  struct{ p int }
+	literal  struct {
+		p int
+		v any
+	}
+	index struct {
+		p        int
+		target   node
+		key      node
+		optional bool
+	}
+	iterate struct {
+		p        int
+		target   node
+		optional bool
+	}
+	slice struct {
+		p        int
+		target   node
+		from, to node // nil means open-ended
+		optional bool
+	}
+	pipe struct {
+		p    int
+		l, r node
+	}
+	comma struct {
+		p    int
+		l, r node
+	}
+	binary struct {
+		p    int
+		op   string
+		l, r node
+	}
+	array struct {
+		p    int
+		body node // nil for []
+	}
+	object struct {
+		p       int
+		entries []objEntry
+	}
+	call struct {
+		p    int
+		name string
+		args []node
+	}
+	try struct {
+		p    int
+		body node
+	}
+)
+
+type objEntry struct {
+	key   node
+	value node
+}
+
+func (n identity) position() int { return n.p }
+func (n recurse) position() int  { return n.p }
+func (n literal) position() int  { return n.p }
+func (n *index) position() int   { return n.p }
+func (n *iterate) position() int { return n.p }
+func (n *slice) position() int   { return n.p }
+func (n pipe) position() int     { return n.p }
+func (n comma) position() int    { return n.p }
+func (n binary) position() int   { return n.p }
+func (n array) position() int    { return n.p }
+func (n object) position() int   { return n.p }
+func (n call) position() int     { return n.p }
+func (n try) position() int      { return n.p }
+
+type parser struct {
+	src  string
+	toks []token
+	i    int
+}
+
+func (p *parser) peek() token { return p.toks[p.i] }
+func (p *parser) next() token { t := p.toks[p.i]; p.i++; return t }
+
+func (p *parser) is(text string) bool {
+	t := p.peek()
+	return (t.kind == tPunct || t.kind == tIdent) && t.text == text
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &Error{Expr: p.src, Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) expect(text string) error {
+	if !p.is(text) {
+		return p.errorf(p.peek(), "expected %q, found %s", text, describe(p.peek()))
+	}
+	p.i++
+	return nil
+}
+
+func describe(t token) string {
+	switch t.kind {
+	case tEOF:
+		return "end of expression"
+	case tString:
+		return strconv.Quote(t.text)
+	case tField:
+		return "." + t.text
+	}
+	return strconv.Quote(t.text)
+}
+
+func (p *parser) parsePipe() (node, error) {
+	l, err := p.parseComma()
+	if err != nil {
+		return nil, err
+	}
+	if p.is("|") {
+		t := p.next()
+		r, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		return pipe{p: t.pos, l: l, r: r}, nil
+	}
+	return l, nil
+}
+
+func (p *parser) parseComma() (node, error) {
+	l, err := p.parseAlt()
+	if err != nil {
+		return nil, err
+	}
+	for p.is(",") {
+		t := p.next()
+		r, err := p.parseAlt()
+		if err != nil {
+			return nil, err
+		}
+		l = comma{p: t.pos, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) parseAlt() (node, error) {
+	l, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.is("//") {
+		t := p.next()
+		r, err := p.parseAlt()
+		if err != nil {
+			return nil, err
+		}
+		return binary{p: t.pos, op: "//", l: l, r: r}, nil
+	}
+	return l, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.parseLeft(p.parseAnd, "or")
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.parseLeft(p.parseCmp, "and")
+}
+
+func (p *parser) parseCmp() (node, error) {
+	l, err := p.parseAdd()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.is(op) {
+			t := p.next()
+			r, err := p.parseAdd()
+			if err != nil {
+				return nil, err
+			}
+			return binary{p: t.pos, op: op, l: l, r: r}, nil
+		}
+	}
+	return l, nil
+}
+
+func (p *parser) parseAdd() (node, error) {
+	return p.parseLeft(p.parseMul, "+", "-")
+}
+
+func (p *parser) parseMul() (node, error) {
+	return p.parseLeft(p.parsePostfix, "*", "/", "%")
+}
+
+func (p *parser) parseLeft(sub func() (node, error), ops ...string) (node, error) {
+	l, err := sub()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		matched := false
+		for _, op := range ops {
+			if p.is(op) {
+				t := p.next()
+				r, err := sub()
+				if err != nil {
+					return nil, err
+				}
+				l = binary{p: t.pos, op: op, l: l, r: r}
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return l, nil
+		}
+	}
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == tField:
+			p.i++
+			n = &index{p: t.pos, target: n, key: literal{p: t.pos, v: t.text}}
+		case t.kind == tDot && p.toks[p.i+1].kind == tPunct && p.toks[p.i+1].text == "[":
+			p.i++ // `.a.[0]` is the same as `.a[0]`
+		case p.is("["):
+			n, err = p.parseBracket(n)
+			if err != nil {
+				return nil, err
+			}
+		case p.is("?"):
+			p.i++
+			switch v := n.(type) {
+			case *index:
+				v.optional = true
+			case *iterate:
+				v.optional = true
+			case *slice:
+				v.optional = true
+			default:
+				n = try{p: t.pos, body: n}
+			}
+		default:
+			return n, nil
+		}
+	}
+}
+
+// parseBracket parses [], [expr] and [from:to] suffixes applied to target.
+func (p *parser) parseBracket(target node) (node, error) {
+	open := p.next()
+	if p.is("]") {
+		p.i++
+		return &iterate{p: open.pos, target: target}, nil
+	}
+	var from node
+	if !p.is(":") {
+		var err error
+		if from, err = p.parsePipe(); err != nil {
+			return nil, err
+		}
+	}
+	if p.is(":") {
+		p.i++
+		var to node
+		if !p.is("]") {
+			var err error
+			if to, err = p.parsePipe(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return &slice{p: open.pos, target: target, from: from, to: to}, nil
+	}
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return &index{p: open.pos, target: target, key: from}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tDot:
+		return identity{p: t.pos}, nil
+	case tDotDot:
+		return recurse{p: t.pos}, nil
+	case tField:
+		return &index{p: t.pos, target: identity{p: t.pos}, key: literal{p: t.pos, v: t.text}}, nil
+	case tString:
+		return literal{p: t.pos, v: t.text}, nil
+	case tNumber:
+		return literal{p: t.pos, v: json.Number(t.text)}, nil
+	case tIdent:
+		switch t.text {
+		case "true":
+			return literal{p: t.pos, v: true}, nil
+		case "false":
+			return literal{p: t.pos, v: false}, nil
+		case "null":
+			return literal{p: t.pos, v: nil}, nil
+		}
+		if t.text[0] == '$' {
+			return nil, p.errorf(t, "variables are not supported")
+		}
+		c := call{p: t.pos, name: t.text}
+		if p.is("(") {
+			p.i++
+			for {
+				arg, err := p.parsePipe()
+				if err != nil {
+					return nil, err
+				}
+				c.args = append(c.args, arg)
+				if p.is(";") {
+					p.i++
+					continue
+				}
+				if err := p.expect(")"); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+		if err := checkCall(c); err != nil {
+			return nil, p.errorf(t, "%v", err)
+		}
+		return c, nil
+	case tPunct:
+		switch t.text {
+		case "(":
+			n, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return n, nil
+		case "[":
+			if p.is("]") {
+				p.i++
+				return array{p: t.pos}, nil
+			}
+			body, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			return array{p: t.pos, body: body}, nil
+		case "{":
+			return p.parseObject(t)
+		case "-":
+			n, err := p.parsePostfix()
+			if err != nil {
+				return nil, err
+			}
+			return binary{p: t.pos, op: "-", l: literal{p: t.pos, v: json.Number("0")}, r: n}, nil
+		}
+	}
+	return nil, p.errorf(t, "unexpected %s", describe(t))
+}
+
+// parseObject parses {a, "b": f, (k): v, c: .x} after the opening brace.
+func (p *parser) parseObject(open token) (node, error) {
+	obj := object{p: open.pos}
+	if p.is("}") {
+		p.i++
+		return obj, nil
+	}
+	for {
+		t := p.next()
+		var key node
+		switch {
+		case t.kind == tIdent && t.text[0] != '$', t.kind == tString:
+			key = literal{p: t.pos, v: t.text}
+		case t.kind == tPunct && t.text == "(":
+			k, err := p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			key = k
+		default:
+			return nil, p.errorf(t, "expected object key, found %s", describe(t))
+		}
+		var value node
+		if p.is(":") {
+			p.i++
+			v, err := p.parseAlt()
+			if err != nil {
+				return nil, err
+			}
+			value = v
+		} else if lit, ok := key.(literal); ok {
+			// {a} is shorthand for {a: .a}
+			value = &index{p: t.pos, target: identity{p: t.pos}, key: lit}
+		} else {
+			return nil, p.errorf(p.peek(), "expected \":\" after computed key")
+		}
+		obj.entries = append(obj.entries, objEntry{key: key, value: value})
+		if p.is(",") {
+			p.i++
+			continue
+		}
+		if err := p.expect("}"); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	}
+}
@@ -0,0 +1,69 @@
+// internal/help/capture.go
+package help
+
+import (
+	"sort"
+	"strings"
+)
+
+// CaptureHelp returns the help text for `restless capture`.
+func CaptureHelp(ctx HelpContext) string {
+	w := ctx.TerminalWidth
+	if w <= 0 {
+		w = detectWidth(92)
+	}
+	if ctx.ProfileDir == "" {
+		ctx.ProfileDir = defaultProfileDir()
+	}
+	if len(ctx.Profiles) == 0 {
+		ctx.Profiles = listProfileNames(ctx.ProfileDir)
+	}
+	sort.Strings(ctx.Profiles)
+
+	var b strings.Builder
+
+	title(&b, "restless capture", "learn a profile from real traffic")
+	blank(&b)
+
+	para(&b, w, "Usage:", "restless capture --profile <name> [--listen addr] [--target url] [flags]")
+	blank(&b)
+
+	para(&b, w, "Description:",
+		"Runs a local proxy that records every request passing through. When you stop it with Ctrl-C, API-like traffic (not pages, scripts, styles or images) is turned into endpoint templates such as /users/{id}, the auth style is inferred, and the result is merged into the profile with capture evidence. Existing endpoints, auth, defaults and collection entries are kept.")
+	blank(&b)
+
+	if len(ctx.Profiles) > 0 {
+		callout(&b, w, "Profiles", strings.Join(ctx.Profiles, ", "))
+		blank(&b)
+	}
+
+	section(&b, "Examples")
+	cmd(&b, "restless capture --profile intranet --listen :8888 --target https://api.intranet.example")
+	cmd(&b, "restless capture --profile shop --host api.shop.example")
+	cmd(&b, "HTTP_PROXY=http://127.0.0.1:8888 ./frontend-e2e.sh")
+	cmd(&b, "restless capture --dry-run --target http://localhost:3000")
+	blank(&b)
+
+	section(&b, "Modes")
+	para(&b, w, "",
+		"With --target the proxy is a reverse proxy: point the frontend's API base URL at the listen address. Without it the proxy is a forward proxy for HTTP_PROXY; HTTPS traffic then passes through CONNECT tunnels unrecorded, so use --target for HTTPS APIs. Forward mode learns from --host, else the profile's base URL host, else the busiest API host.")
+	blank(&b)
+
+	section(&b, "Auth")
+	para(&b, w, "",
+		"Secret values are never written. A bearer token becomes the usual bearer block; basic auth, API key headers and session cookies become a default header read from an environment variable (RESTLESS_BASIC_AUTH, RESTLESS_X_API_KEY, RESTLESS_COOKIE). A key in the query string is only reported.")
+	blank(&b)
+
+	section(&b, "Flags")
+	flag(&b, "--profile <name>", "Profile to create or update.")
+	flag(&b, "--listen <addr>", "Address to listen on. (default 127.0.0.1:8888)")
+	flag(&b, "--target <url>", "Upstream base URL; runs a reverse proxy.")
+	flag(&b, "--host <host>", "Only learn from this host (forward mode).")
+	flag(&b, "--proxy <url>", "Upstream proxy URL (http, https or socks5).")
+	flag(&b, "--dry-run", "Print what was learned without saving.")
+	flag(&b, "--quiet", "Don't log requests.")
+	flag(&b, "--profile-dir <path>", "Custom profile storage directory.")
+	blank(&b)
+
+	return trimEnd(b.String())
+}
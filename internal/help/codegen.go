@@ -0,0 +1,64 @@
+// internal/help/codegen.go
+package help
+
+import (
+	"sort"
+	"strings"
+)
+
+// CodegenHelp returns the help text for `restless codegen`.
+func CodegenHelp(ctx HelpContext) string {
+	w := ctx.TerminalWidth
+	if w <= 0 {
+		w = detectWidth(92)
+	}
+	if ctx.ProfileDir == "" {
+		ctx.ProfileDir = defaultProfileDir()
+	}
+	if len(ctx.Profiles) == 0 {
+		ctx.Profiles = listProfileNames(ctx.ProfileDir)
+	}
+	sort.Strings(ctx.Profiles)
+
+	var b strings.Builder
+
+	title(&b, "restless codegen", "generate a typed client package from a profile")
+	blank(&b)
+
+	para(&b, w, "Usage:", "restless codegen go <profile> -o <dir> [--package name] [flags]")
+	blank(&b)
+
+	para(&b, w, "Description:",
+		"Writes a small Go package with no dependencies outside the standard library: client.go holds a Client with the profile's base URL and auth, api.go one method per endpoint. Path parameters become arguments, query parameters a <Method>Params struct, and request and response bodies structs generated from the OpenAPI schemas. Without a spec, results use the response schemas learned from real responses, request bodies are any, and anything else is json.RawMessage.")
+	blank(&b)
+	para(&b, w, "",
+		"Method names come from operationIds, then collection entry names, then the method and path. Output is gofmt'ed and passes go vet; rerunning over an unchanged profile leaves the files untouched. Files without the generated-code header are never overwritten.")
+	blank(&b)
+
+	if len(ctx.Profiles) > 0 {
+		callout(&b, w, "Profiles", strings.Join(ctx.Profiles, ", "))
+		blank(&b)
+	}
+
+	section(&b, "Examples")
+	cmd(&b, "restless codegen go shop -o ./client")
+	cmd(&b, "restless codegen go shop -o ./internal/shopapi --package shopapi")
+	cmd(&b, "restless codegen go shop -o ./client --spec openapi.yaml")
+	blank(&b)
+
+	section(&b, "Auth")
+	para(&b, w, "",
+		"NewClient reads the same environment variables the profile does: RESTLESS_TOKEN for bearer auth and the ${ENV:NAME} references in default headers, each of which becomes a Client field. OAuth2 client credentials profiles get a FetchToken method. No secret is written to the generated code.")
+	blank(&b)
+
+	section(&b, "Flags")
+	flag(&b, "-o <dir>", "Output directory. (required)")
+	flag(&b, "--package <name>", "Package name. (default: the directory's name)")
+	flag(&b, "--spec <file|url>", "OpenAPI document; defaults to the profile's spec URL.")
+	flag(&b, "--no-spec", "Generate from the profile alone.")
+	flag(&b, "--env <name>", "Use an environment's base URL and headers.")
+	flag(&b, "--profile-dir <path>", "Custom profile storage directory.")
+	blank(&b)
+
+	return trimEnd(b.String())
+}
@@ -0,0 +1,70 @@
+// internal/help/contract.go
+package help
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ContractHelp returns the help text for `restless contract`.
+func ContractHelp(ctx HelpContext) string {
+	w := ctx.TerminalWidth
+	if w <= 0 {
+		w = detectWidth(92)
+	}
+	if ctx.ProfileDir == "" {
+		ctx.ProfileDir = defaultProfileDir()
+	}
+	if len(ctx.Profiles) == 0 {
+		ctx.Profiles = listProfileNames(ctx.ProfileDir)
+	}
+	sort.Strings(ctx.Profiles)
+	name := "openai"
+	if len(ctx.Profiles) > 0 {
+		name = shellSafe(ctx.Profiles[0])
+	}
+
+	var b strings.Builder
+
+	title(&b, "restless contract", "check a live API against its OpenAPI spec")
+	blank(&b)
+
+	para(&b, w, "Usage:", "restless contract <profile> [flags]")
+	blank(&b)
+
+	para(&b, w, "Description:",
+		"Calls every GET and HEAD operation in the spec discovery recorded for the profile (or --spec) and compares each response with the spec: the status must be documented, the content type must match and a JSON body must validate against the response schema. Operations that would change data are never called. Exit status is 1 if any operation drifted or failed.")
+	blank(&b)
+
+	if len(ctx.Profiles) > 0 {
+		callout(&b, w, "Profiles", strings.Join(ctx.Profiles, ", "))
+		blank(&b)
+	}
+
+	section(&b, "Examples")
+	cmd(&b, fmt.Sprintf("restless contract %s", name))
+	cmd(&b, fmt.Sprintf("restless contract %s --env staging --var model_id=gpt-4o", name))
+	cmd(&b, fmt.Sprintf("restless contract %s --spec ./openapi.json --only listModels", name))
+	cmd(&b, fmt.Sprintf("restless contract %s --json | jq 'select(.problems)'", name))
+	blank(&b)
+
+	section(&b, "Parameters")
+	para(&b, w, "",
+		"Path parameters come from --var, then the spec's example, default or enum. An operation whose path parameter has neither is skipped. Required query parameters fall back to a placeholder of the documented type; required headers are sent only when a value is known.")
+	blank(&b)
+
+	section(&b, "Flags")
+	flag(&b, "--env <name>", "Profile environment to test.")
+	flag(&b, "--spec <file|url>", "OpenAPI 3 or Swagger 2 JSON to test against.")
+	flag(&b, "--var <k=v>", "Parameter value. Repeatable.")
+	flag(&b, "--only <id|path>", "Only check this operationId or path. Repeatable.")
+	flag(&b, "--json", "Print one JSON result per operation.")
+	flag(&b, "--timeout <sec>", "Per-request timeout. (default from profile)")
+	flag(&b, "--proxy <url>", "Proxy URL (http, https or socks5).")
+	flag(&b, "--no-history", "Don't record the requests in the history.")
+	flag(&b, "--profile-dir <path>", "Custom profile storage directory.")
+	blank(&b)
+
+	return trimEnd(b.String())
+}
@@ -0,0 +1,79 @@
+// internal/help/diff.go
+package help
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffHelp returns the help text for `restless diff`.
+func DiffHelp(ctx HelpContext) string {
+	w := ctx.TerminalWidth
+	if w <= 0 {
+		w = detectWidth(92)
+	}
+	name := "openai"
+	if ctx.ActiveProfile != "" {
+		name = shellSafe(ctx.ActiveProfile)
+	}
+
+	var b strings.Builder
+
+	title(&b, "restless diff", "compare responses between environments or runs")
+	blank(&b)
+
+	para(&b, w, "Usage:", "restless diff --env <a> --env <b> [flags] [<method>] <path>")
+	para(&b, w, "", "restless diff <id|last> [--env <name>] [flags]")
+	para(&b, w, "", "restless diff <id> <id> [flags]")
+	blank(&b)
+
+	para(&b, w, "Description:",
+		"Send the same profile request to two environments, replay a history entry and compare it with what the server says now, or compare two stored entries. JSON bodies get a structural diff: added, removed and changed values by path, with arrays of objects aligned by id.")
+	blank(&b)
+
+	section(&b, "Examples")
+	cmd(&b, fmt.Sprintf("restless diff --profile %s --env staging --env prod GET /v1/models", name))
+	cmd(&b, fmt.Sprintf("restless diff --profile %s --env staging --env prod /v1/items --ignore-volatile", name))
+	cmd(&b, fmt.Sprintf("restless diff --profile %s --env staging --env prod /v1/items --shape", name))
+	cmd(&b, "restless diff last")
+	cmd(&b, "restless diff 42 --env prod --ignore .meta --ignore etag")
+	cmd(&b, "restless diff 42 57 --json")
+	blank(&b)
+
+	section(&b, "Flags")
+	flag(&b, "--profile <name>", "Profile to use. (default: active profile; a history entry uses its own)")
+	flag(&b, "--profile-dir <path>", "Custom profile storage directory.")
+	flag(&b, "--env <name>", "Environment to compare. Give two, or one to replay a history entry against.")
+	flag(&b, "--method <verb>", "HTTP method. (default GET)")
+	flag(&b, "--path <path>", "Path joined to each environment's base URL.")
+	flag(&b, "-H, --header <h>", "Extra header \"Name: value\". Repeatable.")
+	flag(&b, "--query <k=v>", "Query parameter. Repeatable.")
+	flag(&b, "--data <body>", "Request body. @file reads a file, @- reads stdin.")
+	flag(&b, "--ignore <field>", "Field name (any depth) or path such as .items[].updatedAt. Repeatable.")
+	flag(&b, "--ignore-volatile", "Ignore timestamps, request/trace ids, etags and nonces.")
+	flag(&b, "--shape", "Compare fields, types and formats instead of values.")
+	flag(&b, "--id-key <key>", "Key used to align arrays of objects. (default id, uuid, key, slug, name)")
+	flag(&b, "--timeout <int>", "Timeout in seconds. (default from profile, else 20)")
+	flag(&b, "--proxy <url>", "http, https or socks5 proxy.")
+	flag(&b, "--json", "Print both sides and the changes as JSON.")
+	flag(&b, "--quiet", "Print nothing; only set the exit status.")
+	blank(&b)
+
+	section(&b, "Output")
+	lines(&b,
+		"+ .data[id=9]: {...}         only on the right",
+		"- .meta.cursor: \"x\"          only on the left",
+		"~ .data[id=3].name: \"a\" → \"b\" changed",
+	)
+	blank(&b)
+
+	section(&b, "Exit codes")
+	lines(&b,
+		"0  No differences",
+		"1  Differences found",
+		"2  Error or usage error",
+	)
+	blank(&b)
+
+	return trimEnd(b.String())
+}
@@ -80,6 +80,9 @@ func DiscoverHelp(ctx HelpContext) string {
 		cmd(&b, fmt.Sprintf("restless discover openai.com --verify --fuzz --save-profile %s", shellSafe(name)))
 		para(&b, w, "Tip:",
 			"Use --save-profile to refresh an existing profile (merge-safe).")
+		if ctx.SupportsTUI {
+			para(&b, w, "Browse it:", "restless tui "+shellSafe(name))
+		}
 	}
 	blank(&b)
 
@@ -90,6 +93,9 @@ func DiscoverHelp(ctx HelpContext) string {
 	cmd(&b, "restless discover openai.com --save-profile openai")
 	cmd(&b, "restless discover openai.com --save-profile openai --overwrite-profile")
 	cmd(&b, "restless discover openai.com --save-profile openai --profile-dir ./profiles")
+	cmd(&b, "restless discover example.com --har session.har --save-profile example")
+	cmd(&b, "restless discover openai.com --filter '.endpoints[] | .method + \" \" + .path' -r")
+	cmd(&b, "restless discover openai.com --verify --json-events | jq -c 'select(.type == \"endpoint\")'")
 	blank(&b)
 
 	if len(ctx.Profiles) > 0 {
@@ -104,20 +110,26 @@ func DiscoverHelp(ctx HelpContext) string {
 	}
 
 	section(&b, "Flags")
-	flag(&b, "--verify", "Validate discovered endpoints with live HTTP checks.")
+	flag(&b, "--verify", "Validate discovered endpoints with live HTTP checks; record pagination and response schemas.")
 	flag(&b, "--fuzz", "Expand discovery using pattern-based probing (doc-guided when docs are found).")
 	flag(&b, "--budget-seconds <int>", "Maximum total discovery time. (default 15)")
 	flag(&b, "--budget-pages <int>", "Maximum pages to crawl. (default 6)")
 	flag(&b, "--save-profile <name>", "Save discovery results to a named profile.")
 	flag(&b, "--overwrite-profile", "Replace existing profile instead of merging. (dangerous)")
 	flag(&b, "--profile-dir <path>", "Custom profile storage directory.")
-	flag(&b, "--emit-examples", "Generate example requests inside the profile.")
+	flag(&b, "--har <file>", "Learn endpoints from a devtools HAR export instead of probing. The domain is optional.")
+	flag(&b, "--emit-examples", "Add a runnable request collection to the profile.")
 	flag(&b, "--redact-secrets", "Remove detected tokens from generated examples.")
 	if ctx.SupportsJSON {
 		flag(&b, "--json", "Output machine-readable JSON.")
 	} else {
 		flag(&b, "--json", "Output machine-readable JSON. (if supported in your build)")
 	}
+	flag(&b, "--json-events", "Stream NDJSON events on stdout as discovery runs; logs go to stderr.")
+	flag(&b, "--proxy <url>", "http, https or socks5 proxy for all probes. (default: profile, then env)")
+	flag(&b, "--resolve <h:p:a>", "Send host:port to addr instead of DNS. Repeatable.")
+	flag(&b, "--filter <expr>", "jq-style filter applied to the JSON output (implies --json).")
+	flag(&b, "-r, --raw-output", "With --filter, print strings without quotes.")
 	flag(&b, "--quiet", "Minimal output.")
 	flag(&b, "--debug", "Verbose diagnostic logging.")
 	blank(&b)
@@ -127,6 +139,8 @@ func DiscoverHelp(ctx HelpContext) string {
 		"By default discover prints domain, base URLs, endpoints (method + path), confidence score, and evidence sources.")
 	para(&b, w, "",
 		"When --save-profile is used, discover writes a profile file and prints the path plus counts.")
+	para(&b, w, "",
+		"While probing, a terminal shows one live status line: the current source, endpoints found, budget left and the last URL fetched. Elsewhere each step is logged on its own line. With --json-events every step is an NDJSON object on stdout as soon as it happens; its type is start, source, fetch, baseUrl, doc, endpoint, evidence, error, budget or done. The last line is a summary holding the full finding, as --json would print it, and the saved profile's path.")
 	blank(&b)
 
 	section(&b, "Exit codes")
@@ -141,7 +155,10 @@ func DiscoverHelp(ctx HelpContext) string {
 	lines(&b,
 		"• Discovery is read-only.",
 		"• Fuzz mode never performs destructive requests.",
+		"• Probes retry on 429/5xx and record observed rate limits in the profile.",
 		"• Profiles should reference secrets via environment variables (not stored plaintext).",
+		"• HAR imports keep the domain's API requests only and never store header or cookie values.",
+		"• The tls: and network: blocks of the profile named by --save-profile apply to probes.",
 	)
 	blank(&b)
 
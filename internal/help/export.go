@@ -0,0 +1,61 @@
+// internal/help/export.go
+package help
+
+import (
+	"sort"
+	"strings"
+)
+
+// ExportHelp returns the help text for `restless export`.
+func ExportHelp(ctx HelpContext) string {
+	w := ctx.TerminalWidth
+	if w <= 0 {
+		w = detectWidth(92)
+	}
+	if ctx.ProfileDir == "" {
+		ctx.ProfileDir = defaultProfileDir()
+	}
+	if len(ctx.Profiles) == 0 {
+		ctx.Profiles = listProfileNames(ctx.ProfileDir)
+	}
+	sort.Strings(ctx.Profiles)
+
+	var b strings.Builder
+
+	title(&b, "restless export", "write a profile as OpenAPI or a Postman collection")
+	blank(&b)
+
+	para(&b, w, "Usage:", "restless export <openapi|postman> <profile> [-o file] [flags]")
+	blank(&b)
+
+	para(&b, w, "Description:",
+		"openapi writes an OpenAPI 3.1 document: servers from baseUrls (and environments), one operation per endpoint with path parameters, query parameters and request bodies inferred from the collection entries that call it, response schemas learned from real responses, and security schemes from the auth block and credential headers. Each operation keeps its discovery score and evidence as x-restless-score and x-restless-evidence; pagination and the observed rate limit become x-restless-pagination and x-restless-rate-limit.")
+	blank(&b)
+	para(&b, w, "",
+		"postman writes a Postman Collection v2.1. Collection entries are grouped into folders by their first tag; endpoints no entry covers follow in an Endpoints folder. URLs start with {{baseUrl}}, profile vars become collection variables, and default headers other than Accept and User-Agent are added to every request.")
+	blank(&b)
+
+	if len(ctx.Profiles) > 0 {
+		callout(&b, w, "Profiles", strings.Join(ctx.Profiles, ", "))
+		blank(&b)
+	}
+
+	section(&b, "Examples")
+	cmd(&b, "restless export openapi shop -o shop.openapi.json")
+	cmd(&b, "restless export postman shop -o shop.postman_collection.json")
+	cmd(&b, "restless export postman shop --env staging")
+	blank(&b)
+
+	section(&b, "Secrets")
+	para(&b, w, "",
+		"No credential is written. In OpenAPI, schemes only name where a credential goes. In Postman, bearer and OAuth2 auth refer to empty {{token}}, {{clientId}} and {{clientSecret}} variables, and ${ENV:NAME} references become {{NAME}} for you to set in a Postman environment.")
+	blank(&b)
+
+	section(&b, "Flags")
+	flag(&b, "-o <file>", "Write to a file instead of stdout.")
+	flag(&b, "--env <name>", "Use an environment's base URL and headers.")
+	flag(&b, "--profile-dir <path>", "Custom profile storage directory.")
+	blank(&b)
+
+	return trimEnd(b.String())
+}
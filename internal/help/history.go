@@ -0,0 +1,59 @@
+// internal/help/history.go
+package help
+
+import "strings"
+
+// HistoryHelp returns the help text for `restless history` and `restless replay`.
+func HistoryHelp(ctx HelpContext) string {
+	w := ctx.TerminalWidth
+	if w <= 0 {
+		w = detectWidth(92)
+	}
+
+	var b strings.Builder
+
+	title(&b, "restless history", "list, inspect and replay past requests")
+	blank(&b)
+
+	para(&b, w, "Usage:", "restless history [list] [flags] [search terms]")
+	para(&b, w, "", "restless history show <id|last>")
+	para(&b, w, "", "restless history clear")
+	para(&b, w, "", "restless replay <id|last> [--dry-run] [request flags]")
+	blank(&b)
+
+	para(&b, w, "Description:",
+		"Every request is appended to ~/.config/restless/history.jsonl with its profile, environment, status, timing and the first 8 KiB of each body. Secrets are redacted before anything is written. The newest 1000 entries are kept.")
+	blank(&b)
+
+	section(&b, "Examples")
+	cmd(&b, "restless history")
+	cmd(&b, "restless history --status 5xx --since 24h")
+	cmd(&b, "restless history --profile openai models")
+	cmd(&b, "restless history show last")
+	cmd(&b, "restless replay 42")
+	cmd(&b, "restless replay 42 --env prod -H 'X-Debug: 1'")
+	cmd(&b, "restless replay 42 --dry-run")
+	blank(&b)
+
+	section(&b, "Flags")
+	flag(&b, "--profile <name>", "Only entries for this profile.")
+	flag(&b, "--env <name>", "Only entries for this environment.")
+	flag(&b, "--method <verb>", "Only entries with this method.")
+	flag(&b, "--status <code>", "Exact code (404), class (4xx) or error.")
+	flag(&b, "--since <dur>", "Only entries newer than this, e.g. 30m or 24h.")
+	flag(&b, "--limit <int>", "Show at most N entries. (default 20, 0 = all)")
+	flag(&b, "--json", "Print entries as NDJSON (show: one indented object).")
+	blank(&b)
+
+	section(&b, "Replay")
+	para(&b, w, "",
+		"replay resends an entry through restless request with the same profile, environment, method, path, headers and body. Flags after the id are applied on top, so --env, --path, -H and --data edit the call. Redacted headers are dropped and come from the profile's auth again; a redacted, truncated or binary body has to be given with --data. --dry-run prints the equivalent request command instead.")
+	blank(&b)
+
+	section(&b, "Privacy")
+	para(&b, w, "",
+		"Pass --no-history to request, or set RESTLESS_HISTORY=off, to stop recording.")
+	blank(&b)
+
+	return trimEnd(b.String())
+}
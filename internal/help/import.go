@@ -0,0 +1,69 @@
+// internal/help/import.go
+package help
+
+import (
+	"sort"
+	"strings"
+)
+
+// ImportHelp returns the help text for `restless import`.
+func ImportHelp(ctx HelpContext) string {
+	w := ctx.TerminalWidth
+	if w <= 0 {
+		w = detectWidth(92)
+	}
+	if ctx.ProfileDir == "" {
+		ctx.ProfileDir = defaultProfileDir()
+	}
+	if len(ctx.Profiles) == 0 {
+		ctx.Profiles = listProfileNames(ctx.ProfileDir)
+	}
+	sort.Strings(ctx.Profiles)
+
+	var b strings.Builder
+
+	title(&b, "restless import", "create a profile from a Postman collection")
+	blank(&b)
+
+	para(&b, w, "Usage:", "restless import postman <collection.json> [--profile <name>] [flags]")
+	blank(&b)
+
+	para(&b, w, "Description:",
+		"Reads a Postman Collection v2.1 (or v2.0) export and merges it into a profile. Every request becomes a collection entry runnable with `restless run`, and its method and path become an endpoint with postman evidence. Existing endpoints, auth, defaults, vars and collection entries are kept; entries whose name is already taken are skipped.")
+	blank(&b)
+
+	if len(ctx.Profiles) > 0 {
+		callout(&b, w, "Profiles", strings.Join(ctx.Profiles, ", "))
+		blank(&b)
+	}
+
+	section(&b, "Examples")
+	cmd(&b, "restless import postman ./Shop.postman_collection.json --profile shop")
+	cmd(&b, "restless import postman ./export.json --dry-run")
+	cmd(&b, "restless run shop get-order --var orderId=42")
+	blank(&b)
+
+	section(&b, "Mapping")
+	lines(&b,
+		"folders              tags on each entry (outermost first)",
+		"collection variables profile vars; {{name}} becomes ${name}",
+		"{{baseUrl}}          baseUrls (or the most common scheme://host)",
+		":id path segments    {id}, with the path variable value as a default",
+		"auth                 the auth block: bearer, basic, apikey, oauth2",
+		"saved examples       extra entries named <request>-<example>",
+	)
+	blank(&b)
+
+	section(&b, "Secrets")
+	para(&b, w, "",
+		"Credentials are never copied. Auth attributes are dropped, secret-looking variables are skipped with a warning, and secret headers or query values become ${ENV:RESTLESS_<NAME>} references. Form-data and file bodies can't be expressed in a profile and are left out.")
+	blank(&b)
+
+	section(&b, "Flags")
+	flag(&b, "--profile <name>", "Profile to create or update. (default: the collection name)")
+	flag(&b, "--dry-run", "List what would be imported without saving.")
+	flag(&b, "--profile-dir <path>", "Custom profile storage directory.")
+	blank(&b)
+
+	return trimEnd(b.String())
+}
@@ -0,0 +1,70 @@
+// internal/help/mock.go
+package help
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MockHelp returns the help text for `restless mock`.
+func MockHelp(ctx HelpContext) string {
+	w := ctx.TerminalWidth
+	if w <= 0 {
+		w = detectWidth(92)
+	}
+	if ctx.ProfileDir == "" {
+		ctx.ProfileDir = defaultProfileDir()
+	}
+	if len(ctx.Profiles) == 0 {
+		ctx.Profiles = listProfileNames(ctx.ProfileDir)
+	}
+	sort.Strings(ctx.Profiles)
+	name := "openai"
+	if len(ctx.Profiles) > 0 {
+		name = shellSafe(ctx.Profiles[0])
+	}
+
+	var b strings.Builder
+
+	title(&b, "restless mock", "serve a fake API from a profile")
+	blank(&b)
+
+	para(&b, w, "Usage:", "restless mock <profile> [flags]")
+	blank(&b)
+
+	para(&b, w, "Description:",
+		"Serves every endpoint of the profile on a local port so a frontend can be built before the real backend is reachable. When discovery recorded an OpenAPI spec (or --spec is given) each operation answers with its documented example, or a body generated from the response schema; other endpoints answer with a sample of the response schema learned from real responses, or an empty JSON object. Paths are served at the root, without the base URL's path.")
+	blank(&b)
+
+	if len(ctx.Profiles) > 0 {
+		callout(&b, w, "Profiles", strings.Join(ctx.Profiles, ", "))
+		blank(&b)
+	}
+
+	section(&b, "Examples")
+	cmd(&b, fmt.Sprintf("restless mock %s --port 8080", name))
+	cmd(&b, fmt.Sprintf("restless mock %s --latency 50ms-400ms --error-rate 0.1", name))
+	cmd(&b, fmt.Sprintf("restless mock %s --spec ./openapi.json --host 0.0.0.0", name))
+	cmd(&b, "curl -H 'X-Mock-Status: 404' localhost:8080/models/x")
+	blank(&b)
+
+	section(&b, "Responses")
+	para(&b, w, "",
+		"{name} path segments match any value, and a top-level response field with the same name echoes it back. The first documented 2xx response is the default; send X-Mock-Status to get another documented status. Unknown paths get 404, known paths with another method 405. CORS headers are set on every response and preflight requests are answered.")
+	blank(&b)
+
+	section(&b, "Flags")
+	flag(&b, "--port <n>", "Port to listen on. (default 8080)")
+	flag(&b, "--host <addr>", "Address to listen on. (default 127.0.0.1)")
+	flag(&b, "--latency <d>", "Delay every response: 200ms, or a random delay in 50ms-400ms.")
+	flag(&b, "--error-rate <f>", "Fraction of requests (0-1) that fail.")
+	flag(&b, "--error-status <n>", "Status for failed requests. (default 500)")
+	flag(&b, "--spec <file|url>", "OpenAPI 3 or Swagger 2 JSON to serve.")
+	flag(&b, "--env <name>", "Profile environment used to fetch the spec.")
+	flag(&b, "--quiet", "Don't log requests to stderr.")
+	flag(&b, "--profile-dir <path>", "Custom profile storage directory.")
+	blank(&b)
+
+	return trimEnd(b.String())
+}
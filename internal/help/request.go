@@ -0,0 +1,156 @@
+// internal/help/request.go
+package help
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RequestHelp returns a dynamic help text for: `restless request --help`.
+func RequestHelp(ctx HelpContext) string {
+	w := ctx.TerminalWidth
+	if w <= 0 {
+		w = detectWidth(92)
+	}
+	if ctx.ProfileDir == "" {
+		ctx.ProfileDir = defaultProfileDir()
+	}
+	if len(ctx.Profiles) == 0 {
+		ctx.Profiles = listProfileNames(ctx.ProfileDir)
+	}
+	sort.Strings(ctx.Profiles)
+
+	var b strings.Builder
+
+	title(&b, "restless request", "send a request using a saved profile")
+	blank(&b)
+
+	para(&b, w, "Usage:", "restless request [flags] [<method> <path>]")
+	blank(&b)
+
+	para(&b, w, "Description:",
+		"Send an HTTP request against a profile's base URL with its default headers and auth applied. OAuth2 tokens are fetched, cached and refreshed automatically.")
+	blank(&b)
+
+	if ctx.ActiveProfile != "" {
+		callout(&b, w, "Active profile", ctx.ActiveProfile)
+	}
+	if len(ctx.Profiles) == 0 {
+		callout(&b, w, "Profiles", "none saved yet (run restless discover --save-profile <name>)")
+	} else {
+		callout(&b, w, "Profiles", fmt.Sprintf("%d saved", len(ctx.Profiles)))
+	}
+	blank(&b)
+
+	section(&b, "Examples")
+	name := "openai"
+	if ctx.ActiveProfile != "" {
+		name = shellSafe(ctx.ActiveProfile)
+	} else if len(ctx.Profiles) > 0 {
+		name = shellSafe(ctx.Profiles[0])
+	}
+	cmd(&b, fmt.Sprintf("restless request --profile %s --method GET --path /v1/status", name))
+	cmd(&b, fmt.Sprintf("restless request --profile %s GET /v1/models --query limit=5", name))
+	cmd(&b, fmt.Sprintf("restless request --profile %s POST /v1/items --data '{\"name\":\"x\"}'", name))
+	cmd(&b, "restless request --base-url https://api.example.com GET /health")
+	cmd(&b, fmt.Sprintf("restless request --profile %s --print hb GET /v1/models", name))
+	cmd(&b, fmt.Sprintf("restless request --profile %s --raw GET /v1/models > models.json", name))
+	cmd(&b, fmt.Sprintf("restless request --profile %s GET /v1/models --filter '.data[].id' -r", name))
+	cmd(&b, fmt.Sprintf("restless request --profile %s GET /v1/files --paginate --max-items 500", name))
+	blank(&b)
+
+	section(&b, "Flags")
+	flag(&b, "--profile <name>", "Profile to use. (default: active profile)")
+	flag(&b, "--profile-dir <path>", "Custom profile storage directory.")
+	flag(&b, "--env <name>", "Use a profile environment (base URL and headers from environments:).")
+	flag(&b, "--method <verb>", "HTTP method. (default GET)")
+	flag(&b, "--path <path>", "Path joined to the profile base URL, or an absolute URL.")
+	flag(&b, "--base-url <url>", "Override the profile base URL.")
+	flag(&b, "-H, --header <h>", "Extra header \"Name: value\". Repeatable; ${ENV:VAR} is expanded.")
+	flag(&b, "--query <k=v>", "Query parameter. Repeatable.")
+	flag(&b, "--data <body>", "Request body. @file reads a file, @- reads stdin.")
+	flag(&b, "--data-raw <body>", "Request body sent as given, even if it starts with @.")
+	flag(&b, "--timeout <int>", "Timeout in seconds. (default from profile, else 20)")
+	flag(&b, "--proxy <url>", "http, https or socks5 proxy. (default: profile, then env)")
+	flag(&b, "--resolve <h:p:a>", "Send host:port to addr instead of DNS. Repeatable.")
+	flag(&b, "--retries <int>", "Retries for idempotent requests on 429/502/503/504. (default 3, 0 disables)")
+	flag(&b, "--rate <float>", "Max requests per second. (default: profile rateLimit)")
+	flag(&b, "--print <HBhb>", "Sections: H/B request headers/body, h/b response headers/body. (default b)")
+	flag(&b, "--pretty <mode>", "all, colors, format or none. (default: all on a terminal, none when piped)")
+	flag(&b, "--raw", "Same as --pretty=none --no-pager.")
+	flag(&b, "--no-pager", "Don't page long output through $PAGER.")
+	flag(&b, "-o, --output <file>", "Save the response body to a file.")
+	flag(&b, "--filter <expr>", "jq-style filter for JSON responses, e.g. '.data[] | {id, name}'.")
+	flag(&b, "-r, --raw-output", "With --filter, print strings without quotes.")
+	flag(&b, "--paginate", "Follow next pages; print items as NDJSON.")
+	flag(&b, "--max-pages <int>", "With --paginate, stop after N pages. (default 50, 0 = no limit)")
+	flag(&b, "--max-items <int>", "With --paginate, stop after N items. (default 0 = no limit)")
+	flag(&b, "--merge", "With --paginate, print a single JSON array.")
+	flag(&b, "--expect <check>", "Assert on the response; exit 1 if it fails. Repeatable.")
+	flag(&b, "--timing", "Print DNS, connect, TLS, TTFB and transfer times to stderr.")
+	flag(&b, "-v, --verbose", "Trace the request and response on the wire to stderr, secrets redacted.")
+	flag(&b, "--no-history", "Don't record this request in restless history.")
+	flag(&b, "--no-learn", "Don't refine the endpoint's response schema in the profile.")
+	flag(&b, "--quiet", "Don't print the status line to stderr.")
+	flag(&b, "--debug", "Print headers (secrets redacted) and the negotiated TLS session.")
+	blank(&b)
+
+	section(&b, "Output")
+	para(&b, w, "",
+		"On a terminal, JSON, XML and HTML bodies are indented and colorized and long output is paged through $PAGER (or RESTLESS_PAGER). Binary bodies are never dumped to the terminal. When piped, the body is written byte-for-byte. NO_COLOR disables colors.")
+	blank(&b)
+
+	section(&b, "Retries and rate limits")
+	para(&b, w, "",
+		"GET, HEAD, OPTIONS, PUT and DELETE (and requests carrying an Idempotency-Key) are retried with exponential backoff and jitter. Retry-After is honored, and RateLimit-*/X-RateLimit-* headers slow requests down when the remaining quota runs low. Failed connects (refused, unreachable, DNS) are only retried when --retries is given.")
+	blank(&b)
+
+	section(&b, "Pagination")
+	para(&b, w, "",
+		"--paginate follows Link rel=\"next\" headers, next/cursor fields in the body (next, links.next, next_cursor, nextPageToken, pageInfo.endCursor, ...) and page/offset query parameters. A style recorded for the endpoint by discover --verify is used when present; otherwise it is detected from the first page. --filter applies to each item, or to the merged array.")
+	blank(&b)
+
+	section(&b, "Filters")
+	para(&b, w, "",
+		"--filter supports a jq subset: paths (.a.b, .[0], .[], .[2:5], ..), |, ',', comparisons, and/or/not, //, + - * / %, [..] and {..} construction, and builtins such as map, select, keys, length, has, join, sort_by, group_by, test, split and to_entries.")
+	blank(&b)
+
+	section(&b, "Assertions")
+	lines(&b,
+		"status=200  status=2xx  status=200,204  status!=500",
+		"header:Content-Type=~json  header:ETag  !header:Set-Cookie",
+		"$.ok==true  $.items[0].id  !$.error  $.count>=1  $.name=~^a",
+		".items | length > 0",
+		"body=~healthy",
+		"schema=@item.schema.json  schema={\"type\":\"object\"}",
+		"latency<500ms",
+	)
+	para(&b, w, "",
+		"JSON paths use the --filter syntax, with $ accepted for the root. A bare path must exist and not be null. Right-hand sides are read as JSON when they parse (true, 3, \"x\"), else as text. Every assertion is checked and each failure is printed to stderr.")
+	blank(&b)
+
+	section(&b, "Response schemas")
+	para(&b, w, "",
+		"A 2xx JSON response refines the schema: block of the profile endpoint it matched: field types, which fields are always present, formats such as date-time, uuid and email, and enums once a small set of values repeats. Mocks, codegen, OpenAPI export and diff --shape use it. A response that fails its --expect checks is not learned from; --no-learn leaves the profile untouched.")
+	blank(&b)
+
+	section(&b, "Auth")
+	lines(&b,
+		"bearer   auth.token is read from the environment (token.envVar).",
+		"oauth2   client_credentials, refresh_token or device_code grants;",
+		"         tokens are cached with 0600 permissions and refreshed before",
+		"         expiry. A 401 triggers one retry with a fresh token.",
+	)
+	blank(&b)
+
+	section(&b, "Exit codes")
+	lines(&b,
+		"0  Response received",
+		"1  Error, or an --expect failed",
+		"2  Usage error",
+	)
+	blank(&b)
+
+	return trimEnd(b.String())
+}
@@ -0,0 +1,82 @@
+// internal/help/run.go
+package help
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RunHelp returns the help text for `restless run`.
+func RunHelp(ctx HelpContext) string {
+	w := ctx.TerminalWidth
+	if w <= 0 {
+		w = detectWidth(92)
+	}
+	if ctx.ProfileDir == "" {
+		ctx.ProfileDir = defaultProfileDir()
+	}
+	if len(ctx.Profiles) == 0 {
+		ctx.Profiles = listProfileNames(ctx.ProfileDir)
+	}
+	sort.Strings(ctx.Profiles)
+	name := "openai"
+	if len(ctx.Profiles) > 0 {
+		name = shellSafe(ctx.Profiles[0])
+	}
+
+	var b strings.Builder
+
+	title(&b, "restless run", "send a saved request from a profile's collection")
+	blank(&b)
+
+	para(&b, w, "Usage:", "restless run <profile> [<name>] [--var k=v]... [request flags]")
+	blank(&b)
+
+	para(&b, w, "Description:",
+		"Profiles keep named requests under collection:. discover --emit-examples generates one per discovered endpoint, with required parameters and a sample body from the spec's schema when there is one. Entries are never rewritten once they exist, so hand edits survive a profile refresh. Without a name, run lists the collection.")
+	blank(&b)
+
+	if len(ctx.Profiles) > 0 {
+		callout(&b, w, "Profiles", strings.Join(ctx.Profiles, ", "))
+		blank(&b)
+	}
+
+	section(&b, "Examples")
+	cmd(&b, fmt.Sprintf("restless run %s", name))
+	cmd(&b, fmt.Sprintf("restless run %s list-models", name))
+	cmd(&b, fmt.Sprintf("restless run %s get-model --var id=gpt-4o --print hb", name))
+	cmd(&b, fmt.Sprintf("restless run %s create-item --env staging --data @item.json", name))
+	blank(&b)
+
+	section(&b, "Collection entries")
+	lines(&b,
+		"collection:",
+		"  - name: get-model",
+		"    description: Retrieve a model",
+		"    method: GET",
+		"    path: /v1/models/{id}",
+		"    query:",
+		"      expand: ${expand}",
+		"    headers:",
+		"      X-Trace: \"1\"",
+		"    vars:",
+		"      expand: owner",
+		"    body: |",
+		"      {\"name\": \"${name}\"}",
+	)
+	blank(&b)
+
+	section(&b, "Variables")
+	para(&b, w, "",
+		"${name} in the path, query, headers or body and {name} path segments are filled from --var, then the entry's vars. A missing variable is an error. ${ENV:NAME} reads the environment as everywhere else.")
+	blank(&b)
+
+	section(&b, "Flags")
+	flag(&b, "--var <k=v>", "Set a variable. Repeatable.")
+	flag(&b, "--profile-dir <path>", "Custom profile storage directory.")
+	para(&b, w, "", "All other flags go to restless request: --env, --print, --filter, --data, -H, --timing, ...")
+	blank(&b)
+
+	return trimEnd(b.String())
+}
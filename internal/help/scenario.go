@@ -0,0 +1,84 @@
+// internal/help/scenario.go
+package help
+
+import "strings"
+
+// ScenarioHelp returns the help text for `restless scenario`.
+func ScenarioHelp(ctx HelpContext) string {
+	w := ctx.TerminalWidth
+	if w <= 0 {
+		w = detectWidth(92)
+	}
+
+	var b strings.Builder
+
+	title(&b, "restless scenario", "run multi-step request flows")
+	blank(&b)
+
+	para(&b, w, "Usage:", "restless scenario run <file.yaml>... [flags]")
+	blank(&b)
+
+	para(&b, w, "Description:",
+		"A scenario is a list of steps sent in order against one profile. A step is a request from the profile's collection, an inline request, or a collection request with some fields replaced. Values captured from a response become variables for the steps after it. The run stops at the first failing step; the rest are reported as skipped. Exit status is 1 if any step failed.")
+	blank(&b)
+
+	section(&b, "Examples")
+	cmd(&b, "restless scenario run flows/items.yaml")
+	cmd(&b, "restless scenario run flows/*.yaml --env staging --junit report.xml")
+	cmd(&b, "restless scenario run login.yaml --var user=demo --env prod")
+	blank(&b)
+
+	section(&b, "Scenario file")
+	lines(&b,
+		"name: item lifecycle",
+		"profile: example",
+		"env: staging",
+		"vars:",
+		"  user: demo",
+		"steps:",
+		"  - name: login",
+		"    request: login            # from the profile's collection",
+		"    capture:",
+		"      token: .access_token",
+		"  - name: create",
+		"    method: POST",
+		"    path: /items",
+		"    headers:",
+		"      Authorization: Bearer ${token}",
+		"    body: |",
+		"      {\"owner\": \"${user}\"}",
+		"    status: 201",
+		"    expect:",
+		"      - $.owner==${user}",
+		"      - schema=@item.schema.json",
+		"    capture:",
+		"      id: .id",
+		"      location: header:Location",
+		"  - name: fetch",
+		"    path: /items/${id}",
+		"  - name: delete",
+		"    method: DELETE",
+		"    path: /items/${id}",
+		"    status: 204, 404",
+	)
+	blank(&b)
+
+	section(&b, "Steps")
+	para(&b, w, "",
+		"status: takes a code, a class such as 2xx, or a comma-separated list; without it any 2xx or 3xx passes. capture: maps a variable to a filter expression (as in request --filter) on the JSON body, or to header:Name. expect: takes the same assertions as request --expect (a schema file is relative to the scenario), and a status assertion there replaces the default status check. A capture that matches nothing fails the step. Variables resolve as step vars, then captures, then --var, then the scenario's vars.")
+	blank(&b)
+
+	section(&b, "Flags")
+	flag(&b, "--profile <name>", "Profile to use instead of the scenario's profile:.")
+	flag(&b, "--profile-dir <path>", "Custom profile storage directory.")
+	flag(&b, "--env <name>", "Environment to use instead of the scenario's env:.")
+	flag(&b, "--var <k=v>", "Set a variable. Repeatable.")
+	flag(&b, "--junit <file>", "Write the results as JUnit XML.")
+	flag(&b, "--timeout <sec>", "Per-request timeout. (default from profile)")
+	flag(&b, "--proxy <url>", "Proxy URL (http, https or socks5).")
+	flag(&b, "--retries <int>", "Retries on 429/5xx for idempotent requests. (default 3)")
+	flag(&b, "--no-history", "Don't record the requests in the history.")
+	blank(&b)
+
+	return trimEnd(b.String())
+}
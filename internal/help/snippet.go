@@ -0,0 +1,64 @@
+// internal/help/snippet.go
+package help
+
+import (
+	"sort"
+	"strings"
+)
+
+// SnippetHelp returns the help text for `restless snippet`.
+func SnippetHelp(ctx HelpContext) string {
+	w := ctx.TerminalWidth
+	if w <= 0 {
+		w = detectWidth(92)
+	}
+	if ctx.ProfileDir == "" {
+		ctx.ProfileDir = defaultProfileDir()
+	}
+	if len(ctx.Profiles) == 0 {
+		ctx.Profiles = listProfileNames(ctx.ProfileDir)
+	}
+	sort.Strings(ctx.Profiles)
+
+	var b strings.Builder
+
+	title(&b, "restless snippet", "copy-paste a request for tickets and runbooks")
+	blank(&b)
+
+	para(&b, w, "Usage:", "restless snippet <profile> <method> <path> [--lang curl|httpie|go|python] [flags]")
+	blank(&b)
+
+	para(&b, w, "Description:",
+		"Prints a ready-to-run snippet for one call: the profile's base URL, its default headers and an auth header. When a collection entry has the same method and path, its query, headers and body are used too, with {name} and ${name} filled from vars and --var; anything left unfilled stays visible as a placeholder.")
+	blank(&b)
+
+	if len(ctx.Profiles) > 0 {
+		callout(&b, w, "Profiles", strings.Join(ctx.Profiles, ", "))
+		blank(&b)
+	}
+
+	section(&b, "Examples")
+	cmd(&b, "restless snippet shop GET /orders/{id} --var id=42")
+	cmd(&b, "restless snippet shop POST /orders --lang python --data '{\"sku\":\"A1\"}'")
+	cmd(&b, "restless snippet shop GET /orders --lang go --env staging --query limit=10")
+	blank(&b)
+
+	section(&b, "Secrets")
+	para(&b, w, "",
+		"Secrets are never resolved. Credentials become environment lookups: $RESTLESS_TOKEN in shell, os.Getenv in Go and os.environ in Python. That covers the bearer token, ${ENV:NAME} header values and secret-looking headers or query values given literally. A comment lists the variables the snippet needs.")
+	blank(&b)
+
+	section(&b, "Flags")
+	flag(&b, "--lang <name>", "curl, httpie, go or python. (default curl)")
+	flag(&b, "--var <name=value>", "Fill a path, query or body variable. (repeatable)")
+	flag(&b, "-H, --header <h>", "Extra header \"Name: value\". (repeatable)")
+	flag(&b, "--query <k=v>", "Query parameter. (repeatable)")
+	flag(&b, "--data <body>", "Request body; @file reads a file.")
+	flag(&b, "--env <name>", "Use a profile environment.")
+	flag(&b, "--base-url <url>", "Override the profile base URL.")
+	flag(&b, "--no-auth", "Leave out the auth header.")
+	flag(&b, "--profile-dir <path>", "Custom profile storage directory.")
+	blank(&b)
+
+	return trimEnd(b.String())
+}
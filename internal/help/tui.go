@@ -0,0 +1,80 @@
+// internal/help/tui.go
+package help
+
+import (
+	"sort"
+	"strings"
+)
+
+// TUIHelp returns the help text for `restless tui`.
+func TUIHelp(ctx HelpContext) string {
+	w := ctx.TerminalWidth
+	if w <= 0 {
+		w = detectWidth(92)
+	}
+	if ctx.ProfileDir == "" {
+		ctx.ProfileDir = defaultProfileDir()
+	}
+	if len(ctx.Profiles) == 0 {
+		ctx.Profiles = listProfileNames(ctx.ProfileDir)
+	}
+	sort.Strings(ctx.Profiles)
+
+	var b strings.Builder
+
+	title(&b, "restless tui", "browse a profile and send requests interactively")
+	blank(&b)
+
+	para(&b, w, "Usage:", "restless tui [profile] [flags]")
+	blank(&b)
+
+	para(&b, w, "Description:",
+		"A keyboard-driven terminal UI. The left pane lists the profile's saved requests and endpoints, with a second tab for its history; pick one to load it into the request editor, where the method, path, query parameters, headers and body can be changed before sending. The response pane below scrolls through the status, headers and pretty-printed body.")
+	blank(&b)
+	para(&b, w, "",
+		"Requests go out exactly like restless request: with the profile's auth, environment, TLS and proxy settings, recorded in the history and refining the endpoint's response schema. On screens narrower than 80 columns, as in Termux, one pane is shown at a time. Only basic terminal escape sequences are used, so it works over SSH.")
+	blank(&b)
+
+	if ctx.ActiveProfile != "" {
+		callout(&b, w, "Active profile", ctx.ActiveProfile)
+	}
+	if len(ctx.Profiles) > 0 {
+		callout(&b, w, "Profiles", strings.Join(ctx.Profiles, ", "))
+	}
+	if ctx.ActiveProfile != "" || len(ctx.Profiles) > 0 {
+		blank(&b)
+	}
+
+	section(&b, "Examples")
+	cmd(&b, "restless tui")
+	cmd(&b, "restless tui shop --env staging")
+	cmd(&b, "restless tui --profile-dir ./profiles --no-history")
+	blank(&b)
+
+	section(&b, "Keys")
+	lines(&b,
+		"tab / shift-tab   Next / previous pane",
+		"enter             Open the highlighted item; in the editor, send from the path",
+		"/                 Filter the list (words match in any order)",
+		"← →               Switch between endpoints and history in the list",
+		"ctrl-r, F5        Send the request",
+		"esc               Cancel a request in flight",
+		"ctrl-p            Pick another profile",
+		"ctrl-n            Next environment",
+		"h, w              Response pane: toggle headers, toggle wrapping",
+		"F1                All keys",
+		"ctrl-q            Quit",
+	)
+	blank(&b)
+
+	section(&b, "Flags")
+	flag(&b, "--profile <name>", "Profile to open. (default: the active profile, else a picker)")
+	flag(&b, "--env <name>", "Environment to start with.")
+	flag(&b, "--proxy <url>", "http, https or socks5 proxy for every request.")
+	flag(&b, "--no-history", "Don't record requests, and hide the history tab.")
+	flag(&b, "--no-learn", "Don't refine response schemas in the profile.")
+	flag(&b, "--profile-dir <path>", "Custom profile storage directory.")
+	blank(&b)
+
+	return trimEnd(b.String())
+}
@@ -0,0 +1,332 @@
+// Package jsondiff computes a structural diff between two decoded JSON
+// documents: added, removed and changed values addressed by jq-style paths.
+// Arrays of objects are aligned by an id-like key when every element has
+// one, so a reordered or grown list doesn't show up as a wall of changes.
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Kind string
+
+const (
+	Added   Kind = "added"
+	Removed Kind = "removed"
+	Changed Kind = "changed"
+)
+
+// Change is one difference. Old is unset for Added, New for Removed.
+type Change struct {
+	Path string `json:"path"`
+	Kind Kind   `json:"kind"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+type Options struct {
+	// Ignore lists field names (matched at any depth) and paths such as
+	// .meta.requestId or .items[].updatedAt (any index matches []).
+	Ignore []string
+	// IgnoreVolatile skips VolatileFields and values that are timestamps on
+	// both sides.
+	IgnoreVolatile bool
+	// IDKeys are tried in order to align arrays of objects; nil means
+	// DefaultIDKeys.
+	IDKeys []string
+}
+
+var DefaultIDKeys = []string{"id", "uuid", "key", "slug", "name"}
+
+// VolatileFields are field names that usually differ between otherwise
+// identical responses. Matching ignores case, - and _.
+var VolatileFields = []string{
+	"createdat", "updatedat", "modifiedat", "deletedat", "lastmodified", "timestamp", "time", "date",
+	"expiresat", "expires", "requestid", "traceid", "spanid", "correlationid", "etag", "nonce",
+}
+
+// Diff returns the changes that turn a into b, in document order.
+func Diff(a, b any, opt Options) []Change {
+	if opt.IDKeys == nil {
+		opt.IDKeys = DefaultIDKeys
+	}
+	d := &differ{opt: opt}
+	for _, p := range opt.Ignore {
+		if strings.HasPrefix(p, ".") || strings.HasPrefix(p, "[") {
+			d.paths = append(d.paths, normalize(p))
+		} else {
+			d.names = append(d.names, fold(p))
+		}
+	}
+	d.walk(".", a, b)
+	return d.out
+}
+
+// Summary renders "3 differences (1 added, 1 removed, 1 changed)".
+func Summary(cs []Change) string {
+	if len(cs) == 0 {
+		return "no differences"
+	}
+	n := map[Kind]int{}
+	for _, c := range cs {
+		n[c.Kind]++
+	}
+	word := "differences"
+	if len(cs) == 1 {
+		word = "difference"
+	}
+	return fmt.Sprintf("%d %s (%d added, %d removed, %d changed)", len(cs), word, n[Added], n[Removed], n[Changed])
+}
+
+type differ struct {
+	opt   Options
+	names []string
+	paths []string
+	out   []Change
+}
+
+func (d *differ) add(c Change) { d.out = append(d.out, c) }
+
+func (d *differ) walk(path string, a, b any) {
+	if d.ignoredPath(path) {
+		return
+	}
+	switch x := a.(type) {
+	case map[string]any:
+		if y, ok := b.(map[string]any); ok {
+			d.objects(path, x, y)
+			return
+		}
+	case []any:
+		if y, ok := b.([]any); ok {
+			d.arrays(path, x, y)
+			return
+		}
+	}
+	if equal(a, b) {
+		return
+	}
+	if d.opt.IgnoreVolatile && isTimestamp(a) && isTimestamp(b) {
+		return
+	}
+	d.add(Change{Path: path, Kind: Changed, Old: a, New: b})
+}
+
+func (d *differ) objects(path string, a, b map[string]any) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if d.ignoredName(k) {
+			continue
+		}
+		p := join(path, k)
+		av, inA := a[k]
+		bv, inB := b[k]
+		switch {
+		case !inB:
+			if !d.ignoredPath(p) {
+				d.add(Change{Path: p, Kind: Removed, Old: av})
+			}
+		case !inA:
+			if !d.ignoredPath(p) {
+				d.add(Change{Path: p, Kind: Added, New: bv})
+			}
+		default:
+			d.walk(p, av, bv)
+		}
+	}
+}
+
+func (d *differ) arrays(path string, a, b []any) {
+	if key := d.idKey(a, b); key != "" {
+		ids := func(xs []any) map[string]any {
+			m := make(map[string]any, len(xs))
+			for _, x := range xs {
+				m[scalar(x.(map[string]any)[key])] = x
+			}
+			return m
+		}
+		am, bm := ids(a), ids(b)
+		elem := func(x any) string {
+			id := x.(map[string]any)[key]
+			if s, ok := id.(string); ok {
+				return fmt.Sprintf("%s[%s=%q]", path, key, s)
+			}
+			return fmt.Sprintf("%s[%s=%s]", path, key, scalar(id))
+		}
+		for _, x := range a {
+			id := scalar(x.(map[string]any)[key])
+			if y, ok := bm[id]; ok {
+				d.walk(elem(x), x, y)
+			} else if p := elem(x); !d.ignoredPath(p) {
+				d.add(Change{Path: p, Kind: Removed, Old: x})
+			}
+		}
+		for _, y := range b {
+			if _, ok := am[scalar(y.(map[string]any)[key])]; !ok {
+				if p := elem(y); !d.ignoredPath(p) {
+					d.add(Change{Path: p, Kind: Added, New: y})
+				}
+			}
+		}
+		return
+	}
+	for i := 0; i < len(a) || i < len(b); i++ {
+		p := fmt.Sprintf("%s[%d]", strings.TrimSuffix(path, "."), i)
+		if path == "." {
+			p = fmt.Sprintf(".[%d]", i)
+		}
+		switch {
+		case i >= len(b):
+			if !d.ignoredPath(p) {
+				d.add(Change{Path: p, Kind: Removed, Old: a[i]})
+			}
+		case i >= len(a):
+			if !d.ignoredPath(p) {
+				d.add(Change{Path: p, Kind: Added, New: b[i]})
+			}
+		default:
+			d.walk(p, a[i], b[i])
+		}
+	}
+}
+
+// idKey picks the first id key that every element on both sides has, with
+// scalar values unique within each side.
+func (d *differ) idKey(a, b []any) string {
+	if len(a) == 0 && len(b) == 0 {
+		return ""
+	}
+next:
+	for _, key := range d.opt.IDKeys {
+		for _, xs := range [][]any{a, b} {
+			seen := map[string]bool{}
+			for _, x := range xs {
+				m, ok := x.(map[string]any)
+				if !ok {
+					return ""
+				}
+				v, ok := m[key]
+				if !ok {
+					continue next
+				}
+				switch v.(type) {
+				case string, json.Number, float64, bool:
+				default:
+					continue next
+				}
+				if s := scalar(v); seen[s] {
+					continue next
+				} else {
+					seen[s] = true
+				}
+			}
+		}
+		return key
+	}
+	return ""
+}
+
+func (d *differ) ignoredName(k string) bool {
+	f := fold(k)
+	for _, n := range d.names {
+		if n == f {
+			return true
+		}
+	}
+	if d.opt.IgnoreVolatile {
+		for _, n := range VolatileFields {
+			if n == f {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (d *differ) ignoredPath(p string) bool {
+	if len(d.paths) == 0 {
+		return false
+	}
+	n := normalize(p)
+	for _, q := range d.paths {
+		if n == q || strings.HasPrefix(n, q+".") || strings.HasPrefix(n, q+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+var index = regexp.MustCompile(`\[[^\]]*\]`)
+
+// normalize turns every [3] or [id=4] into [] so patterns match any element.
+func normalize(p string) string { return index.ReplaceAllString(p, "[]") }
+
+func fold(s string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(s))
+}
+
+var ident = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func join(path, key string) string {
+	seg := "." + key
+	if !ident.MatchString(key) {
+		seg = fmt.Sprintf("[%q]", key)
+	}
+	if path == "." {
+		if seg[0] == '[' {
+			return "." + seg
+		}
+		return seg
+	}
+	return path + seg
+}
+
+func scalar(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+func equal(a, b any) bool {
+	if x, ok := a.(json.Number); ok {
+		if y, ok := b.(json.Number); ok {
+			if x == y {
+				return true
+			}
+			fx, errx := x.Float64()
+			fy, erry := y.Float64()
+			return errx == nil && erry == nil && fx == fy
+		}
+	}
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
+
+func isTimestamp(v any) bool {
+	s, ok := v.(string)
+	if !ok || len(s) < 10 {
+		return false
+	}
+	for _, layout := range []string{time.RFC3339Nano, time.RFC1123, time.RFC1123Z, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,313 @@
+package jsonschema
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Inferred schemas carry two bookkeeping keywords so they can be refined
+// one response at a time: while a string's values could still be an enum,
+// x-restless-values holds the distinct values and x-restless-seen counts
+// them. Both are ignored by Validate; Public drops them.
+const (
+	seenKey   = "x-restless-seen"
+	valuesKey = "x-restless-values"
+)
+
+const (
+	// maxEnum is the largest value set reported as an enum.
+	maxEnum = 6
+	// maxEnumLen is the longest string considered as an enum value.
+	maxEnumLen = 40
+	// maxSeen caps the counter so a settled schema stops changing.
+	maxSeen = 50
+)
+
+// Infer returns a schema describing v, a value decoded by encoding/json.
+// Objects list every key as required and strings record their format
+// (date-time, date, uuid, email or uri) when they have one.
+func Infer(v any) map[string]any {
+	switch t := typeOf(v); t {
+	case "null", "boolean", "integer", "number":
+		return map[string]any{"type": t}
+	case "string":
+		s := v.(string)
+		out := map[string]any{"type": "string"}
+		if f := detectFormat(s); f != "" {
+			out["format"] = f
+		} else if len(s) <= maxEnumLen {
+			out[seenKey], out[valuesKey] = 1, []any{s}
+		}
+		return out
+	case "array":
+		out := map[string]any{"type": "array"}
+		var items map[string]any
+		for _, it := range v.([]any) {
+			items = Merge(items, Infer(it))
+		}
+		if items != nil {
+			out["items"] = items
+		}
+		return out
+	case "object":
+		m := v.(map[string]any)
+		props := make(map[string]any, len(m))
+		req := make([]string, 0, len(m))
+		for k, x := range m {
+			props[k] = Infer(x)
+			req = append(req, k)
+		}
+		sort.Strings(req)
+		out := map[string]any{"type": "object", "properties": props}
+		if len(req) > 0 {
+			out["required"] = toAny(req)
+		}
+		return out
+	}
+	return map[string]any{}
+}
+
+// Refine folds another observed value into an inferred schema.
+func Refine(s map[string]any, v any) map[string]any { return Merge(s, Infer(v)) }
+
+// Merge combines two inferred schemas into one both observations satisfy:
+// properties are united and only keys required by both stay required,
+// integer widens to number, differing types become a type list, a null
+// makes the type nullable, and formats and enums survive only while every
+// value agrees. Either side may be nil.
+func Merge(a, b map[string]any) map[string]any {
+	switch {
+	case a == nil && b == nil:
+		return nil
+	case a == nil:
+		return clone(b)
+	case b == nil:
+		return clone(a)
+	}
+	ta, tb := typeList(a["type"]), typeList(b["type"])
+	if len(ta) == 0 || len(tb) == 0 {
+		return map[string]any{} // one side accepts anything
+	}
+	nullable := has(ta, "null") || has(tb, "null")
+	ta, tb = without(ta, "null"), without(tb, "null")
+	var out map[string]any
+	switch {
+	case len(ta) == 0:
+		out = clone(b)
+	case len(tb) == 0:
+		out = clone(a)
+	case len(ta) == 1 && len(tb) == 1 && ta[0] == tb[0]:
+		out = mergeSame(ta[0], a, b)
+	case len(ta) == 1 && len(tb) == 1 && has([]string{ta[0], tb[0]}, "integer") && has([]string{ta[0], tb[0]}, "number"):
+		out = map[string]any{"type": "number"}
+	default:
+		types := map[string]bool{}
+		for _, t := range append(ta, tb...) {
+			if t == "integer" && (has(ta, "number") || has(tb, "number")) {
+				continue
+			}
+			types[t] = true
+		}
+		list := make([]string, 0, len(types))
+		for t := range types {
+			list = append(list, t)
+		}
+		sort.Strings(list)
+		out = map[string]any{"type": toAny(list)}
+	}
+	if nullable {
+		types := typeList(out["type"])
+		if len(types) == 0 {
+			types = []string{"null"}
+		} else if !has(types, "null") {
+			types = append(types, "null")
+		}
+		if len(types) == 1 {
+			out["type"] = types[0]
+		} else {
+			out["type"] = toAny(types)
+		}
+	}
+	return out
+}
+
+func mergeSame(t string, a, b map[string]any) map[string]any {
+	out := map[string]any{"type": t}
+	switch t {
+	case "object":
+		pa, _ := a["properties"].(map[string]any)
+		pb, _ := b["properties"].(map[string]any)
+		props := map[string]any{}
+		for k, v := range pa {
+			props[k] = v
+		}
+		for k, v := range pb {
+			x, _ := props[k].(map[string]any)
+			y, _ := v.(map[string]any)
+			if x == nil {
+				props[k] = clone(y)
+			} else {
+				props[k] = Merge(x, y)
+			}
+		}
+		out["properties"] = props
+		rb := map[string]bool{}
+		for _, k := range typeList(b["required"]) {
+			rb[k] = true
+		}
+		var req []string
+		for _, k := range typeList(a["required"]) {
+			if rb[k] {
+				req = append(req, k)
+			}
+		}
+		if len(req) > 0 {
+			out["required"] = toAny(req)
+		}
+	case "array":
+		ia, _ := a["items"].(map[string]any)
+		ib, _ := b["items"].(map[string]any)
+		if items := Merge(ia, ib); items != nil {
+			out["items"] = items
+		}
+	case "string":
+		if fa, _ := a["format"].(string); fa != "" && fa == b["format"] {
+			out["format"] = fa
+		}
+		va, oka := values(a)
+		vb, okb := values(b)
+		if !oka || !okb || out["format"] != nil {
+			break
+		}
+		n := min(count(a[seenKey])+count(b[seenKey]), maxSeen)
+		seen := map[string]bool{}
+		var vals []string
+		for _, v := range append(va, vb...) {
+			if !seen[v] {
+				seen[v] = true
+				vals = append(vals, v)
+			}
+		}
+		if len(vals) > maxEnum {
+			break
+		}
+		sort.Strings(vals)
+		out[seenKey] = n
+		// A value set is an enum once most values have repeated.
+		if n >= 4 && len(vals)*2 <= n {
+			out["enum"] = toAny(vals)
+		} else {
+			out[valuesKey] = toAny(vals)
+		}
+	}
+	return out
+}
+
+// Public returns s without the bookkeeping keywords, ready for a document
+// or a mock.
+func Public(s map[string]any) map[string]any {
+	if s == nil {
+		return nil
+	}
+	out := map[string]any{}
+	for k, v := range s {
+		switch k {
+		case seenKey, valuesKey:
+			continue
+		case "properties":
+			props := map[string]any{}
+			for name, p := range v.(map[string]any) {
+				ps, _ := p.(map[string]any)
+				props[name] = Public(ps)
+			}
+			out[k] = props
+		case "items":
+			it, _ := v.(map[string]any)
+			out[k] = Public(it)
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// values returns the tracked string values: the enum once confirmed, the
+// candidates before. ok is false once the set was given up.
+func values(s map[string]any) ([]string, bool) {
+	if e, ok := s["enum"].([]any); ok {
+		return typeList(e), true
+	}
+	if c, ok := s[valuesKey].([]any); ok {
+		return typeList(c), true
+	}
+	return nil, false
+}
+
+func detectFormat(s string) string {
+	switch {
+	case len(s) >= 20 && strings.ContainsAny(s[10:11], "Tt") && validFormat("date-time", s):
+		return "date-time"
+	case len(s) == 10 && validFormat("date", s):
+		return "date"
+	case len(s) == 36 && validFormat("uuid", s):
+		return "uuid"
+	case strings.Contains(s, "@") && !strings.ContainsAny(s, " <>") && validFormat("email", s) && strings.Contains(s[strings.LastIndex(s, "@"):], "."):
+		return "email"
+	case strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://"):
+		if u, err := url.Parse(s); err == nil && u.Host != "" {
+			return "uri"
+		}
+	}
+	return ""
+}
+
+func count(v any) int {
+	n, _ := intOf(v)
+	return n
+}
+
+func clone(s map[string]any) map[string]any {
+	if s == nil {
+		return nil
+	}
+	out := make(map[string]any, len(s))
+	for k, v := range s {
+		switch t := v.(type) {
+		case map[string]any:
+			out[k] = clone(t)
+		case []any:
+			out[k] = append([]any(nil), t...)
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func has(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func without(list []string, s string) []string {
+	var out []string
+	for _, x := range list {
+		if x != s {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+func toAny(ss []string) []any {
+	out := make([]any, len(ss))
+	for i, s := range ss {
+		out[i] = s
+	}
+	return out
+}
@@ -0,0 +1,138 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// infer refines a schema with each JSON document in turn and returns the
+// public result as compact JSON.
+func infer(t *testing.T, docs ...string) string {
+	t.Helper()
+	var s map[string]any
+	for _, d := range docs {
+		var v any
+		if err := json.Unmarshal([]byte(d), &v); err != nil {
+			t.Fatalf("bad fixture %s: %v", d, err)
+		}
+		s = Refine(s, v)
+	}
+	out, err := json.Marshal(Public(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestRefine(t *testing.T) {
+	tests := []struct {
+		name string
+		docs []string
+		want string
+	}{
+		{"scalar", []string{`true`}, `{"type":"boolean"}`},
+		{"integer", []string{`1`, `2`}, `{"type":"integer"}`},
+		{"integer widens to number", []string{`1`, `1.5`}, `{"type":"number"}`},
+		{"number absorbs integer in a list", []string{`1.5`, `"x"`, `2`}, `{"type":["number","string"]}`},
+		{"differing types", []string{`1`, `"a"`}, `{"type":["integer","string"]}`},
+		{"null first", []string{`null`, `"a"`}, `{"type":["string","null"]}`},
+		{"null later", []string{`3`, `null`}, `{"type":["integer","null"]}`},
+		{"only null", []string{`null`, `null`}, `{"type":"null"}`},
+		{"nullable stays nullable", []string{`null`, `1`, `2`}, `{"type":["integer","null"]}`},
+		{"format", []string{`"2024-05-01T10:00:00Z"`}, `{"format":"date-time","type":"string"}`},
+		{"format kept when all agree", []string{`"a@b.io"`, `"c@d.org"`}, `{"format":"email","type":"string"}`},
+		{"format dropped on disagreement", []string{`"2024-05-01"`, `"soon"`}, `{"type":"string"}`},
+		{"uuid", []string{`"0b6c1b1e-8a6d-4c2e-9a61-3f1f0c9d2e7a"`}, `{"format":"uuid","type":"string"}`},
+		{"uri", []string{`"https://example.com/a"`}, `{"format":"uri","type":"string"}`},
+		{"too few values for an enum", []string{`"a"`, `"a"`, `"b"`}, `{"type":"string"}`},
+		{"enum once values repeat", []string{`"b"`, `"a"`, `"a"`, `"b"`}, `{"enum":["a","b"],"type":"string"}`},
+		{"enum given up when too many values",
+			[]string{`"a"`, `"b"`, `"c"`, `"d"`, `"e"`, `"f"`, `"g"`, `"a"`, `"b"`, `"c"`, `"d"`, `"e"`, `"f"`, `"g"`},
+			`{"type":"string"}`},
+		{"enum broken by a new value",
+			[]string{`"a"`, `"a"`, `"b"`, `"b"`, `"a"`, `"a"`, `"b"`, `"b"`, `"c"`, `"d"`, `"e"`, `"f"`, `"g"`},
+			`{"type":"string"}`},
+		{"array items", []string{`[1, 2.5]`}, `{"items":{"type":"number"},"type":"array"}`},
+		{"empty array", []string{`[]`}, `{"type":"array"}`},
+		{"empty then filled array", []string{`[]`, `["x"]`}, `{"items":{"type":"string"},"type":"array"}`},
+		{"object", []string{`{"id": 1, "name": "pen"}`},
+			`{"properties":{"id":{"type":"integer"},"name":{"type":"string"}},"required":["id","name"],"type":"object"}`},
+		{"required only when always present", []string{`{"id": 1, "note": "x"}`, `{"id": 2, "tag": true}`},
+			`{"properties":{"id":{"type":"integer"},"note":{"type":"string"},"tag":{"type":"boolean"}},"required":["id"],"type":"object"}`},
+		{"empty object", []string{`{}`}, `{"properties":{},"type":"object"}`},
+		{"nested", []string{`{"items": [{"id": 1}, {"id": 2, "x": null}]}`},
+			`{"properties":{"items":{"items":{"properties":{"id":{"type":"integer"},"x":{"type":"null"}},"required":["id"],"type":"object"},"type":"array"}},"required":["items"],"type":"object"}`},
+		{"object or null", []string{`{"a": 1}`, `null`},
+			`{"properties":{"a":{"type":"integer"}},"required":["a"],"type":["object","null"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := infer(t, tt.docs...); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	str := map[string]any{"type": "string"}
+	tests := []struct {
+		name string
+		a, b map[string]any
+		want string
+	}{
+		{"both nil", nil, nil, `null`},
+		{"nil left", nil, str, `{"type":"string"}`},
+		{"nil right", str, nil, `{"type":"string"}`},
+		{"untyped accepts anything", map[string]any{}, str, `{}`},
+		{"type lists unite", map[string]any{"type": []any{"integer", "string"}}, map[string]any{"type": "number"}, `{"type":["number","string"]}`},
+		{"null in a list", map[string]any{"type": []any{"string", "null"}}, map[string]any{"type": "boolean"}, `{"type":["boolean","string","null"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, _ := json.Marshal(Merge(tt.a, tt.b))
+			if got := string(out); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeDoesNotAlias(t *testing.T) {
+	a := Infer(map[string]any{"tags": []any{"x"}})
+	b := Merge(nil, a)
+	b["properties"].(map[string]any)["tags"].(map[string]any)["type"] = "string"
+	if got := a["properties"].(map[string]any)["tags"].(map[string]any)["type"]; got != "array" {
+		t.Errorf("changing the merged schema changed its input: type = %v", got)
+	}
+}
+
+func TestInferredSchemaValidatesItsInputs(t *testing.T) {
+	docs := []string{
+		`{"id": 1, "status": "open", "when": "2024-05-01T10:00:00Z", "tags": ["a"], "owner": null}`,
+		`{"id": 2, "status": "closed", "when": "2024-05-02T10:00:00Z", "tags": [], "owner": {"name": "ann"}}`,
+		`{"id": 3, "status": "open", "when": "2024-05-03T10:00:00Z", "tags": ["b", "c"], "owner": null, "extra": 1.5}`,
+		`{"id": 4, "status": "closed", "when": "2024-05-04T10:00:00Z", "tags": ["a"], "owner": {"name": "bo"}}`,
+	}
+	var s map[string]any
+	var vals []any
+	for _, d := range docs {
+		var v any
+		if err := json.Unmarshal([]byte(d), &v); err != nil {
+			t.Fatal(err)
+		}
+		s = Refine(s, v)
+		vals = append(vals, v)
+	}
+	pub := Public(s)
+	for i, v := range vals {
+		if errs := Validate(pub, v); len(errs) > 0 {
+			t.Errorf("document %d: %v", i, errs)
+		}
+	}
+	var bad any
+	_ = json.Unmarshal([]byte(`{"id": "x", "status": "gone", "when": "yesterday", "tags": [1], "owner": 2}`), &bad)
+	if errs := Validate(pub, bad); len(errs) != 5 {
+		t.Errorf("got %d errors %v, want 5", len(errs), errs)
+	}
+}
@@ -0,0 +1,425 @@
+// Package jsonschema validates decoded JSON against a JSON Schema. It covers
+// the keywords API descriptions use in practice: type, enum/const, string,
+// number, array and object bounds, properties, items, the allOf/anyOf/oneOf/
+// not combinators, local $refs and OpenAPI 3.0's nullable. Unknown keywords
+// and formats are ignored.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Error is one violation, addressed by a jq-style path into the document.
+type Error struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e Error) Error() string { return e.Path + ": " + e.Message }
+
+// Validate checks v (as decoded by encoding/json, numbers as float64 or
+// json.Number) against schema and returns every violation found.
+func Validate(schema map[string]any, v any) []Error {
+	val := &validator{root: schema}
+	val.check(".", schema, v)
+	return val.out
+}
+
+// Valid reports whether v matches schema.
+func Valid(schema map[string]any, v any) bool { return len(Validate(schema, v)) == 0 }
+
+type validator struct {
+	root  map[string]any
+	out   []Error
+	depth int
+}
+
+func (v *validator) fail(path, format string, args ...any) {
+	v.out = append(v.out, Error{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// try runs a sub-check without recording its errors.
+func (v *validator) try(path string, s map[string]any, x any) []Error {
+	sub := &validator{root: v.root, depth: v.depth}
+	sub.check(path, s, x)
+	return sub.out
+}
+
+func (v *validator) check(path string, s map[string]any, x any) {
+	if s == nil || v.depth > 64 {
+		return
+	}
+	if ref, ok := s["$ref"].(string); ok {
+		if t := v.resolve(ref); t != nil {
+			v.depth++
+			v.check(path, t, x)
+			v.depth--
+		}
+	}
+	if x == nil && s["nullable"] == true {
+		return
+	}
+	if types := typeList(s["type"]); len(types) > 0 && !matchesType(types, x) {
+		v.fail(path, "expected %s, got %s", strings.Join(types, " or "), typeOf(x))
+		return
+	}
+	if enum, ok := s["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if equal(e, x) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "%s is not one of %s", show(x), show(enum))
+		}
+	}
+	if c, ok := s["const"]; ok && !equal(c, x) {
+		v.fail(path, "expected %s, got %s", show(c), show(x))
+	}
+
+	switch val := x.(type) {
+	case string:
+		v.checkString(path, s, val)
+	case map[string]any:
+		v.checkObject(path, s, val)
+	case []any:
+		v.checkArray(path, s, val)
+	default:
+		if n, ok := toFloat(x); ok {
+			v.checkNumber(path, s, n)
+		}
+	}
+
+	for _, sub := range schemas(s["allOf"]) {
+		v.check(path, sub, x)
+	}
+	if alts := schemas(s["anyOf"]); len(alts) > 0 {
+		ok := false
+		for _, sub := range alts {
+			if len(v.try(path, sub, x)) == 0 {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			v.fail(path, "matches none of anyOf")
+		}
+	}
+	if alts := schemas(s["oneOf"]); len(alts) > 0 {
+		n := 0
+		for _, sub := range alts {
+			if len(v.try(path, sub, x)) == 0 {
+				n++
+			}
+		}
+		if n != 1 {
+			v.fail(path, "matches %d of oneOf, want exactly 1", n)
+		}
+	}
+	if not, ok := s["not"].(map[string]any); ok && len(v.try(path, not, x)) == 0 {
+		v.fail(path, "must not match the \"not\" schema")
+	}
+}
+
+func (v *validator) checkString(path string, s map[string]any, x string) {
+	n := utf8.RuneCountInString(x)
+	if min, ok := intOf(s["minLength"]); ok && n < min {
+		v.fail(path, "length %d is below minLength %d", n, min)
+	}
+	if max, ok := intOf(s["maxLength"]); ok && n > max {
+		v.fail(path, "length %d is above maxLength %d", n, max)
+	}
+	if p, ok := s["pattern"].(string); ok {
+		if re, err := regexp.Compile(p); err == nil && !re.MatchString(x) {
+			v.fail(path, "%s does not match %s", show(x), p)
+		}
+	}
+	if f, ok := s["format"].(string); ok && !validFormat(f, x) {
+		v.fail(path, "%s is not a valid %s", show(x), f)
+	}
+}
+
+func (v *validator) checkNumber(path string, s map[string]any, n float64) {
+	if min, ok := toFloat(s["minimum"]); ok {
+		if s["exclusiveMinimum"] == true && n <= min {
+			v.fail(path, "%v is not above %v", n, min)
+		} else if n < min {
+			v.fail(path, "%v is below minimum %v", n, min)
+		}
+	}
+	if max, ok := toFloat(s["maximum"]); ok {
+		if s["exclusiveMaximum"] == true && n >= max {
+			v.fail(path, "%v is not below %v", n, max)
+		} else if n > max {
+			v.fail(path, "%v is above maximum %v", n, max)
+		}
+	}
+	// 2019+ spells exclusive bounds as numbers.
+	if min, ok := toFloat(s["exclusiveMinimum"]); ok && n <= min {
+		v.fail(path, "%v is not above %v", n, min)
+	}
+	if max, ok := toFloat(s["exclusiveMaximum"]); ok && n >= max {
+		v.fail(path, "%v is not below %v", n, max)
+	}
+	if m, ok := toFloat(s["multipleOf"]); ok && m > 0 {
+		if q := n / m; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.fail(path, "%v is not a multiple of %v", n, m)
+		}
+	}
+}
+
+func (v *validator) checkArray(path string, s map[string]any, x []any) {
+	if min, ok := intOf(s["minItems"]); ok && len(x) < min {
+		v.fail(path, "%d items, want at least %d", len(x), min)
+	}
+	if max, ok := intOf(s["maxItems"]); ok && len(x) > max {
+		v.fail(path, "%d items, want at most %d", len(x), max)
+	}
+	if s["uniqueItems"] == true {
+	dup:
+		for i := range x {
+			for j := 0; j < i; j++ {
+				if equal(x[i], x[j]) {
+					v.fail(index(path, i), "duplicate of item %d", j)
+					break dup
+				}
+			}
+		}
+	}
+	prefix := schemas(s["prefixItems"])
+	for i, it := range x {
+		switch {
+		case i < len(prefix):
+			v.check(index(path, i), prefix[i], it)
+		default:
+			if items, ok := s["items"].(map[string]any); ok {
+				v.check(index(path, i), items, it)
+			} else if s["items"] == false {
+				v.fail(index(path, i), "no items allowed past %d", len(prefix))
+			}
+		}
+	}
+}
+
+func (v *validator) checkObject(path string, s map[string]any, x map[string]any) {
+	if req, ok := s["required"].([]any); ok {
+		for _, r := range req {
+			if k, ok := r.(string); ok {
+				if _, present := x[k]; !present {
+					v.fail(join(path, k), "required property is missing")
+				}
+			}
+		}
+	}
+	if min, ok := intOf(s["minProperties"]); ok && len(x) < min {
+		v.fail(path, "%d properties, want at least %d", len(x), min)
+	}
+	if max, ok := intOf(s["maxProperties"]); ok && len(x) > max {
+		v.fail(path, "%d properties, want at most %d", len(x), max)
+	}
+	props, _ := s["properties"].(map[string]any)
+	keys := make([]string, 0, len(x))
+	for k := range x {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if ps, ok := props[k].(map[string]any); ok {
+			v.check(join(path, k), ps, x[k])
+			continue
+		}
+		if _, ok := props[k]; ok {
+			continue // a boolean true schema
+		}
+		switch ap := s["additionalProperties"].(type) {
+		case bool:
+			if !ap {
+				v.fail(join(path, k), "unexpected property")
+			}
+		case map[string]any:
+			v.check(join(path, k), ap, x[k])
+		}
+	}
+}
+
+// resolve follows a local "#/..." JSON pointer.
+func (v *validator) resolve(ref string) map[string]any {
+	p, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil
+	}
+	var cur any = v.root
+	for _, seg := range strings.Split(strings.TrimPrefix(p, "/"), "/") {
+		if seg == "" {
+			continue
+		}
+		seg = strings.NewReplacer("~1", "/", "~0", "~").Replace(seg)
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[seg]
+	}
+	m, _ := cur.(map[string]any)
+	return m
+}
+
+func typeList(t any) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var out []string
+		for _, x := range t {
+			if s, ok := x.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func matchesType(types []string, x any) bool {
+	got := typeOf(x)
+	for _, t := range types {
+		if t == got || (t == "number" && got == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(x any) string {
+	switch x.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	if f, ok := toFloat(x); ok {
+		if f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", x)
+}
+
+func toFloat(x any) (float64, bool) {
+	switch n := x.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func intOf(x any) (int, bool) {
+	f, ok := toFloat(x)
+	return int(f), ok
+}
+
+func schemas(x any) []map[string]any {
+	list, _ := x.([]any)
+	var out []map[string]any
+	for _, it := range list {
+		if m, ok := it.(map[string]any); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+func equal(a, b any) bool {
+	fa, okA := toFloat(a)
+	fb, okB := toFloat(b)
+	if okA && okB {
+		return fa == fb
+	}
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return string(ja) == string(jb)
+}
+
+var (
+	uuidRe  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	emailRe = regexp.MustCompile(`^[^@\s]+@[^@\s]+$`)
+)
+
+func validFormat(format, s string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, s)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	case "uuid":
+		return uuidRe.MatchString(s)
+	case "email":
+		return emailRe.MatchString(s)
+	case "uri", "url":
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	}
+	return true
+}
+
+var ident = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func join(path, key string) string {
+	seg := "." + key
+	if !ident.MatchString(key) {
+		seg = fmt.Sprintf("[%q]", key)
+	}
+	if path == "." {
+		if seg[0] == '[' {
+			return "." + seg
+		}
+		return seg
+	}
+	return path + seg
+}
+
+func index(path string, i int) string {
+	if path == "." {
+		return ".[" + strconv.Itoa(i) + "]"
+	}
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// show renders a value on one line, cut at 60 characters.
+func show(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if r := []rune(string(b)); len(r) > 60 {
+		return string(r[:57]) + "..."
+	}
+	return string(b)
+}
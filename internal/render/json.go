@@ -0,0 +1,98 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// jsonBody indents (if opt.Format) and colorizes JSON, keeping key order.
+// NDJSON bodies are rendered value by value.
+func jsonBody(b *bytes.Buffer, body []byte, opt Options) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var vals []json.RawMessage
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		vals = append(vals, raw)
+	}
+	if len(vals) == 0 {
+		return errEmpty
+	}
+	for _, raw := range vals {
+		src := []byte(raw)
+		if opt.Format {
+			var ind bytes.Buffer
+			if err := json.Indent(&ind, raw, "", "  "); err != nil {
+				return err
+			}
+			src = ind.Bytes()
+		}
+		colorJSON(b, src, opt.Color)
+		b.WriteString("\n")
+	}
+	return nil
+}
+
+type renderError string
+
+func (e renderError) Error() string { return string(e) }
+
+const errEmpty = renderError("empty body")
+
+// colorJSON copies valid JSON text to b, coloring keys, strings, numbers and literals.
+func colorJSON(b *bytes.Buffer, src []byte, color bool) {
+	if !color {
+		b.Write(src)
+		return
+	}
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '"':
+			j := i + 1
+			for j < len(src) && src[j] != '"' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < len(src) {
+				j++
+			}
+			// A string followed by ':' is an object key.
+			k := j
+			for k < len(src) && (src[k] == ' ' || src[k] == '\n' || src[k] == '\t' || src[k] == '\r') {
+				k++
+			}
+			style := green
+			if k < len(src) && src[k] == ':' {
+				style = bold + blue
+			}
+			paint(b, true, style, string(src[i:j]))
+			i = j
+		case c == '-' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(src) && bytes.IndexByte([]byte("0123456789.eE+-"), src[j]) >= 0 {
+				j++
+			}
+			paint(b, true, cyan, string(src[i:j]))
+			i = j
+		case c == 't' || c == 'f' || c == 'n':
+			j := i
+			for j < len(src) && src[j] >= 'a' && src[j] <= 'z' {
+				j++
+			}
+			paint(b, true, magenta, string(src[i:j]))
+			i = j
+		case c == '{' || c == '}' || c == '[' || c == ']' || c == ',' || c == ':':
+			paint(b, true, dim, string(c))
+			i++
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// xmlBody re-indents XML (if opt.Format) and colorizes tags.
+func xmlBody(b *bytes.Buffer, body []byte, opt Options) error {
+	src := body
+	if opt.Format {
+		out, err := indentXML(body)
+		if err != nil {
+			return err
+		}
+		src = out
+	}
+	markup(b, src, opt.Color)
+	endLine(b)
+	return nil
+}
+
+// indentXML puts each element on its own line, indented by depth. Tokens
+// are copied from the input byte for byte, so namespace prefixes, quoting
+// and entities stay as the server sent them; only whitespace between
+// tokens changes.
+func indentXML(body []byte) ([]byte, error) {
+	var out bytes.Buffer
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	dec.Strict = false
+	depth, pos := 0, int64(0)
+	// textOnly is set while the element last opened has held nothing but
+	// text, so it can close on the same line.
+	textOnly := false
+	newline := func() {
+		if out.Len() > 0 {
+			out.WriteString("\n")
+		}
+		out.WriteString(strings.Repeat("  ", depth))
+	}
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		end := dec.InputOffset()
+		raw := body[pos:end]
+		pos = end
+		switch t := tok.(type) {
+		case xml.StartElement:
+			newline()
+			out.Write(raw)
+			depth++
+			textOnly = true
+		case xml.EndElement:
+			depth--
+			if len(raw) == 0 {
+				// The end of a self-closing <tag/>, already written.
+				textOnly = false
+				continue
+			}
+			// Keep <a>text</a> and <a></a> on one line.
+			if !textOnly {
+				newline()
+			}
+			out.Write(raw)
+			textOnly = false
+		case xml.CharData:
+			// Whitespace-only text is the old indentation.
+			if len(bytes.TrimSpace(t)) == 0 {
+				continue
+			}
+			if !textOnly {
+				newline()
+			}
+			out.Write(bytes.TrimSpace(raw))
+		default:
+			newline()
+			out.Write(raw)
+			textOnly = false
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// markup colorizes tags, attribute names and values in XML/HTML text
+// without changing it.
+func markup(b *bytes.Buffer, src []byte, color bool) {
+	if !color {
+		b.Write(src)
+		return
+	}
+	s := string(src)
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '<')
+		if i < 0 {
+			b.WriteString(s)
+			return
+		}
+		b.WriteString(s[:i])
+		s = s[i:]
+		if strings.HasPrefix(s, "<!--") {
+			end := strings.Index(s, "-->")
+			if end < 0 {
+				end = len(s) - 3
+			}
+			paint(b, true, dim, s[:end+3])
+			s = s[end+3:]
+			continue
+		}
+		end := strings.IndexByte(s, '>')
+		if end < 0 {
+			b.WriteString(s)
+			return
+		}
+		tag(b, s[:end+1])
+		s = s[end+1:]
+	}
+}
+
+func tag(b *bytes.Buffer, t string) {
+	inner := t[1 : len(t)-1]
+	name := inner
+	rest := ""
+	if i := strings.IndexAny(inner, " \t\n"); i >= 0 {
+		name, rest = inner[:i], inner[i:]
+	}
+	paint(b, true, blue, "<"+name)
+	for len(rest) > 0 {
+		eq := strings.IndexByte(rest, '=')
+		if eq < 0 {
+			paint(b, true, blue, rest)
+			break
+		}
+		paint(b, true, cyan, rest[:eq])
+		b.WriteString("=")
+		rest = rest[eq+1:]
+		if len(rest) > 0 && (rest[0] == '"' || rest[0] == '\'') {
+			q := rest[0]
+			j := strings.IndexByte(rest[1:], q)
+			if j < 0 {
+				paint(b, true, green, rest)
+				rest = ""
+				break
+			}
+			paint(b, true, green, rest[:j+2])
+			rest = rest[j+2:]
+			continue
+		}
+		j := strings.IndexAny(rest, " \t\n/")
+		if j < 0 {
+			j = len(rest)
+		}
+		paint(b, true, green, rest[:j])
+		rest = rest[j:]
+	}
+	paint(b, true, blue, ">")
+}
@@ -0,0 +1,106 @@
+package render
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var ansi = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func TestXMLBodyFormat(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{
+			name: "namespaces keep their prefixes",
+			in: `<?xml version="1.0" encoding="UTF-8"?><soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:m="urn:shop">` +
+				`<soap:Header/><soap:Body><m:GetPriceResponse><m:Price currency='EUR'>1.90</m:Price></m:GetPriceResponse></soap:Body></soap:Envelope>`,
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:m="urn:shop">
+  <soap:Header/>
+  <soap:Body>
+    <m:GetPriceResponse>
+      <m:Price currency='EUR'>1.90</m:Price>
+    </m:GetPriceResponse>
+  </soap:Body>
+</soap:Envelope>
+`,
+		},
+		{
+			name: "default namespace and reindenting",
+			in:   "<feed xmlns=\"http://www.w3.org/2005/Atom\">\n\t<entry>\n\t\t<title>a &amp; b</title>\n\t</entry>\n</feed>",
+			want: `<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry>
+    <title>a &amp; b</title>
+  </entry>
+</feed>
+`,
+		},
+		{
+			name: "empty elements, comments and CDATA",
+			in:   `<a><!-- note --><b></b><c x="1"/><d><![CDATA[<raw>]]></d></a>`,
+			want: `<a>
+  <!-- note -->
+  <b></b>
+  <c x="1"/>
+  <d><![CDATA[<raw>]]></d>
+</a>
+`,
+		},
+		{
+			name: "mixed content",
+			in:   `<p>one <b>two</b> three</p>`,
+			want: `<p>one
+  <b>two</b>
+  three
+</p>
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := xmlBody(&b, []byte(tt.in), Options{Format: true}); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXMLBodyUnformatted(t *testing.T) {
+	in := `<x:a xmlns:x="urn:x"><x:b/></x:a>`
+	var b bytes.Buffer
+	if err := xmlBody(&b, []byte(in), Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != in+"\n" {
+		t.Errorf("got %q, want the body unchanged", got)
+	}
+}
+
+func TestXMLBodyColorKeepsText(t *testing.T) {
+	in := `<s:a xmlns:s="urn:s" k="v">t</s:a>`
+	var b bytes.Buffer
+	if err := xmlBody(&b, []byte(in), Options{Format: true, Color: true}); err != nil {
+		t.Fatal(err)
+	}
+	plain := ansi.ReplaceAllString(b.String(), "")
+	if plain != in+"\n" {
+		t.Errorf("without colors got %q, want %q", plain, in+"\n")
+	}
+	if !strings.Contains(b.String(), blue+"<s:a") {
+		t.Errorf("tag not colored: %q", b.String())
+	}
+}
+
+func TestXMLBodyRejectsBrokenXML(t *testing.T) {
+	var b bytes.Buffer
+	if err := xmlBody(&b, []byte(`<a><b></a`), Options{Format: true}); err == nil {
+		t.Errorf("no error; rendered %q", b.String())
+	}
+}
@@ -0,0 +1,66 @@
+package render
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// IsTerminal reports whether f is attached to a terminal.
+func IsTerminal(f *os.File) bool { return term.IsTerminal(int(f.Fd())) }
+
+// ColorEnabled honors NO_COLOR (https://no-color.org) and TERM=dumb.
+func ColorEnabled(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTerminal(f)
+}
+
+// Page writes content to out, through $PAGER (default "less -FRX") when out
+// is a terminal and the content is taller than the screen.
+func Page(out *os.File, content []byte) error {
+	if !IsTerminal(out) || !tallerThanScreen(out, content) {
+		_, err := out.Write(content)
+		return err
+	}
+	pager := strings.TrimSpace(os.Getenv("RESTLESS_PAGER"))
+	if pager == "" {
+		pager = strings.TrimSpace(os.Getenv("PAGER"))
+	}
+	if pager == "" {
+		pager = "less -FRX"
+	}
+	if pager == "cat" {
+		_, err := out.Write(content)
+		return err
+	}
+	fields := strings.Fields(pager)
+	cmd := exec.Command(fields[0], fields[1:]...)
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	// less needs -R to pass colors through when invoked via $PAGER=less.
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	if err := cmd.Run(); err != nil {
+		// Missing pager: fall back to plain output rather than losing the response.
+		if _, ok := err.(*exec.Error); ok {
+			_, err = out.Write(content)
+		}
+		return err
+	}
+	return nil
+}
+
+func tallerThanScreen(f *os.File, content []byte) bool {
+	_, h, err := term.GetSize(int(f.Fd()))
+	if err != nil || h <= 0 {
+		return false
+	}
+	return bytes.Count(content, []byte("\n")) >= h
+}
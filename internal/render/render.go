@@ -0,0 +1,187 @@
+// Package render formats HTTP messages for the terminal: pretty-printed and
+// colorized bodies, header sections, binary detection and paging.
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+type Options struct {
+	Color  bool // ANSI colors
+	Format bool // re-indent JSON/XML
+}
+
+// ANSI styles; kept to the basic 16 colors so they work in Termux and over SSH.
+const (
+	reset   = "\x1b[0m"
+	bold    = "\x1b[1m"
+	dim     = "\x1b[2m"
+	red     = "\x1b[31m"
+	green   = "\x1b[32m"
+	yellow  = "\x1b[33m"
+	blue    = "\x1b[34m"
+	magenta = "\x1b[35m"
+	cyan    = "\x1b[36m"
+)
+
+func paint(b *bytes.Buffer, on bool, style, s string) {
+	if !on || style == "" {
+		b.WriteString(s)
+		return
+	}
+	b.WriteString(style)
+	b.WriteString(s)
+	b.WriteString(reset)
+}
+
+// Kind classifies a body for rendering.
+type Kind int
+
+const (
+	Text Kind = iota
+	JSON
+	XML
+	HTML
+	Binary
+)
+
+// Detect picks a Kind from the Content-Type, falling back to sniffing.
+func Detect(contentType string, body []byte) Kind {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mt == "application/json" || strings.HasSuffix(mt, "+json") || mt == "application/x-ndjson":
+		return JSON
+	case mt == "text/html" || mt == "application/xhtml+xml":
+		return HTML
+	case mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml"):
+		return XML
+	}
+	if IsBinary(contentType, body) {
+		return Binary
+	}
+	t := bytes.TrimSpace(body)
+	switch {
+	case len(t) > 0 && (t[0] == '{' || t[0] == '['):
+		return JSON
+	case bytes.HasPrefix(bytes.ToLower(t), []byte("<!doctype html")) || bytes.HasPrefix(bytes.ToLower(t), []byte("<html")):
+		return HTML
+	case bytes.HasPrefix(t, []byte("<?xml")):
+		return XML
+	}
+	return Text
+}
+
+// IsBinary reports whether body shouldn't be written to a terminal.
+func IsBinary(contentType string, body []byte) bool {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasPrefix(mt, "text/"), strings.HasSuffix(mt, "json"), strings.HasSuffix(mt, "xml"),
+		mt == "application/javascript", mt == "application/x-www-form-urlencoded":
+		return false
+	case strings.HasPrefix(mt, "image/"), strings.HasPrefix(mt, "audio/"), strings.HasPrefix(mt, "video/"),
+		strings.HasPrefix(mt, "font/"), mt == "application/octet-stream", mt == "application/pdf",
+		mt == "application/zip", mt == "application/gzip", mt == "application/x-protobuf", mt == "application/grpc":
+		return true
+	}
+	sample := body
+	if len(sample) > 8192 {
+		sample = sample[:8192]
+	}
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+	// Tolerate a rune cut off at the sample boundary.
+	if len(body) > len(sample) {
+		for i := 0; i < utf8.UTFMax-1 && !utf8.Valid(sample); i++ {
+			sample = sample[:len(sample)-1]
+		}
+	}
+	return !utf8.Valid(sample)
+}
+
+// Body writes body to b according to its content type.
+func Body(b *bytes.Buffer, contentType string, body []byte, opt Options) {
+	switch Detect(contentType, body) {
+	case Binary:
+		ct := contentType
+		if ct == "" {
+			ct = "unknown type"
+		}
+		paint(b, opt.Color, yellow, fmt.Sprintf("[binary body: %d bytes, %s; use --output <file> or pipe to save it]", len(body), ct))
+		b.WriteString("\n")
+		return
+	case JSON:
+		if err := jsonBody(b, body, opt); err == nil {
+			return
+		}
+	case XML:
+		if err := xmlBody(b, body, opt); err == nil {
+			return
+		}
+	case HTML:
+		markup(b, body, opt.Color)
+		endLine(b)
+		return
+	}
+	b.Write(body)
+	endLine(b)
+}
+
+// StatusLine renders "HTTP/1.1 200 OK" with the status colored by class.
+func StatusLine(b *bytes.Buffer, proto, status string, code int, opt Options) {
+	style := green
+	switch {
+	case code >= 500:
+		style = red
+	case code >= 400:
+		style = yellow
+	case code >= 300:
+		style = cyan
+	}
+	paint(b, opt.Color, blue, proto)
+	b.WriteString(" ")
+	paint(b, opt.Color, bold+style, status)
+	b.WriteString("\n")
+}
+
+// RequestLine renders "GET /path HTTP/1.1".
+func RequestLine(b *bytes.Buffer, method, target, proto string, opt Options) {
+	paint(b, opt.Color, bold+green, method)
+	b.WriteString(" ")
+	paint(b, opt.Color, cyan, target)
+	b.WriteString(" ")
+	paint(b, opt.Color, blue, proto)
+	b.WriteString("\n")
+}
+
+// Headers renders headers sorted by name; redact rewrites sensitive values.
+func Headers(b *bytes.Buffer, h http.Header, redact func(name, value string) string, opt Options) {
+	names := make([]string, 0, len(h))
+	for k := range h {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		for _, v := range h[k] {
+			if redact != nil {
+				v = redact(k, v)
+			}
+			paint(b, opt.Color, cyan, k)
+			paint(b, opt.Color, dim, ": ")
+			b.WriteString(v)
+			b.WriteString("\n")
+		}
+	}
+}
+
+func endLine(b *bytes.Buffer) {
+	if b.Len() > 0 && b.Bytes()[b.Len()-1] != '\n' {
+		b.WriteString("\n")
+	}
+}
@@ -0,0 +1,582 @@
+// Package tui is restless's interactive terminal UI: a profile picker, a
+// filterable list of endpoints, saved requests and history, a request
+// editor and a scrolling response view, all driven from the keyboard.
+//
+// It needs nothing beyond golang.org/x/term and the basic VT100/xterm
+// escape sequences, so it runs the same in Termux, over SSH and in a
+// desktop terminal. Sending is left to the caller, which wires in the
+// profile's auth, transport and history.
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/history"
+	"github.com/bspippi1337/restless/internal/core/profile"
+	"github.com/bspippi1337/restless/internal/render"
+)
+
+// Call is a request as edited in the UI. Query and header values may use
+// ${ENV:NAME} like the command line.
+type Call struct {
+	Profile string
+	Env     string
+	Method  string
+	Path    string   // relative to the profile base URL, or absolute
+	Query   []string // name=value
+	Headers []string // Name: value
+	Body    []byte
+}
+
+// Result is the answer to a Call.
+type Result struct {
+	Method    string
+	URL       string // redacted
+	Proto     string
+	Status    string // e.g. "200 OK"
+	Code      int
+	Header    http.Header
+	Body      []byte
+	Truncated bool // Body holds only the start of the response
+	Elapsed   time.Duration
+	Note      string // shown in the status line, e.g. a warning
+}
+
+// Options configures Run.
+type Options struct {
+	ProfileDir string
+	// Profile is opened first; empty opens the profile picker.
+	Profile string
+	Env     string
+	// Send performs a call. Anything written to log, such as OAuth2
+	// device-code instructions, is shown while the call is in flight.
+	Send func(ctx context.Context, c Call, log io.Writer) (*Result, error)
+	// History backs the history tab; nil hides it.
+	History *history.Store
+	In, Out *os.File
+}
+
+type pane int
+
+const (
+	paneList pane = iota
+	paneEditor
+	paneResponse
+)
+
+const (
+	tabEndpoints = iota
+	tabHistory
+)
+
+// wideWidth is the narrowest screen that shows the panes side by side;
+// below it, as on a phone, only the focused pane is shown.
+const wideWidth = 80
+
+type sent struct {
+	res *Result
+	err error
+}
+
+type app struct {
+	opt  Options
+	term *terminal
+	ro   render.Options
+
+	prof *profile.Profile // nil until a profile is open
+	name string
+	env  string
+
+	focus   pane
+	tab     int
+	lists   [2]list
+	calls   []Call          // behind lists[tabEndpoints]
+	entries []history.Entry // behind lists[tabHistory]
+	ed      editor
+	resp    response
+
+	picker *list // the profile picker, while open
+	names  []string
+	help   bool
+
+	msg, msgStyle string
+
+	cancel  context.CancelFunc // set while a call is in flight
+	started time.Time
+	waiting Call
+	log     []string
+	done    chan sent
+	logs    chan string
+
+	// Sizes of the panes as last drawn, for paging.
+	listH, respH int
+	quit         bool
+}
+
+// Run shows the UI until the user quits.
+func Run(opt Options) error {
+	if opt.In == nil {
+		opt.In = os.Stdin
+	}
+	if opt.Out == nil {
+		opt.Out = os.Stdout
+	}
+	if opt.Send == nil {
+		return errors.New("tui: no Send function")
+	}
+	t, err := openTerminal(opt.In, opt.Out)
+	if err != nil {
+		return err
+	}
+	defer t.close()
+
+	a := &app{
+		opt:  opt,
+		term: t,
+		ro:   render.Options{Color: os.Getenv("NO_COLOR") == "", Format: true},
+		env:  opt.Env,
+		done: make(chan sent, 1),
+		logs: make(chan string, 16),
+	}
+	a.lists[tabEndpoints].empty = "no endpoints; type a path in the editor"
+	a.lists[tabHistory].empty = "no requests yet"
+	a.ed.load(Call{})
+	a.resp.setText("", []string{style(dim, "Pick an endpoint and press enter, then ctrl-r to send.")})
+	if opt.Profile != "" {
+		if err := a.open(opt.Profile); err != nil {
+			a.flash(err.Error(), red)
+		}
+	}
+	if a.prof == nil {
+		a.openPicker()
+	}
+	a.loadHistory()
+
+	tick := time.NewTicker(200 * time.Millisecond)
+	defer tick.Stop()
+	var w, h int
+	redraw := true
+	for !a.quit {
+		if redraw {
+			w, h = t.size()
+			a.draw(w, h)
+		}
+		redraw = true
+		select {
+		case k, ok := <-t.keys:
+			if !ok {
+				return nil
+			}
+			a.key(k)
+		case s := <-a.done:
+			a.finish(s)
+		case l := <-a.logs:
+			a.log = append(a.log, clean(l))
+		case <-tick.C:
+			// Redraw on resize, and to move the clock while waiting.
+			nw, nh := t.size()
+			redraw = nw != w || nh != h || a.cancel != nil
+		}
+	}
+	if a.cancel != nil {
+		a.cancel()
+	}
+	return nil
+}
+
+func (a *app) flash(msg, st string) { a.msg, a.msgStyle = msg, st }
+
+// open loads the named profile and lists its saved requests and endpoints.
+func (a *app) open(name string) error {
+	dir := a.opt.ProfileDir
+	p, err := profile.Load(dir, name)
+	if err != nil {
+		return err
+	}
+	a.prof, a.name = p, name
+	if _, ok := p.Environments[a.env]; !ok {
+		a.env = ""
+	}
+
+	var items []item
+	a.calls = nil
+	for _, r := range p.Collection {
+		c := requestCall(p, r)
+		a.calls = append(a.calls, c)
+		text := r.Name
+		if r.Description != "" {
+			text += " — " + r.Description
+		}
+		items = append(items, item{
+			tag: c.Method, tagStyle: methodStyle(c.Method), text: text,
+			search: strings.ToLower(c.Method + " " + r.Name + " " + r.Path + " " + strings.Join(r.Tags, " ")),
+		})
+	}
+	for _, ep := range p.Endpoints {
+		m := strings.ToUpper(ep.Method)
+		a.calls = append(a.calls, Call{Method: m, Path: ep.Path})
+		items = append(items, item{
+			tag: m, tagStyle: methodStyle(m), text: ep.Path,
+			search: strings.ToLower(m + " " + ep.Path),
+		})
+	}
+	l := &a.lists[tabEndpoints]
+	l.sel, l.top, l.filter, l.filtering = 0, 0, nil, false
+	l.set(items)
+	a.loadHistory()
+	a.flash(fmt.Sprintf("opened %s: %d requests, %d endpoints", name, len(p.Collection), len(p.Endpoints)), "")
+	return nil
+}
+
+// requestCall fills a collection entry's variables from its own and the
+// profile's defaults. Variables without a value stay as ${name} to edit.
+func requestCall(p *profile.Profile, r profile.Request) Call {
+	r, _ = p.WithVars(r).Render(nil)
+	c := Call{Method: strings.ToUpper(firstNonEmpty(r.Method, "GET")), Path: r.Path, Body: []byte(r.Body)}
+	for _, k := range sortedKeys(r.Query) {
+		c.Query = append(c.Query, k+"="+r.Query[k])
+	}
+	for _, k := range sortedKeys(r.Headers) {
+		c.Headers = append(c.Headers, k+": "+r.Headers[k])
+	}
+	return c
+}
+
+func (a *app) loadHistory() {
+	if a.opt.History == nil {
+		return
+	}
+	entries, err := a.opt.History.List(history.Query{Profile: a.name, Limit: 200})
+	if err != nil {
+		a.flash("history: "+err.Error(), red)
+	}
+	a.entries = entries
+	var items []item
+	for _, e := range entries {
+		st, stStyle := fmt.Sprint(e.Status), statusStyle(e.Status)
+		if e.Status == 0 {
+			st = "ERR"
+		}
+		text := e.Method + " " + entryTarget(e)
+		if e.Env != "" {
+			text += "  [" + e.Env + "]"
+		}
+		items = append(items, item{
+			tag: st, tagStyle: stStyle, text: clean(text),
+			search: strings.ToLower(fmt.Sprintf("#%d %s %s %s", e.ID, st, text, e.Time.Local().Format("2006-01-02 15:04"))),
+		})
+	}
+	a.lists[tabHistory].set(items)
+}
+
+// entryTarget is the entry's path relative to the profile when known.
+func entryTarget(e history.Entry) string {
+	if e.Profile != "" && e.Path != "" {
+		return e.Path
+	}
+	return e.URL
+}
+
+// pick loads the highlighted list item into the editor.
+func (a *app) pick() {
+	i := a.lists[a.tab].selected()
+	if i < 0 {
+		return
+	}
+	if a.tab == tabEndpoints {
+		a.ed.load(a.calls[i])
+		a.focus = paneEditor
+		return
+	}
+	e := a.entries[i]
+	if a.prof != nil {
+		if _, ok := a.prof.Environments[e.Env]; ok || e.Env == "" {
+			a.env = e.Env
+		}
+	}
+	a.ed.load(entryCall(e))
+	if e.Request.Size > 0 && !e.Request.Replayable() {
+		a.flash(fmt.Sprintf("the body of #%d was not stored in full; fill it in before sending", e.ID), yellow)
+	}
+	if e.Response == nil {
+		a.resp.setText("error · history #"+fmt.Sprint(e.ID), []string{style(red, clean(e.Error))})
+	} else {
+		a.resp.set(&Result{
+			Method:    e.Method,
+			URL:       e.URL,
+			Proto:     e.Proto,
+			Status:    fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+			Code:      e.Status,
+			Header:    headerMap(e.Response.Headers),
+			Body:      []byte(e.Response.Body),
+			Truncated: e.Response.Truncated,
+			Elapsed:   time.Duration(e.Duration * float64(time.Millisecond)),
+		}, fmt.Sprintf("history #%d", e.ID), a.ro)
+	}
+	a.focus = paneEditor
+}
+
+// entryCall rebuilds the request of a history entry the way replay does:
+// only headers given explicitly, and no redacted values.
+func entryCall(e history.Entry) Call {
+	c := Call{Method: e.Method}
+	target, rawQuery, _ := strings.Cut(entryTarget(e), "?")
+	c.Path = target
+	for _, kv := range strings.Split(rawQuery, "&") {
+		if kv == "" {
+			continue
+		}
+		if s, err := url.QueryUnescape(kv); err == nil {
+			kv = s
+		}
+		c.Query = append(c.Query, kv)
+	}
+	names := e.HeaderArgs
+	if e.Profile == "" {
+		names = sortedKeys(e.Request.Headers)
+	}
+	for _, k := range names {
+		if v, ok := e.Request.Headers[k]; ok && !strings.Contains(v, "[redacted]") {
+			c.Headers = append(c.Headers, k+": "+v)
+		}
+	}
+	if e.Request.Replayable() {
+		c.Body = []byte(e.Request.Body)
+	}
+	return c
+}
+
+// send starts the call in the editor; finish picks up the result.
+func (a *app) send() {
+	if a.cancel != nil {
+		return
+	}
+	c := a.ed.call()
+	if c.Path == "" {
+		a.flash("type a path first", yellow)
+		a.focus = paneEditor
+		return
+	}
+	c.Profile, c.Env = a.name, a.env
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel, a.started, a.waiting, a.log = cancel, time.Now(), c, nil
+	a.msg = ""
+	go func() {
+		res, err := a.opt.Send(ctx, c, logWriter(a.logs))
+		a.done <- sent{res, err}
+	}()
+}
+
+func (a *app) finish(s sent) {
+	a.cancel()
+	a.cancel = nil
+	// Warnings may have been written straight to the terminal.
+	a.term.invalidate()
+	switch {
+	case s.err != nil && errors.Is(s.err, context.Canceled):
+		a.resp.setText("cancelled", nil)
+		a.flash("cancelled", yellow)
+	case s.err != nil:
+		a.resp.setText("error", append(a.log, style(red, clean(s.err.Error()))))
+		a.flash(clean(s.err.Error()), red)
+	default:
+		a.resp.set(s.res, "", a.ro)
+		if s.res.Note != "" {
+			a.flash(clean(s.res.Note), yellow)
+		}
+	}
+	a.loadHistory()
+	a.lists[tabHistory].sel = 0
+}
+
+// logWriter passes each line written to it to the UI.
+type logWriter chan<- string
+
+func (w logWriter) Write(p []byte) (int, error) {
+	for _, l := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		w <- l
+	}
+	return len(p), nil
+}
+
+func (a *app) openPicker() {
+	a.names = profile.Names(a.opt.ProfileDir)
+	l := &list{filtering: true, empty: "no profiles; create one with restless discover <domain> --save-profile <name>"}
+	var items []item
+	for i, n := range a.names {
+		items = append(items, item{text: n, search: strings.ToLower(n)})
+		if n == a.name {
+			l.sel = i
+		}
+	}
+	l.set(items)
+	a.picker = l
+}
+
+func (a *app) key(k Key) {
+	a.msg = ""
+	if a.help {
+		a.help = false
+		return
+	}
+	if a.picker != nil {
+		a.pickerKey(k)
+		return
+	}
+
+	// Keys that work everywhere.
+	switch {
+	case k.Code == Ctrl && k.Rune == 'c', k.Code == Esc && a.cancel != nil:
+		if a.cancel != nil {
+			a.cancel()
+			return
+		}
+		a.quit = true
+		return
+	case k.Code == Ctrl && k.Rune == 'q':
+		a.quit = true
+		return
+	case k.Code == Ctrl && k.Rune == 'r', k.Code == F5:
+		a.send()
+		return
+	case k.Code == Ctrl && k.Rune == 'p':
+		a.openPicker()
+		return
+	case k.Code == Ctrl && k.Rune == 'n':
+		a.nextEnv()
+		return
+	case k.Code == F1:
+		a.help = true
+		return
+	case k.Code == Tab:
+		a.focus = (a.focus + 1) % 3
+		return
+	case k.Code == Backtab:
+		a.focus = (a.focus + 2) % 3
+		return
+	}
+
+	switch a.focus {
+	case paneList:
+		l := &a.lists[a.tab]
+		if l.key(k, a.listH) {
+			return
+		}
+		switch {
+		case k.Code == Enter:
+			a.pick()
+		case k.Code == Left || k.Code == Right:
+			if a.opt.History != nil {
+				a.tab = 1 - a.tab
+			}
+		case k.Code == Rune && k.Rune == '?':
+			a.help = true
+		case k.Code == Rune && k.Rune == 'q':
+			a.quit = true
+		}
+	case paneEditor:
+		used, send := a.ed.key(k)
+		if send {
+			a.send()
+		} else if !used && k.Code == Esc {
+			a.focus = paneList
+		}
+	case paneResponse:
+		if a.resp.key(k, a.respH) {
+			return
+		}
+		switch {
+		case k.Code == Rune && k.Rune == '?':
+			a.help = true
+		case k.Code == Rune && k.Rune == 'q':
+			a.quit = true
+		case k.Code == Esc:
+			a.focus = paneList
+		}
+	}
+}
+
+func (a *app) pickerKey(k Key) {
+	l := a.picker
+	switch {
+	case k.Code == Ctrl && (k.Rune == 'c' || k.Rune == 'q'):
+		a.quit = true
+	case k.Code == Esc && len(l.filter) == 0:
+		a.picker = nil
+	case k.Code == Enter:
+		if i := l.selected(); i >= 0 {
+			if err := a.open(a.names[i]); err != nil {
+				a.flash(err.Error(), red)
+				return
+			}
+			a.picker, a.focus, a.tab = nil, paneList, tabEndpoints
+		}
+	default:
+		l.key(k, a.listH)
+		l.filtering = true // the picker has nothing else to type into
+	}
+}
+
+// nextEnv cycles through the profile's environments and back to none.
+func (a *app) nextEnv() {
+	if a.prof == nil || len(a.prof.Environments) == 0 {
+		a.flash("this profile has no environments", yellow)
+		return
+	}
+	envs := append([]string{""}, sortedKeys(a.prof.Environments)...)
+	for i, e := range envs {
+		if e == a.env {
+			a.env = envs[(i+1)%len(envs)]
+			break
+		}
+	}
+	if a.env == "" {
+		a.flash("environment: none (profile defaults)", "")
+	} else {
+		a.flash("environment: "+a.env, "")
+	}
+}
+
+func methodStyle(m string) string {
+	switch m {
+	case "GET":
+		return green
+	case "POST":
+		return yellow
+	case "PUT", "PATCH":
+		return blue
+	case "DELETE":
+		return red
+	}
+	return magenta
+}
+
+func statusStyle(code int) string {
+	switch {
+	case code == 0 || code >= 500:
+		return red
+	case code >= 400:
+		return yellow
+	case code >= 300:
+		return cyan
+	}
+	return green
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,209 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var spinner = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+var helpLines = []string{
+	style(bold, "Everywhere"),
+	"  tab / shift-tab   next / previous pane",
+	"  ctrl-r, F5        send the request in the editor",
+	"  ctrl-p            pick another profile",
+	"  ctrl-n            next environment of the profile",
+	"  esc               cancel a request in flight; back to the list",
+	"  ctrl-q, ctrl-c    quit",
+	"",
+	style(bold, "List"),
+	"  ↑ ↓ j k, pgup pgdn, g G   move",
+	"  /                 filter; words match in any order, esc clears",
+	"  ← →               endpoints and saved requests / history",
+	"  enter             open in the editor (history shows the stored response)",
+	"",
+	style(bold, "Editor"),
+	"  ↑ ↓               move between fields",
+	"  ← →, letters      change the method (on the method row)",
+	"  enter             send (on the path), add a row, or a new body line",
+	"  backspace         on an empty query or header row, remove it",
+	"  ctrl-a ctrl-k ctrl-u ctrl-w   start, kill to end, kill to start, delete word",
+	"",
+	style(bold, "Response"),
+	"  ↑ ↓ j k, pgup pgdn space b, g G   scroll",
+	"  h                 show or hide the status line and headers",
+	"  w                 wrap or cut long lines",
+	"",
+	style(dim, "Press any key to close."),
+}
+
+func (a *app) draw(w, h int) {
+	if w < 20 || h < 6 {
+		a.term.draw(pad([]string{fit("restless: window too small", w)}, h, w), w, -1, 0)
+		return
+	}
+	out := []string{a.titleBar(w)}
+	bodyH := h - 2
+	crow, ccol := -1, 0
+	switch {
+	case a.picker != nil:
+		out = append(out, paneHeader("Profiles", a.opt.ProfileDir, true, w))
+		a.listH = bodyH - 1
+		out = append(out, a.picker.render(w, bodyH-1, true)...)
+		crow, ccol = 2, a.picker.filterCursor()
+	case a.help:
+		out = append(out, paneHeader("Keys", "", true, w))
+		var body []string
+		for _, l := range helpLines {
+			body = append(body, fit(l, w))
+		}
+		out = append(out, pad(body, bodyH-1, w)[:bodyH-1]...)
+	case w >= wideWidth:
+		sw := max(24, min(44, w/3))
+		rw := w - sw - 1
+		left, lr, lc := a.listPane(sw, bodyH)
+		edH := max(5, min(len(a.ed.rows)+len(sections)+1, bodyH/2))
+		right, er, ec := a.editorPane(rw, edH)
+		right = append(right, a.responsePane(rw, bodyH-edH)...)
+		sep := style(dim, "│")
+		for i := range left {
+			out = append(out, left[i]+sep+right[i])
+		}
+		switch {
+		case a.focus == paneList && lr >= 0:
+			crow, ccol = 1+lr, lc
+		case a.focus == paneEditor:
+			crow, ccol = 1+er, sw+1+ec
+		}
+	default:
+		switch a.focus {
+		case paneList:
+			body, r, c := a.listPane(w, bodyH)
+			out = append(out, body...)
+			if r >= 0 {
+				crow, ccol = 1+r, c
+			}
+		case paneEditor:
+			body, r, c := a.editorPane(w, bodyH)
+			out = append(out, body...)
+			crow, ccol = 1+r, c
+		case paneResponse:
+			out = append(out, a.responsePane(w, bodyH)...)
+		}
+	}
+	out = append(out, a.statusBar(w))
+	a.term.draw(out, w, crow, ccol)
+}
+
+func (a *app) titleBar(w int) string {
+	left := " restless"
+	if a.name != "" {
+		left += " · " + a.name
+		if a.env != "" {
+			left += " · " + a.env
+		}
+	} else {
+		left += " · no profile"
+	}
+	right := "F1 help "
+	gap := max(1, w-width(left)-width(right))
+	return reverse + fit(left+strings.Repeat(" ", gap)+right, w) + reset
+}
+
+func (a *app) statusBar(w int) string {
+	if a.cancel != nil {
+		el := time.Since(a.started)
+		s := fmt.Sprintf(" %s %s %s  %s  esc cancels", spinner[int(el/(100*time.Millisecond))%len(spinner)],
+			a.waiting.Method, a.waiting.Path, fmtDuration(el.Round(100*time.Millisecond)))
+		return fit(style(cyan, s), w)
+	}
+	if a.msg != "" {
+		return fit(style(a.msgStyle, " "+a.msg), w)
+	}
+	var hint string
+	switch {
+	case a.picker != nil:
+		hint = "type to filter · enter open · esc close"
+	case a.help:
+		hint = "any key closes"
+	case a.focus == paneList:
+		hint = "enter open · / filter · tab pane · ^R send · ^P profile · ^N env · q quit"
+		if a.opt.History != nil {
+			hint = strings.Replace(hint, "/ filter · ", "/ filter · ←→ history · ", 1)
+		}
+	case a.focus == paneEditor:
+		hint = "^R send · ↑↓ fields · ←→ method · enter new row · tab pane · esc list"
+	case a.focus == paneResponse:
+		hint = "↑↓ scroll · h headers · w wrap · tab pane · ^R send · q quit"
+	}
+	return fit(style(dim, " "+hint), w)
+}
+
+// paneHeader is the first line of a pane; the focused pane stands out.
+func paneHeader(title, right string, focused bool, w int) string {
+	st := dim
+	if focused {
+		st = bold + cyan
+	}
+	left := style(st, " "+title)
+	if right != "" {
+		right = style(dim, " "+right+" ")
+		left += strings.Repeat(" ", max(1, w-width(left)-width(right))) + right
+	}
+	return fit(left, w)
+}
+
+// listPane draws the tabs and the current list, and returns where the
+// filter cursor is when typing one (row -1 otherwise).
+func (a *app) listPane(w, h int) (out []string, crow, ccol int) {
+	focused := a.focus == paneList
+	tabs := []string{fmt.Sprintf("Endpoints %d", len(a.lists[tabEndpoints].items))}
+	if a.opt.History != nil {
+		tabs = append(tabs, fmt.Sprintf("History %d", len(a.lists[tabHistory].items)))
+	}
+	var head strings.Builder
+	for i, t := range tabs {
+		switch {
+		case i == a.tab && focused:
+			head.WriteString(style(bold+cyan, " "+t+" "))
+		case i == a.tab:
+			head.WriteString(style(bold, " "+t+" "))
+		default:
+			head.WriteString(style(dim, " "+t+" "))
+		}
+	}
+	l := &a.lists[a.tab]
+	a.listH = h - 1
+	out = append([]string{fit(head.String(), w)}, l.render(w, h-1, focused)...)
+	crow = -1
+	if l.filtering {
+		crow, ccol = 1, l.filterCursor()
+	}
+	return out, crow, ccol
+}
+
+func (a *app) editorPane(w, h int) (out []string, crow, ccol int) {
+	focused := a.focus == paneEditor
+	body, r, c := a.ed.render(w, h-1, focused)
+	return append([]string{paneHeader("Request", "^R sends", focused, w)}, body...), 1 + r, c
+}
+
+func (a *app) responsePane(w, h int) []string {
+	focused := a.focus == paneResponse
+	if a.cancel != nil {
+		lines := []string{fit(style(dim, "waiting for "+a.waiting.Method+" "+a.waiting.Path+" …"), w)}
+		for _, l := range a.log {
+			lines = append(lines, fit(l, w))
+		}
+		return append([]string{paneHeader("Response", "", focused, w)}, pad(lines, h-1, w)[:h-1]...)
+	}
+	a.respH = h - 1
+	body := a.resp.render(w, h-1)
+	right := a.resp.position(h - 1)
+	title := "Response"
+	if a.resp.title != "" {
+		title += "  " + a.resp.title
+	}
+	return append([]string{paneHeader(title, right, focused, w)}, body...)
+}
@@ -0,0 +1,359 @@
+package tui
+
+import (
+	"strings"
+	"unicode"
+)
+
+var methods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+type rowKind int
+
+const (
+	rowMethod rowKind = iota
+	rowPath
+	rowQuery
+	rowHeader
+	rowBody
+)
+
+// editor is the request form: method, path, then one row per query
+// parameter, header and body line. Each section always has at least one
+// row, so there is somewhere to type.
+type editor struct {
+	rows     []edRow
+	cur, col int
+	top      int // first visible display line
+}
+
+type edRow struct {
+	kind rowKind
+	text []rune
+}
+
+func (e *editor) load(c Call) {
+	method := strings.ToUpper(c.Method)
+	if method == "" {
+		method = "GET"
+	}
+	e.rows = []edRow{{rowMethod, []rune(method)}, {rowPath, []rune(c.Path)}}
+	add := func(kind rowKind, lines []string) {
+		if len(lines) == 0 {
+			lines = []string{""}
+		}
+		for _, l := range lines {
+			e.rows = append(e.rows, edRow{kind, []rune(l)})
+		}
+	}
+	add(rowQuery, c.Query)
+	add(rowHeader, c.Headers)
+	var body []string
+	if len(c.Body) > 0 {
+		body = strings.Split(strings.ReplaceAll(string(c.Body), "\r\n", "\n"), "\n")
+	}
+	add(rowBody, body)
+	e.cur, e.col, e.top = 1, len(e.rows[1].text), 0
+	// Put the cursor on the first {param} left to fill in.
+	if i := strings.IndexByte(c.Path, '{'); i >= 0 {
+		e.col = len([]rune(c.Path[:i]))
+	}
+}
+
+// call returns the request the form describes. Blank query and header
+// rows are skipped, as are trailing blank body lines.
+func (e *editor) call() Call {
+	var c Call
+	var body []string
+	for _, r := range e.rows {
+		s := string(r.text)
+		switch r.kind {
+		case rowMethod:
+			c.Method = s
+		case rowPath:
+			c.Path = strings.TrimSpace(s)
+		case rowQuery:
+			if strings.TrimSpace(s) != "" {
+				c.Query = append(c.Query, strings.TrimSpace(s))
+			}
+		case rowHeader:
+			if strings.TrimSpace(s) != "" {
+				c.Headers = append(c.Headers, strings.TrimSpace(s))
+			}
+		case rowBody:
+			body = append(body, s)
+		}
+	}
+	for len(body) > 0 && strings.TrimSpace(body[len(body)-1]) == "" {
+		body = body[:len(body)-1]
+	}
+	if len(body) > 0 {
+		c.Body = []byte(strings.Join(body, "\n"))
+	}
+	return c
+}
+
+func (e *editor) row() *edRow { return &e.rows[e.cur] }
+
+func (e *editor) count(kind rowKind) int {
+	n := 0
+	for _, r := range e.rows {
+		if r.kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+func (e *editor) insertRow(at int, r edRow) {
+	e.rows = append(e.rows[:at], append([]edRow{r}, e.rows[at:]...)...)
+}
+
+func (e *editor) deleteRow(at int) {
+	e.rows = append(e.rows[:at], e.rows[at+1:]...)
+}
+
+// key edits the form. It reports whether the key was used and whether it
+// asks for the request to be sent (Enter on the path).
+func (e *editor) key(k Key) (used, send bool) {
+	r := e.row()
+	if r.kind == rowMethod {
+		return e.methodKey(k)
+	}
+	switch k.Code {
+	case Rune:
+		e.insert(string(k.Rune))
+	case Paste:
+		e.paste(k.Text)
+	case Up:
+		e.moveRow(-1)
+	case Down:
+		e.moveRow(1)
+	case Left:
+		if e.col > 0 {
+			e.col--
+		}
+	case Right:
+		if e.col < len(r.text) {
+			e.col++
+		}
+	case Home:
+		e.col = 0
+	case End:
+		e.col = len(r.text)
+	case Backspace:
+		e.backspace()
+	case Delete:
+		e.delete()
+	case Enter:
+		switch r.kind {
+		case rowPath:
+			return true, true
+		case rowBody:
+			rest := append([]rune(nil), r.text[e.col:]...)
+			r.text = r.text[:e.col]
+			e.insertRow(e.cur+1, edRow{rowBody, rest})
+		default:
+			e.insertRow(e.cur+1, edRow{r.kind, nil})
+		}
+		e.cur++
+		e.col = 0
+	case Ctrl:
+		switch k.Rune {
+		case 'a':
+			e.col = 0
+		case 'k':
+			r.text = r.text[:e.col]
+		case 'u':
+			r.text = append([]rune(nil), r.text[e.col:]...)
+			e.col = 0
+		case 'w':
+			i := e.col
+			for i > 0 && unicode.IsSpace(r.text[i-1]) {
+				i--
+			}
+			for i > 0 && !unicode.IsSpace(r.text[i-1]) {
+				i--
+			}
+			r.text = append(r.text[:i], r.text[e.col:]...)
+			e.col = i
+		default:
+			return false, false
+		}
+	default:
+		return false, false
+	}
+	return true, false
+}
+
+// methodKey cycles the method with Left/Right or the first letter typed.
+func (e *editor) methodKey(k Key) (used, send bool) {
+	r := e.row()
+	at := 0
+	for i, m := range methods {
+		if m == string(r.text) {
+			at = i
+		}
+	}
+	switch {
+	case k.Code == Left:
+		at = (at + len(methods) - 1) % len(methods)
+	case k.Code == Right || k.Code == Rune && k.Rune == ' ':
+		at = (at + 1) % len(methods)
+	case k.Code == Rune:
+		// Typing P again moves from POST to PUT to PATCH.
+		for i := 1; i <= len(methods); i++ {
+			if unicode.ToUpper(k.Rune) == rune(methods[(at+i)%len(methods)][0]) {
+				at = (at + i) % len(methods)
+				break
+			}
+		}
+	case k.Code == Up:
+		return true, false
+	case k.Code == Down || k.Code == Enter:
+		e.moveRow(1)
+		return true, false
+	default:
+		return false, false
+	}
+	r.text = []rune(methods[at])
+	e.col = 0
+	return true, false
+}
+
+func (e *editor) moveRow(d int) {
+	e.cur = max(0, min(e.cur+d, len(e.rows)-1))
+	e.col = min(e.col, len(e.row().text))
+}
+
+// insert types s at the cursor. Tabs become two spaces and other control
+// characters are dropped, so every rune takes the cells it appears to.
+func (e *editor) insert(s string) {
+	r := e.row()
+	var ins []rune
+	for _, c := range s {
+		switch {
+		case c == '\t':
+			ins = append(ins, ' ', ' ')
+		case c >= 0x20 && c != 0x7f:
+			ins = append(ins, c)
+		}
+	}
+	r.text = append(r.text[:e.col], append(ins, r.text[e.col:]...)...)
+	e.col += len(ins)
+}
+
+// paste inserts text; extra lines become new rows of the same section,
+// except on the method and path rows which take one line.
+func (e *editor) paste(s string) {
+	s = strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\r", "\n")
+	lines := strings.Split(s, "\n")
+	kind := e.row().kind
+	if kind == rowPath {
+		e.insert(strings.Join(strings.Fields(strings.Join(lines, " ")), " "))
+		return
+	}
+	r := e.row()
+	tail := append([]rune(nil), r.text[e.col:]...)
+	r.text = r.text[:e.col]
+	e.insert(lines[0])
+	for _, l := range lines[1:] {
+		e.insertRow(e.cur+1, edRow{kind, nil})
+		e.cur++
+		e.col = 0
+		e.insert(l)
+	}
+	e.row().text = append(e.row().text, tail...)
+}
+
+func (e *editor) backspace() {
+	r := e.row()
+	if e.col > 0 {
+		r.text = append(r.text[:e.col-1], r.text[e.col:]...)
+		e.col--
+		return
+	}
+	prev := e.cur - 1
+	switch {
+	case r.kind == rowBody && e.rows[prev].kind == rowBody:
+		// Join with the line above.
+		e.col = len(e.rows[prev].text)
+		e.rows[prev].text = append(e.rows[prev].text, r.text...)
+		e.deleteRow(e.cur)
+		e.cur = prev
+	case (r.kind == rowQuery || r.kind == rowHeader) && len(r.text) == 0 && e.count(r.kind) > 1:
+		kind := r.kind
+		e.deleteRow(e.cur)
+		if e.cur >= len(e.rows) || e.rows[e.cur].kind != kind {
+			e.cur--
+		}
+		e.col = len(e.row().text)
+	}
+}
+
+func (e *editor) delete() {
+	r := e.row()
+	if e.col < len(r.text) {
+		r.text = append(r.text[:e.col], r.text[e.col+1:]...)
+		return
+	}
+	if r.kind == rowBody && e.cur+1 < len(e.rows) {
+		r.text = append(r.text, e.rows[e.cur+1].text...)
+		e.deleteRow(e.cur + 1)
+	}
+}
+
+var sections = map[rowKind]string{rowQuery: "Query", rowHeader: "Headers", rowBody: "Body"}
+
+var placeholders = map[rowKind]string{
+	rowPath:   "/path or https://…",
+	rowQuery:  "name=value",
+	rowHeader: "Name: value",
+	rowBody:   "request body",
+}
+
+// render draws the form in w×h cells and returns the cursor position
+// within it.
+func (e *editor) render(w, h int, focused bool) (out []string, crow, ccol int) {
+	const label = 9 // cells before the text of each row
+	var lines []string
+	cursorLine := 0
+	prev := rowKind(-1)
+	for i, r := range e.rows {
+		if name, ok := sections[r.kind]; ok && r.kind != prev {
+			lines = append(lines, fit(style(bold+blue, name), w))
+		}
+		prev = r.kind
+		text := string(r.text)
+		head := strings.Repeat(" ", label)
+		switch r.kind {
+		case rowMethod:
+			head = "Method   "
+			text = style(bold+green, text) + style(dim, "  ◂ ▸")
+		case rowPath:
+			head = "Path     "
+		}
+		if i == e.cur {
+			cursorLine = len(lines)
+			// Scroll long lines so the cursor stays visible.
+			if avail := w - label - 1; r.kind != rowMethod && e.col > avail && avail > 0 {
+				text = string(r.text[e.col-avail:])
+				ccol = label + avail
+			} else {
+				ccol = label + width(string(r.text[:e.col]))
+			}
+		}
+		if len(r.text) == 0 && (i != e.cur || !focused) {
+			text = style(dim, placeholders[r.kind])
+		}
+		lines = append(lines, fit(style(dim, head)+text, w))
+	}
+	if cursorLine < e.top {
+		e.top = cursorLine
+	}
+	if h > 0 && cursorLine >= e.top+h {
+		e.top = cursorLine - h + 1
+	}
+	e.top = max(0, min(e.top, len(lines)-h))
+	end := min(len(lines), e.top+h)
+	return pad(append([]string(nil), lines[e.top:end]...), h, w), cursorLine - e.top, ccol
+}
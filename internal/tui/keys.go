@@ -0,0 +1,209 @@
+package tui
+
+import (
+	"bytes"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Code identifies a key that isn't plain text.
+type Code int
+
+const (
+	Rune Code = iota // printable text in Key.Rune
+	Ctrl             // Ctrl+letter, lower case in Key.Rune
+	Enter
+	Tab
+	Backtab
+	Esc
+	Backspace
+	Delete
+	Up
+	Down
+	Left
+	Right
+	Home
+	End
+	PgUp
+	PgDn
+	F1
+	F5
+	Paste // bracketed paste, the text in Key.Text
+)
+
+// Key is one keypress, or one paste.
+type Key struct {
+	Code Code
+	Rune rune
+	Text string
+}
+
+// decoder turns terminal input into keys. Escape sequences and pastes may
+// be split across reads, so unfinished input is kept for the next feed.
+type decoder struct {
+	pending []byte
+	paste   *bytes.Buffer
+}
+
+var (
+	pasteStart = []byte("\x1b[200~")
+	pasteEnd   = []byte("\x1b[201~")
+)
+
+func (d *decoder) feed(b []byte) []Key {
+	buf := append(d.pending, b...)
+	d.pending = nil
+	var keys []Key
+	for len(buf) > 0 {
+		if d.paste != nil {
+			i := bytes.Index(buf, pasteEnd)
+			if i < 0 {
+				// Keep a possible start of the end marker for the next read.
+				keep := 0
+				for n := len(pasteEnd) - 1; n > 0; n-- {
+					if bytes.HasSuffix(buf, pasteEnd[:n]) {
+						keep = n
+						break
+					}
+				}
+				d.paste.Write(buf[:len(buf)-keep])
+				d.pending = append([]byte(nil), buf[len(buf)-keep:]...)
+				return keys
+			}
+			d.paste.Write(buf[:i])
+			keys = append(keys, Key{Code: Paste, Text: d.paste.String()})
+			d.paste = nil
+			buf = buf[i+len(pasteEnd):]
+			continue
+		}
+		if bytes.HasPrefix(buf, pasteStart) {
+			d.paste = &bytes.Buffer{}
+			buf = buf[len(pasteStart):]
+			continue
+		}
+		k, n := decodeOne(buf)
+		if n == 0 {
+			// An unfinished escape sequence or UTF-8 rune.
+			d.pending = append([]byte(nil), buf...)
+			return keys
+		}
+		if k.Code != Rune || k.Rune != 0 {
+			keys = append(keys, k)
+		}
+		buf = buf[n:]
+	}
+	return keys
+}
+
+// decodeOne decodes the key at the start of b and returns how many bytes
+// it used; 0 means b ends in the middle of a key. Unknown sequences are
+// swallowed as a zero Key.
+func decodeOne(b []byte) (Key, int) {
+	switch c := b[0]; {
+	case c == 0x1b:
+		if len(b) == 1 {
+			return Key{Code: Esc}, 1 // a lone ESC arrives in a read of its own
+		}
+		switch b[1] {
+		case '[':
+			return decodeCSI(b)
+		case 'O':
+			if len(b) < 3 {
+				return Key{}, 0
+			}
+			switch b[2] {
+			case 'A':
+				return Key{Code: Up}, 3
+			case 'B':
+				return Key{Code: Down}, 3
+			case 'C':
+				return Key{Code: Right}, 3
+			case 'D':
+				return Key{Code: Left}, 3
+			case 'H':
+				return Key{Code: Home}, 3
+			case 'F':
+				return Key{Code: End}, 3
+			case 'P':
+				return Key{Code: F1}, 3
+			}
+			return Key{}, 3
+		case 0x1b:
+			return Key{Code: Esc}, 1
+		}
+		// Alt+key: the key alone is more useful than nothing.
+		k, n := decodeOne(b[1:])
+		if n == 0 {
+			return Key{}, 0
+		}
+		return k, n + 1
+	case c == '\r' || c == '\n':
+		return Key{Code: Enter}, 1
+	case c == '\t':
+		return Key{Code: Tab}, 1
+	case c == 0x7f || c == 0x08:
+		return Key{Code: Backspace}, 1
+	case c >= 0x01 && c <= 0x1a:
+		return Key{Code: Ctrl, Rune: rune('a' + c - 1)}, 1
+	case c < 0x20:
+		return Key{}, 1
+	}
+	if !utf8.FullRune(b) {
+		return Key{}, 0
+	}
+	r, n := utf8.DecodeRune(b)
+	return Key{Code: Rune, Rune: r}, n
+}
+
+// decodeCSI decodes ESC [ params final.
+func decodeCSI(b []byte) (Key, int) {
+	i := 2
+	for i < len(b) && (b[i] < 0x40 || b[i] > 0x7e) {
+		i++
+	}
+	if i == len(b) {
+		if len(b) > 16 {
+			return Key{}, len(b) // not a key sequence; drop it
+		}
+		return Key{}, 0
+	}
+	n := i + 1
+	params := string(b[2:i])
+	if j := bytes.IndexByte(b[2:i], ';'); j >= 0 {
+		params = string(b[2 : 2+j]) // modifiers are ignored
+	}
+	switch b[i] {
+	case 'A':
+		return Key{Code: Up}, n
+	case 'B':
+		return Key{Code: Down}, n
+	case 'C':
+		return Key{Code: Right}, n
+	case 'D':
+		return Key{Code: Left}, n
+	case 'H':
+		return Key{Code: Home}, n
+	case 'F':
+		return Key{Code: End}, n
+	case 'Z':
+		return Key{Code: Backtab}, n
+	case '~':
+		switch num, _ := strconv.Atoi(params); num {
+		case 1, 7:
+			return Key{Code: Home}, n
+		case 4, 8:
+			return Key{Code: End}, n
+		case 3:
+			return Key{Code: Delete}, n
+		case 5:
+			return Key{Code: PgUp}, n
+		case 6:
+			return Key{Code: PgDn}, n
+		case 11:
+			return Key{Code: F1}, n
+		case 15:
+			return Key{Code: F5}, n
+		}
+	}
+	return Key{}, n
+}
@@ -0,0 +1,199 @@
+package tui
+
+import "strings"
+
+// item is one row of a list: a short colored tag (method, status) and text.
+type item struct {
+	tag      string
+	tagStyle string
+	text     string
+	search   string // lower-cased text the filter matches against
+}
+
+// list is a scrolling, filterable list. The filter keeps items containing
+// every word typed, in any order.
+type list struct {
+	items     []item
+	view      []int // indexes of the items that pass the filter
+	sel, top  int
+	filter    []rune
+	filtering bool   // keys go to the filter
+	tagWidth  int    // cells reserved for tags
+	empty     string // shown when there are no items at all
+}
+
+func (l *list) set(items []item) {
+	l.items = items
+	l.tagWidth = 0
+	for _, it := range items {
+		l.tagWidth = max(l.tagWidth, width(it.tag))
+	}
+	l.apply()
+}
+
+func (l *list) apply() {
+	words := strings.Fields(strings.ToLower(string(l.filter)))
+	l.view = l.view[:0]
+	for i, it := range l.items {
+		ok := true
+		for _, w := range words {
+			if !strings.Contains(it.search, w) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			l.view = append(l.view, i)
+		}
+	}
+	l.sel = min(l.sel, max(len(l.view)-1, 0))
+}
+
+// selected returns the index of the highlighted item, or -1.
+func (l *list) selected() int {
+	if l.sel < len(l.view) {
+		return l.view[l.sel]
+	}
+	return -1
+}
+
+func (l *list) move(d int) {
+	l.sel = max(0, min(l.sel+d, len(l.view)-1))
+}
+
+// key handles navigation and filter typing and reports whether it used k.
+// height is the number of visible rows, for paging.
+func (l *list) key(k Key, height int) bool {
+	if l.filtering {
+		switch k.Code {
+		case Rune:
+			l.filter = append(l.filter, k.Rune)
+		case Paste:
+			l.filter = append(l.filter, []rune(strings.Join(strings.Fields(k.Text), " "))...)
+		case Backspace:
+			if len(l.filter) > 0 {
+				l.filter = l.filter[:len(l.filter)-1]
+			}
+		case Ctrl:
+			if k.Rune != 'u' {
+				return l.nav(k, height)
+			}
+			l.filter = nil
+		case Esc:
+			l.filter, l.filtering = nil, false
+		case Enter:
+			l.filtering = false
+			return false // Enter also picks the item
+		default:
+			return l.nav(k, height)
+		}
+		l.sel, l.top = 0, 0
+		l.apply()
+		return true
+	}
+	if k.Code == Rune {
+		switch k.Rune {
+		case '/':
+			l.filtering = true
+			return true
+		case 'j':
+			k = Key{Code: Down}
+		case 'k':
+			k = Key{Code: Up}
+		case 'g':
+			k = Key{Code: Home}
+		case 'G':
+			k = Key{Code: End}
+		}
+	}
+	if k.Code == Esc && len(l.filter) > 0 {
+		l.filter = nil
+		l.apply()
+		return true
+	}
+	return l.nav(k, height)
+}
+
+func (l *list) nav(k Key, height int) bool {
+	switch k.Code {
+	case Up:
+		l.move(-1)
+	case Down:
+		l.move(1)
+	case PgUp:
+		l.move(-max(height-1, 1))
+	case PgDn:
+		l.move(max(height-1, 1))
+	case Home:
+		l.sel = 0
+	case End:
+		l.move(len(l.view))
+	case Ctrl:
+		switch k.Rune {
+		case 'p':
+			l.move(-1)
+		case 'n':
+			l.move(1)
+		default:
+			return false
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// render draws the filter line, when there is one, and the visible rows.
+func (l *list) render(w, h int, focused bool) []string {
+	total := h
+	var out []string
+	if l.filtering || len(l.filter) > 0 {
+		f := style(dim, "/") + string(l.filter)
+		if !l.filtering {
+			f += style(dim, "  (esc clears)")
+		}
+		out = append(out, fit(f, w))
+		h--
+	}
+	if len(l.view) == 0 {
+		msg := "no matches"
+		if len(l.items) == 0 {
+			msg = l.empty
+		}
+		out = append(out, fit(style(dim, " "+msg), w))
+		return pad(out, total, w)
+	}
+	if l.sel < l.top {
+		l.top = l.sel
+	}
+	if h > 0 && l.sel >= l.top+h {
+		l.top = l.sel - h + 1
+	}
+	for i := l.top; i < len(l.view) && i < l.top+h; i++ {
+		it := l.items[l.view[i]]
+		tag := ""
+		if l.tagWidth > 0 {
+			tag = it.tag + strings.Repeat(" ", l.tagWidth-width(it.tag)) + " "
+		}
+		switch {
+		case i == l.sel && focused:
+			out = append(out, reverse+fit(" "+tag+it.text, w)+reset)
+		case i == l.sel:
+			out = append(out, fit(style(bold, " "+tag+it.text), w))
+		default:
+			out = append(out, fit(" "+style(it.tagStyle, tag)+it.text, w))
+		}
+	}
+	return pad(out, total, w)
+}
+
+// filterCursor is the screen column of the filter's cursor, when typing.
+func (l *list) filterCursor() int { return 1 + width(string(l.filter)) }
+
+// pad adds blank lines to out until it has n lines.
+func pad(out []string, n, w int) []string {
+	for len(out) < n {
+		out = append(out, strings.Repeat(" ", w))
+	}
+	return out
+}
@@ -0,0 +1,177 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/core/transport"
+	"github.com/bspippi1337/restless/internal/render"
+)
+
+// response is the scrolling view of the last exchange: the request and
+// status lines and headers (toggled with h) above the rendered body.
+type response struct {
+	title   string
+	head    []string
+	body    []string
+	headers bool
+	nowrap  bool
+	top     int
+
+	// The wrapped lines for the last width drawn.
+	lines  []string
+	linesW int
+}
+
+func (r *response) reset() {
+	r.top, r.lines = 0, nil
+}
+
+// set shows res; source says where it came from, e.g. "history #12".
+func (r *response) set(res *Result, source string, ro render.Options) {
+	r.reset()
+	r.title = fmt.Sprintf("%s · %s · %s", res.Status, fmtDuration(res.Elapsed), fmtSize(len(res.Body)))
+	if res.Truncated {
+		r.title += "+"
+	}
+	if source != "" {
+		r.title += " · " + source
+	}
+	h := http.Header{}
+	for k, vs := range res.Header {
+		for _, v := range vs {
+			h.Add(clean(k), clean(v))
+		}
+	}
+	var b bytes.Buffer
+	render.RequestLine(&b, res.Method, clean(res.URL), res.Proto, ro)
+	render.StatusLine(&b, res.Proto, clean(res.Status), res.Code, ro)
+	render.Headers(&b, h, transport.RedactHeader, ro)
+	r.head = splitLines(b.String())
+
+	b.Reset()
+	ct := res.Header.Get("Content-Type")
+	switch {
+	case len(res.Body) == 0:
+		b.WriteString(style(dim, "(empty body)"))
+	case render.IsBinary(ct, res.Body):
+		fmt.Fprintf(&b, "[binary body: %s, %s]", fmtSize(len(res.Body)), firstNonEmpty(ct, "unknown type"))
+	default:
+		render.Body(&b, ct, []byte(clean(string(res.Body))), ro)
+	}
+	if res.Truncated {
+		b.WriteString("\n" + style(yellow, fmt.Sprintf("[only the first %s are shown]", fmtSize(len(res.Body)))))
+	}
+	r.body = splitLines(b.String())
+}
+
+// setText shows plain lines, e.g. an error or what is being waited on.
+func (r *response) setText(title string, lines []string) {
+	r.reset()
+	r.title, r.head, r.body = title, nil, lines
+}
+
+// render draws the view in w×h cells, wrapping long lines unless nowrap.
+func (r *response) render(w, h int) []string {
+	if r.lines == nil || r.linesW != w {
+		r.lines, r.linesW = nil, w
+		src := r.body
+		if r.headers && len(r.head) > 0 {
+			src = append(append(append([]string(nil), r.head...), ""), r.body...)
+		}
+		for _, l := range src {
+			if r.nowrap {
+				r.lines = append(r.lines, l)
+			} else {
+				r.lines = append(r.lines, wrap(l, w)...)
+			}
+		}
+	}
+	r.top = max(0, min(r.top, len(r.lines)-h))
+	out := make([]string, 0, h)
+	for i := r.top; i < len(r.lines) && len(out) < h; i++ {
+		out = append(out, fit(r.lines[i], w))
+	}
+	return pad(out, h, w)
+}
+
+// position describes the scroll position for the pane title.
+func (r *response) position(h int) string {
+	if len(r.lines) <= h {
+		return ""
+	}
+	if r.top+h >= len(r.lines) {
+		return "bot"
+	}
+	return fmt.Sprintf("%d%%", r.top*100/max(len(r.lines)-h, 1))
+}
+
+// key scrolls and toggles; it reports whether it used k.
+func (r *response) key(k Key, h int) bool {
+	switch {
+	case k.Code == Up || k.Code == Rune && k.Rune == 'k':
+		r.top--
+	case k.Code == Down || k.Code == Rune && k.Rune == 'j':
+		r.top++
+	case k.Code == PgUp || k.Code == Rune && k.Rune == 'b':
+		r.top -= max(h-1, 1)
+	case k.Code == PgDn || k.Code == Rune && k.Rune == ' ':
+		r.top += max(h-1, 1)
+	case k.Code == Home || k.Code == Rune && k.Rune == 'g':
+		r.top = 0
+	case k.Code == End || k.Code == Rune && k.Rune == 'G':
+		r.top = len(r.lines)
+	case k.Code == Rune && k.Rune == 'h':
+		r.headers = !r.headers
+		r.lines = nil
+	case k.Code == Rune && k.Rune == 'w':
+		r.nowrap = !r.nowrap
+		r.lines = nil
+	default:
+		return false
+	}
+	r.top = max(r.top, 0)
+	return true
+}
+
+func splitLines(s string) []string {
+	return strings.Split(strings.TrimRight(s, "\n"), "\n")
+}
+
+func fmtDuration(d time.Duration) string {
+	if d >= time.Second {
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	}
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
+
+func fmtSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// headerMap turns the joined headers history stores back into a Header.
+func headerMap(m map[string]string) http.Header {
+	h := http.Header{}
+	for k, v := range m {
+		h[k] = []string{v}
+	}
+	return h
+}
+
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
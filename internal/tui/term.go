@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// terminal is the screen in raw mode: the alternate screen buffer, bracketed
+// paste on, and keys decoded on a goroutine. Only the basic VT100/xterm
+// sequences are used, so it works in Termux and over SSH.
+type terminal struct {
+	in, out *os.File
+	state   *term.State
+	keys    chan Key
+	prev    []string // the last frame, so only changed lines are sent
+	prevW   int
+}
+
+func openTerminal(in, out *os.File) (*terminal, error) {
+	if !term.IsTerminal(int(in.Fd())) || !term.IsTerminal(int(out.Fd())) {
+		return nil, errors.New("stdin and stdout must be a terminal")
+	}
+	st, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return nil, err
+	}
+	t := &terminal{in: in, out: out, state: st, keys: make(chan Key, 64)}
+	_, _ = out.WriteString("\x1b[?1049h\x1b[?2004h\x1b[?25l\x1b[2J")
+	go t.read()
+	return t, nil
+}
+
+// read feeds input to the decoder until stdin fails. The goroutine is left
+// blocked in Read when the UI exits; the process is about to end anyway.
+func (t *terminal) read() {
+	var d decoder
+	buf := make([]byte, 4096)
+	for {
+		n, err := t.in.Read(buf)
+		for _, k := range d.feed(buf[:n]) {
+			t.keys <- k
+		}
+		if err != nil {
+			close(t.keys)
+			return
+		}
+	}
+}
+
+func (t *terminal) close() {
+	_, _ = t.out.WriteString("\x1b[?2004l\x1b[?25h\x1b[?1049l")
+	_ = term.Restore(int(t.in.Fd()), t.state)
+}
+
+func (t *terminal) size() (w, h int) {
+	w, h, err := term.GetSize(int(t.out.Fd()))
+	if err != nil || w <= 0 || h <= 0 {
+		return 80, 24
+	}
+	return w, h
+}
+
+// draw paints lines, each exactly w cells wide, and shows the cursor at
+// row, col (zero based) when row >= 0. Lines unchanged since the last frame
+// are skipped, which keeps redraws cheap over SSH.
+func (t *terminal) draw(lines []string, w, row, col int) {
+	var b strings.Builder
+	b.WriteString("\x1b[?25l")
+	if len(t.prev) != len(lines) || t.prevW != w {
+		b.WriteString("\x1b[2J")
+		t.prev, t.prevW = nil, w
+	}
+	for i, l := range lines {
+		if i < len(t.prev) && t.prev[i] == l {
+			continue
+		}
+		b.WriteString("\x1b[" + strconv.Itoa(i+1) + ";1H" + reset + l)
+	}
+	t.prev = lines
+	if row >= 0 {
+		b.WriteString("\x1b[" + strconv.Itoa(row+1) + ";" + strconv.Itoa(col+1) + "H\x1b[?25h")
+	}
+	_, _ = t.out.WriteString(b.String())
+}
+
+// invalidate makes the next draw repaint everything, e.g. after something
+// else wrote to the screen.
+func (t *terminal) invalidate() { t.prev = nil }
@@ -0,0 +1,153 @@
+package tui
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Styles are kept to the basic 16 colors, like the rest of restless.
+const (
+	reset   = "\x1b[0m"
+	bold    = "\x1b[1m"
+	dim     = "\x1b[2m"
+	reverse = "\x1b[7m"
+	red     = "\x1b[31m"
+	green   = "\x1b[32m"
+	yellow  = "\x1b[33m"
+	blue    = "\x1b[34m"
+	magenta = "\x1b[35m"
+	cyan    = "\x1b[36m"
+)
+
+func style(st, s string) string {
+	if st == "" || s == "" {
+		return s
+	}
+	return st + s + reset
+}
+
+// runeWidth is the number of cells r takes: 0 for combining marks, 2 for
+// wide East Asian characters and emoji, 1 otherwise.
+func runeWidth(r rune) int {
+	switch {
+	case unicode.Is(unicode.Mn, r) || r == 0x200b:
+		return 0
+	case r >= 0x1100 && r <= 0x115f, r >= 0x2e80 && r <= 0xa4cf && r != 0x303f,
+		r >= 0xac00 && r <= 0xd7a3, r >= 0xf900 && r <= 0xfaff, r >= 0xfe30 && r <= 0xfe4f,
+		r >= 0xff00 && r <= 0xff60, r >= 0xffe0 && r <= 0xffe6,
+		r >= 0x1f300 && r <= 0x1f64f, r >= 0x1f900 && r <= 0x1f9ff, r >= 0x20000 && r <= 0x3fffd:
+		return 2
+	}
+	return 1
+}
+
+// escLen returns the length of the ANSI escape sequence at the start of s,
+// or 0.
+func escLen(s string) int {
+	if len(s) < 2 || s[0] != 0x1b || s[1] != '[' {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		if s[i] >= 0x40 && s[i] <= 0x7e {
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// width is the number of cells s takes, ignoring escape sequences.
+func width(s string) int {
+	n := 0
+	for i := 0; i < len(s); {
+		if e := escLen(s[i:]); e > 0 {
+			i += e
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		n += runeWidth(r)
+		i += size
+	}
+	return n
+}
+
+// cut splits s after at most w cells. Styles open at the cut are closed in
+// head and reopened in rest, so both halves render on their own.
+func cut(s string, w int) (head, rest string) {
+	var open strings.Builder
+	n := 0
+	for i := 0; i < len(s); {
+		if e := escLen(s[i:]); e > 0 {
+			if seq := s[i : i+e]; seq == reset {
+				open.Reset()
+			} else if seq[len(seq)-1] == 'm' {
+				open.WriteString(seq)
+			}
+			i += e
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		rw := runeWidth(r)
+		if n+rw > w {
+			head, rest = s[:i], s[i:]
+			if open.Len() > 0 {
+				head += reset
+				rest = open.String() + rest
+			}
+			return head, rest
+		}
+		n += rw
+		i += size
+	}
+	return s, ""
+}
+
+// fit truncates or pads s to exactly w cells.
+func fit(s string, w int) string {
+	if w <= 0 {
+		return ""
+	}
+	if n := width(s); n <= w {
+		return s + strings.Repeat(" ", w-n)
+	}
+	head, _ := cut(s, w-1)
+	return head + "…" + strings.Repeat(" ", w-1-width(head))
+}
+
+// wrap breaks s into lines of at most w cells.
+func wrap(s string, w int) []string {
+	if w <= 0 || width(s) <= w {
+		return []string{s}
+	}
+	var out []string
+	for width(s) > w {
+		head, rest := cut(s, w)
+		if head == "" || rest == s {
+			break
+		}
+		out = append(out, head)
+		s = rest
+	}
+	return append(out, s)
+}
+
+// clean makes text from the network safe to draw: tabs become spaces and
+// other control characters, escape sequences included, a visible dot.
+func clean(s string) string {
+	if strings.IndexFunc(s, func(r rune) bool { return r < 0x20 && r != '\n' || r == 0x7f }) < 0 {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\t':
+			b.WriteString("    ")
+		case r == '\r':
+		case r < 0x20 && r != '\n' || r == 0x7f:
+			b.WriteRune('·')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}